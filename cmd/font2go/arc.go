@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// parseArcParams parses the parameters of an elliptical arc command,
+// whose flags may be written without separators (e.g. "a5 5 0 011 1").
+func parseArcParams(d string) (result []float64) {
+	for len(d) > 0 {
+		if len(result)%7 == 3 || len(result)%7 == 4 {
+			d = strings.TrimLeft(d, " \t\r\n,")
+			if len(d) > 0 && (d[0] == '0' || d[0] == '1') {
+				result = append(result, float64(d[0]-'0'))
+				d = strings.TrimLeft(d[1:], " \t\r\n,")
+				continue
+			}
+		}
+		m := numRE.FindStringSubmatch(d)
+		if len(m) == 2 {
+			result = append(result, atof(m[1]))
+			d = d[len(m[0]):]
+			continue
+		}
+		if strings.TrimSpace(d) == "" {
+			break
+		}
+		fatal(fmt.Errorf("parseArcParams: unable to parse %q", d))
+	}
+	return result
+}
+
+// convertArcs replaces the elliptical arc steps of the path with cubic
+// Bézier curves (absolute C steps), which the gerber package renders.
+func (g *Glyph) convertArcs() {
+	var steps []*PathStep
+	var x, y, startX, startY float64 // current point and start of the subpath
+	for _, ps := range g.PathSteps {
+		p := ps.Parameters
+		rel := ps.Command == strings.ToLower(ps.Command)
+		cmd := strings.ToUpper(ps.Command)
+		if cmd != "A" {
+			steps = append(steps, ps)
+		}
+		switch cmd {
+		case "Z":
+			x, y = startX, startY
+		case "H":
+			for _, v := range p {
+				if rel {
+					x += v
+				} else {
+					x = v
+				}
+			}
+		case "V":
+			for _, v := range p {
+				if rel {
+					y += v
+				} else {
+					y = v
+				}
+			}
+		case "M", "L", "T", "C", "S", "Q":
+			n := map[string]int{"M": 2, "L": 2, "T": 2, "C": 6, "S": 4, "Q": 4}[cmd]
+			for i := 0; i+n <= len(p); i += n {
+				if rel {
+					x, y = x+p[i+n-2], y+p[i+n-1]
+				} else {
+					x, y = p[i+n-2], p[i+n-1]
+				}
+				if cmd == "M" && i == 0 {
+					startX, startY = x, y
+				}
+			}
+		case "A":
+			for i := 0; i+7 <= len(p); i += 7 {
+				ex, ey := p[i+5], p[i+6]
+				if rel {
+					ex, ey = x+ex, y+ey
+				}
+				steps = append(steps, arcSteps(x, y, p[i], p[i+1], p[i+2], p[i+3] != 0, p[i+4] != 0, ex, ey)...)
+				x, y = ex, ey
+			}
+		}
+	}
+	g.PathSteps = steps
+}
+
+// arcSteps converts the SVG elliptical arc from x1,y1 to x2,y2 into
+// cubic Bézier steps of at most a quarter turn each, following the
+// endpoint to center conversion of the SVG implementation notes.
+// Degenerate arcs become a line (zero radius) or nothing (coincident
+// end points) and radii too small to reach x2,y2 are scaled up.
+func arcSteps(x1, y1, rx, ry, rotation float64, largeArc, sweep bool, x2, y2 float64) []*PathStep {
+	if x1 == x2 && y1 == y2 {
+		return nil
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		return []*PathStep{{Command: "L", Parameters: []float64{x2, y2}}}
+	}
+	sin, cos := math.Sincos(rotation * math.Pi / 180)
+
+	// The midpoint of the chord in the ellipse's frame.
+	dx, dy := 0.5*(x1-x2), 0.5*(y1-y2)
+	px, py := cos*dx+sin*dy, -sin*dx+cos*dy
+	if lambda := px*px/(rx*rx) + py*py/(ry*ry); lambda > 1 {
+		rx, ry = rx*math.Sqrt(lambda), ry*math.Sqrt(lambda)
+	}
+	num := rx*rx*ry*ry - rx*rx*py*py - ry*ry*px*px
+	den := rx*rx*py*py + ry*ry*px*px
+	coef := math.Sqrt(math.Max(0, num/den))
+	if largeArc == sweep {
+		coef = -coef
+	}
+	ccx, ccy := coef*rx*py/ry, -coef*ry*px/rx
+	cx := cos*ccx - sin*ccy + 0.5*(x1+x2)
+	cy := sin*ccx + cos*ccy + 0.5*(y1+y2)
+
+	theta := math.Atan2((py-ccy)/ry, (px-ccx)/rx)
+	delta := math.Atan2((-py-ccy)/ry, (-px-ccx)/rx) - theta
+	switch {
+	case sweep && delta < 0:
+		delta += 2 * math.Pi
+	case !sweep && delta > 0:
+		delta -= 2 * math.Pi
+	}
+
+	// point maps a point of the unit circle onto the ellipse.
+	point := func(ux, uy float64) (float64, float64) {
+		return cx + rx*ux*cos - ry*uy*sin, cy + rx*ux*sin + ry*uy*cos
+	}
+	n := max(1, int(math.Ceil(math.Abs(delta)/(0.5*math.Pi)-1e-9)))
+	d := delta / float64(n)
+	k := 4.0 / 3 * math.Tan(d/4)
+	steps := make([]*PathStep, n)
+	for i := range steps {
+		s1, c1 := math.Sincos(theta + float64(i)*d)
+		s2, c2 := math.Sincos(theta + float64(i+1)*d)
+		ax, ay := point(c1-k*s1, s1+k*c1)
+		bx, by := point(c2+k*s2, s2-k*c2)
+		ex, ey := point(c2, s2)
+		if i == n-1 {
+			ex, ey = x2, y2
+		}
+		steps[i] = &PathStep{Command: "C", Parameters: []float64{ax, ay, bx, by, ex, ey}}
+	}
+	return steps
+}
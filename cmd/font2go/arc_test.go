@@ -0,0 +1,183 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseArcParams(t *testing.T) {
+	tests := []struct {
+		name string
+		d    string
+		want []float64
+	}{
+		{name: "separated", d: "5 5 0 0 1 10 0", want: []float64{5, 5, 0, 0, 1, 10, 0}},
+		{name: "commas", d: "5,5,0,1,0,-3,4", want: []float64{5, 5, 0, 1, 0, -3, 4}},
+		{name: "flags without separators", d: "5 5 0 011 1", want: []float64{5, 5, 0, 0, 1, 1, 1}},
+		{name: "flags before a coordinate", d: "5 5 30 1010 0", want: []float64{5, 5, 30, 1, 0, 10, 0}},
+		{name: "two arcs", d: "1 1 0 0 1 2 0 1 1 0 10-2 0", want: []float64{1, 1, 0, 0, 1, 2, 0, 1, 1, 0, 1, 0, -2, 0}},
+		{name: "trailing space", d: "1 1 0 0 0 2 2 ", want: []float64{1, 1, 0, 0, 0, 2, 2}},
+	}
+	for _, tt := range tests {
+		if got := parseArcParams(tt.d); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: parseArcParams(%q) = %v, want %v", tt.name, tt.d, got, tt.want)
+		}
+	}
+}
+
+// k is the distance of the control points of a quarter circle of radius 1.
+var k = 4.0 / 3 * math.Tan(math.Pi/8)
+
+func TestArcSteps(t *testing.T) {
+	tests := []struct {
+		name            string
+		x1, y1, rx, ry  float64
+		rotation        float64
+		largeArc, sweep bool
+		x2, y2          float64
+		want            []*PathStep
+	}{
+		{
+			name: "quarter",
+			x1:   1, rx: 1, ry: 1, sweep: true, y2: 1,
+			want: []*PathStep{{Command: "C", Parameters: []float64{1, k, k, 1, 0, 1}}},
+		},
+		{
+			name: "reverse quarter",
+			y1:   1, rx: 1, ry: 1, x2: 1,
+			want: []*PathStep{{Command: "C", Parameters: []float64{k, 1, 1, k, 1, 0}}},
+		},
+		{
+			name: "half",
+			x1:   1, rx: 1, ry: 1, sweep: true, x2: -1,
+			want: []*PathStep{
+				{Command: "C", Parameters: []float64{1, k, k, 1, 0, 1}},
+				{Command: "C", Parameters: []float64{-k, 1, -1, k, -1, 0}},
+			},
+		},
+		{
+			name: "half the other way",
+			x1:   1, rx: 1, ry: 1, x2: -1,
+			want: []*PathStep{
+				{Command: "C", Parameters: []float64{1, -k, k, -1, 0, -1}},
+				{Command: "C", Parameters: []float64{-k, -1, -1, -k, -1, 0}},
+			},
+		},
+		{
+			name: "radii scaled up to reach the end",
+			x1:   1, rx: 0.5, ry: 0.5, sweep: true, x2: -1,
+			want: []*PathStep{
+				{Command: "C", Parameters: []float64{1, k, k, 1, 0, 1}},
+				{Command: "C", Parameters: []float64{-k, 1, -1, k, -1, 0}},
+			},
+		},
+		{
+			name: "large arc",
+			x1:   1, rx: 1, ry: 1, largeArc: true, sweep: true, y2: 1,
+			want: []*PathStep{
+				{Command: "C", Parameters: []float64{1 + k, 0, 2, 1 - k, 2, 1}},
+				{Command: "C", Parameters: []float64{2, 1 + k, 1 + k, 2, 1, 2}},
+				{Command: "C", Parameters: []float64{1 - k, 2, 0, 1 + k, 0, 1}},
+			},
+		},
+		{
+			name: "rotated ellipse",
+			x1:   0, rx: 2, ry: 1, rotation: 90, sweep: true, x2: -1, y2: 2,
+			want: []*PathStep{{Command: "C", Parameters: []float64{0, 2 * k, k - 1, 2, -1, 2}}},
+		},
+		{name: "zero radius", x1: 1, ry: 1, x2: 3, y2: 4, want: []*PathStep{{Command: "L", Parameters: []float64{3, 4}}}},
+		{name: "coincident ends", x1: 1, y1: 1, rx: 1, ry: 1, x2: 1, y2: 1},
+	}
+	for _, tt := range tests {
+		got := arcSteps(tt.x1, tt.y1, tt.rx, tt.ry, tt.rotation, tt.largeArc, tt.sweep, tt.x2, tt.y2)
+		if !sameSteps(got, tt.want) {
+			t.Errorf("%v: arcSteps = %v, want %v", tt.name, stepsString(got), stepsString(tt.want))
+		}
+	}
+}
+
+func TestGlyph_convertArcs(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []*PathStep
+		want  []*PathStep
+	}{
+		{
+			name: "absolute",
+			steps: []*PathStep{
+				{Command: "M", Parameters: []float64{1, 0}},
+				{Command: "A", Parameters: []float64{1, 1, 0, 0, 1, 0, 1}},
+				{Command: "Z"},
+			},
+			want: []*PathStep{
+				{Command: "M", Parameters: []float64{1, 0}},
+				{Command: "C", Parameters: []float64{1, k, k, 1, 0, 1}},
+				{Command: "Z"},
+			},
+		},
+		{
+			name: "relative after h",
+			steps: []*PathStep{
+				{Command: "M", Parameters: []float64{0, 0}},
+				{Command: "h", Parameters: []float64{1}},
+				{Command: "a", Parameters: []float64{1, 1, 0, 0, 1, -1, 1, 1, 1, 0, 0, 1, -1, -1}},
+			},
+			want: []*PathStep{
+				{Command: "M", Parameters: []float64{0, 0}},
+				{Command: "h", Parameters: []float64{1}},
+				{Command: "C", Parameters: []float64{1, k, k, 1, 0, 1}},
+				{Command: "C", Parameters: []float64{-k, 1, -1, k, -1, 0}},
+			},
+		},
+		{
+			name: "relative zero radius after a closed subpath",
+			steps: []*PathStep{
+				{Command: "M", Parameters: []float64{2, 2}},
+				{Command: "l", Parameters: []float64{1, 0}},
+				{Command: "z"},
+				{Command: "a", Parameters: []float64{0, 0, 0, 0, 1, 3, 4}},
+			},
+			want: []*PathStep{
+				{Command: "M", Parameters: []float64{2, 2}},
+				{Command: "l", Parameters: []float64{1, 0}},
+				{Command: "z"},
+				{Command: "L", Parameters: []float64{5, 6}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		g := &Glyph{PathSteps: tt.steps}
+		g.convertArcs()
+		if !sameSteps(g.PathSteps, tt.want) {
+			t.Errorf("%v: convertArcs = %v, want %v", tt.name, stepsString(g.PathSteps), stepsString(tt.want))
+		}
+	}
+}
+
+// sameSteps reports whether the steps have the same commands and
+// parameters up to rounding.
+func sameSteps(got, want []*PathStep) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i, s := range got {
+		if s.Command != want[i].Command || len(s.Parameters) != len(want[i].Parameters) {
+			return false
+		}
+		for j, v := range s.Parameters {
+			if math.Abs(v-want[i].Parameters[j]) > 1e-9 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func stepsString(steps []*PathStep) []PathStep {
+	var result []PathStep
+	for _, s := range steps {
+		result = append(result, *s)
+	}
+	return result
+}
@@ -9,17 +9,33 @@ import (
 	"fmt"
 	"go/format"
 	"io/ioutil"
-	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"text/template"
+
+	"github.com/gmlewis/go-gerber/gerber"
+	"github.com/gmlewis/go-gerber/internal/cli"
 )
 
 var (
-	filename = flag.String("out", "fonts.go", "Output filename for Go fonts file")
+	opts      = cli.Register(flag.CommandLine)
+	filename  = flag.String("out", "fonts.go", "Output filename for Go fonts file (within -outdir)")
+	preview   = flag.Bool("preview", false, "Also write an SVG specimen sheet (<font>-preview.svg) for each font (within -outdir)")
+	coverage  = flag.Bool("coverage", false, "Print the Unicode block coverage of each font")
+	require   = flag.String("require", "", "Characters that must be present in every font; missing ones are reported and fail the conversion")
+	fillRule  = flag.String("fill-rule", "nonzero", "SVG fill rule (nonzero or evenodd) deciding which contours of the glyphs are holes when they have no gerber-lp attribute")
+	chars     = flag.String("chars", "", "Characters to keep in the generated fonts (default all); the missing glyph is always kept")
+	runesFile = flag.String("runes-file", "", "File whose characters (except line breaks) are kept in the generated fonts, in addition to -chars")
+	pkg       = flag.String("package", "gerber", "Package name of the generated Go file; packages other than gerber register their fonts in gerber.Fonts")
+
+	logger = slog.Default()
 
 	outTemp = template.Must(template.New("out").Funcs(funcMap).Parse(goTemplate))
 	funcMap = template.FuncMap{
+		"deref":   deref,
 		"floats":  floats,
 		"orEmpty": orEmpty,
 		"utf8":    utf8Escape,
@@ -27,48 +43,190 @@ var (
 )
 
 func main() {
-	flag.Parse()
+	if err := cli.Parse(flag.CommandLine, os.Args[1:]); err != nil {
+		fatal(err)
+	}
+	logger = opts.Logger()
+	out := opts.Path(*filename)
+	if r := gerber.FillRule(*fillRule); r != gerber.NonZero && r != gerber.EvenOdd {
+		fatal(fmt.Errorf("invalid -fill-rule %q: want nonzero or evenodd", *fillRule))
+	}
+
+	args, err := expandArgs(flag.Args())
+	if err != nil {
+		fatal(err)
+	}
+
+	keep, err := subset()
+	if err != nil {
+		fatal(err)
+	}
 
 	var fonts []*Font
-	for _, arg := range flag.Args() {
-		log.Printf("Processing file %q ...", arg)
+	var failed bool
+	for _, arg := range args {
+		logger.Info("processing file", "file", arg)
 
 		buf, err := ioutil.ReadFile(arg)
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 		fontData := &FontData{}
 		if err := xml.Unmarshal(buf, fontData); err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
 		fontData.Font.ID = strings.ToLower(fontData.Font.ID)
+		fontData.Font.License = fontData.License()
 
 		for _, g := range fontData.Font.Glyphs {
 			g.ParsePath()
 		}
+		fontData.Font.Metrics()
+		if keep != nil {
+			fontData.Font.Subset(keep)
+		}
+
+		if *coverage || *require != "" {
+			if !checkCoverage(fontData.Font) {
+				failed = true
+			}
+		}
+
+		if *preview {
+			if err := writePreview(opts.Path(fontData.Font.ID+"-preview.svg"), fontData.Font); err != nil {
+				fatal(err)
+			}
+		}
 
 		fonts = append(fonts, fontData.Font)
 	}
 
+	if failed {
+		fatal(fmt.Errorf("fonts are missing required characters %q", *require))
+	}
+	if err := opts.Err(); err != nil {
+		fatal(err)
+	}
+
 	sort.Slice(fonts, func(a, b int) bool { return fonts[a].ID < fonts[b].ID })
 
+	data := &templateData{Package: *pkg, Fonts: fonts}
+	if gerber.FillRule(*fillRule) == gerber.EvenOdd {
+		data.FillRule = gerber.EvenOdd
+	}
 	var buf bytes.Buffer
-	if err := outTemp.Execute(&buf, fonts); err != nil {
-		log.Fatal(err)
+	if err := outTemp.Execute(&buf, data); err != nil {
+		fatal(err)
 	}
 
 	fmtBuf, err := format.Source(buf.Bytes())
 	if err != nil {
-		ioutil.WriteFile(*filename, buf.Bytes(), 0644) // Dump the unformatted output.
-		log.Fatal(err)
+		ioutil.WriteFile(out, buf.Bytes(), 0644) // Dump the unformatted output.
+		fatal(err)
+	}
+
+	if err := ioutil.WriteFile(out, fmtBuf, 0644); err != nil {
+		fatal(err)
+	}
+
+	if !opts.Quiet {
+		fmt.Println("Done.")
+	}
+}
+
+// checkCoverage prints the font's Unicode coverage (with -coverage) and
+// reports whether it contains all -require characters.
+func checkCoverage(font *Font) bool {
+	have := map[rune]bool{}
+	var runes []rune
+	for _, g := range font.Glyphs {
+		if g.Unicode == nil {
+			continue
+		}
+		if r := []rune(*g.Unicode); len(r) == 1 && !have[r[0]] {
+			have[r[0]] = true
+			runes = append(runes, r[0])
+		}
 	}
+	sort.Slice(runes, func(a, b int) bool { return runes[a] < runes[b] })
 
-	if err := ioutil.WriteFile(*filename, fmtBuf, 0644); err != nil {
-		log.Fatal(err)
+	var missing []rune
+	for _, r := range *require {
+		if !have[r] {
+			have[r] = true // Only report once.
+			missing = append(missing, r)
+		}
+	}
+	if *coverage || len(missing) > 0 {
+		fmt.Print(gerber.CoverageReport(font.ID, runes, missing))
+	}
+	return len(missing) == 0
+}
+
+// subset returns the characters selected by -chars and -runes-file,
+// or nil to keep all glyphs.
+func subset() (map[rune]bool, error) {
+	if *chars == "" && *runesFile == "" {
+		return nil, nil
+	}
+	s := *chars
+	if *runesFile != "" {
+		buf, err := ioutil.ReadFile(*runesFile)
+		if err != nil {
+			return nil, err
+		}
+		s += strings.NewReplacer("\r", "", "\n", "").Replace(string(buf))
+	}
+	keep := map[rune]bool{}
+	for _, r := range s {
+		keep[r] = true
+	}
+	return keep, nil
+}
+
+// templateData represents the data used to generate the Go file.
+type templateData struct {
+	Package string
+	Fonts   []*Font
+	// FillRule is the fill rule of the fonts, if not the default (nonzero).
+	FillRule gerber.FillRule
+}
+
+// Qualified reports whether the generated types must be qualified
+// with the gerber package name.
+func (t *templateData) Qualified() bool {
+	return t.Package != "gerber"
+}
+
+// expandArgs replaces directory arguments with the SVG webfonts they contain.
+func expandArgs(args []string) ([]string, error) {
+	var result []string
+	for _, arg := range args {
+		fi, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			result = append(result, arg)
+			continue
+		}
+		files, err := filepath.Glob(filepath.Join(arg, "*.svg"))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			logger.Warn("no SVG webfonts found", "dir", arg)
+		}
+		result = append(result, files...)
 	}
+	return result, nil
+}
 
-	fmt.Println("Done.")
+// fatal logs the error and exits.
+func fatal(err error) {
+	logger.Error(err.Error())
+	os.Exit(1)
 }
 
 func utf8Escape(s *string) string {
@@ -85,14 +243,34 @@ func orEmpty(s *string) string {
 	return fmt.Sprintf("%q", *s)
 }
 
+func deref(f *float64) float64 {
+	if f == nil {
+		return 0
+	}
+	return *f
+}
+
 func floats(f []float64) string {
 	return fmt.Sprintf("%#v", f)
 }
 
 var goTemplate = `// Auto-generated - DO NOT EDIT!
+{{ range .Fonts }}{{ if .License }}
+// {{ .ID }}:{{ range .License }}
+//   {{ . }}{{ end }}
+{{ end }}{{ end }}
+package {{ .Package }}
+{{ if .Qualified }}
+import "github.com/gmlewis/go-gerber/gerber"
 
-package gerber
+func init() {
+	for name, font := range Fonts {
+		gerber.Fonts[name] = font
+	}
+}
 
+// Fonts represents the fonts of this package, registered in gerber.Fonts.
+var Fonts = map[string]*gerber.Font{ {{ else }}
 // Font represents a webfont.
 type Font struct {
 	ID           string
@@ -100,7 +278,12 @@ type Font struct {
 	UnitsPerEm   float64
 	Ascent       float64
 	Descent      float64
+	UnderlinePosition  float64
+	UnderlineThickness float64
+	CapHeight    float64
+	XHeight      float64
 	MissingHorizAdvX float64
+	FillRule     FillRule
 	Glyphs       map[string]*Glyph
 }
 
@@ -129,20 +312,25 @@ type PathStep struct {
 	P []float64 // P are the parameters of the command.
 }
 
-var Fonts = map[string]*Font{ {{ range . }}
+var Fonts = map[string]*Font{ {{ end }}{{ range .Fonts }}
 	"{{ .ID }}": {
 		// ID: "{{ .ID }}",
 		HorizAdvX:  {{ .HorizAdvX }},
 		UnitsPerEm: {{ .FontFace.UnitsPerEm }},
 		Ascent:     {{ .FontFace.Ascent }},
 		Descent:    {{ .FontFace.Descent }},
-		MissingHorizAdvX: {{ .MissingGlyph.HorizAdvX }},
-		Glyphs: map[string]*Glyph{ {{ range .Glyphs }}{{ if .Unicode }}
+		UnderlinePosition:  {{ .FontFace.UnderlinePosition | deref }},
+		UnderlineThickness: {{ .FontFace.UnderlineThickness | deref }},
+		CapHeight:  {{ .FontFace.CapHeight | deref }},
+		XHeight:    {{ .FontFace.XHeight | deref }},
+		MissingHorizAdvX: {{ .MissingGlyph.HorizAdvX }},{{ if $.FillRule }}
+		FillRule: "{{ $.FillRule }}",{{ end }}
+		Glyphs: map[string]*{{ if $.Qualified }}gerber.{{ end }}Glyph{ {{ range .Glyphs }}{{ if .Unicode }}
 			{{ .Unicode | utf8 }}: {
 				HorizAdvX: {{ .HorizAdvX }},
 				Unicode: {{ .Unicode | utf8 }},
 				GerberLP: {{ .GerberLP | orEmpty }},
-				PathSteps: []*PathStep{ {{ range .PathSteps }}
+				PathSteps: []*{{ if $.Qualified }}gerber.{{ end }}PathStep{ {{ range .PathSteps }}
 					{ C: '{{ .Command }}'{{ if .Parameters }}, P: {{ .Parameters | floats }}{{ end }} },{{ end }}
 				},
 			},{{ end }}{{ end }}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"os"
+)
+
+const (
+	previewWidth   = 1200.0 // px
+	previewMargin  = 20.0   // px
+	previewCell    = 60.0   // px
+	sampleSentence = "The quick brown fox jumps over the lazy dog. 0123456789"
+)
+
+var previewSizes = []float64{12, 18, 24, 36, 48} // px
+
+// writePreview writes an SVG specimen sheet for the font showing all its
+// glyphs followed by a sample sentence at several sizes.
+func writePreview(filename string, font *Font) error {
+	glyphs := map[string]*Glyph{}
+	var all []*Glyph
+	for _, g := range font.Glyphs {
+		if g.Unicode == nil || *g.Unicode == "" {
+			continue
+		}
+		glyphs[*g.Unicode] = g
+		all = append(all, g)
+	}
+
+	var body bytes.Buffer
+	em := float64(font.FontFace.UnitsPerEm)
+	ascent := float64(font.FontFace.Ascent)
+	y := previewMargin + 24
+
+	fmt.Fprintf(&body, `<text x="%v" y="%v" font-family="sans-serif" font-size="20">%v (%v glyphs)</text>`+"\n", previewMargin, y, escape(font.ID), len(all))
+	y += 10
+
+	perRow := int(math.Floor((previewWidth - 2*previewMargin) / previewCell))
+	for i, g := range all {
+		col, row := i%perRow, i/perRow
+		x := previewMargin + float64(col)*previewCell
+		top := y + float64(row)*previewCell
+		fmt.Fprintf(&body, `<rect x="%v" y="%v" width="%v" height="%v" fill="none" stroke="#ccc"/>`+"\n", x, top, previewCell, previewCell)
+		scale := 0.8 * previewCell / em
+		writeGlyph(&body, g, x+0.1*previewCell, top+0.1*previewCell+scale*ascent, scale)
+	}
+	y += float64((len(all)+perRow-1)/perRow)*previewCell + previewMargin
+
+	for _, size := range previewSizes {
+		scale := size / em
+		y += scale * ascent
+		x := previewMargin
+		for _, r := range sampleSentence {
+			g, ok := glyphs[string(r)]
+			if !ok {
+				x += scale * float64(font.MissingGlyph.HorizAdvX)
+				continue
+			}
+			writeGlyph(&body, g, x, y, scale)
+			adv := g.HorizAdvX
+			if adv == 0 {
+				adv = font.HorizAdvX
+			}
+			x += scale * float64(adv)
+		}
+		y += scale*(em-ascent) + previewMargin
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%v" height="%v" viewBox="0 0 %[1]v %[2]v">`+"\n", previewWidth, y)
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+	buf.Write(body.Bytes())
+	buf.WriteString("</svg>\n")
+	return os.WriteFile(filename, buf.Bytes(), 0644)
+}
+
+// writeGlyph writes the glyph's path with its origin (baseline) at x,y.
+// Font units are y-up, so the path is flipped vertically.
+func writeGlyph(buf *bytes.Buffer, g *Glyph, x, y, scale float64) {
+	d := g.D
+	if g.DOrig != nil && *g.DOrig != "" {
+		d = g.DOrig
+	}
+	if d == nil || *d == "" {
+		return
+	}
+	fmt.Fprintf(buf, `<path transform="translate(%.3f,%.3f) scale(%.5f,%.5f)" d="%v"/>`+"\n", x, y, scale, -scale, escape(*d))
+}
+
+func escape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
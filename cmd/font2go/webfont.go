@@ -1,14 +1,33 @@
 package main
 
 import (
-	"log"
+	"fmt"
+	"math"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
 // FontData represents the SVG webfont data.
 type FontData struct {
-	Font *Font `xml:"defs>font"`
+	Metadata string `xml:"metadata"`
+	Font     *Font  `xml:"defs>font"`
+}
+
+// License returns the copyright and attribution lines of the webfont metadata.
+func (f *FontData) License() []string {
+	var lines []string
+	for _, line := range strings.Split(f.Metadata, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "This is a custom SVG webfont") {
+			continue
+		}
+		if parts := strings.SplitN(line, ":", 2); len(parts) == 2 {
+			line = strings.TrimSpace(parts[0]) + ": " + strings.TrimSpace(parts[1])
+		}
+		lines = append(lines, line)
+	}
+	return lines
 }
 
 // Font represents the <font> XML block of the webfont data.
@@ -18,13 +37,70 @@ type Font struct {
 	FontFace     *FontFace     `xml:"font-face"`
 	MissingGlyph *MissingGlyph `xml:"missing-glyph"`
 	Glyphs       []*Glyph      `xml:"glyph"`
+
+	// License holds the copyright lines of the webfont metadata.
+	License []string `xml:"-"`
 }
 
 // FontFace represents the <font-face> XML block of the webfont data.
 type FontFace struct {
-	UnitsPerEm int `xml:"units-per-em,attr"`
-	Ascent     int `xml:"ascent,attr"`
-	Descent    int `xml:"descent,attr"`
+	UnitsPerEm         int      `xml:"units-per-em,attr"`
+	Ascent             int      `xml:"ascent,attr"`
+	Descent            int      `xml:"descent,attr"`
+	UnderlinePosition  *float64 `xml:"underline-position,attr"`
+	UnderlineThickness *float64 `xml:"underline-thickness,attr"`
+	CapHeight          *float64 `xml:"cap-height,attr"`
+	XHeight            *float64 `xml:"x-height,attr"`
+}
+
+// Metrics fills in the vertical metrics missing from the font-face block:
+// cap height and x-height are measured from the "H" and "x" glyphs and
+// the underline defaults to a twentieth of an em, halfway to the descent.
+func (f *Font) Metrics() {
+	ff := f.FontFace
+	measure := func(unicode string, fallback float64) *float64 {
+		for _, g := range f.Glyphs {
+			if g.Unicode != nil && *g.Unicode == unicode && len(g.PathSteps) > 0 {
+				v := g.MaxY()
+				return &v
+			}
+		}
+		return &fallback
+	}
+	if ff.CapHeight == nil {
+		ff.CapHeight = measure("H", float64(ff.Ascent))
+	}
+	if ff.XHeight == nil {
+		ff.XHeight = measure("x", 0.5*float64(ff.Ascent))
+	}
+	if ff.UnderlinePosition == nil {
+		v := 0.5 * float64(ff.Descent)
+		ff.UnderlinePosition = &v
+	}
+	if ff.UnderlineThickness == nil {
+		v := float64(ff.UnitsPerEm) / 20
+		ff.UnderlineThickness = &v
+	}
+}
+
+// Subset removes the glyphs of characters other than those to keep.
+// Glyphs of several characters (ligatures) are kept if all of them are.
+func (f *Font) Subset(keep map[rune]bool) {
+	var glyphs []*Glyph
+	for _, g := range f.Glyphs {
+		if g.Unicode == nil || *g.Unicode == "" {
+			continue
+		}
+		all := true
+		for _, r := range *g.Unicode {
+			all = all && keep[r]
+		}
+		if all {
+			glyphs = append(glyphs, g)
+		}
+	}
+	logger.Info("subset font", "font", f.ID, "glyphs", len(glyphs), "of", len(f.Glyphs))
+	f.Glyphs = glyphs
 }
 
 // MissingGlyph represents the <missing-glyph> XML block of the webfont data.
@@ -67,15 +143,15 @@ var (
 	numRE   = regexp.MustCompile(`^\s*(-?\d+\.?\d*)[,\s+]?`)
 )
 
-// ParsePath parses a Glyph path.
+// ParsePath parses a Glyph path. Elliptical arcs are converted to cubic
+// Bézier curves as the gerber package does not render them.
 func (g *Glyph) ParsePath() {
 	if g == nil || g.D == nil {
 		return
 	}
 	d := *g.D
 	if g.DOrig != nil && *g.DOrig != "" {
-		// log.Printf("Warning: ignoring DOrig for glyph %+q", *g.Unicode)
-		log.Printf("Warning: using DOrig for glyph %+q", *g.Unicode)
+		logger.Warn("using DOrig for glyph", "glyph", fmt.Sprintf("%+q", *g.Unicode))
 		d = *g.DOrig
 	}
 
@@ -91,22 +167,26 @@ func (g *Glyph) ParsePath() {
 
 		m = cmdRE.FindStringSubmatch(d)
 		if len(m) >= 3 {
-			g.PathSteps = append(g.PathSteps, &PathStep{
-				Command:    m[1],
-				Parameters: parseParams(m[0][1:]),
-			})
+			step := &PathStep{Command: m[1]}
+			if strings.ToUpper(m[1]) == "A" {
+				step.Parameters = parseArcParams(m[0][1:])
+			} else {
+				step.Parameters = parseParams(m[0][1:])
+			}
+			g.PathSteps = append(g.PathSteps, step)
 			d = d[len(m[0]):]
 			continue
 		}
 
-		log.Fatalf("Unknown path command: %q", d)
+		fatal(fmt.Errorf("unknown path command: %q", d))
 	}
+	g.convertArcs()
 
 	if numZs > 1 && (g.GerberLP == nil || len(*g.GerberLP) != numZs) {
 		if g.GerberLP == nil {
-			log.Printf("Warning: glyph=%+q, numZs=%v, g.GerberLP=<nil>", *g.Unicode, numZs)
+			logger.Warn("mismatched GerberLP", "glyph", fmt.Sprintf("%+q", *g.Unicode), "numZs", numZs, "GerberLP", nil)
 		} else {
-			log.Printf("Warning: glyph=%+q, numZs=%v, g.GerberLP=%q", *g.Unicode, numZs, *g.GerberLP)
+			logger.Warn("mismatched GerberLP", "glyph", fmt.Sprintf("%+q", *g.Unicode), "numZs", numZs, "GerberLP", *g.GerberLP)
 		}
 	}
 }
@@ -114,7 +194,7 @@ func (g *Glyph) ParsePath() {
 func atof(s string) float64 {
 	v, err := strconv.ParseFloat(s, 64)
 	if err != nil {
-		log.Fatalf("unable to parse %q as float64", s)
+		fatal(fmt.Errorf("unable to parse %q as float64", s))
 	}
 	return v
 }
@@ -127,7 +207,66 @@ func parseParams(d string) (result []float64) {
 			d = d[len(m[0]):]
 			continue
 		}
-		log.Fatalf("parseParams: unable to parse %q", d)
+		fatal(fmt.Errorf("parseParams: unable to parse %q", d))
 	}
 	return result
 }
+
+// MaxY returns the maximum Y coordinate (in font units) reached by the
+// glyph's path, including Bézier control points.
+func (g *Glyph) MaxY() float64 {
+	var x, y float64
+	maxY := math.Inf(-1)
+	for _, ps := range g.PathSteps {
+		p := ps.Parameters
+		rel := ps.Command == strings.ToLower(ps.Command)
+		switch strings.ToUpper(ps.Command) {
+		case "H":
+			for _, v := range p {
+				if rel {
+					x += v
+				} else {
+					x = v
+				}
+			}
+		case "V":
+			for _, v := range p {
+				if rel {
+					y += v
+				} else {
+					y = v
+				}
+				maxY = math.Max(maxY, y)
+			}
+		case "M", "L", "T", "C", "S", "Q":
+			n := map[string]int{"M": 2, "L": 2, "T": 2, "C": 6, "S": 4, "Q": 4}[strings.ToUpper(ps.Command)]
+			for i := 0; i+n <= len(p); i += n {
+				for j := 1; j < n; j += 2 {
+					cy := p[i+j]
+					if rel {
+						cy += y
+					}
+					maxY = math.Max(maxY, cy)
+				}
+				if rel {
+					x, y = x+p[i+n-2], y+p[i+n-1]
+				} else {
+					x, y = p[i+n-2], p[i+n-1]
+				}
+			}
+		case "A":
+			for i := 0; i+7 <= len(p); i += 7 {
+				if rel {
+					x, y = x+p[i+5], y+p[i+6]
+				} else {
+					x, y = p[i+5], p[i+6]
+				}
+				maxY = math.Max(maxY, y)
+			}
+		}
+	}
+	if math.IsInf(maxY, -1) {
+		return 0
+	}
+	return maxY
+}
@@ -0,0 +1,236 @@
+// gerber is the go-gerber project tool.
+//
+// Usage:
+//
+//	gerber init [flags] <dir>
+//
+// init scaffolds a new design module in dir: a main.go program writing
+// the Gerber files of the board, a fab.json fab capability profile (the
+// design rules checked by gerbercheck) and go:generate hooks, so that
+// "go generate" writes the files, the ZIP and previews and checks them.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/gmlewis/go-gerber/internal/cli"
+)
+
+// goVersion is the minimum Go version of the go-gerber module (see its
+// go.mod), required of the design modules depending on it.
+const goVersion = "1.26.0"
+
+var logger = slog.Default()
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "init" {
+		fmt.Fprintln(os.Stderr, "usage: gerber init [flags] <dir>")
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet("gerber init", flag.ExitOnError)
+	opts := cli.Register(fs)
+	module := fs.String("module", "", "Module path of the new design (default: the directory name)")
+	width := fs.Float64("width", 50, "Width of the board in mm")
+	height := fs.Float64("height", 50, "Height of the board in mm")
+	force := fs.Bool("force", false, "Overwrite existing files")
+	if err := cli.Parse(fs, os.Args[2:]); err != nil {
+		fatal(err)
+	}
+	logger = opts.Logger()
+	if fs.NArg() != 1 {
+		fatal(fmt.Errorf("usage: gerber init [flags] <dir>"))
+	}
+
+	dir := fs.Arg(0)
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		fatal(err)
+	}
+	data := &project{
+		Name:   filepath.Base(abs),
+		Module: *module,
+		Width:  *width,
+		Height: *height,
+
+		GoVersion: goVersion,
+	}
+	if data.Module == "" {
+		data.Module = data.Name
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fatal(err)
+	}
+	for _, f := range scaffold {
+		if err := f.write(dir, data, *force); err != nil {
+			fatal(err)
+		}
+		logger.Info("wrote file", "file", filepath.Join(dir, f.name))
+	}
+
+	if !opts.Quiet {
+		fmt.Printf("Done. Run \"go mod tidy && go generate\" in %v.\n", dir)
+	}
+}
+
+// project is the data of the scaffolded design.
+type project struct {
+	Name   string
+	Module string
+	Width  float64
+	Height float64
+	// GoVersion is the minimum Go version of the design module.
+	GoVersion string
+}
+
+// file is a scaffolded file.
+type file struct {
+	name string
+	tmpl *template.Template
+}
+
+// write renders the file into dir, refusing to overwrite existing files
+// unless force is set.
+func (f *file) write(dir string, data *project, force bool) error {
+	name := filepath.Join(dir, f.name)
+	if _, err := os.Stat(name); err == nil && !force {
+		return fmt.Errorf("%v already exists (use -force to overwrite)", name)
+	}
+	var buf bytes.Buffer
+	if err := f.tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+	out := buf.Bytes()
+	if strings.HasSuffix(f.name, ".go") {
+		var err error
+		if out, err = format.Source(out); err != nil {
+			return fmt.Errorf("%v: %v", name, err)
+		}
+	}
+	return os.WriteFile(name, out, 0644)
+}
+
+var scaffold = []*file{
+	{name: "go.mod", tmpl: template.Must(template.New("go.mod").Parse(goModTemplate))},
+	{name: "main.go", tmpl: template.Must(template.New("main.go").Parse(mainTemplate))},
+	{name: "fab.json", tmpl: template.Must(template.New("fab.json").Parse(fabTemplate))},
+	{name: ".gitignore", tmpl: template.Must(template.New(".gitignore").Parse(gitignoreTemplate))},
+}
+
+func fatal(err error) {
+	logger.Error(err.Error())
+	os.Exit(1)
+}
+
+var goModTemplate = `module {{ .Module }}
+
+go {{ .GoVersion }}
+`
+
+var mainTemplate = `// {{ .Name }} creates Gerber files (and a bundled ZIP) of the
+// {{ .Name }} board for manufacture on a printed circuit board (PCB).
+//
+// Run "go generate" to write the files and previews and to check them
+// against the fab capabilities in fab.json.
+//
+//go:generate go run . -preview
+//go:generate go run github.com/gmlewis/go-gerber/cmd/gerbercheck -rules fab.json .
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/gmlewis/go-gerber/gerber"
+)
+
+var (
+	width    = flag.Float64("width", {{ .Width }}, "Width of the board in mm")
+	height   = flag.Float64("height", {{ .Height }}, "Height of the board in mm")
+	prefix   = flag.String("prefix", "{{ .Name }}", "Filename prefix for all Gerber files and zip")
+	fontName = flag.String("font", "ubuntumonoregular", "Name of font to use for the silkscreen (empty to not write)")
+	pts      = flag.Float64("pts", 12, "Font point size (72 pts = 1 inch = 25.4 mm)")
+	preview  = flag.Bool("preview", false, "Also write a PNG preview of the board")
+	strict   = flag.Bool("strict", false, "Fail on warnings (such as missing glyphs) instead of logging them")
+)
+
+const (
+	holeInset = 3.5 // mm
+	holeDrill = 3.2 // mm, for M3 screws
+	holePad   = 6.0 // mm
+)
+
+func main() {
+	flag.Parse()
+
+	b := gerber.Board2Layer(*prefix)
+	b.Strict = *strict
+	top, topMask, topSilk := b.TopCopper(), b.TopSolderMask(), b.TopSilkscreen()
+	bottom, bottomMask := b.BottomCopper(), b.BottomSolderMask()
+	drill, outline := b.Drill(), b.Outline()
+
+	w, h := *width, *height
+	outline.Add(
+		gerber.Line(0, 0, w, 0, gerber.CircleShape, 0.1),
+		gerber.Line(w, 0, w, h, gerber.CircleShape, 0.1),
+		gerber.Line(w, h, 0, h, gerber.CircleShape, 0.1),
+		gerber.Line(0, h, 0, 0, gerber.CircleShape, 0.1),
+	)
+
+	// Mounting holes in the corners.
+	for _, pt := range []gerber.Pt{gerber.Point(holeInset, holeInset), gerber.Point(w-holeInset, holeInset), gerber.Point(w-holeInset, h-holeInset), gerber.Point(holeInset, h-holeInset)} {
+		drill.Add(gerber.Circle(pt.X, pt.Y, holeDrill))
+		for _, l := range []*gerber.Layer{top, bottom, topMask, bottomMask} {
+			l.Add(gerber.Circle(pt.X, pt.Y, holePad))
+		}
+	}
+
+	// TODO: Add the design here.
+
+	if *fontName != "" {
+		tw, th := gerber.MeasureText(*prefix, *fontName, *pts)
+		topSilk.Add(gerber.Text(0.5*(w-tw), 0.5*(h-th), 1.0, *prefix, *fontName, *pts))
+	}
+
+	if err := b.WriteGerber(); err != nil {
+		log.Fatal(err)
+	}
+
+	if *preview {
+		if err := gerber.WritePNGFile(*prefix+".png", 300,
+			&gerber.PNGLayer{Layer: bottom}, &gerber.PNGLayer{Layer: top}, &gerber.PNGLayer{Layer: topSilk}, &gerber.PNGLayer{Layer: drill}); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	fmt.Println("Done.")
+}
+`
+
+var fabTemplate = `[
+	{"name": "fab-min-trace", "kind": "min-width", "layers": ["gtl", "gbl"], "params": {"width": 0.127}},
+	{"name": "fab-drill-size", "kind": "drill-size", "params": {"min": 0.2, "max": 6.35}},
+	{"name": "fab-clearance", "kind": "clearance", "layers": ["gtl", "gbl"], "params": {"clearance": 0.127}},
+	{"name": "fab-annular-ring", "kind": "annular-ring", "params": {"ring": 0.1}}
+]
+`
+
+var gitignoreTemplate = `*.g?l
+*.g?s
+*.g?o
+*.g?p
+*.gko
+*.xln
+*.drl
+*.zip
+*.png
+`
@@ -0,0 +1,220 @@
+// gerbercheck runs the validation passes, the design rules, the fab
+// capability checks and (with -audit) the cross-layer audit over a
+// directory of Gerber files (or the files written by a design program)
+// and exits with a nonzero status on violations, for gating CI pipelines.
+//
+// Usage:
+//
+//	gerbercheck [flags] <dir>
+//	gerbercheck [flags] -run ./path/to/design
+//
+// Exit status is 0 when all checks pass, 1 on violations (of at least the
+// -fail-on severity) and 2 when the files can't be checked at all.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/gmlewis/go-gerber/gerber"
+	"github.com/gmlewis/go-gerber/internal/cli"
+)
+
+// fabRules are the default fab capability checks,
+// typical of low-cost prototype fabs.
+const fabRules = `[
+	{"name": "fab-min-trace", "kind": "min-width", "layers": ["gtl", "gbl", "g2l", "g3l"], "params": {"width": 0.127}},
+	{"name": "fab-drill-size", "kind": "drill-size", "params": {"min": 0.2, "max": 6.35}},
+	{"name": "fab-clearance", "kind": "clearance", "layers": ["gtl", "gbl", "g2l", "g3l"], "params": {"clearance": 0.127}},
+	{"name": "fab-annular-ring", "kind": "annular-ring", "params": {"ring": 0.1}}
+]`
+
+var (
+	opts   = cli.Register(flag.CommandLine)
+	rules  = flag.String("rules", "", "JSON file of design rules (default: built-in fab capability rules)")
+	run    = flag.String("run", "", "Design program (Go package) to build and run, checking the files it writes")
+	failOn = flag.String("fail-on", "error", "Minimum severity of violations that fail the check (error, warning or note)")
+	sarif  = flag.String("sarif", "", "Write the violations as SARIF to this file (within -outdir)")
+	report = flag.String("report", "", "Write the violations as JSON to this file (within -outdir)")
+	audit  = flag.Bool("audit", false, "Cross-check the mask, paste, drill and silkscreen layers against the copper and the outline, reporting violations like the design rules")
+
+	logger = slog.Default()
+)
+
+func main() {
+	if err := cli.Parse(flag.CommandLine, os.Args[1:]); err != nil {
+		fatal(err)
+	}
+	logger = opts.Logger()
+	gerber.SetLogger(logger)
+
+	if *run == "" && flag.NArg() != 1 || *run != "" && flag.NArg() != 0 {
+		fatal(fmt.Errorf("usage: gerbercheck [flags] <dir> | -run <package>"))
+	}
+	dir := flag.Arg(0)
+	if *run != "" {
+		var err error
+		if dir, err = runDesign(*run); err != nil {
+			fatal(err)
+		}
+	}
+
+	g, err := readDir(dir)
+	if err != nil {
+		fatal(err)
+	}
+	ruleSet, err := loadRules()
+	if err != nil {
+		fatal(err)
+	}
+
+	var failed bool
+	if err := g.Validate(); err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			logger.Error("validation failed", "error", line)
+		}
+		failed = true
+	}
+
+	r, err := g.DRC(ruleSet)
+	if err != nil {
+		fatal(err)
+	}
+	if *audit {
+		r.Violations = append(r.Violations, g.Audit()...)
+	}
+	if err := writeReports(r); err != nil {
+		fatal(err)
+	}
+	for _, v := range r.Violations {
+		// Violations below -fail-on are only reported, so that they don't
+		// fail the check in strict mode either.
+		log := logger.Info
+		if severities[v.Severity] >= severities[gerber.Severity(*failOn)] {
+			log, failed = logger.Warn, true
+		}
+		log(v.Message, "rule", v.Rule, "severity", v.Severity, "layer", v.Layer, "index", v.Index)
+	}
+	logger.Info("checked design", "layers", len(g.Layers), "violations", len(r.Violations), "waived", r.Waived)
+	if err := opts.Err(); err != nil {
+		logger.Error(err.Error())
+		failed = true
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// severities ranks the severities for -fail-on.
+var severities = map[gerber.Severity]int{
+	gerber.SeverityNote:    1,
+	gerber.SeverityWarning: 2,
+	gerber.SeverityError:   3,
+}
+
+// runDesign builds the design program and runs it in its package
+// directory (where design programs expect to find their inputs and
+// write their files), returning the directory.
+func runDesign(pkg string) (string, error) {
+	tmp, err := os.MkdirTemp("", "gerbercheck")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	bin := filepath.Join(tmp, "design")
+	build := exec.Command("go", "build", "-o", bin, pkg)
+	build.Stdout, build.Stderr = os.Stderr, os.Stderr
+	if err := build.Run(); err != nil {
+		return "", fmt.Errorf("building %v: %v", pkg, err)
+	}
+	dir, err := exec.Command("go", "list", "-f", "{{.Dir}}", pkg).Output()
+	if err != nil {
+		return "", fmt.Errorf("locating %v: %v", pkg, err)
+	}
+	logger.Info("running design program", "package", pkg)
+	design := exec.Command(bin)
+	design.Dir, design.Stdout, design.Stderr = strings.TrimSpace(string(dir)), os.Stderr, os.Stderr
+	if err := design.Run(); err != nil {
+		return "", fmt.Errorf("running %v: %v", pkg, err)
+	}
+	return design.Dir, nil
+}
+
+// readDir reads all the Gerber layer files in the directory.
+func readDir(dir string) (*gerber.Gerber, error) {
+	g := gerber.New(filepath.Base(dir))
+	for _, ext := range gerber.LayerExtensions() {
+		files, err := filepath.Glob(filepath.Join(dir, "*."+ext))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range files {
+			f, err := os.Open(name)
+			if err != nil {
+				return nil, err
+			}
+			_, err = g.ReadLayer(name, f)
+			f.Close()
+			if err != nil {
+				return nil, err
+			}
+			logger.Info("read layer", "file", name)
+		}
+	}
+	if len(g.Layers) == 0 {
+		return nil, fmt.Errorf("%v: no Gerber files found", dir)
+	}
+	return g, nil
+}
+
+func loadRules() ([]*gerber.Rule, error) {
+	if _, ok := severities[gerber.Severity(*failOn)]; !ok {
+		return nil, fmt.Errorf("invalid -fail-on severity %q", *failOn)
+	}
+	if *rules == "" {
+		return gerber.LoadRules(strings.NewReader(fabRules))
+	}
+	f, err := os.Open(*rules)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return gerber.LoadRules(f)
+}
+
+func writeReports(r *gerber.DRCReport) error {
+	for _, out := range []struct {
+		name  string
+		write func(w io.Writer) error
+	}{
+		{*sarif, r.WriteSARIF},
+		{*report, r.WriteJSON},
+	} {
+		if out.name == "" {
+			continue
+		}
+		f, err := os.Create(opts.Path(out.name))
+		if err != nil {
+			return err
+		}
+		if err := out.write(f); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fatal(err error) {
+	logger.Error(err.Error())
+	os.Exit(2)
+}
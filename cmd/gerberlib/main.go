@@ -0,0 +1,402 @@
+// gerberlib manages a local library of fonts and footprints converted
+// for go-gerber designs.
+//
+// Usage:
+//
+//	gerberlib add [flags] <file or URL>...
+//	gerberlib list [flags]
+//	gerberlib gen [flags] <dir>
+//
+// add downloads (or copies) SVG webfonts, TrueType or OpenType fonts and
+// KiCad footprints (.kicad_mod files) into the library under
+// -lib/-version, then converts the whole library into a Go package (SVG
+// webfonts with font2go, TrueType and OpenType fonts and footprints
+// embedded and parsed at init time) with an index of its fonts and
+// footprints. list prints the fonts and footprints of the library and gen
+// copies its Go package into dir (e.g. a package of a design module)
+// where importing it registers the fonts in gerber.Fonts and the
+// footprints (see footprints.ReadKiCad) in its Footprints map.
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/gmlewis/go-gerber/footprints"
+	"github.com/gmlewis/go-gerber/internal/cli"
+)
+
+var logger = slog.Default()
+
+// fontExtensions are the supported font source file extensions.
+var fontExtensions = map[string]bool{".svg": true, ".ttf": true, ".otf": true}
+
+// footprintExtension is the extension of KiCad footprint sources.
+const footprintExtension = ".kicad_mod"
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd := os.Args[1]
+	fs := flag.NewFlagSet("gerberlib "+cmd, flag.ExitOnError)
+	opts := cli.Register(fs)
+	cache, _ := os.UserCacheDir()
+	lib := fs.String("lib", filepath.Join(cache, "go-gerber", "lib"), "Directory of the local library")
+	version := fs.String("version", "v1", "Version of the library, so that designs can pin the fonts they were made with")
+	pkg := fs.String("package", "gerberlib", "Package name of the generated Go package")
+	font2go := fs.String("font2go", "go run github.com/gmlewis/go-gerber/cmd/font2go", "Command converting SVG webfonts to Go")
+	if err := cli.Parse(fs, os.Args[2:]); err != nil {
+		fatal(err)
+	}
+	logger = opts.Logger()
+	l := &library{dir: filepath.Join(*lib, *version), version: *version, pkg: *pkg, font2go: strings.Fields(*font2go), strict: opts.Strict}
+
+	var err error
+	switch cmd {
+	case "add":
+		if fs.NArg() == 0 {
+			usage()
+		}
+		err = l.add(fs.Args())
+	case "list":
+		var fonts, fps []*entry
+		if fonts, err = l.fonts(); err == nil {
+			fps, err = l.footprints()
+		}
+		for _, e := range append(fonts, fps...) {
+			fmt.Printf("%v\t%v\n", e.Name, e.Source)
+		}
+	case "gen":
+		if fs.NArg() != 1 {
+			usage()
+		}
+		err = l.gen(fs.Arg(0))
+	default:
+		usage()
+	}
+	if err == nil {
+		err = opts.Err()
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: gerberlib add|list|gen [flags] [args]")
+	os.Exit(2)
+}
+
+// library is a versioned local library: its sources and their
+// conversion into a Go package.
+type library struct {
+	dir     string
+	version string
+	pkg     string
+	font2go []string
+	strict  bool
+}
+
+// entry is a font or footprint of the library.
+type entry struct {
+	// Name is the name the font is registered under in gerber.Fonts, or
+	// that of the footprint in the Footprints of the package.
+	Name string
+	// Source is the filename of the source in the library.
+	Source string
+}
+
+func (l *library) sources() string { return filepath.Join(l.dir, "sources") }
+func (l *library) pkgDir() string  { return filepath.Join(l.dir, l.pkg) }
+
+// add fetches the font and footprint sources into the library and
+// converts it.
+func (l *library) add(args []string) error {
+	if err := os.MkdirAll(l.sources(), 0755); err != nil {
+		return err
+	}
+	for _, arg := range args {
+		name := path.Base(arg)
+		ext := strings.ToLower(filepath.Ext(name))
+		if !fontExtensions[ext] && ext != footprintExtension {
+			return fmt.Errorf("%v: unsupported source (want .svg, .ttf, .otf or %v)", arg, footprintExtension)
+		}
+		data, err := fetch(arg)
+		if err != nil {
+			return err
+		}
+		if ext == footprintExtension {
+			if _, err := footprints.ReadKiCad(bytes.NewReader(data)); err != nil {
+				return fmt.Errorf("%v: %v", arg, err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(l.sources(), name), data, 0644); err != nil {
+			return err
+		}
+		logger.Info("added source", "source", arg, "library", l.dir)
+	}
+	return l.convert()
+}
+
+// fetch downloads the URL or reads the file.
+func fetch(arg string) ([]byte, error) {
+	if !strings.HasPrefix(arg, "http://") && !strings.HasPrefix(arg, "https://") {
+		return os.ReadFile(arg)
+	}
+	resp, err := http.Get(arg)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%v: %v", arg, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// fonts returns the fonts of the library sorted by name.
+func (l *library) fonts() ([]*entry, error) {
+	files, err := filepath.Glob(filepath.Join(l.sources(), "*"))
+	if err != nil {
+		return nil, err
+	}
+	var fonts []*entry
+	for _, file := range files {
+		ext := strings.ToLower(filepath.Ext(file))
+		if !fontExtensions[ext] {
+			continue
+		}
+		f := &entry{Name: strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)), Source: filepath.Base(file)}
+		if ext == ".svg" {
+			if f.Name, err = webfontID(file); err != nil {
+				return nil, err
+			}
+		}
+		fonts = append(fonts, f)
+	}
+	sort.Slice(fonts, func(a, b int) bool { return fonts[a].Name < fonts[b].Name })
+	return fonts, nil
+}
+
+// footprints returns the footprints of the library sorted by name.
+func (l *library) footprints() ([]*entry, error) {
+	files, err := filepath.Glob(filepath.Join(l.sources(), "*"+footprintExtension))
+	if err != nil {
+		return nil, err
+	}
+	var fps []*entry
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		f, err := footprints.ReadKiCad(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", file, err)
+		}
+		fps = append(fps, &entry{Name: f.Name, Source: filepath.Base(file)})
+	}
+	sort.Slice(fps, func(a, b int) bool { return fps[a].Name < fps[b].Name })
+	return fps, nil
+}
+
+// webfontID returns the name font2go registers the SVG webfont under.
+func webfontID(filename string) (string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	var svg struct {
+		Font struct {
+			ID string `xml:"id,attr"`
+		} `xml:"defs>font"`
+	}
+	if err := xml.Unmarshal(data, &svg); err != nil {
+		return "", fmt.Errorf("%v: %v", filename, err)
+	}
+	return strings.ToLower(svg.Font.ID), nil
+}
+
+// convert regenerates the Go package of the library from its sources.
+func (l *library) convert() error {
+	fonts, err := l.fonts()
+	if err != nil {
+		return err
+	}
+	fps, err := l.footprints()
+	if err != nil {
+		return err
+	}
+	dir := l.pkgDir()
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	var webfonts []string
+	data := &indexData{Package: l.pkg, Version: l.version, Fonts: fonts, Footprints: fps}
+	for _, f := range fonts {
+		src := filepath.Join(l.sources(), f.Source)
+		if strings.ToLower(filepath.Ext(f.Source)) == ".svg" {
+			webfonts = append(webfonts, src)
+			continue
+		}
+		// TrueType and OpenType fonts are embedded and parsed at init time.
+		buf, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, f.Source), buf, 0644); err != nil {
+			return err
+		}
+		data.Embedded = append(data.Embedded, f)
+	}
+	for _, f := range fps {
+		// Footprints are embedded and read at init time.
+		buf, err := os.ReadFile(filepath.Join(l.sources(), f.Source))
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, f.Source), buf, 0644); err != nil {
+			return err
+		}
+	}
+	if len(webfonts) > 0 {
+		args := append(l.font2go[1:], "-package", l.pkg, "-outdir", dir, "-out", "webfonts.go", "-quiet")
+		if l.strict {
+			args = append(args, "-strict")
+		}
+		cmd := exec.Command(l.font2go[0], append(args, webfonts...)...)
+		cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("converting webfonts: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := indexTemplate.Execute(&buf, data); err != nil {
+		return err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.go"), src, 0644); err != nil {
+		return err
+	}
+	logger.Info("converted library", "fonts", len(fonts), "footprints", len(fps), "package", dir)
+	return nil
+}
+
+// gen copies the Go package of the library into dir.
+func (l *library) gen(dir string) error {
+	files, err := filepath.Glob(filepath.Join(l.pkgDir(), "*"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("library %v is empty: add fonts or footprints first", l.dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, file := range files {
+		buf, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, filepath.Base(file)), buf, 0644); err != nil {
+			return err
+		}
+	}
+	logger.Info("generated package", "dir", dir, "files", len(files))
+	return nil
+}
+
+// fatal logs the error and exits.
+func fatal(err error) {
+	logger.Error(err.Error())
+	os.Exit(1)
+}
+
+// indexData represents the data used to generate the package index.
+type indexData struct {
+	Package    string
+	Version    string
+	Fonts      []*entry
+	Embedded   []*entry
+	Footprints []*entry
+}
+
+var indexTemplate = template.Must(template.New("index").Parse(`// Code generated by gerberlib - DO NOT EDIT.
+
+// Package {{ .Package }} is a library of fonts and footprints converted by
+// gerberlib. Importing it registers its fonts in gerber.Fonts.
+package {{ .Package }}
+{{ if or .Embedded .Footprints }}
+import (
+	{{ if .Footprints }}"bytes"
+	{{ end }}_ "embed"
+	{{ if .Footprints }}
+	"github.com/gmlewis/go-gerber/footprints"{{ end }}{{ if .Embedded }}
+	"github.com/gmlewis/go-gerber/gerber"{{ end }}
+)
+{{ end }}
+// Version is the version of the library.
+const Version = {{ printf "%q" .Version }}
+
+// Names are the names of the fonts of the library in gerber.Fonts.
+var Names = []string{ {{ range .Fonts }}
+	{{ printf "%q" .Name }},{{ end }}
+}
+{{ range $i, $f := .Embedded }}
+//go:embed {{ $f.Source }}
+var font{{ $i }} []byte
+{{ end }}{{ if .Embedded }}
+func init() {
+	for name, data := range map[string][]byte{ {{ range $i, $f := .Embedded }}
+		{{ printf "%q" $f.Name }}: font{{ $i }},{{ end }}
+	} {
+		f, err := gerber.ParseFont(data)
+		if err != nil {
+			panic(name + ": " + err.Error())
+		}
+		f.ID = name
+		gerber.Fonts[name] = f
+	}
+}
+{{ end }}{{ if .Footprints }}
+// Footprints are the footprints of the library by name.
+var Footprints = map[string]*footprints.Footprint{}
+{{ range $i, $f := .Footprints }}
+//go:embed {{ printf "%q" $f.Source }}
+var footprint{{ $i }} []byte
+{{ end }}
+func init() {
+	for name, data := range map[string][]byte{ {{ range $i, $f := .Footprints }}
+		{{ printf "%q" $f.Name }}: footprint{{ $i }},{{ end }}
+	} {
+		f, err := footprints.ReadKiCad(bytes.NewReader(data))
+		if err != nil {
+			panic(name + ": " + err.Error())
+		}
+		Footprints[name] = f
+	}
+}
+{{ end }}`))
@@ -129,7 +129,7 @@ func main() {
 		}
 		lines := strings.Split(string(buf), "\n")
 		half := len(lines) / 2
-		x, y := -40000.0, 100000.0
+		x, y := -24.1, 60.3
 
 		tss := g.TopSilkscreen()
 		tss.Add(
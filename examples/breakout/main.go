@@ -0,0 +1,43 @@
+// breakout creates Gerber files (and a bundled ZIP) representing
+// a breakout board for a dual-row surface mount package, fanning
+// its pins out to 2.54mm headers.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	. "github.com/gmlewis/go-gerber/gerber"
+)
+
+var (
+	name       = flag.String("name", "SOIC-8", "Name of the package to print on the silkscreen")
+	pins       = flag.Int("pins", 8, "Total number of pins (even)")
+	pitch      = flag.Float64("pitch", 1.27, "Pin pitch in mm")
+	bodyWidth  = flag.Float64("width", 3.9, "Body width (across the rows) in mm")
+	bodyLength = flag.Float64("length", 4.9, "Body length (along the rows) in mm")
+	prefix     = flag.String("prefix", "breakout", "Filename prefix for all Gerber files and zip")
+	fontName   = flag.String("font", "ubuntumonoregular", "Name of font to use for labels")
+)
+
+func main() {
+	flag.Parse()
+
+	pkg := &Package{
+		Name:       *name,
+		Pins:       *pins,
+		Pitch:      *pitch,
+		BodyWidth:  *bodyWidth,
+		BodyLength: *bodyLength,
+	}
+	g, err := Breakout(*prefix, pkg, *fontName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := g.WriteGerber(); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("Done.")
+}
@@ -0,0 +1,176 @@
+// Package footprints provides parametric land patterns of common
+// components (chip passives, SOIC, TSSOP, QFN and pin headers) for
+// go-gerber designs.
+//
+// A footprint is placed with a single call that adds its pads (as pad
+// stack instances expanded into the copper, solder mask and paste
+// layers), its silkscreen outline and its component for assembly:
+//
+//	footprints.SOIC(8).At(10, 20, 90).Ref("U1").Net("1", "GND").AddTo(g, silk)
+//
+// Footprints are read from and written to KiCad footprint files with
+// ReadKiCad and Footprint.WriteKiCad.
+//
+// All dimensions are in millimeters and angles in degrees.
+package footprints
+
+import (
+	"math"
+
+	"github.com/gmlewis/go-gerber/gerber"
+)
+
+// SilkWidth is the width of the silkscreen lines of the footprints.
+var SilkWidth = 0.12
+
+// Footprint is a land pattern: the pads of a component and its
+// silkscreen outline, relative to the center of the component.
+type Footprint struct {
+	// Name is the name of the footprint (e.g. "SOIC-8").
+	Name string
+	// Mount is the mount type of the component ("SMD" or "TH").
+	Mount string
+	// Pads are the pads of the footprint, pin 1 first.
+	Pads []*Pad
+	// Silkscreen are the silkscreen lines of the footprint.
+	Silkscreen [][2]gerber.Pt
+	// Pin1 is the location of the pin 1 marker dot, if any.
+	Pin1 *gerber.Pt
+	// Width and Height are the size of the component body.
+	Width, Height float64
+	// Courtyard and Fab are the lines of the courtyard and fabrication
+	// layers of the footprint, kept for exporting it (see WriteKiCad).
+	Courtyard, Fab [][2]gerber.Pt
+	// ExcludeFromBOM leaves the component out of bills of materials.
+	ExcludeFromBOM bool
+	// Models are the references to the 3D models of the component
+	// (e.g. "${KICAD8_3DMODEL_DIR}/Package_SO.3dshapes/SOIC-8.wrl").
+	Models []string
+
+	bottom map[*gerber.PadStack]*gerber.PadStack // stacks mirrored to the bottom side
+}
+
+// Pad is a pad of a footprint.
+type Pad struct {
+	// Number is the pin number (e.g. "1" or "EP").
+	Number string
+	// X and Y are the center of the pad.
+	X, Y float64
+	// Rotation is the rotation of the pad stack.
+	Rotation float64
+	// Stack is the pad stack of the pad, shared by the pads of the same
+	// shape, so changing it (e.g. its MaskExpansion) updates them all.
+	Stack *gerber.PadStack
+}
+
+// Placement is a footprint placed on a design (see Footprint.At).
+type Placement struct {
+	f        *Footprint
+	x, y     float64
+	rotation float64
+	bottom   bool
+	refdes   string
+	value    string
+	nets     map[string]string
+}
+
+// At returns the footprint placed with its center at x,y and rotated
+// by rotation degrees (counterclockwise).
+func (f *Footprint) At(x, y, rotation float64) *Placement {
+	return &Placement{f: f, x: x, y: y, rotation: rotation, nets: map[string]string{}}
+}
+
+// Bottom mounts the footprint on the bottom side, mirrored about its
+// Y axis (as seen from the top).
+func (p *Placement) Bottom() *Placement {
+	p.bottom = true
+	return p
+}
+
+// Ref sets the reference designator of the component (e.g. "U1").
+func (p *Placement) Ref(refdes string) *Placement {
+	p.refdes = refdes
+	return p
+}
+
+// Value sets the value of the component (e.g. "10k").
+func (p *Placement) Value(value string) *Placement {
+	p.value = value
+	return p
+}
+
+// Net connects the pad with the pin number to the named net.
+func (p *Placement) Net(pin, net string) *Placement {
+	p.nets[pin] = net
+	return p
+}
+
+// point returns the design coordinates of the footprint point x,y.
+func (p *Placement) point(x, y float64) gerber.Pt {
+	if p.bottom {
+		x = -x
+	}
+	sin, cos := math.Sincos(p.rotation * math.Pi / 180)
+	return gerber.Pt{X: p.x + x*cos - y*sin, Y: p.y + x*sin + y*cos}
+}
+
+// AddTo adds the pads of the placed footprint to the design, its
+// silkscreen outline to silk (unless nil) and its component with its
+// pins to the design for assembly, and returns the component.
+func (p *Placement) AddTo(g *gerber.Gerber, silk *gerber.Layer) *gerber.Component {
+	c := &gerber.Component{
+		Refdes:    p.refdes,
+		Value:     p.value,
+		Footprint: p.f.Name,
+		Mount:     p.f.Mount,
+		X:         p.x,
+		Y:         p.y,
+		Rotation:  p.rotation,
+		Bottom:    p.bottom,
+	}
+	for _, pad := range p.f.Pads {
+		at := p.point(pad.X, pad.Y)
+		stack, rotation := pad.Stack, pad.Rotation
+		if p.bottom {
+			stack, rotation = p.f.bottomStack(stack), -rotation
+		}
+		instance := stack.At(at.X, at.Y).Rotate(rotation + p.rotation)
+		if net := p.nets[pad.Number]; net != "" {
+			instance.Net(net)
+		}
+		if p.refdes != "" {
+			instance.Pin(p.refdes, pad.Number)
+		}
+		g.AddPad(instance)
+		c.Pins = append(c.Pins, &gerber.ComponentPin{Number: pad.Number, X: at.X, Y: at.Y})
+	}
+	w, h := 0.5*p.f.Width, 0.5*p.f.Height
+	for _, pt := range []gerber.Pt{{X: -w, Y: -h}, {X: w, Y: -h}, {X: w, Y: h}, {X: -w, Y: h}} {
+		c.Outline = append(c.Outline, p.point(pt.X, pt.Y))
+	}
+	if silk != nil {
+		for _, line := range p.f.Silkscreen {
+			a, b := p.point(line[0].X, line[0].Y), p.point(line[1].X, line[1].Y)
+			silk.Add(gerber.Line(a.X, a.Y, b.X, b.Y, gerber.CircleShape, SilkWidth))
+		}
+		if p.f.Pin1 != nil {
+			dot := p.point(p.f.Pin1.X, p.f.Pin1.Y)
+			silk.Add(gerber.Circle(dot.X, dot.Y, 2*SilkWidth))
+		}
+	}
+	return g.AddComponent(c)
+}
+
+// bottomStack returns the pad stack with its top and bottom pads swapped.
+func (f *Footprint) bottomStack(s *gerber.PadStack) *gerber.PadStack {
+	if f.bottom == nil {
+		f.bottom = map[*gerber.PadStack]*gerber.PadStack{}
+	}
+	if b, ok := f.bottom[s]; ok {
+		return b
+	}
+	b := *s
+	b.Top, b.Bottom = s.Bottom, s.Top
+	f.bottom[s] = &b
+	return &b
+}
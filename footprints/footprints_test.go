@@ -0,0 +1,101 @@
+package footprints
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-gerber/gerber"
+)
+
+func near(a, b gerber.Pt) bool {
+	return math.Abs(a.X-b.X) < 1e-9 && math.Abs(a.Y-b.Y) < 1e-9
+}
+
+func TestPlacement_AddTo(t *testing.T) {
+	g := gerber.New("board")
+	top := g.TopCopper()
+	silk := g.TopSilkscreen()
+	c := SOIC(8).At(10, 20, 90).Ref("U1").Value("NE555").Net("1", "GND").AddTo(g, silk)
+
+	if len(g.Pads) != 8 || len(c.Pins) != 8 || len(g.Components) != 1 {
+		t.Fatalf("added %v pads and %v pins, want 8", len(g.Pads), len(c.Pins))
+	}
+	// Pin 1 at (-2.475,1.905) is rotated by 90 degrees.
+	if got, want := (gerber.Pt{X: c.Pins[0].X, Y: c.Pins[0].Y}), (gerber.Pt{X: 10 - 1.905, Y: 20 - 2.475}); !near(got, want) {
+		t.Errorf("pin 1 at %v, want %v", got, want)
+	}
+	if got, want := (gerber.Pt{X: c.Pins[4].X, Y: c.Pins[4].Y}), (gerber.Pt{X: 10 + 1.905, Y: 20 + 2.475}); !near(got, want) {
+		t.Errorf("pin 5 at %v, want %v", got, want)
+	}
+	if c.Footprint != "SOIC-8" || c.Mount != "SMD" || c.Value != "NE555" || len(c.Outline) != 4 {
+		t.Errorf("component = %+v", c)
+	}
+	if len(silk.Primitives) != 3 {
+		t.Errorf("silkscreen has %v primitives, want 2 lines and the pin 1 marker", len(silk.Primitives))
+	}
+
+	var buf bytes.Buffer
+	g.Layers = []*gerber.Layer{top}
+	g.Pads = g.Pads[:1]
+	if err := g.WriteComponents(&buf, false); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "%TO.C,U1*%") {
+		t.Errorf("missing component U1 in:\n%v", buf.String())
+	}
+}
+
+func TestPlacement_Bottom(t *testing.T) {
+	g := gerber.New("board")
+	f := Chip("0603")
+	f.At(0, 0, 0).Ref("R1").AddTo(g, nil)
+	c := f.At(5, 0, 0).Ref("R2").Bottom().AddTo(g, nil)
+	if !c.Bottom {
+		t.Error("component is not on the bottom side")
+	}
+	// Mirrored, pad 1 is on the right.
+	if got := c.Pins[0].X; math.Abs(got-5.825) > 1e-9 {
+		t.Errorf("pad 1 at x=%v, want 5.825", got)
+	}
+	if g.Pads[2].Stack().Bottom == nil || g.Pads[2].Stack().Top != nil {
+		t.Error("bottom pad stack has no bottom pad")
+	}
+	if g.Pads[2].Stack() != g.Pads[3].Stack() {
+		t.Error("bottom pads do not share their pad stack")
+	}
+}
+
+func TestFootprints(t *testing.T) {
+	tests := []struct {
+		name string
+		f    *Footprint
+		pads int
+		// A pad and its expected center.
+		pad  int
+		want gerber.Pt
+	}{
+		{name: "Chip_0805", f: Chip("0805"), pads: 2, pad: 1, want: gerber.Pt{X: 0.9125}},
+		{name: "TSSOP-14", f: TSSOP(14), pads: 14, pad: 13, want: gerber.Pt{X: 2.8625, Y: 1.95}},
+		{name: "QFN-16_3x3_P0.5", f: QFN(16, 3, 0.5, 1.7), pads: 17, pad: 4, want: gerber.Pt{X: -0.75, Y: -1.4}},
+		{name: "PinHeader_2x05_P2.54mm", f: Header(10, 2), pads: 10, pad: 1, want: gerber.Pt{X: 1.27, Y: 5.08}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.f.Name != tt.name {
+				t.Errorf("Name = %q, want %q", tt.f.Name, tt.name)
+			}
+			if len(tt.f.Pads) != tt.pads {
+				t.Fatalf("%v pads, want %v", len(tt.f.Pads), tt.pads)
+			}
+			p := tt.f.Pads[tt.pad]
+			if got := (gerber.Pt{X: p.X, Y: p.Y}); !near(got, tt.want) {
+				t.Errorf("pad %v at %v, want %v", p.Number, got, tt.want)
+			}
+		})
+	}
+	if Chip("2512") != nil {
+		t.Error("Chip(2512) != nil, want nil for an unknown size")
+	}
+}
@@ -0,0 +1,312 @@
+package footprints
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gmlewis/go-gerber/gerber"
+)
+
+// Widths of the lines of the courtyard and fabrication layers written
+// by WriteKiCad, after the KiCad library conventions.
+const (
+	courtyardWidth = 0.05
+	fabWidth       = 0.1
+)
+
+// ReadKiCad reads a KiCad footprint (a .kicad_mod file) with its pads,
+// its silkscreen, courtyard and fabrication lines (F.SilkS, F.CrtYd and
+// F.Fab, rectangles being split into lines), its pin 1 marker (a filled
+// silkscreen circle), its SMD, through-hole and exclude-from-BOM
+// attributes and its 3D model references. Pads with the same shape and
+// layers share their pad stack. Other graphics are left out. The size of
+// the body is that of the fabrication lines.
+// KiCad's Y axis points down, so Y coordinates are negated.
+func ReadKiCad(r io.Reader) (*Footprint, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	root, err := parseSexpr(data)
+	if err != nil {
+		return nil, err
+	}
+	if h := root.head(); h != "footprint" && h != "module" || len(root.list) < 2 {
+		return nil, errors.New("not a KiCad footprint")
+	}
+	f := &Footprint{Name: root.list[1].atom}
+	stacks := map[string]*gerber.PadStack{}
+	for _, n := range root.list[2:] {
+		switch n.head() {
+		case "attr":
+			for _, a := range n.list[1:] {
+				switch a.atom {
+				case "smd":
+					f.Mount = "SMD"
+				case "through_hole":
+					f.Mount = "TH"
+				case "exclude_from_bom":
+					f.ExcludeFromBOM = true
+				}
+			}
+		case "fp_line", "fp_rect":
+			start, end := n.child("start").pt(), n.child("end").pt()
+			lines := [][2]gerber.Pt{{start, end}}
+			if n.head() == "fp_rect" {
+				a, b := gerber.Pt{X: end.X, Y: start.Y}, gerber.Pt{X: start.X, Y: end.Y}
+				lines = [][2]gerber.Pt{{start, a}, {a, end}, {end, b}, {b, start}}
+			}
+			switch n.child("layer").arg(0) {
+			case "F.SilkS":
+				f.Silkscreen = append(f.Silkscreen, lines...)
+			case "F.CrtYd":
+				f.Courtyard = append(f.Courtyard, lines...)
+			case "F.Fab":
+				f.Fab = append(f.Fab, lines...)
+			}
+		case "fp_circle":
+			if fill := n.child("fill").arg(0); n.child("layer").arg(0) == "F.SilkS" && (fill == "solid" || fill == "yes") {
+				center := n.child("center").pt()
+				f.Pin1 = &center
+			}
+		case "pad":
+			pad, err := readKiCadPad(n, stacks)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v", f.Name, err)
+			}
+			f.Pads = append(f.Pads, pad)
+		case "model":
+			f.Models = append(f.Models, n.arg(0))
+		}
+	}
+	if len(f.Fab) > 0 {
+		min, max := f.Fab[0][0], f.Fab[0][0]
+		for _, line := range f.Fab {
+			for _, pt := range line {
+				min = gerber.Pt{X: math.Min(min.X, pt.X), Y: math.Min(min.Y, pt.Y)}
+				max = gerber.Pt{X: math.Max(max.X, pt.X), Y: math.Max(max.Y, pt.Y)}
+			}
+		}
+		f.Width, f.Height = max.X-min.X, max.Y-min.Y
+	}
+	return f, nil
+}
+
+// readKiCadPad returns the pad of the footprint, sharing the pad stacks
+// with the same KiCad definition.
+func readKiCadPad(n *node, stacks map[string]*gerber.PadStack) (*Pad, error) {
+	number, kind, shape := n.arg(0), n.arg(1), n.arg(2)
+	at, size := n.child("at"), n.child("size")
+	pad := &Pad{Number: number, X: at.num(0), Y: -at.num(1), Rotation: at.num(2)}
+	w, h := size.num(0), size.num(1)
+	s := &gerber.PadStack{
+		MaskExpansion: n.child("solder_mask_margin").num(0),
+		PasteShrink:   -n.child("solder_paste_margin").num(0),
+		Tented:        true,
+	}
+	if h == w {
+		h = 0
+	}
+	ps := &gerber.PadShape{Width: w, Height: h}
+	switch shape {
+	case "circle", "oval":
+		ps.Shape = gerber.CircleShape
+	case "rect", "roundrect", "trapezoid":
+		ps.Shape = gerber.RectShape
+	default:
+		return nil, fmt.Errorf("pad %v: unsupported shape %v", number, shape)
+	}
+	for _, l := range n.child("layers").list[1:] {
+		switch {
+		case l.atom == "F.Cu" && kind == "smd":
+			s.Top = ps
+		case l.atom == "B.Cu" && kind == "smd":
+			s.Bottom = ps
+		case strings.HasSuffix(l.atom, ".Mask"):
+			s.Tented = false
+		case strings.HasSuffix(l.atom, ".Paste"):
+			s.Paste = true
+		}
+	}
+	switch kind {
+	case "smd":
+	case "thru_hole":
+		s.Top, s.Inner, s.Bottom = ps, ps, ps
+		fallthrough
+	case "np_thru_hole":
+		drill := n.child("drill")
+		if drill.arg(0) == "oval" {
+			// Slots are drilled with their width.
+			drill.list = drill.list[1:]
+		}
+		s.Drill, s.NonPlated = drill.num(0), kind == "np_thru_hole"
+	default:
+		return nil, fmt.Errorf("pad %v: unsupported kind %v", number, kind)
+	}
+	if !s.Paste {
+		s.PasteShrink = 0
+	}
+
+	key := s.KiCad("", 0, 0, 0)
+	if stacks[key] == nil {
+		s.Name = fmt.Sprintf("%v_%v_%vx%v", kind, shape, w, size.num(1))
+		stacks[key] = s
+	}
+	pad.Stack = stacks[key]
+	return pad, nil
+}
+
+// WriteKiCad writes the footprint as a KiCad footprint (a .kicad_mod
+// file) that ReadKiCad reads back: its pads (see gerber.PadStack.KiCad),
+// its silkscreen, courtyard and fabrication lines, its pin 1 marker, its
+// attributes and its 3D model references.
+func (f *Footprint) WriteKiCad(w io.Writer) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "(footprint %q\n  (layer \"F.Cu\")\n", f.Name)
+	var attrs []string
+	switch f.Mount {
+	case "SMD":
+		attrs = append(attrs, "smd")
+	case "TH":
+		attrs = append(attrs, "through_hole")
+	}
+	if f.ExcludeFromBOM {
+		attrs = append(attrs, "exclude_from_bom")
+	}
+	if len(attrs) > 0 {
+		fmt.Fprintf(&b, "  (attr %v)\n", strings.Join(attrs, " "))
+	}
+	for _, layer := range []struct {
+		name  string
+		lines [][2]gerber.Pt
+		width float64
+	}{
+		{"F.SilkS", f.Silkscreen, SilkWidth},
+		{"F.CrtYd", f.Courtyard, courtyardWidth},
+		{"F.Fab", f.Fab, fabWidth},
+	} {
+		for _, line := range layer.lines {
+			fmt.Fprintf(&b, "  (fp_line (start %v %v) (end %v %v) (stroke (width %v) (type solid)) (layer %q))\n",
+				num(line[0].X), num(-line[0].Y), num(line[1].X), num(-line[1].Y), num(layer.width), layer.name)
+		}
+	}
+	if f.Pin1 != nil {
+		fmt.Fprintf(&b, "  (fp_circle (center %v %v) (end %v %v) (stroke (width %v) (type solid)) (fill solid) (layer \"F.SilkS\"))\n",
+			num(f.Pin1.X), num(-f.Pin1.Y), num(f.Pin1.X+SilkWidth), num(-f.Pin1.Y), num(SilkWidth))
+	}
+	for _, p := range f.Pads {
+		fmt.Fprintf(&b, "  %v\n", p.Stack.KiCad(p.Number, p.X, p.Y, p.Rotation))
+	}
+	for _, m := range f.Models {
+		fmt.Fprintf(&b, "  (model %q)\n", m)
+	}
+	b.WriteString(")\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// num formats a coordinate like gerber.PadStack.KiCad.
+func num(f float64) string {
+	return strconv.FormatFloat(math.Round(f*1e6)/1e6+0, 'f', -1, 64) // +0 turns -0 into 0
+}
+
+// node is a parsed KiCad s-expression: an atom or a list.
+type node struct {
+	atom string
+	list []*node
+}
+
+// head returns the name of the list, e.g. "pad" for (pad "1" smd ...).
+func (n *node) head() string {
+	if n == nil || len(n.list) == 0 {
+		return ""
+	}
+	return n.list[0].atom
+}
+
+// child returns the first sublist with the name, or nil.
+func (n *node) child(name string) *node {
+	if n == nil {
+		return nil
+	}
+	for _, c := range n.list {
+		if c.head() == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// arg returns the i-th atom after the name of the list, or "".
+func (n *node) arg(i int) string {
+	if n == nil || i+1 >= len(n.list) {
+		return ""
+	}
+	return n.list[i+1].atom
+}
+
+// num returns the i-th argument of the list as a number, or 0.
+func (n *node) num(i int) float64 {
+	v, _ := strconv.ParseFloat(n.arg(i), 64)
+	return v
+}
+
+// pt returns the point of a list such as (start x y), with Y negated.
+func (n *node) pt() gerber.Pt {
+	return gerber.Pt{X: n.num(0), Y: -n.num(1)}
+}
+
+// parseSexpr parses the s-expression of a KiCad file.
+func parseSexpr(data []byte) (*node, error) {
+	var stack []*node
+	var root *node
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; {
+		case c == '(':
+			n := &node{list: []*node{}}
+			if len(stack) > 0 {
+				top := stack[len(stack)-1]
+				top.list = append(top.list, n)
+			}
+			stack = append(stack, n)
+		case c == ')':
+			if len(stack) == 0 {
+				return nil, errors.New("unbalanced parentheses")
+			}
+			root, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("unexpected %q outside of a list", c)
+			}
+			var atom strings.Builder
+			if c == '"' {
+				for i++; i < len(data) && data[i] != '"'; i++ {
+					if data[i] == '\\' && i+1 < len(data) {
+						i++
+					}
+					atom.WriteByte(data[i])
+				}
+				if i == len(data) {
+					return nil, errors.New("unterminated string")
+				}
+			} else {
+				for ; i < len(data) && !strings.ContainsRune("() \t\r\n", rune(data[i])); i++ {
+					atom.WriteByte(data[i])
+				}
+				i--
+			}
+			top := stack[len(stack)-1]
+			top.list = append(top.list, &node{atom: atom.String()})
+		}
+	}
+	if len(stack) > 0 || root == nil {
+		return nil, errors.New("unbalanced parentheses")
+	}
+	return root, nil
+}
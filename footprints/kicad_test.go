@@ -0,0 +1,100 @@
+package footprints
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-gerber/gerber"
+)
+
+func TestFootprint_WriteKiCad(t *testing.T) {
+	for _, f := range []*Footprint{SOIC(8), Header(4, 1)} {
+		f.Courtyard = [][2]gerber.Pt{{{X: -3, Y: -2.5}, {X: 3, Y: -2.5}}, {{X: 3, Y: -2.5}, {X: 3, Y: 2.5}}}
+		f.Fab = [][2]gerber.Pt{{{X: -2, Y: -1.5}, {X: 2, Y: 1.5}}}
+		f.ExcludeFromBOM = true
+		f.Models = []string{"${KICAD8_3DMODEL_DIR}/" + f.Name + ".wrl"}
+
+		var buf bytes.Buffer
+		if err := f.WriteKiCad(&buf); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ReadKiCad(&buf)
+		if err != nil {
+			t.Fatalf("%v: %v", f.Name, err)
+		}
+		if got.Name != f.Name || got.Mount != f.Mount || !got.ExcludeFromBOM || !reflect.DeepEqual(got.Models, f.Models) {
+			t.Errorf("%v: read %+v", f.Name, got)
+		}
+		for _, lines := range []struct{ got, want [][2]gerber.Pt }{{got.Silkscreen, f.Silkscreen}, {got.Courtyard, f.Courtyard}, {got.Fab, f.Fab}} {
+			if len(lines.got) != len(lines.want) {
+				t.Fatalf("%v: read lines %v, want %v", f.Name, lines.got, lines.want)
+			}
+			for i, l := range lines.want {
+				if !near(lines.got[i][0], l[0]) || !near(lines.got[i][1], l[1]) {
+					t.Errorf("%v: line #%v = %v, want %v", f.Name, i, lines.got[i], l)
+				}
+			}
+		}
+		if got.Width != 4 || got.Height != 3 {
+			t.Errorf("%v: body is %vx%v, want the 4x3 of the fabrication lines", f.Name, got.Width, got.Height)
+		}
+		if (f.Pin1 == nil) != (got.Pin1 == nil) || f.Pin1 != nil && !near(*got.Pin1, *f.Pin1) {
+			t.Errorf("%v: pin 1 marker = %v, want %v", f.Name, got.Pin1, f.Pin1)
+		}
+		if len(got.Pads) != len(f.Pads) {
+			t.Fatalf("%v: read %v pads, want %v", f.Name, len(got.Pads), len(f.Pads))
+		}
+		for i, p := range f.Pads {
+			g := got.Pads[i]
+			if g.Number != p.Number || !near(gerber.Pt{X: g.X, Y: g.Y}, gerber.Pt{X: p.X, Y: p.Y}) || g.Rotation != p.Rotation {
+				t.Errorf("%v: pad #%v = %+v, want %+v", f.Name, i, g, p)
+			}
+			if g.Stack.KiCad("", 0, 0, 0) != p.Stack.KiCad("", 0, 0, 0) {
+				t.Errorf("%v: pad #%v stack = %v, want %v", f.Name, i, g.Stack.KiCad("", 0, 0, 0), p.Stack.KiCad("", 0, 0, 0))
+			}
+		}
+		if got.Pads[1].Stack != got.Pads[2].Stack {
+			t.Errorf("%v: pads of the same shape don't share their stack", f.Name)
+		}
+	}
+}
+
+func TestReadKiCad(t *testing.T) {
+	// An excerpt of a footprint of the KiCad library (in the older format).
+	const mod = `(module R_0603_1608Metric (layer F.Cu) (tedit 5F68FEEE)
+  (descr "Resistor SMD 0603")
+  (attr smd)
+  (fp_text reference REF** (at 0 -1.43) (layer F.SilkS))
+  (fp_line (start -0.237258 -0.5225) (end 0.237258 -0.5225) (layer F.SilkS) (width 0.12))
+  (fp_rect (start -1.48 -0.73) (end 1.48 0.73) (layer F.CrtYd) (width 0.05))
+  (pad 1 smd roundrect (at -0.825 0) (size 0.8 0.95) (layers F.Cu F.Mask F.Paste) (roundrect_rratio 0.25))
+  (pad 2 smd roundrect (at 0.825 0) (size 0.8 0.95) (layers F.Cu F.Mask F.Paste) (roundrect_rratio 0.25))
+  (pad "" np_thru_hole circle (at 0 2) (size 1 1) (drill 1) (layers *.Cu *.Mask))
+  (model ${KISYS3DMOD}/Resistor_SMD.3dshapes/R_0603_1608Metric.wrl
+    (at (xyz 0 0 0)) (scale (xyz 1 1 1)) (rotate (xyz 0 0 0)))
+)`
+	f, err := ReadKiCad(strings.NewReader(mod))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Name != "R_0603_1608Metric" || f.Mount != "SMD" || len(f.Silkscreen) != 1 || len(f.Courtyard) != 4 || len(f.Pads) != 3 {
+		t.Fatalf("read %+v", f)
+	}
+	if !reflect.DeepEqual(f.Models, []string{"${KISYS3DMOD}/Resistor_SMD.3dshapes/R_0603_1608Metric.wrl"}) {
+		t.Errorf("models = %v", f.Models)
+	}
+	if s := f.Pads[0].Stack; s != f.Pads[1].Stack || s.Top == nil || s.Top.Shape != gerber.RectShape || s.Top.Width != 0.8 || s.Top.Height != 0.95 || !s.Paste || s.Tented {
+		t.Errorf("pad 1 stack = %+v", s)
+	}
+	if s := f.Pads[2].Stack; !s.NonPlated || s.Drill != 1 || s.Top != nil || f.Pads[2].Y != -2 {
+		t.Errorf("hole = %+v at %v", s, f.Pads[2].Y)
+	}
+
+	for _, bad := range []string{"(module x", "(symbol x)", `(module x (pad 1 smd custom (at 0 0) (size 1 1) (layers F.Cu)))`} {
+		if _, err := ReadKiCad(strings.NewReader(bad)); err == nil {
+			t.Errorf("ReadKiCad(%q): expected an error", bad)
+		}
+	}
+}
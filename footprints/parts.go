@@ -0,0 +1,185 @@
+package footprints
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gmlewis/go-gerber/gerber"
+)
+
+// MaskExpansion is the solder mask expansion of the pad stacks of the
+// footprints created afterwards.
+var MaskExpansion = 0.05
+
+// smdStack returns the pad stack of a width by height surface mount pad.
+func smdStack(width, height float64) *gerber.PadStack {
+	return &gerber.PadStack{
+		Name:          fmt.Sprintf("SMD_%vx%v", width, height),
+		Top:           &gerber.PadShape{Shape: gerber.RectShape, Width: width, Height: height},
+		MaskExpansion: MaskExpansion,
+		Paste:         true,
+	}
+}
+
+// chipSizes are the body (length and width) and pad (size and center
+// distance) dimensions of chip resistors and capacitors by imperial size,
+// after IPC-7351 nominal density.
+var chipSizes = map[string]struct{ length, width, padW, padH, pitch float64 }{
+	"0402": {length: 1.0, width: 0.5, padW: 0.54, padH: 0.64, pitch: 1.02},
+	"0603": {length: 1.6, width: 0.8, padW: 0.8, padH: 0.95, pitch: 1.65},
+	"0805": {length: 2.0, width: 1.25, padW: 1.025, padH: 1.4, pitch: 1.825},
+	"1206": {length: 3.2, width: 1.6, padW: 1.125, padH: 1.75, pitch: 2.925},
+}
+
+// Chip returns the footprint of a two-terminal chip component (such as
+// a resistor or capacitor) of the imperial size "0402", "0603", "0805"
+// or "1206", with pad 1 on the left. It returns nil for other sizes.
+func Chip(size string) *Footprint {
+	s, ok := chipSizes[size]
+	if !ok {
+		return nil
+	}
+	stack := smdStack(s.padW, s.padH)
+	x := 0.5 * s.pitch
+	// The silkscreen runs along the long sides between the pads.
+	sx, sy := x-0.5*s.padW-SilkWidth-MaskExpansion, 0.5*s.width+SilkWidth
+	f := &Footprint{
+		Name:   "Chip_" + size,
+		Mount:  "SMD",
+		Width:  s.length,
+		Height: s.width,
+		Pads: []*Pad{
+			{Number: "1", X: -x, Stack: stack},
+			{Number: "2", X: x, Stack: stack},
+		},
+	}
+	if sx > 0 {
+		f.Silkscreen = [][2]gerber.Pt{
+			{{X: -sx, Y: sy}, {X: sx, Y: sy}},
+			{{X: -sx, Y: -sy}, {X: sx, Y: -sy}},
+		}
+	}
+	return f
+}
+
+// dual returns the footprint of a dual row package of pins pins with
+// the given pitch, pad size, pad row distance and body size.
+func dual(name string, pins int, pitch, padW, padH, rows, bodyW, bodyH float64) *Footprint {
+	stack := smdStack(padW, padH)
+	f := &Footprint{Name: name, Mount: "SMD", Width: bodyW, Height: bodyH}
+	n := pins / 2
+	top := 0.5 * float64(n-1) * pitch
+	for i := 0; i < pins; i++ {
+		pad := &Pad{Number: strconv.Itoa(i + 1), X: -0.5 * rows, Y: top - float64(i)*pitch, Stack: stack}
+		if i >= n { // Counterclockwise, up the right row.
+			pad.X, pad.Y = 0.5*rows, -top+float64(i-n)*pitch
+		}
+		f.Pads = append(f.Pads, pad)
+	}
+	// The outline of the body, open along the pad rows, and the pin 1
+	// marker beside the pad.
+	w, h := 0.5*bodyW, 0.5*bodyH+SilkWidth
+	f.Silkscreen = [][2]gerber.Pt{
+		{{X: -w, Y: h}, {X: w, Y: h}},
+		{{X: -w, Y: -h}, {X: w, Y: -h}},
+	}
+	f.Pin1 = &gerber.Pt{X: -0.5*rows - 0.5*padW - 3*SilkWidth, Y: top}
+	return f
+}
+
+// SOIC returns the footprint of the SOIC-pins package (3.9mm body,
+// 1.27mm pitch) for an even number of pins (e.g. 8, 14 or 16).
+func SOIC(pins int) *Footprint {
+	return dual(fmt.Sprintf("SOIC-%v", pins), pins, 1.27, 1.525, 0.6, 4.95, 3.9, float64(pins/2-1)*1.27+1.09)
+}
+
+// TSSOP returns the footprint of the TSSOP-pins package (4.4mm body,
+// 0.65mm pitch) for an even number of pins (e.g. 8, 14 or 20).
+func TSSOP(pins int) *Footprint {
+	return dual(fmt.Sprintf("TSSOP-%v", pins), pins, 0.65, 1.475, 0.4, 5.725, 4.4, float64(pins/2-1)*0.65+1.1)
+}
+
+// QFN returns the footprint of the square QFN package of the given body
+// size and pitch with pins pins (a multiple of 4) and, if exposed is
+// positive, an exposed pad of that size (numbered "EP"). Pin 1 is the
+// top pin of the left side and the pins are numbered counterclockwise.
+func QFN(pins int, body, pitch, exposed float64) *Footprint {
+	const padL = 0.8
+	stack := smdStack(padL, 0.5*pitch)
+	f := &Footprint{Name: fmt.Sprintf("QFN-%v_%vx%v_P%v", pins, body, body, pitch), Mount: "SMD", Width: body, Height: body}
+	n := pins / 4
+	first := 0.5 * float64(n-1) * pitch
+	c := 0.5*body - 0.5*padL + 0.3 // The pads extend beyond the body.
+	for i := 0; i < pins; i++ {
+		side, k := i/n, float64(i%n)
+		pad := &Pad{Number: strconv.Itoa(i + 1), Stack: stack}
+		switch side {
+		case 0: // left, downwards
+			pad.X, pad.Y = -c, first-k*pitch
+		case 1: // bottom, rightwards
+			pad.X, pad.Y, pad.Rotation = -first+k*pitch, -c, 90
+		case 2: // right, upwards
+			pad.X, pad.Y = c, -first+k*pitch
+		case 3: // top, leftwards
+			pad.X, pad.Y, pad.Rotation = first-k*pitch, c, 90
+		}
+		f.Pads = append(f.Pads, pad)
+	}
+	if exposed > 0 {
+		ep := smdStack(exposed, exposed)
+		ep.Paste = false // Paste is applied through a window array, if at all.
+		f.Pads = append(f.Pads, &Pad{Number: "EP", Stack: ep})
+	}
+	// Corner marks outside the pads and the pin 1 marker.
+	b, m := 0.5*body+SilkWidth, 0.5*body-first-0.5*pitch
+	for _, s := range []gerber.Pt{{X: -1, Y: -1}, {X: 1, Y: -1}, {X: 1, Y: 1}, {X: -1, Y: 1}} {
+		corner := gerber.Pt{X: s.X * b, Y: s.Y * b}
+		f.Silkscreen = append(f.Silkscreen,
+			[2]gerber.Pt{corner, {X: corner.X - s.X*m, Y: corner.Y}},
+			[2]gerber.Pt{corner, {X: corner.X, Y: corner.Y - s.Y*m}},
+		)
+	}
+	f.Pin1 = &gerber.Pt{X: -c - 0.5*padL - 3*SilkWidth, Y: first}
+	return f
+}
+
+// Header returns the footprint of a through-hole pin header with pins
+// pins in the given number of rows (2.54mm pitch, 1mm drills). Pin 1
+// (with a square pad) is at the top left and the pins are numbered
+// along the rows (1, 2 across the first row of a dual row header).
+func Header(pins, rows int) *Footprint {
+	const pitch = 2.54
+	if rows < 1 {
+		rows = 1
+	}
+	round := &gerber.PadStack{Name: "TH_1.7_1.0", Drill: 1, MaskExpansion: MaskExpansion}
+	round.Top = &gerber.PadShape{Shape: gerber.CircleShape, Width: 1.7}
+	round.Inner, round.Bottom = round.Top, round.Top
+	square := &gerber.PadStack{Name: "TH_1.7x1.7_1.0", Drill: 1, MaskExpansion: MaskExpansion}
+	square.Top = &gerber.PadShape{Shape: gerber.RectShape, Width: 1.7}
+	square.Inner, square.Bottom = round.Top, square.Top
+
+	cols := (pins + rows - 1) / rows
+	f := &Footprint{
+		Name:   fmt.Sprintf("PinHeader_%vx%02d_P2.54mm", rows, cols),
+		Mount:  "TH",
+		Width:  float64(rows) * pitch,
+		Height: float64(cols) * pitch,
+	}
+	x0, y0 := -0.5*float64(rows-1)*pitch, 0.5*float64(cols-1)*pitch
+	for i := 0; i < pins; i++ {
+		pad := &Pad{Number: strconv.Itoa(i + 1), X: x0 + float64(i%rows)*pitch, Y: y0 - float64(i/rows)*pitch, Stack: round}
+		if i == 0 {
+			pad.Stack = square
+		}
+		f.Pads = append(f.Pads, pad)
+	}
+	w, h := 0.5*f.Width, 0.5*f.Height
+	f.Silkscreen = [][2]gerber.Pt{
+		{{X: -w, Y: -h}, {X: w, Y: -h}},
+		{{X: w, Y: -h}, {X: w, Y: h}},
+		{{X: w, Y: h}, {X: -w, Y: h}},
+		{{X: -w, Y: h}, {X: -w, Y: -h}},
+	}
+	return f
+}
@@ -1,3 +1,3 @@
 #!/bin/bash -ex
-go run cmd/font2go/*.go webfonts/*.svg
+go run ./cmd/font2go webfonts
 mv fonts.go gerber
@@ -0,0 +1,143 @@
+package gerber
+
+import (
+	"errors"
+	"fmt"
+)
+
+// AlignmentKit adds the features registering the top and bottom artwork
+// of a double-sided board for home fabrication (e.g. toner transfer or
+// photo etching): holes for alignment pins beside the board, targets
+// on both copper layers centered on the pins, and frame marks at the
+// corners of the board.
+//
+// The pins are on the left, right and bottom of the board, symmetric
+// about its vertical center line, so that the board flipped over for
+// its second side sits on the same pins while a board rotated by 180
+// degrees does not. Each target has an orientation tick pointing up and
+// outwards (up and right for the bottom pin), mirrored on the bottom
+// copper, so that both sides of the board read the same from their own
+// side.
+type AlignmentKit struct {
+	// Design is the design the kit is added to. Its outline layer sets
+	// the size of the board.
+	Design *Gerber
+	// PinDiameter is the diameter of the alignment pins (e.g. 3 for
+	// 3mm dowel pins or M3 screws).
+	PinDiameter float64
+	// Clearance is added to the pin diameter for the pin holes.
+	Clearance float64
+	// Margin is the distance between the board outline and the centers
+	// of the pin holes.
+	Margin float64
+	// LineWidth is the width of the lines of the targets and frame marks.
+	LineWidth float64
+}
+
+// NewAlignmentKit returns the alignment kit of the design for pins of
+// the given diameter, with a 0.1mm clearance, a margin of twice the pin
+// diameter and 0.2mm lines.
+func NewAlignmentKit(design *Gerber, pinDiameter float64) *AlignmentKit {
+	return &AlignmentKit{
+		Design:      design,
+		PinDiameter: pinDiameter,
+		Clearance:   0.1,
+		Margin:      2 * pinDiameter,
+		LineWidth:   0.2,
+	}
+}
+
+// Pins returns the centers of the alignment pins: left, right and bottom
+// of the board.
+func (k *AlignmentKit) Pins() ([]Pt, error) {
+	bmin, bmax, err := k.board()
+	if err != nil {
+		return nil, err
+	}
+	cx, cy := 0.5*(bmin.X+bmax.X), 0.5*(bmin.Y+bmax.Y)
+	return []Pt{
+		{X: bmin.X - k.Margin, Y: cy},
+		{X: bmax.X + k.Margin, Y: cy},
+		{X: cx, Y: bmin.Y - k.Margin},
+	}, nil
+}
+
+// board returns the bounds of the outline of the design.
+func (k *AlignmentKit) board() (min, max Pt, err error) {
+	if k.PinDiameter <= 0 || k.LineWidth <= 0 || k.Clearance < 0 {
+		return min, max, fmt.Errorf("invalid alignment pins of %vmm (clearance %vmm, lines %vmm)", k.PinDiameter, k.Clearance, k.LineWidth)
+	}
+	// The targets must clear the board.
+	if k.Margin < 1.5*k.PinDiameter+k.LineWidth {
+		return min, max, fmt.Errorf("margin of %vmm is too narrow for %vmm pins", k.Margin, k.PinDiameter)
+	}
+	var outline *Layer
+	for _, l := range k.Design.Layers {
+		if l.extension() == "gko" {
+			outline = l
+		}
+	}
+	if outline == nil {
+		return min, max, errors.New("design has no outline layer")
+	}
+	var ops []op
+	for _, prim := range outline.Primitives {
+		ops = append(ops, plot(prim)...)
+	}
+	min, max, ok := centerlineBounds(ops)
+	if !ok {
+		return min, max, errors.New("design has an empty outline layer")
+	}
+	return min, max, nil
+}
+
+// Add adds the pin holes to the non-plated drill layer and the targets
+// and frame marks to the top and bottom copper layers of the design,
+// adding the layers if needed.
+func (k *AlignmentKit) Add() error {
+	pins, err := k.Pins()
+	if err != nil {
+		return err
+	}
+	bmin, bmax, _ := k.board()
+	g := k.Design
+
+	npth := g.panelLayer("NonPlatedDrill")
+	for _, pt := range pins {
+		npth.Add(Flash(pt.X, pt.Y, CircleShape, k.PinDiameter+k.Clearance))
+	}
+
+	d, lw := k.PinDiameter, k.LineWidth
+	for _, side := range []struct {
+		ext    string
+		mirror float64
+	}{{"gtl", 1}, {"gbl", -1}} {
+		l := g.layerFor(side.ext)
+		for i, pt := range pins {
+			out := []float64{-1, 1, side.mirror}[i]
+			l.Add(
+				CircleOutline(pt.X, pt.Y, 2*d, lw),
+				Line(pt.X-1.5*d, pt.Y, pt.X+1.5*d, pt.Y, CircleShape, lw),
+				Line(pt.X, pt.Y-1.5*d, pt.X, pt.Y+1.5*d, CircleShape, lw),
+				Line(pt.X+out*0.7071*d, pt.Y+0.7071*d, pt.X+out*1.0607*d, pt.Y+1.0607*d, CircleShape, lw),
+			)
+		}
+
+		// L-shaped frame marks just outside the corners of the board.
+		m := 0.5 * k.Margin
+		for _, s := range []Pt{{X: -1, Y: -1}, {X: 1, Y: -1}, {X: 1, Y: 1}, {X: -1, Y: 1}} {
+			c := Pt{X: bmin.X - m, Y: bmin.Y - m}
+			if s.X > 0 {
+				c.X = bmax.X + m
+			}
+			if s.Y > 0 {
+				c.Y = bmax.Y + m
+			}
+			l.Add(
+				Line(c.X, c.Y, c.X-s.X*k.Margin, c.Y, CircleShape, lw),
+				Line(c.X, c.Y, c.X, c.Y-s.Y*k.Margin, CircleShape, lw),
+			)
+		}
+	}
+	return nil
+}
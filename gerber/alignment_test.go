@@ -0,0 +1,61 @@
+package gerber
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAlignmentKit_Add(t *testing.T) {
+	g := panelDesign()
+	k := NewAlignmentKit(g, 3)
+	if err := k.Add(); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	holes := panelLayerOps(t, g, "nxln")
+	if len(holes) != 3 {
+		t.Fatalf("got %v pin holes, want 3", len(holes))
+	}
+	for i, want := range []Pt{{X: 4, Y: 20}, {X: 26, Y: 20}, {X: 15, Y: 4}} {
+		min, max, _ := bounds(holes[i : i+1])
+		if c := (Pt{X: 0.5 * (min.X + max.X), Y: 0.5 * (min.Y + max.Y)}); math.Abs(c.X-want.X) > 1e-6 || math.Abs(c.Y-want.Y) > 1e-6 {
+			t.Errorf("pin hole %v at %v, want %v", i, c, want)
+		}
+		if d := max.X - min.X; math.Abs(d-3.1) > 1e-3 {
+			t.Errorf("pin hole %v diameter = %v, want 3.1", i, d)
+		}
+	}
+
+	// The pad, 3 targets of 4 primitives and 4 frame marks of 2 lines.
+	top, bottom := g.layerFor("gtl"), g.layerFor("gbl")
+	if len(top.Primitives) != 21 || len(bottom.Primitives) != 20 {
+		t.Fatalf("got %v top and %v bottom primitives, want 21 and 20", len(top.Primitives), len(bottom.Primitives))
+	}
+	// The tick of the bottom pin's target points right on the top copper
+	// and left on the bottom copper.
+	tt, bt := top.Primitives[1+2*4+3].(*LineT), bottom.Primitives[2*4+3].(*LineT)
+	if tt.x2 <= tt.x1 || bt.x2 >= bt.x1 || tt.y1 != bt.y1 || tt.y2 != bt.y2 {
+		t.Errorf("ticks = %+v and %+v, want mirrored", tt, bt)
+	}
+	// The crosshairs reach 4.5mm beside the pins.
+	min, max, _ := bottom.bounds()
+	if math.Abs(min.X+0.6) > 1e-6 || math.Abs(max.X-30.6) > 1e-6 {
+		t.Errorf("bottom copper bounds = %v..%v", min, max)
+	}
+}
+
+func TestAlignmentKit_Errors(t *testing.T) {
+	k := NewAlignmentKit(New("board"), 3)
+	if err := k.Add(); err == nil {
+		t.Error("Add without an outline = nil, want error")
+	}
+	k = NewAlignmentKit(panelDesign(), 3)
+	k.Margin = 4
+	if _, err := k.Pins(); err == nil {
+		t.Error("Pins with a narrow margin = nil, want error")
+	}
+	k = NewAlignmentKit(panelDesign(), 0)
+	if _, err := k.Pins(); err == nil {
+		t.Error("Pins without a pin diameter = nil, want error")
+	}
+}
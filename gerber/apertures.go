@@ -0,0 +1,119 @@
+package gerber
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Macro is a Gerber aperture macro (%AM): a custom aperture shape made
+// of macro primitives (such as "21,1,$1,$2,0,0,0" for a centered
+// rectangle) whose modifiers refer to the parameters of the apertures
+// using it as $1, $2 and so on. All parameters are lengths, so that
+// they are converted to the units of the output.
+type Macro struct {
+	// Name identifies the macro in the Gerber files. Macros of the same
+	// layer must have distinct names.
+	Name string
+	// Primitives are the macro primitives, without the trailing '*'.
+	Primitives []string
+}
+
+// CustomShape is the shape of an aperture defined by an aperture macro.
+type CustomShape struct {
+	Macro *Macro
+	// Params are the parameters of the macro (in millimeters).
+	Params []float64
+}
+
+// WriteGerber writes the macro definition to the Gerber file.
+func (m *Macro) WriteGerber(w io.Writer) error {
+	fmt.Fprintf(w, "%%AM%v*\n%v*%%\n", m.Name, strings.Join(m.Primitives, "*\n"))
+	return nil
+}
+
+// The macros of the custom apertures.
+var (
+	roundRectMacro = &Macro{Name: "RoundRect", Primitives: []string{
+		"0 width, height, corner radius",
+		"21,1,$1,$2-$3-$3,0,0,0",
+		"21,1,$1-$3-$3,$2,0,0,0",
+		"1,1,$3+$3,$1/2-$3,$2/2-$3",
+		"1,1,$3+$3,-$1/2+$3,$2/2-$3",
+		"1,1,$3+$3,-$1/2+$3,-$2/2+$3",
+		"1,1,$3+$3,$1/2-$3,-$2/2+$3",
+	}}
+	chamferedRectMacro = &Macro{Name: "ChamferRect", Primitives: []string{
+		"0 width, height, chamfer",
+		"4,1,8,-$1/2+$3,-$2/2,$1/2-$3,-$2/2,$1/2,-$2/2+$3,$1/2,$2/2-$3,$1/2-$3,$2/2,-$1/2+$3,$2/2,-$1/2,$2/2-$3,-$1/2,-$2/2+$3,-$1/2+$3,-$2/2,0",
+	}}
+	thermalMacro = &Macro{Name: "Thermal", Primitives: []string{
+		"0 outer diameter, inner diameter, gap",
+		"7,0,0,$1,$2,$3,0",
+	}}
+)
+
+// polygonMacro returns the macro of polygon apertures with n vertices.
+func polygonMacro(n int) *Macro {
+	prim := []string{"4", "1", fmt.Sprint(n)}
+	for i := 1; i <= 2*n; i++ {
+		prim = append(prim, fmt.Sprintf("$%v", i))
+	}
+	prim = append(prim, "$1", "$2", "0")
+	return &Macro{Name: fmt.Sprintf("Polygon%v", n), Primitives: []string{strings.Join(prim, ",")}}
+}
+
+// RoundRectAperture returns the aperture of the width by height
+// rectangle with corners rounded to radius (e.g. an SMD pad), to be
+// flashed with FlashAperture.
+// All dimensions are in millimeters (or Lengths).
+func RoundRectAperture[L Lengths](width, height, radius L) *Aperture {
+	return &Aperture{Custom: &CustomShape{Macro: roundRectMacro, Params: []float64{float64(width), float64(height), float64(radius)}}}
+}
+
+// ChamferedRectAperture returns the aperture of the width by height
+// rectangle with corners cut by chamfer along both sides, to be flashed
+// with FlashAperture.
+// All dimensions are in millimeters (or Lengths).
+func ChamferedRectAperture[L Lengths](width, height, chamfer L) *Aperture {
+	return &Aperture{Custom: &CustomShape{Macro: chamferedRectMacro, Params: []float64{float64(width), float64(height), float64(chamfer)}}}
+}
+
+// ThermalAperture returns the aperture of a thermal relief: the ring
+// between the outer and inner diameters broken by four gaps of the
+// given width along the X and Y axes, to be flashed with FlashAperture
+// (e.g. on a negative plane layer to connect a pad through four spokes).
+// All dimensions are in millimeters (or Lengths).
+func ThermalAperture[L Lengths](outer, inner, gap L) *Aperture {
+	return &Aperture{Custom: &CustomShape{Macro: thermalMacro, Params: []float64{float64(outer), float64(inner), float64(gap)}}}
+}
+
+// PolygonAperture returns the aperture of the polygon through the
+// points around its center (the origin), to be flashed with
+// FlashAperture. The last point should not repeat the first one.
+// All dimensions are in millimeters.
+func PolygonAperture(points []Pt) *Aperture {
+	c := &CustomShape{Macro: polygonMacro(len(points))}
+	for _, pt := range points {
+		c.Params = append(c.Params, pt.X, pt.Y)
+	}
+	return &Aperture{Custom: c}
+}
+
+// FlashAperture returns a flash primitive of the aperture (such as
+// a RoundRectAperture) at x,y.
+// All dimensions are in millimeters (or Lengths).
+func FlashAperture[L Lengths](x, y L, a *Aperture) *FlashT {
+	return &FlashT{x: toNM(float64(x)), y: toNM(float64(y)), aperture: a}
+}
+
+// writeMacros writes the definitions of the macros of the apertures.
+func writeMacros(w io.Writer, apertures []*Aperture) {
+	seen := map[string]bool{}
+	for _, a := range apertures {
+		if a.Custom != nil && !seen[a.Custom.Macro.Name] {
+			seen[a.Custom.Macro.Name] = true
+			a.Custom.Macro.WriteGerber(w)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package gerber
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestFlashAperture_WriteGerber(t *testing.T) {
+	g := New("board", Inches)
+	top := g.TopCopper()
+	top.Add(
+		FlashAperture(0, 0, RoundRectAperture(Inch(0.1), Inch(0.05), Inch(0.01))),
+		FlashAperture(1, 0, RoundRectAperture(Inch(0.1), Inch(0.05), Inch(0.01))),
+		FlashAperture(2, 0, RoundRectAperture(Inch(0.2), Inch(0.05), Inch(0.01))),
+		Object(FlashAperture(3, 0, ThermalAperture(2, 1, 0.3))).Function("ThermalReliefPad"),
+	)
+	if len(top.Apertures) != 3 {
+		t.Errorf("layer has %v apertures, want 3", len(top.Apertures))
+	}
+	var buf bytes.Buffer
+	if err := top.WriteGerber(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"%AMRoundRect*\n0 width, height, corner radius*\n21,1,$1,$2-$3-$3,0,0,0*\n",
+		"%ADD12RoundRect,0.100000X0.050000X0.010000*%",
+		"%ADD13RoundRect,0.200000X0.050000X0.010000*%",
+		"%TA.AperFunction,ThermalReliefPad*%\n%ADD14Thermal,0.078740X0.039370X0.011811*%",
+		"G54D12*\nX000000Y000000D03*\nG54D12*\nX039370Y000000D03*\nG54D13*\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%v", want, got)
+		}
+	}
+	if n := strings.Count(got, "%AMRoundRect*"); n != 1 {
+		t.Errorf("RoundRect macro defined %v times, want once", n)
+	}
+}
+
+func TestFlashAperture_plot(t *testing.T) {
+	tests := []struct {
+		name     string
+		aperture *Aperture
+		min, max Pt
+		inside   []Pt
+		outside  []Pt
+	}{
+		{name: "round rectangle", aperture: RoundRectAperture(2, 1, 0.25), min: Pt{X: 4, Y: 4.5}, max: Pt{X: 6, Y: 5.5}, inside: []Pt{{X: 5.9, Y: 5}, {X: 5.7, Y: 5.2}}, outside: []Pt{{X: 5.98, Y: 5.48}}},
+		{name: "chamfered rectangle", aperture: ChamferedRectAperture(2, 1, 0.25), min: Pt{X: 4, Y: 4.5}, max: Pt{X: 6, Y: 5.5}, inside: []Pt{{X: 5.9, Y: 5}}, outside: []Pt{{X: 5.9, Y: 5.4}}},
+		{name: "thermal", aperture: ThermalAperture(2, 1, 0.3), min: Pt{X: 4.0113, Y: 4.0113}, max: Pt{X: 5.9887, Y: 5.9887}, inside: []Pt{{X: 5.5, Y: 5.5}, {X: 4.5, Y: 4.5}}, outside: []Pt{{X: 5.7, Y: 5}, {X: 5, Y: 4.3}, {X: 5, Y: 5}}},
+		{name: "polygon", aperture: PolygonAperture([]Pt{{X: -1, Y: -1}, {X: 1, Y: -1}, {X: 0, Y: 1}}), min: Pt{X: 4, Y: 4}, max: Pt{X: 6, Y: 6}, inside: []Pt{{X: 5, Y: 5}}, outside: []Pt{{X: 4.2, Y: 5.8}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := plot(FlashAperture(5, 5, tt.aperture))
+			min, max, _ := bounds(ops)
+			if math.Abs(min.X-tt.min.X) > 1e-3 || math.Abs(min.Y-tt.min.Y) > 1e-3 || math.Abs(max.X-tt.max.X) > 1e-3 || math.Abs(max.Y-tt.max.Y) > 1e-3 {
+				t.Errorf("bounds = %v..%v, want %v..%v", min, max, tt.min, tt.max)
+			}
+			covered := func(pt Pt) bool {
+				for _, o := range ops {
+					if insidePolygon(o.pts, pt) {
+						return true
+					}
+				}
+				return false
+			}
+			for _, pt := range tt.inside {
+				if !covered(pt) {
+					t.Errorf("%v is not covered", pt)
+				}
+			}
+			for _, pt := range tt.outside {
+				if covered(pt) {
+					t.Errorf("%v is covered", pt)
+				}
+			}
+		})
+	}
+}
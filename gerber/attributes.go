@@ -0,0 +1,173 @@
+package gerber
+
+import (
+	"fmt"
+	"io"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// modulePath is the path of the go-gerber module in the build info.
+const modulePath = "github.com/gmlewis/go-gerber"
+
+// Generator identifies the software generating the files of a design,
+// written as their %TF.GenerationSoftware attribute.
+type Generator struct {
+	// Vendor is the vendor of the software (e.g. "gmlewis").
+	Vendor string
+	// Application is the name of the software. Files of a generator
+	// without one have no GenerationSoftware attribute.
+	Application string
+	// Version is the version of the software, if known.
+	Version string
+}
+
+// DefaultGenerator returns the generator of the files of designs that
+// do not set one: go-gerber with its module version (or VCS revision)
+// read from the build info of the running binary, if available.
+func DefaultGenerator() *Generator {
+	gen := &Generator{Vendor: "gmlewis", Application: "go-gerber"}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return gen
+	}
+	mod := &info.Main
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			mod = dep
+		}
+	}
+	if mod.Replace != nil {
+		mod = mod.Replace
+	}
+	switch {
+	case mod.Version != "" && mod.Version != "(devel)":
+		gen.Version = mod.Version
+	case mod == &info.Main && mod.Path == modulePath:
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" && len(s.Value) >= 12 {
+				gen.Version = s.Value[:12]
+			}
+		}
+	}
+	return gen
+}
+
+// generator returns the generator of the files of the layer, or nil
+// if they have no GenerationSoftware attribute.
+func (l *Layer) generator() *Generator {
+	gen := DefaultGenerator()
+	if l.g != nil && l.g.Generator != nil {
+		gen = l.g.Generator
+	}
+	if gen.Application == "" {
+		return nil
+	}
+	return gen
+}
+
+// attributeField strips the characters that are not allowed in the
+// field of an attribute value.
+var attributeField = strings.NewReplacer(",", "", "*", "", "%", "", "\n", "")
+
+// String returns the GenerationSoftware attribute value of the generator.
+func (gen *Generator) String() string {
+	fields := []string{attributeField.Replace(gen.Vendor), attributeField.Replace(gen.Application)}
+	if gen.Version != "" {
+		fields = append(fields, attributeField.Replace(gen.Version))
+	}
+	return strings.Join(fields, ",")
+}
+
+// Attributes are the Gerber X2 file attributes of a layer, required
+// by modern CAM tools to identify the files of a design.
+type Attributes struct {
+	// FileFunction is the function of the file (e.g. "Copper,L1,Top").
+	// It defaults to the function of the kind of layer.
+	FileFunction string
+	// FilePolarity is "Positive" or "Negative".
+	// It defaults to the polarity of the kind of layer.
+	FilePolarity string
+	// Part is the part the file represents (e.g. "Single" or "Array").
+	// It defaults to "Single".
+	Part string
+	// Custom are additional file attributes by name (e.g. "ProjectId"
+	// or ".SameCoordinates" for standard ones), written sorted by name.
+	Custom map[string]string
+}
+
+// fileFunction returns the X2 file function of the layer, if known.
+func (l *Layer) fileFunction() string {
+	if l.Attributes.FileFunction != "" {
+		return l.Attributes.FileFunction
+	}
+	if l.spec != nil && l.spec.FileFunction != "" {
+		return l.spec.FileFunction
+	}
+	copper := 2
+	if l.g != nil && l.g.layerCount() > copper {
+		copper = l.g.layerCount()
+	}
+	switch ext := l.extension(); ext {
+	case "gtl":
+		return "Copper,L1,Top"
+	case "gbl":
+		return fmt.Sprintf("Copper,L%v,Bot", copper)
+	case "gts":
+		return "Soldermask,Top"
+	case "gbs":
+		return "Soldermask,Bot"
+	case "gto":
+		return "Legend,Top"
+	case "gbo":
+		return "Legend,Bot"
+	case "gtp":
+		return "Paste,Top"
+	case "gbp":
+		return "Paste,Bot"
+	case "xln":
+		return fmt.Sprintf("Plated,1,%v,PTH", copper)
+	case "nxln":
+		return fmt.Sprintf("NonPlated,1,%v,NPTH", copper)
+	case "gko":
+		return "Profile,NP"
+	default:
+		if l.copper() {
+			return fmt.Sprintf("Copper,L%v,Inr", ext[1:len(ext)-1])
+		}
+	}
+	return ""
+}
+
+// writeAttributes writes the X2 file attributes of the layer.
+func (l *Layer) writeAttributes(w io.Writer) {
+	a := l.Attributes
+	if f := l.fileFunction(); f != "" {
+		polarity := a.FilePolarity
+		if polarity == "" {
+			polarity = "Positive"
+			if l.Negative() {
+				polarity = "Negative"
+			}
+		}
+		writeAttribute(w, "%%TF.FileFunction,%v*%%\n", f)
+		writeAttribute(w, "%%TF.FilePolarity,%v*%%\n", polarity)
+	}
+	part := a.Part
+	if part == "" {
+		part = "Single"
+	}
+	writeAttribute(w, "%%TF.Part,%v*%%\n", part)
+	if gen := l.generator(); gen != nil {
+		writeAttribute(w, "%%TF.GenerationSoftware,%v*%%\n", gen)
+	}
+	var names []string
+	for name := range a.Custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeAttribute(w, "%%TF%v,%v*%%\n", name, a.Custom[name])
+	}
+}
@@ -0,0 +1,149 @@
+package gerber
+
+import "errors"
+
+// Audit rule names, for reports and waivers (see ObjectT.Waive).
+const (
+	AuditMaskCopper   = "audit-mask-copper"
+	AuditPasteMask    = "audit-paste-mask"
+	AuditDrillOutline = "audit-drill-outline"
+	AuditSilkOutline  = "audit-silk-outline"
+)
+
+// Audit cross-checks the layers of the design as it is written
+// (including its derived pads) and returns a violation, located at the
+// offending point, for every inconsistency:
+//   - solder mask openings without copper beneath their center
+//     (AuditMaskCopper),
+//   - paste without a solder mask opening beneath its center
+//     (AuditPasteMask),
+//   - drill holes outside the board outline (AuditDrillOutline),
+//   - silkscreen outside the board outline (AuditSilkOutline, a warning,
+//     the others are errors).
+//
+// The outline checks need an outline layer and are skipped without one.
+// The violations can be added to those of DRC (see DRCReport).
+func (g *Gerber) Audit() []*Violation {
+	all := g.withDerived()
+	layers := map[string][]*Layer{}
+	for _, l := range all {
+		layers[l.extension()] = append(layers[l.extension()], l)
+	}
+	// covered returns a function reporting whether the primitives of the
+	// layer with the given extension cover a point.
+	covered := func(ext string) func(Pt) bool {
+		var items []*copperItem
+		for _, l := range layers[ext] {
+			for _, p := range l.Primitives {
+				if p = forVariant(p, g.Variant); p == nil {
+					continue
+				}
+				ops := plot(p)
+				if min, max, ok := bounds(ops); ok {
+					items = append(items, &copperItem{min: min, max: max, features: features(ops)})
+				}
+			}
+		}
+		return func(pt Pt) bool {
+			for _, item := range items {
+				if pt.X >= item.min.X && pt.X <= item.max.X && pt.Y >= item.min.Y && pt.Y <= item.max.Y && item.covers(pt) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	var edges [][2]Pt
+	for _, l := range layers["gko"] {
+		for _, p := range l.Primitives {
+			for _, o := range plot(p) {
+				if o.code == drawOp {
+					edges = append(edges, [2]Pt{o.pts[0], o.pts[1]})
+				}
+			}
+		}
+	}
+	onBoard := func(pt Pt) bool { return len(edges) == 0 || insideEdges(edges, pt) }
+
+	checks := map[string]struct {
+		check     func(Pt) bool
+		rule, msg string
+	}{
+		"gts":  {covered("gtl"), AuditMaskCopper, "solder mask opening has no copper beneath"},
+		"gbs":  {covered("gbl"), AuditMaskCopper, "solder mask opening has no copper beneath"},
+		"gtp":  {covered("gts"), AuditPasteMask, "paste has no solder mask opening beneath"},
+		"gbp":  {covered("gbs"), AuditPasteMask, "paste has no solder mask opening beneath"},
+		"xln":  {onBoard, AuditDrillOutline, "drill hole is outside the board outline"},
+		"nxln": {onBoard, AuditDrillOutline, "drill hole is outside the board outline"},
+	}
+	violations := []*Violation{}
+	for _, l := range all {
+		ext := l.extension()
+		c, ok := checks[ext]
+		silk := ext == "gto" || ext == "gbo"
+		if !ok && !silk {
+			continue
+		}
+		for i, p := range l.Primitives {
+			if p = forVariant(p, g.Variant); p == nil {
+				continue
+			}
+			ops := plot(p)
+			min, max, ok := bounds(ops)
+			if !ok {
+				continue
+			}
+			var bad *Pt
+			if silk {
+				// Every point of the silkscreen must be on the board.
+			points:
+				for _, o := range ops {
+					for _, pt := range o.pts {
+						if !onBoard(pt) {
+							bad = &pt
+							break points
+						}
+					}
+				}
+			} else if center := (Pt{X: 0.5 * (min.X + max.X), Y: 0.5 * (min.Y + max.Y)}); !c.check(center) {
+				bad = &center
+			}
+			if bad == nil {
+				continue
+			}
+			rule, severity, msg := c.rule, SeverityError, c.msg
+			if silk {
+				rule, severity, msg = AuditSilkOutline, SeverityWarning, "silkscreen is outside the board outline"
+			}
+			if waived(p, rule) {
+				continue
+			}
+			e := l.wrapErr(i, errors.New(msg)).(*Error)
+			violations = append(violations, &Violation{
+				Rule:     rule,
+				Severity: severity,
+				Layer:    l.Filename,
+				Index:    i,
+				Path:     e.Path,
+				Caller:   e.Caller,
+				X:        bad.X,
+				Y:        bad.Y,
+				Message:  msg,
+			})
+		}
+	}
+	return violations
+}
+
+// insideEdges reports whether pt lies within the closed contours made of
+// the edges, in any order (by the even-odd rule).
+func insideEdges(edges [][2]Pt, pt Pt) bool {
+	inside := false
+	for _, e := range edges {
+		a, b := e[0], e[1]
+		if (a.Y > pt.Y) != (b.Y > pt.Y) && pt.X < a.X+(pt.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y) {
+			inside = !inside
+		}
+	}
+	return inside
+}
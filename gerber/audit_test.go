@@ -0,0 +1,40 @@
+package gerber
+
+import "testing"
+
+func TestGerber_Audit(t *testing.T) {
+	g := panelDesign() // a 10x20mm board with a pad at (15,15)
+	// The pad derives its mask and paste openings.
+	g.TopCopper().Add(Object(Flash(15, 20, CircleShape, 1)).ExposeMask().ApplyPaste())
+	mask := g.TopSolderMask()
+	mask.Add(Flash(12, 12, CircleShape, 1))
+	g.TopPaste().Add(Flash(18, 18, RectShape, 1))
+	g.Drill().Add(Flash(25, 25, CircleShape, 0.4))
+	g.TopSilkscreen().Add(Line(15, 29, 15, 35, CircleShape, 0.15), Line(11, 11, 19, 11, CircleShape, 0.15))
+
+	got := g.Audit()
+	want := []Violation{
+		{Rule: AuditMaskCopper, Severity: SeverityError, Layer: "board.gts", X: 12, Y: 12},
+		{Rule: AuditPasteMask, Severity: SeverityError, Layer: "board.gtp", X: 18, Y: 18},
+		{Rule: AuditDrillOutline, Severity: SeverityError, Layer: "board.xln", X: 25, Y: 25},
+		{Rule: AuditSilkOutline, Severity: SeverityWarning, Layer: "board.gto", X: 15, Y: 35},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Audit = %+v, want %v violations", got, len(want))
+	}
+	for _, w := range want {
+		var found bool
+		for _, v := range got {
+			found = found || v.Rule == w.Rule && v.Severity == w.Severity && v.Layer == w.Layer && v.Index == 0 && v.X == w.X && v.Y == w.Y
+		}
+		if !found {
+			t.Errorf("missing %+v in %+v", w, got)
+		}
+	}
+
+	// Violations can be waived like those of the design rules.
+	mask.Primitives[0] = Object(Flash(12, 12, CircleShape, 1)).Waive(AuditMaskCopper)
+	if got := g.Audit(); len(got) != len(want)-1 {
+		t.Errorf("Audit = %+v, want the waived violation left out", got)
+	}
+}
@@ -0,0 +1,171 @@
+package gerber
+
+import (
+	"fmt"
+	"io"
+)
+
+// Board is a design with a preset stack of layers created by
+// Board2Layer, Board4Layer or FlexBoard. Its layer accessors return
+// the preset layers instead of adding new ones.
+type Board struct {
+	*Gerber
+	// layers are the preset layers, by layer name (e.g. "TopCopper").
+	layers map[string]*Layer
+}
+
+// Board2Layer returns a new two-layer board design with top and bottom
+// copper, solder mask and silkscreen, a drill layer and an outline.
+func Board2Layer(filenamePrefix string) *Board {
+	return newBoard(filenamePrefix,
+		"TopCopper", "TopSolderMask", "TopSilkscreen",
+		"BottomCopper", "BottomSolderMask", "BottomSilkscreen",
+		"Drill", "Outline")
+}
+
+// Board4Layer returns a new four-layer board design: a two-layer board
+// with the inner copper layers 2 and 3.
+func Board4Layer(filenamePrefix string) *Board {
+	return newBoard(filenamePrefix,
+		"TopCopper", "TopSolderMask", "TopSilkscreen",
+		"Layer2", "Layer3",
+		"BottomCopper", "BottomSolderMask", "BottomSilkscreen",
+		"Drill", "Outline")
+}
+
+// FlexBoard returns a new two-layer flexible board design with top and
+// bottom copper, coverlay openings (on the solder mask layers), a top
+// silkscreen, a stiffener layer, a drill layer and an outline.
+func FlexBoard(filenamePrefix string) *Board {
+	return newBoard(filenamePrefix,
+		"TopCopper", "TopSolderMask", "TopSilkscreen",
+		"BottomCopper", "BottomSolderMask",
+		"Stiffener", "Drill", "Outline")
+}
+
+func newBoard(filenamePrefix string, names ...string) *Board {
+	b := &Board{Gerber: New(filenamePrefix), layers: map[string]*Layer{}}
+	for _, name := range names {
+		l, err := b.AddLayer(name)
+		if err != nil {
+			panic(err) // Presets only use built-in layers.
+		}
+		b.layers[name] = l
+	}
+	return b
+}
+
+// Layer returns the preset layer with the given name
+// (e.g. "Layer2"), or nil if the board has none.
+func (b *Board) Layer(name string) *Layer {
+	return b.layers[name]
+}
+
+// WriteLayer writes the preset layer with the given name (e.g.
+// "TopCopper"), including its derived pads (see ObjectT.ExposeMask),
+// to w in Gerber format.
+func (b *Board) WriteLayer(name string, w io.Writer) error {
+	l := b.layers[name]
+	for i, layer := range b.Layers {
+		if layer == l && l != nil {
+			return b.withDerived()[i].WriteGerber(w)
+		}
+	}
+	return fmt.Errorf("board has no layer %q", name)
+}
+
+// group returns the preset layers with the given names, in stack order.
+func (b *Board) group(names ...string) []*Layer {
+	var layers []*Layer
+	for _, l := range b.Layers {
+		for _, name := range names {
+			if b.layers[name] == l {
+				layers = append(layers, l)
+			}
+		}
+	}
+	return layers
+}
+
+// Copper returns the copper layers of the board, from top to bottom.
+func (b *Board) Copper() []*Layer {
+	return b.group("TopCopper", "Layer2", "Layer3", "BottomCopper")
+}
+
+// SolderMasks returns the solder mask (or coverlay) layers of the board.
+func (b *Board) SolderMasks() []*Layer {
+	return b.group("TopSolderMask", "BottomSolderMask")
+}
+
+// Silkscreens returns the silkscreen layers of the board.
+func (b *Board) Silkscreens() []*Layer {
+	return b.group("TopSilkscreen", "BottomSilkscreen")
+}
+
+// TopCopper returns the top copper layer.
+func (b *Board) TopCopper() *Layer {
+	return b.layers["TopCopper"]
+}
+
+// TopSolderMask returns the top solder mask layer.
+func (b *Board) TopSolderMask() *Layer {
+	return b.layers["TopSolderMask"]
+}
+
+// TopSilkscreen returns the top silkscreen layer.
+func (b *Board) TopSilkscreen() *Layer {
+	return b.layers["TopSilkscreen"]
+}
+
+// BottomCopper returns the bottom copper layer.
+func (b *Board) BottomCopper() *Layer {
+	return b.layers["BottomCopper"]
+}
+
+// BottomSolderMask returns the bottom solder mask layer.
+func (b *Board) BottomSolderMask() *Layer {
+	return b.layers["BottomSolderMask"]
+}
+
+// BottomSilkscreen returns the bottom silkscreen layer,
+// or nil if the board has none.
+func (b *Board) BottomSilkscreen() *Layer {
+	return b.layers["BottomSilkscreen"]
+}
+
+// Layer2 returns the layer-2 copper layer, or nil if the board has none.
+func (b *Board) Layer2() *Layer {
+	return b.layers["Layer2"]
+}
+
+// Layer3 returns the layer-3 copper layer, or nil if the board has none.
+func (b *Board) Layer3() *Layer {
+	return b.layers["Layer3"]
+}
+
+// TopCoverlay returns the top coverlay openings layer of a flexible
+// board (its top solder mask layer).
+func (b *Board) TopCoverlay() *Layer {
+	return b.layers["TopSolderMask"]
+}
+
+// BottomCoverlay returns the bottom coverlay openings layer of a flexible
+// board (its bottom solder mask layer).
+func (b *Board) BottomCoverlay() *Layer {
+	return b.layers["BottomSolderMask"]
+}
+
+// Stiffener returns the stiffener layer, or nil if the board has none.
+func (b *Board) Stiffener() *Layer {
+	return b.layers["Stiffener"]
+}
+
+// Drill returns the drill layer.
+func (b *Board) Drill() *Layer {
+	return b.layers["Drill"]
+}
+
+// Outline returns the outline layer.
+func (b *Board) Outline() *Layer {
+	return b.layers["Outline"]
+}
@@ -0,0 +1,107 @@
+package gerber
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestBoardPresets(t *testing.T) {
+	tests := []struct {
+		name   string
+		b      *Board
+		want   []string
+		copper int
+	}{
+		{"2-layer", Board2Layer("b"), []string{"gtl", "gts", "gto", "gbl", "gbs", "gbo", "xln", "gko"}, 2},
+		{"4-layer", Board4Layer("b"), []string{"gtl", "gts", "gto", "g2l", "g3l", "gbl", "gbs", "gbo", "xln", "gko"}, 4},
+		{"flex", FlexBoard("b"), []string{"gtl", "gts", "gto", "gbl", "gbs", "gst", "xln", "gko"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, l := range tt.b.Layers {
+				got = append(got, l.extension())
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("layers = %v, want %v", got, tt.want)
+			}
+			if got := len(tt.b.Copper()); got != tt.copper {
+				t.Errorf("Copper has %v layers, want %v", got, tt.copper)
+			}
+			// Accessors return the preset layers rather than adding new ones.
+			if tt.b.TopCopper() != tt.b.Copper()[0] || tt.b.Drill() != tt.b.Layer("Drill") {
+				t.Error("accessors do not return the preset layers")
+			}
+			if len(tt.b.Layers) != len(tt.want) {
+				t.Errorf("accessors added layers: %v, want %v", len(tt.b.Layers), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestFlexBoard(t *testing.T) {
+	b := FlexBoard("flex")
+	if b.TopCoverlay() != b.TopSolderMask() || b.BottomSilkscreen() != nil {
+		t.Error("flex board layers are wrong")
+	}
+	var buf bytes.Buffer
+	if err := b.Stiffener().WriteGerber(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := "%TF.FileFunction,Other,Stiffener*%"; !strings.Contains(buf.String(), want) {
+		t.Errorf("stiffener layer missing %q:\n%v", want, buf.String())
+	}
+}
+
+func TestBoard_WriteLayer(t *testing.T) {
+	b := Board4Layer("b")
+	b.TopCopper().Add(Object(Flash(1, 2, RectShape, 1)).ExposeMask())
+
+	var buf bytes.Buffer
+	if err := b.WriteLayer("TopSolderMask", &buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := "X1000000Y2000000D03*"; !strings.Contains(buf.String(), want) {
+		t.Errorf("derived mask opening %q missing:\n%v", want, buf.String())
+	}
+	if err := b.WriteLayer("TopPaste", &buf); err == nil {
+		t.Error("WriteLayer of a missing layer: want error")
+	}
+}
+
+func TestGerber_WriteLayers(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters []LayerFilter
+		want    []string
+	}{
+		{"copper", []LayerFilter{CopperLayers}, []string{"b.g2l", "b.g3l", "b.gbl", "b.gtl"}},
+		{"outer copper", []LayerFilter{CopperLayers, OuterLayers}, []string{"b.gbl", "b.gtl"}},
+		{"outer", []LayerFilter{OuterLayers}, []string{"b.drl", "b.gbl", "b.gbo", "b.gbs", "b.gko", "b.gtl", "b.gto", "b.gts", "b.xln"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			b := Board4Layer(filepath.Join(dir, "b"))
+			if err := b.WriteLayers(tt.filters...); err != nil {
+				t.Fatal(err)
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Name())
+			}
+			sort.Strings(got)
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("wrote %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,506 @@
+package gerber
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Union returns regions covering the dark area of the primitives as they
+// are written (clear polarity subtracts), merging overlapping shapes
+// into single outlines, e.g. to simplify artwork before output.
+// The regions cut their holes out in clear polarity (see Region), so
+// they must be added to a layer before any artwork within their holes.
+func Union(primitives ...Primitive) ([]Primitive, error) {
+	return boolean(primitives, nil, func(a, b bool) bool { return a })
+}
+
+// Difference returns regions covering the dark area of the subject
+// outside that of the clip primitives, e.g. a copper pour with its
+// keep-out areas subtracted. The regions are those of Union.
+func Difference(subject, clip []Primitive) ([]Primitive, error) {
+	return boolean(subject, clip, func(a, b bool) bool { return a && !b })
+}
+
+// Intersection returns regions covering the dark area common to the
+// subject and the clip primitives. The regions are those of Union.
+func Intersection(subject, clip []Primitive) ([]Primitive, error) {
+	return boolean(subject, clip, func(a, b bool) bool { return a && b })
+}
+
+// darkShape is the dark area of primitives: their contours painted in
+// order with their polarities.
+type darkShape []*shape
+
+// newDarkShape returns the dark area of the primitives.
+func newDarkShape(primitives []Primitive) darkShape {
+	var result darkShape
+	for _, p := range primitives {
+		for _, o := range plot(p) {
+			for _, c := range contours(o) {
+				if min, max, ok := bounds([]op{{code: regionOp, pts: c}}); ok && len(c) >= 3 {
+					result = append(result, &shape{clear: o.clear, polys: [][]Pt{c}, min: min, max: max})
+				}
+			}
+		}
+	}
+	return result
+}
+
+// piece is a segment of the contour owner.
+type piece struct {
+	a, b  point
+	owner int
+}
+
+// boolean returns the regions covering the area where keep is true of
+// whether it is dark in a and b.
+//
+// The contours of both shapes are snapped to the nanometer grid and
+// split where they touch or cross until no crossings are left. The
+// pieces make the edges of a planar graph whose faces are classified
+// exactly: crossing an edge toggles whether a face is inside the
+// contours owning it, starting from the unbounded face of each connected
+// part of the graph. The edges between kept and dropped faces are then
+// linked into counterclockwise outlines and clockwise holes.
+func boolean(a, b []Primitive, keep func(a, b bool) bool) ([]Primitive, error) {
+	all := newDarkShape(a)
+	na := len(all)
+	all = append(all, newDarkShape(b)...)
+	var pieces []piece
+	for c, s := range all {
+		pts := snapContour(s.polys[0])
+		for i, p := range pts {
+			if q := pts[(i+1)%len(pts)]; p != q {
+				pieces = append(pieces, piece{a: p, b: q, owner: c})
+			}
+		}
+	}
+	pieces, err := splitPieces(pieces)
+	if err != nil {
+		return nil, err
+	}
+	g := newArrangement(pieces)
+	// keepFace reports whether the face inside the contours of state is kept.
+	keepFace := func(state []uint64) bool {
+		da, db := false, false
+		for c, s := range all {
+			if state[c/64]&(1<<uint(c%64)) != 0 {
+				if c < na {
+					da = !s.clear
+				} else {
+					db = !s.clear
+				}
+			}
+		}
+		return keep(da, db)
+	}
+	faces := g.classify(len(all))
+	keptFace := make([]bool, len(faces))
+	for i, state := range faces {
+		keptFace[i] = keepFace(state)
+	}
+	kept := map[[2]point]bool{}
+	for _, h := range g.halves {
+		if keptFace[h.cycle] && !keptFace[h.twin.cycle] {
+			kept[[2]point{h.from, h.to}] = true
+		}
+	}
+	contours, err := linkEdges(kept)
+	if err != nil {
+		return nil, err
+	}
+	return regions(contours)
+}
+
+// maxSplitPasses bounds the passes splitting pieces at the crossings
+// left by the rounding of earlier ones.
+const maxSplitPasses = 16
+
+// splitPieces splits the pieces where they touch or cross until none
+// cross (rounding a crossing to the nanometer grid bends the pieces
+// slightly, which may make them cross others).
+func splitPieces(pieces []piece) ([]piece, error) {
+	for pass := 0; pass < maxSplitPasses; pass++ {
+		var split bool
+		if pieces, split = splitOnce(pieces); !split {
+			return pieces, nil
+		}
+	}
+	return nil, fmt.Errorf("contours still cross after %v passes", maxSplitPasses)
+}
+
+// splitOnce splits the pieces at every point where they touch or cross
+// another piece and reports whether any piece was split.
+func splitOnce(pieces []piece) ([]piece, bool) {
+	cuts := make([][]point, len(pieces))
+	order := make([]int, len(pieces))
+	for i := range order {
+		order[i] = i
+	}
+	minX := func(s piece) nm { return min(s.a.X, s.b.X) }
+	sort.Slice(order, func(i, j int) bool { return minX(pieces[order[i]]) < minX(pieces[order[j]]) })
+	for k, i := range order {
+		s := pieces[i]
+		maxX := max(s.a.X, s.b.X)
+		for _, j := range order[k+1:] {
+			t := pieces[j]
+			if minX(t) > maxX {
+				break
+			}
+			if !segmentsIntersect(s.a, s.b, t.a, t.b) {
+				continue
+			}
+			// Ends lying on the other piece (touching or collinear
+			// pieces) split it there exactly.
+			touch := false
+			for _, e := range []point{t.a, t.b} {
+				if orient(s.a, s.b, e) == 0 && onSegment(s.a, s.b, e) {
+					cuts[i], touch = append(cuts[i], e), true
+				}
+			}
+			for _, e := range []point{s.a, s.b} {
+				if orient(t.a, t.b, e) == 0 && onSegment(t.a, t.b, e) {
+					cuts[j], touch = append(cuts[j], e), true
+				}
+			}
+			if !touch {
+				x := intersection(s, t)
+				cuts[i], cuts[j] = append(cuts[i], x), append(cuts[j], x)
+			}
+		}
+	}
+
+	var result []piece
+	split := false
+	for i, s := range pieces {
+		pts := []point{s.a, s.b}
+		for _, c := range cuts[i] {
+			if c != s.a && c != s.b {
+				pts = append(pts, c)
+				split = true
+			}
+		}
+		dx, dy := float64(s.b.X-s.a.X), float64(s.b.Y-s.a.Y)
+		along := func(p point) float64 { return float64(p.X-s.a.X)*dx + float64(p.Y-s.a.Y)*dy }
+		sort.Slice(pts, func(a, b int) bool { return along(pts[a]) < along(pts[b]) })
+		for k := 1; k < len(pts); k++ {
+			if pts[k] != pts[k-1] {
+				result = append(result, piece{a: pts[k-1], b: pts[k], owner: s.owner})
+			}
+		}
+	}
+	return result, split
+}
+
+// intersection returns the crossing point of the pieces, rounded to the
+// nanometer grid.
+func intersection(s, t piece) point {
+	p, r := s.a.pt(), Pt{X: (s.b.X - s.a.X).mm(), Y: (s.b.Y - s.a.Y).mm()}
+	q, u := t.a.pt(), Pt{X: (t.b.X - t.a.X).mm(), Y: (t.b.Y - t.a.Y).mm()}
+	d := r.X*u.Y - r.Y*u.X
+	f := ((q.X-p.X)*u.Y - (q.Y-p.Y)*u.X) / d
+	f = math.Max(0, math.Min(1, f))
+	return toPoint(Pt{X: p.X + f*r.X, Y: p.Y + f*r.Y})
+}
+
+// half is a directed edge of an arrangement, bounding the face on its
+// left.
+type half struct {
+	from, to point
+	owners   []int // the contours owning the edge, an odd number of times
+	twin     *half
+	next     *half // along the boundary of the face
+	cycle    int   // of the boundary of the face
+}
+
+// arrangement is the planar graph of the pieces of contours.
+type arrangement struct {
+	halves []*half
+	cycles [][]*half
+}
+
+// newArrangement returns the arrangement of the pieces, which must only
+// meet at their ends. Pieces owned an even number of times by every
+// contour change no face and are left out.
+func newArrangement(pieces []piece) *arrangement {
+	type key [2]point
+	owners := map[key]map[int]bool{}
+	var keys []key
+	for _, p := range pieces {
+		k := key{p.a, p.b}
+		if p.b.X < p.a.X || p.b.X == p.a.X && p.b.Y < p.a.Y {
+			k = key{p.b, p.a}
+		}
+		if owners[k] == nil {
+			owners[k] = map[int]bool{}
+			keys = append(keys, k)
+		}
+		owners[k][p.owner] = !owners[k][p.owner]
+	}
+
+	g := &arrangement{}
+	out := map[point][]*half{}
+	for _, k := range keys {
+		var odd []int
+		for c, ok := range owners[k] {
+			if ok {
+				odd = append(odd, c)
+			}
+		}
+		if len(odd) == 0 {
+			continue
+		}
+		sort.Ints(odd)
+		h, t := &half{from: k[0], to: k[1], owners: odd}, &half{from: k[1], to: k[0], owners: odd}
+		h.twin, t.twin = t, h
+		g.halves = append(g.halves, h, t)
+		out[h.from] = append(out[h.from], h)
+		out[t.from] = append(out[t.from], t)
+	}
+	// The halves leaving each vertex are sorted counterclockwise; the
+	// boundary of the face on the left of a half continues with the half
+	// leaving its end just clockwise of its twin.
+	for v, hs := range out {
+		upper := func(h *half) bool { return h.to.Y > v.Y || h.to.Y == v.Y && h.to.X > v.X }
+		sort.Slice(hs, func(i, j int) bool {
+			if ui, uj := upper(hs[i]), upper(hs[j]); ui != uj {
+				return ui
+			}
+			return orient(v, hs[i].to, hs[j].to) > 0
+		})
+		for i, h := range hs {
+			h.twin.next = hs[(i+len(hs)-1)%len(hs)]
+		}
+	}
+	for _, h := range g.halves {
+		h.cycle = -1
+	}
+	for _, h := range g.halves {
+		if h.cycle >= 0 {
+			continue
+		}
+		var cycle []*half
+		for e := h; e.cycle < 0; e = e.next {
+			e.cycle = len(g.cycles)
+			cycle = append(cycle, e)
+		}
+		g.cycles = append(g.cycles, cycle)
+	}
+	return g
+}
+
+// classify returns, for the face of every cycle, whether it lies inside
+// each of the contours (as a bit set).
+func (g *arrangement) classify(contours int) [][]uint64 {
+	words := (contours + 63) / 64
+	toggle := func(state []uint64, owners []int) []uint64 {
+		result := append([]uint64(nil), state...)
+		for _, c := range owners {
+			result[c/64] ^= 1 << uint(c%64)
+		}
+		return result
+	}
+
+	// The connected parts of the graph.
+	parent := map[point]point{}
+	var find func(point) point
+	find = func(p point) point {
+		if q, ok := parent[p]; ok && q != p {
+			parent[p] = find(q)
+			return parent[p]
+		}
+		return p
+	}
+	for _, h := range g.halves {
+		if a, b := find(h.from), find(h.to); a != b {
+			parent[a] = b
+		}
+	}
+	parts := map[point][]int{} // the cycles of every connected part
+	for i, c := range g.cycles {
+		root := find(c[0].from)
+		parts[root] = append(parts[root], i)
+	}
+
+	faces := make([][]uint64, len(g.cycles))
+	for root, cycles := range parts {
+		// The unbounded face of the part is bounded by its only cycle
+		// that is not counterclockwise.
+		outer := cycles[0]
+		for _, i := range cycles {
+			pts := make([]point, len(g.cycles[i]))
+			for k, h := range g.cycles[i] {
+				pts[k] = h.from
+			}
+			if twiceArea(pts).Sign() <= 0 {
+				outer = i
+				break
+			}
+		}
+		// It lies inside the contours whose edges outside the part a
+		// ray from the part crosses an odd number of times.
+		state := make([]uint64, words)
+		v := g.cycles[outer][0].from
+		for _, h := range g.halves {
+			if h.from.X > h.to.X || h.from.X == h.to.X && h.from.Y > h.to.Y || find(h.from) == root {
+				continue // each edge once, and only outside the part
+			}
+			a, b := h.from, h.to
+			if (a.Y > v.Y) != (b.Y > v.Y) {
+				if o := orient(a, b, v); b.Y > a.Y && o > 0 || b.Y < a.Y && o < 0 {
+					state = toggle(state, h.owners)
+				}
+			}
+		}
+		faces[outer] = state
+		queue := []int{outer}
+		for len(queue) > 0 {
+			i := queue[0]
+			queue = queue[1:]
+			for _, h := range g.cycles[i] {
+				if j := h.twin.cycle; faces[j] == nil {
+					faces[j] = toggle(faces[i], h.owners)
+					queue = append(queue, j)
+				}
+			}
+		}
+	}
+	return faces
+}
+
+// linkEdges links the directed edges into closed contours, turning
+// left as sharply as possible where several edges meet so that
+// contours touching at a vertex stay separate.
+func linkEdges(edges map[[2]point]bool) ([][]point, error) {
+	out := map[point][]point{}
+	for e := range edges {
+		out[e[0]] = append(out[e[0]], e[1])
+	}
+	// The start points are sorted for a deterministic output.
+	var starts []point
+	for p := range out {
+		starts = append(starts, p)
+	}
+	sort.Slice(starts, func(i, j int) bool {
+		if starts[i].X != starts[j].X {
+			return starts[i].X < starts[j].X
+		}
+		return starts[i].Y < starts[j].Y
+	})
+	take := func(from, to point) {
+		next := out[from]
+		for i, p := range next {
+			if p == to {
+				out[from] = append(next[:i], next[i+1:]...)
+				return
+			}
+		}
+	}
+
+	var result [][]point
+	for _, start := range starts {
+		for len(out[start]) > 0 {
+			contour := []point{start}
+			prev, cur := start, out[start][0]
+			take(start, cur)
+			for cur != start {
+				contour = append(contour, cur)
+				next := out[cur]
+				if len(next) == 0 {
+					return nil, fmt.Errorf("open boundary at %v", cur.pt())
+				}
+				dx, dy := float64(cur.X-prev.X), float64(cur.Y-prev.Y)
+				best, bestTurn := next[0], math.Inf(-1)
+				for _, p := range next {
+					ex, ey := float64(p.X-cur.X), float64(p.Y-cur.Y)
+					if turn := math.Atan2(dx*ey-dy*ex, dx*ex+dy*ey); turn > bestTurn {
+						best, bestTurn = p, turn
+					}
+				}
+				take(cur, best)
+				prev, cur = cur, best
+			}
+			result = append(result, dropCollinear(contour))
+		}
+	}
+	return result, nil
+}
+
+// dropCollinear returns the closed contour without the vertices lying
+// on the line through their neighbors.
+func dropCollinear(pts []point) []point {
+	for changed := true; changed && len(pts) >= 3; {
+		changed = false
+		for i := 0; i < len(pts) && len(pts) >= 3; i++ {
+			a, b, c := pts[(i+len(pts)-1)%len(pts)], pts[i], pts[(i+1)%len(pts)]
+			if orient(a, b, c) == 0 {
+				pts = append(pts[:i], pts[i+1:]...)
+				changed = true
+			}
+		}
+	}
+	return pts
+}
+
+// regions returns the contours (counterclockwise outlines and clockwise
+// holes) as regions: every hole is cut out of the smallest outline
+// containing it, and outlines are ordered so that the islands within
+// the holes of others are written after them.
+func regions(contours [][]point) ([]Primitive, error) {
+	type outline struct {
+		pts   []Pt
+		area  float64
+		holes [][]Pt
+		depth int
+	}
+	var outlines []*outline
+	var holes [][]Pt
+	for _, c := range contours {
+		if len(c) < 3 {
+			continue
+		}
+		pts := make([]Pt, len(c))
+		for i, p := range c {
+			pts[i] = p.pt()
+		}
+		if area := signedArea(pts); area > 0 {
+			outlines = append(outlines, &outline{pts: pts, area: area})
+		} else if area < 0 {
+			holes = append(holes, pts)
+		}
+	}
+	// smallest returns the smallest outline containing pt.
+	smallest := func(pt Pt) *outline {
+		var best *outline
+		for _, o := range outlines {
+			if insidePolygon(o.pts, pt) && (best == nil || o.area < best.area) {
+				best = o
+			}
+		}
+		return best
+	}
+	for _, h := range holes {
+		in, ok := insidePoint(h)
+		o := smallest(in)
+		if !ok || o == nil {
+			return nil, fmt.Errorf("hole at %v is outside all outlines", h[0])
+		}
+		o.holes = append(o.holes, h)
+	}
+	for _, o := range outlines {
+		if in, ok := insidePoint(o.pts); ok {
+			for _, p := range outlines {
+				if p != o && insidePolygon(p.pts, in) {
+					o.depth++
+				}
+			}
+		}
+	}
+	sort.SliceStable(outlines, func(i, j int) bool { return outlines[i].depth < outlines[j].depth })
+	var result []Primitive
+	for _, o := range outlines {
+		result = append(result, Region(o.pts, o.holes...))
+	}
+	return result, nil
+}
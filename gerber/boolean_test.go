@@ -0,0 +1,159 @@
+package gerber
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestBoolean(t *testing.T) {
+	rect := func(x0, y0, x1, y1 float64) *RegionT {
+		return Region([]Pt{{X: x0, Y: y0}, {X: x1, Y: y0}, {X: x1, Y: y1}, {X: x0, Y: y1}})
+	}
+	a, b := []Primitive{rect(0, 0, 10, 10)}, []Primitive{rect(5, 0, 15, 10)}
+	area := func(t *testing.T, primitives []Primitive) float64 {
+		t.Helper()
+		l := New("boolean").TopCopper()
+		l.Add(primitives...)
+		got, err := l.Area(508)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	must := func(got []Primitive, err error) []Primitive {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	tests := []struct {
+		name     string
+		got      []Primitive
+		regions  int
+		cutouts  int
+		area     float64
+		min, max Pt
+	}{
+		{name: "union", got: must(Union(append(a, b...)...)), regions: 1, area: 150, max: Pt{X: 15, Y: 10}},
+		{name: "intersection", got: must(Intersection(a, b)), regions: 1, area: 50, min: Pt{X: 5}, max: Pt{X: 10, Y: 10}},
+		{name: "difference", got: must(Difference(a, b)), regions: 1, area: 50, max: Pt{X: 5, Y: 10}},
+		{name: "keep-out", got: must(Difference(a, []Primitive{rect(3, 3, 7, 7)})), regions: 1, cutouts: 1, area: 84, max: Pt{X: 10, Y: 10}},
+		{name: "split", got: must(Difference(a, []Primitive{rect(4, -1, 6, 11)})), regions: 2, area: 80, max: Pt{X: 10, Y: 10}},
+		{name: "touching corners", got: must(Union(rect(0, 0, 1, 1), rect(1, 1, 2, 2))), regions: 2, area: 2, max: Pt{X: 2, Y: 2}},
+		{name: "island", got: must(Union(Region([]Pt{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}}, []Pt{{X: 2, Y: 2}, {X: 8, Y: 2}, {X: 8, Y: 8}, {X: 2, Y: 8}}), rect(4, 4, 6, 6))), regions: 2, cutouts: 1, area: 68, max: Pt{X: 10, Y: 10}},
+		{name: "disjoint intersection", got: must(Intersection(a, []Primitive{rect(20, 0, 30, 10)}))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.got) != tt.regions {
+				t.Fatalf("got %v regions, want %v", len(tt.got), tt.regions)
+			}
+			if tt.regions == 0 {
+				return
+			}
+			var cutouts int
+			for _, p := range tt.got {
+				cutouts += len(p.(*RegionT).cutouts)
+			}
+			if cutouts != tt.cutouts {
+				t.Errorf("got %v cutouts, want %v", cutouts, tt.cutouts)
+			}
+			if got := area(t, tt.got); math.Abs(got-tt.area) > 0.5 {
+				t.Errorf("area = %v, want %v", got, tt.area)
+			}
+			var ops []op
+			for _, p := range tt.got {
+				ops = append(ops, plot(p)...)
+			}
+			if min, max, _ := bounds(ops); min != tt.min || max != tt.max {
+				t.Errorf("bounds = %v..%v, want %v..%v", min, max, tt.min, tt.max)
+			}
+		})
+	}
+
+	// The island is written after the region whose hole it sits in.
+	if got := must(Union(Region([]Pt{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}}, []Pt{{X: 2, Y: 2}, {X: 8, Y: 2}, {X: 8, Y: 8}, {X: 2, Y: 8}}), rect(4, 4, 6, 6))); len(got[0].(*RegionT).cutouts) != 1 {
+		t.Error("outer region is not written first")
+	}
+
+	// Apertures and clear polarity count as they are written.
+	pad := Flash(0, 0, CircleShape, 4)
+	trace := Line(0, 0, 10, 0, CircleShape, 1)
+	merged := must(Union(pad, trace))
+	if len(merged) != 1 {
+		t.Fatalf("merged pad and trace into %v regions, want 1", len(merged))
+	}
+	if got, want := area(t, merged), area(t, []Primitive{pad, trace}); math.Abs(got-want) > 0.2 {
+		t.Errorf("merged area = %v, want the area of the pad and trace (%v)", got, want)
+	}
+
+	text := Text(0, 0, 1, "ABO8", "aaarghnormal", 24)
+	if got, want := area(t, must(Union(text))), area(t, []Primitive{text}); math.Abs(got-want) > 0.02*want {
+		t.Errorf("text area = %v, want %v", got, want)
+	}
+}
+
+func TestBooleanArea(t *testing.T) {
+	area := func(t *testing.T, primitives []Primitive) float64 {
+		t.Helper()
+		l := New("boolean").TopCopper()
+		l.Add(primitives...)
+		got, err := l.Area(1016)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	// Rounding the crossings of this trace and pad once made new ones,
+	// which lost the whole union.
+	pair := []Primitive{
+		Line(6.040559, 2.436122, 1.328022, 6.695272, CircleShape, 0.39457555874535394),
+		Flash(4.343872, 4.935719, CircleShape, 1.187555776304534),
+	}
+	got, err := Union(pair...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := area(t, got), area(t, pair); math.Abs(got-want) > 0.01*want {
+		t.Errorf("union of trace and pad has area %v, want %v", got, want)
+	}
+
+	random := func(r *rand.Rand) []Primitive {
+		var result []Primitive
+		for i := 0; i < 25; i++ {
+			x, y := 10*r.Float64(), 10*r.Float64()
+			switch r.Intn(3) {
+			case 0:
+				result = append(result, Flash(x, y, CircleShape, 0.2+2*r.Float64()))
+			case 1:
+				result = append(result, Line(x, y, 10*r.Float64(), 10*r.Float64(), CircleShape, 0.1+0.5*r.Float64()))
+			default:
+				result = append(result, Flash(x, y, RectShape, 0.2+2*r.Float64()))
+			}
+		}
+		return result
+	}
+	for seed := int64(1); seed <= 30; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		a, b := random(r), random(r)
+		union, err := Union(a...)
+		if err != nil {
+			t.Fatalf("seed %v: %v", seed, err)
+		}
+		if got, want := area(t, union), area(t, a); math.Abs(got-want) > 0.01*want+0.05 {
+			t.Errorf("seed %v: union has area %v, want %v", seed, got, want)
+		}
+		diff, err := Difference(a, b)
+		if err != nil {
+			t.Fatalf("seed %v: %v", seed, err)
+		}
+		want := area(t, append(append([]Primitive(nil), a...), b...)) - area(t, b)
+		if got := area(t, diff); math.Abs(got-want) > 0.01*want+0.05 {
+			t.Errorf("seed %v: difference has area %v, want %v", seed, got, want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package gerber
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	headerPitch = 2.54 // mm
+	headerPad   = 1.7  // mm
+	headerDrill = 1.0  // mm
+	maskMargin  = 0.05 // mm
+)
+
+// Package describes a dual-row surface mount package (such as SOIC or TSSOP).
+type Package struct {
+	// Name is printed on the silkscreen (e.g. "SOIC-8").
+	Name string
+	// Pins is the total number of pins (even).
+	Pins int
+	// Pitch is the distance between adjacent pins in a row.
+	Pitch float64
+	// BodyWidth and BodyLength are the size of the body (across and along the rows).
+	BodyWidth, BodyLength float64
+	// PadWidth and PadLength are the size of the pads
+	// (0.6*Pitch by 1.5mm if zero).
+	PadWidth, PadLength float64
+}
+
+// Breakout returns a complete breakout board design for the package:
+// its footprint fanned out to two rows of 2.54mm headers, with pin labels,
+// a board outline and drills.
+// Pin 1 is at the top left and pins are numbered counterclockwise.
+func Breakout(filenamePrefix string, pkg *Package, fontName string) (*Gerber, error) {
+	if pkg.Pins < 2 || pkg.Pins%2 != 0 {
+		return nil, fmt.Errorf("breakout %q: pin count %v must be even", pkg.Name, pkg.Pins)
+	}
+	if pkg.Pitch <= 0 {
+		return nil, fmt.Errorf("breakout %q: pitch %v must be positive", pkg.Name, pkg.Pitch)
+	}
+	padWidth, padLength := pkg.PadWidth, pkg.PadLength
+	if padWidth == 0 {
+		padWidth = 0.6 * pkg.Pitch
+	}
+	if padLength == 0 {
+		padLength = 1.5
+	}
+	traceWidth := math.Min(0.25, 0.5*pkg.Pitch)
+
+	n := pkg.Pins / 2
+	padX := 0.5*pkg.BodyWidth + 0.25*padLength // center of the pads
+	stubX := padX + 0.5*padLength + 0.5        // end of the straight fanout
+	// Header columns are a multiple of 2.54mm apart and clear of the fanout.
+	span := math.Max(0.5*float64(n-1)*(headerPitch-pkg.Pitch), headerPad)
+	headerX := headerPitch * math.Ceil((stubX+span)/headerPitch)
+	rowY := func(i int, pitch float64) float64 {
+		return (0.5*float64(n-1) - float64(i)) * pitch
+	}
+
+	g := New(filenamePrefix)
+	top, topMask, topSilk := g.TopCopper(), g.TopSolderMask(), g.TopSilkscreen()
+	bottom, bottomMask := g.BottomCopper(), g.BottomSolderMask()
+	drill, outline := g.Drill(), g.Outline()
+
+	for side := 0; side < 2; side++ {
+		dir := float64(2*side - 1) // -1 is left, +1 is right
+		for i := 0; i < n; i++ {
+			pin := i + 1
+			if side == 1 {
+				pin = pkg.Pins - i
+			}
+			py, hy := rowY(i, pkg.Pitch), rowY(i, headerPitch)
+			px, sx, hx := dir*padX, dir*stubX, dir*headerX
+
+			top.Add(
+				Line(px-0.5*(padLength-padWidth), py, px+0.5*(padLength-padWidth), py, RectShape, padWidth),
+				Line(px, py, sx, py, CircleShape, traceWidth),
+				Line(sx, py, hx, hy, CircleShape, traceWidth),
+			)
+			topMask.Add(Line(px-0.5*(padLength-padWidth), py, px+0.5*(padLength-padWidth), py, RectShape, padWidth+2*maskMargin))
+
+			shape := CircleShape
+			if pin == 1 {
+				shape = RectShape
+			}
+			for _, l := range []*Layer{top, bottom} {
+				l.Add(Line(hx, hy, hx, hy, shape, headerPad))
+			}
+			for _, l := range []*Layer{topMask, bottomMask} {
+				l.Add(Line(hx, hy, hx, hy, shape, headerPad+2*maskMargin))
+			}
+			drill.Add(Circle(hx, hy, headerDrill))
+
+			label := fmt.Sprintf("%v", pin)
+			w, h := MeasureText(label, fontName, 6)
+			lx := hx + dir*(0.5*headerPad+0.5)
+			if side == 0 {
+				lx -= w
+			}
+			topSilk.Add(Text(lx, hy-0.5*h, 1, label, fontName, 6))
+		}
+	}
+
+	// Body outline with a pin 1 marker.
+	bx, by := 0.5*pkg.BodyWidth, 0.5*pkg.BodyLength
+	topSilk.Add(
+		Line(-bx, by, bx, by, CircleShape, 0.15),
+		Line(bx, by, bx, -by, CircleShape, 0.15),
+		Line(bx, -by, -bx, -by, CircleShape, 0.15),
+		Line(-bx, -by, -bx, by, CircleShape, 0.15),
+		Circle(-bx+0.5, by-0.5, 0.3),
+	)
+
+	// Board outline around the headers and labels.
+	ox := headerX + 4
+	oy := math.Max(rowY(0, headerPitch)+0.5*headerPad, by) + 2
+	nameWidth, nameHeight := MeasureText(pkg.Name, fontName, 8)
+	topSilk.Add(Text(-0.5*nameWidth, oy, 1, pkg.Name, fontName, 8))
+	oy += nameHeight + 1
+	bottomY := -math.Max(rowY(0, headerPitch)+0.5*headerPad, by) - 2
+	outline.Add(
+		Line(-ox, oy, ox, oy, CircleShape, 0.1),
+		Line(ox, oy, ox, bottomY, CircleShape, 0.1),
+		Line(ox, bottomY, -ox, bottomY, CircleShape, 0.1),
+		Line(-ox, bottomY, -ox, oy, CircleShape, 0.1),
+	)
+	return g, nil
+}
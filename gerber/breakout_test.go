@@ -0,0 +1,33 @@
+package gerber
+
+import "testing"
+
+func TestBreakout(t *testing.T) {
+	pkg := &Package{Name: "SOIC-8", Pins: 8, Pitch: 1.27, BodyWidth: 3.9, BodyLength: 4.9}
+	g, err := Breakout("soic8", pkg, "latoregular")
+	if err != nil {
+		t.Fatalf("Breakout: %v", err)
+	}
+	layers := map[string]*Layer{}
+	for _, l := range g.Layers {
+		layers[l.extension()] = l
+	}
+	if got := len(layers["xln"].Primitives); got != 8 {
+		t.Errorf("got %v drills, want 8", got)
+	}
+	// Each pin has a pad, two fanout traces and a header pad.
+	if got := len(layers["gtl"].Primitives); got != 4*8 {
+		t.Errorf("got %v top copper primitives, want %v", got, 4*8)
+	}
+
+	// The headers must lie inside the board outline.
+	omin, omax, _ := layers["gko"].bounds()
+	hmin, hmax, _ := layers["gbl"].bounds()
+	if hmin.X <= omin.X || hmin.Y <= omin.Y || hmax.X >= omax.X || hmax.Y >= omax.Y {
+		t.Errorf("headers %v-%v outside outline %v-%v", hmin, hmax, omin, omax)
+	}
+
+	if _, err := Breakout("bad", &Package{Pins: 7, Pitch: 1}, "latoregular"); err == nil {
+		t.Error("Breakout with an odd pin count must fail")
+	}
+}
@@ -0,0 +1,119 @@
+package gerber
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// BusT represents parallel traces routed along a shared path and
+// satisfies the Primitive interface.
+type BusT struct {
+	path       []Pt
+	n          int
+	pitch      float64
+	thickness  float64
+	start, end []Pt
+}
+
+// Bus returns a bus of n parallel traces pitch apart (center to center)
+// along the path, centered on it, such as an address or data bus or the
+// connections of an LED strip. Lane 0 is the leftmost lane looking
+// along the path.
+// All dimensions are in millimeters (or Lengths).
+func Bus[L Lengths](path []Pt, n int, pitch, thickness L) *BusT {
+	return &BusT{path: path, n: n, pitch: float64(pitch), thickness: float64(thickness)}
+}
+
+// FanOut connects the lanes of the bus to rows of pads at the start and
+// end of its path: lane i runs straight from start[i] into the bus and
+// out of it to end[i]. Either row may be nil to end the lanes at the
+// path. Pads are matched in order, so rows should be ordered from left
+// to right looking along the path to avoid crossing traces.
+func (b *BusT) FanOut(start, end []Pt) *BusT {
+	b.start, b.end = start, end
+	return b
+}
+
+// Traces returns the traces of the lanes of the bus, such as to assign
+// each one to its net with Object.
+func (b *BusT) Traces() ([]*TraceT, error) {
+	if b.n < 1 || len(b.path) < 2 {
+		return nil, fmt.Errorf("bus needs at least 1 lane and 2 path points, got %v and %v", b.n, len(b.path))
+	}
+	if b.start != nil && len(b.start) != b.n || b.end != nil && len(b.end) != b.n {
+		return nil, fmt.Errorf("bus of %v lanes fans out to %v and %v pads", b.n, len(b.start), len(b.end))
+	}
+	var path []Pt
+	for i, pt := range b.path {
+		if i == 0 || pt != path[len(path)-1] {
+			path = append(path, pt)
+		}
+	}
+	if len(path) < 2 {
+		return nil, fmt.Errorf("bus path has no length")
+	}
+	// normals are the left normals of the segments of the path.
+	normals := make([]Pt, len(path)-1)
+	for i := range normals {
+		a, c := path[i], path[i+1]
+		l := math.Hypot(c.X-a.X, c.Y-a.Y)
+		normals[i] = Pt{X: -(c.Y - a.Y) / l, Y: (c.X - a.X) / l}
+	}
+	// miter returns the unit-offset direction at a vertex, which keeps
+	// the lanes pitch apart on both adjacent segments.
+	miter := func(i int) Pt {
+		switch i {
+		case 0:
+			return normals[0]
+		case len(path) - 1:
+			return normals[i-1]
+		}
+		n1, n2 := normals[i-1], normals[i]
+		k := 1 + n1.X*n2.X + n1.Y*n2.Y
+		if k < 1e-9 {
+			return n1 // The path doubles back on itself.
+		}
+		return Pt{X: (n1.X + n2.X) / k, Y: (n1.Y + n2.Y) / k}
+	}
+
+	traces := make([]*TraceT, b.n)
+	for lane := range traces {
+		offset := (0.5*float64(b.n-1) - float64(lane)) * b.pitch
+		var pts []Pt
+		if b.start != nil {
+			pts = append(pts, b.start[lane])
+		}
+		for i, pt := range path {
+			m := miter(i)
+			pts = append(pts, Pt{X: pt.X + offset*m.X, Y: pt.Y + offset*m.Y})
+		}
+		if b.end != nil {
+			pts = append(pts, b.end[lane])
+		}
+		traces[lane] = Trace(pts, b.thickness)
+	}
+	return traces, nil
+}
+
+// WriteGerber writes the primitive to the Gerber file.
+func (b *BusT) WriteGerber(w io.Writer, apertureIndex int) error {
+	traces, err := b.Traces()
+	if err != nil {
+		return err
+	}
+	for _, t := range traces {
+		if err := t.WriteGerber(w, apertureIndex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Aperture returns the primitive's desired aperture.
+func (b *BusT) Aperture() *Aperture {
+	return &Aperture{
+		Shape: CircleShape,
+		Size:  b.thickness,
+	}
+}
@@ -0,0 +1,85 @@
+package gerber
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBus(t *testing.T) {
+	path := []Pt{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}}
+	tests := []struct {
+		name string
+		bus  *BusT
+		want [][]Pt
+	}{
+		{
+			name: "lanes",
+			bus:  Bus(path, 3, 1, 0.2),
+			want: [][]Pt{
+				{{X: 0, Y: 1}, {X: 9, Y: 1}, {X: 9, Y: 10}},
+				{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}},
+				{{X: 0, Y: -1}, {X: 11, Y: -1}, {X: 11, Y: 10}},
+			},
+		},
+		{
+			name: "fan out",
+			bus:  Bus(path, 2, 1, 0.2).FanOut([]Pt{{X: -3, Y: 2}, {X: -3, Y: -2}}, []Pt{{X: 8, Y: 12}, {X: 12, Y: 12}}),
+			want: [][]Pt{
+				{{X: -3, Y: 2}, {X: 0, Y: 0.5}, {X: 9.5, Y: 0.5}, {X: 9.5, Y: 10}, {X: 8, Y: 12}},
+				{{X: -3, Y: -2}, {X: 0, Y: -0.5}, {X: 10.5, Y: -0.5}, {X: 10.5, Y: 10}, {X: 12, Y: 12}},
+			},
+		},
+		{
+			name: "fan in only",
+			bus:  Bus(path[:2], 2, 2, 0.2).FanOut([]Pt{{X: -1, Y: 3}, {X: -1, Y: -3}}, nil),
+			want: [][]Pt{
+				{{X: -1, Y: 3}, {X: 0, Y: 1}, {X: 10, Y: 1}},
+				{{X: -1, Y: -3}, {X: 0, Y: -1}, {X: 10, Y: -1}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			traces, err := tt.bus.Traces()
+			if err != nil {
+				t.Fatalf("Traces: %v", err)
+			}
+			if len(traces) != len(tt.want) {
+				t.Fatalf("got %v traces, want %v", len(traces), len(tt.want))
+			}
+			for i, trace := range traces {
+				if len(trace.points) != len(tt.want[i]) {
+					t.Fatalf("lane %v = %v, want %v", i, trace.points, tt.want[i])
+				}
+				for j, pt := range trace.points {
+					if toPoint(pt) != toPoint(tt.want[i][j]) {
+						t.Errorf("lane %v point %v = %v, want %v", i, j, pt, tt.want[i][j])
+					}
+				}
+			}
+
+			var buf bytes.Buffer
+			if err := tt.bus.WriteGerber(&buf, 12); err != nil {
+				t.Fatalf("WriteGerber: %v", err)
+			}
+			if got := strings.Count(buf.String(), "D02*"); got != len(tt.want) {
+				t.Errorf("WriteGerber drew %v traces, want %v", got, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestBus_Errors(t *testing.T) {
+	path := []Pt{{X: 0, Y: 0}, {X: 10, Y: 0}}
+	for name, bus := range map[string]*BusT{
+		"no lanes":      Bus(path, 0, 1, 0.2),
+		"short path":    Bus(path[:1], 2, 1, 0.2),
+		"zero length":   Bus([]Pt{{X: 1, Y: 1}, {X: 1, Y: 1}}, 2, 1, 0.2),
+		"pads mismatch": Bus(path, 2, 1, 0.2).FanOut([]Pt{{X: -1, Y: 0}}, nil),
+	} {
+		if _, err := bus.Traces(); err == nil {
+			t.Errorf("%v: expected an error", name)
+		}
+	}
+}
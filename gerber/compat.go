@@ -0,0 +1,129 @@
+package gerber
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Revision is the revision of the Gerber specification the output is
+// compatible with. It selects which constructs are emitted so that the
+// output of a design can be pinned for conservative CAM tools.
+type Revision string
+
+const (
+	// Revision2021 is the default revision (2021.02), with Gerber X2
+	// attributes, circular arcs and Gerber X3 component layers.
+	Revision2021 Revision = "2021.02"
+	// RevisionX2 is revision 2019.06: X2 attributes and circular arcs,
+	// but no X3 component attributes (nor component layers).
+	RevisionX2 Revision = "2019.06"
+	// RevisionX1 is plain RS-274X: no attributes nor aperture blocks,
+	// and arcs written as line segments.
+	RevisionX1 Revision = "X1"
+)
+
+// Revisions are the supported revisions, from the oldest.
+var Revisions = []Revision{RevisionX1, RevisionX2, Revision2021}
+
+// arcTolerance is the maximum deviation (in nanometers) of the line
+// segments approximating arcs for revisions without circular arcs.
+const arcTolerance = 1000
+
+// validate returns an error if the revision is not supported.
+func (r Revision) validate() error {
+	if r == "" {
+		return nil
+	}
+	for _, rev := range Revisions {
+		if r == rev {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported revision %q", r)
+}
+
+// atLeast reports whether the revision is rev or a later one.
+// The empty revision is the latest one.
+func (r Revision) atLeast(rev Revision) bool {
+	if r == "" {
+		return true
+	}
+	var got, want int
+	for i, v := range Revisions {
+		if v == r {
+			got = i
+		}
+		if v == rev {
+			want = i
+		}
+	}
+	return got >= want
+}
+
+// revisionOf returns the revision in effect for w.
+func revisionOf(w io.Writer) Revision {
+	if fw, ok := w.(*writer); ok && fw.revision != "" {
+		return fw.revision
+	}
+	return Revision2021
+}
+
+// writeAttribute writes the attribute command (e.g. "%TO.N,GND*%\n")
+// unless the revision in effect for w does not support it: revisions
+// before RevisionX2 have no attributes and those before Revision2021 no
+// Gerber X3 component attributes (such as .CRot or .CMfr, but not .C).
+func writeAttribute(w io.Writer, format string, args ...interface{}) {
+	cmd := fmt.Sprintf(format, args...)
+	rev := revisionOf(w)
+	if !rev.atLeast(RevisionX2) || !rev.atLeast(Revision2021) && componentAttribute(cmd) {
+		return
+	}
+	io.WriteString(w, cmd)
+}
+
+// componentAttribute reports whether the attribute command sets a Gerber
+// X3 component characteristic: .C followed by an upper case letter.
+func componentAttribute(cmd string) bool {
+	return strings.HasPrefix(cmd, "%TO.C") && len(cmd) > 5 && cmd[5] >= 'A' && cmd[5] <= 'Z'
+}
+
+// writeArcMode writes the multi quadrant mode (G75) for the circular
+// arcs of revisions that have them.
+func writeArcMode(w io.Writer) {
+	if revisionOf(w).atLeast(RevisionX2) {
+		io.WriteString(w, "G75*\n")
+	}
+}
+
+// writeArcSegments writes the arc from the current point to x,y around
+// the center offset by i,j from the current point as line segments
+// deviating at most by arcTolerance from the arc.
+func writeArcSegments(w *writer, code string, x, y, i, j nm) {
+	start, end := w.cur, point{X: x, Y: y}
+	cx, cy := float64(start.X+i), float64(start.Y+j)
+	r := math.Hypot(float64(i), float64(j))
+	a1 := math.Atan2(float64(start.Y)-cy, float64(start.X)-cx)
+	a2 := math.Atan2(float64(y)-cy, float64(x)-cx)
+	sweep := a2 - a1
+	clockwise := code == string(Clockwise)
+	if clockwise {
+		sweep = -sweep
+	}
+	for sweep <= 1e-12 {
+		sweep += 2 * math.Pi
+	}
+	n := 1
+	if r > arcTolerance {
+		n = int(math.Ceil(sweep / (2 * math.Acos(1-arcTolerance/r))))
+	}
+	for k := 1; k < n; k++ {
+		a := a1 + sweep*float64(k)/float64(n)
+		if clockwise {
+			a = a1 - sweep*float64(k)/float64(n)
+		}
+		writeXY(w, nm(math.Round(cx+r*math.Cos(a))), nm(math.Round(cy+r*math.Sin(a))), 1)
+	}
+	writeXY(w, end.X, end.Y, 1)
+}
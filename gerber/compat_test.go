@@ -0,0 +1,76 @@
+package gerber
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestGerber_Revision(t *testing.T) {
+	tests := []struct {
+		revision Revision
+		want     []string
+		dontWant []string
+	}{
+		{want: []string{"%TF.FileFunction,Copper,L1,Top*%", "%TO.C,R1*%", "%TO.CMfr,Yageo*%", "%TO.N,GND*%", "G75*", "G03X"}},
+		{revision: Revision2021, want: []string{"%TO.C,R1*%", "%TO.CMfr,Yageo*%", "G03X"}},
+		{revision: RevisionX2, want: []string{"%TF.FileFunction,Copper,L1,Top*%", "%TO.C,R1*%", "%TO.N,GND*%", "G75*", "G03X"}, dontWant: []string{"%TO.CMfr"}},
+		{revision: RevisionX1, want: []string{"%FSLAX36Y36*%", "%ADD12C,0.200000*%"}, dontWant: []string{"%T", "G75*", "G02", "G03"}},
+	}
+	for _, tt := range tests {
+		g := New("board")
+		g.Revision = tt.revision
+		top := g.TopCopper()
+		top.Add(
+			Object(Line(0, 0, 5, 0, CircleShape, 0.2)).Net("GND").Component("R1").Attribute(".CMfr", "Yageo"),
+			CircularArc(0, 0, 3, 0, 90, CounterClockwise, 0.2),
+		)
+		var buf bytes.Buffer
+		if err := top.WriteGerber(&buf); err != nil {
+			t.Fatalf("%q: WriteGerber: %v", tt.revision, err)
+		}
+		got := buf.String()
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%q: missing %q in:\n%v", tt.revision, want, got)
+			}
+		}
+		for _, dontWant := range tt.dontWant {
+			if strings.Contains(got, dontWant) {
+				t.Errorf("%q: unexpected %q in:\n%v", tt.revision, dontWant, got)
+			}
+		}
+
+		// The arc is drawn the same (within arcTolerance) by all revisions.
+		ops, _ := decode(buf.Bytes(), map[int]*Aperture{})
+		end := ops[len(ops)-1].pts
+		if last := end[len(end)-1]; math.Hypot(last.X, last.Y-3) > 1e-6 {
+			t.Errorf("%q: arc ends at %v, want (0,3)", tt.revision, last)
+		}
+		for _, o := range ops[1:] {
+			for _, pt := range o.pts {
+				if r := math.Hypot(pt.X, pt.Y); math.Abs(r-3) > 1e-3 {
+					t.Errorf("%q: point %v is %v from the center, want 3", tt.revision, pt, r)
+				}
+			}
+			if tt.revision == RevisionX1 && len(o.pts) != 2 {
+				t.Errorf("%q: arc segment has %v points, want 2", tt.revision, len(o.pts))
+			}
+		}
+	}
+}
+
+func TestGerber_Revision_Components(t *testing.T) {
+	g := New("board")
+	g.Revision = RevisionX2
+	g.AddComponent(&Component{Refdes: "R1"})
+	if err := g.WriteComponents(&bytes.Buffer{}, false); err == nil {
+		t.Error("WriteComponents = nil, want an error for revision 2019.06")
+	}
+
+	g.Revision = "2000.01"
+	if err := g.TopCopper().WriteGerber(&bytes.Buffer{}); err == nil {
+		t.Error("WriteGerber = nil, want an error for an unsupported revision")
+	}
+}
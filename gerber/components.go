@@ -0,0 +1,197 @@
+package gerber
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Component describes a placed component for assembly.
+type Component struct {
+	// Refdes is the reference designator (e.g. "R1"). Primitives tagged
+	// with the same component (see ObjectT.Component) belong to it.
+	Refdes string
+	// Value is the value of the component (e.g. "10k"), if any.
+	Value string
+	// Footprint is the name of the footprint (e.g. "R_0603"), if any.
+	Footprint string
+	// Mount is the mount type ("SMD", "TH" or "Other"), if known.
+	Mount string
+	// X and Y are the center of the component (in millimeters).
+	X, Y float64
+	// Rotation is the rotation of the component (in degrees, counterclockwise).
+	Rotation float64
+	// Bottom is true for components mounted on the bottom side.
+	Bottom bool
+	// Pins are the component's pins. The first pin is pin 1.
+	Pins []*ComponentPin
+	// Outline is the closed outline of the component body (in millimeters).
+	// If empty, the bounding box of the primitives tagged with the
+	// component is used.
+	Outline []Pt
+	// Variants are the board variants the component is part of (see
+	// Gerber.Variant). It is part of all variants if empty.
+	Variants []string
+}
+
+// inVariant reports whether the component is part of the named variant.
+func (c *Component) inVariant(variant string) bool {
+	return variant == "" || len(c.Variants) == 0 || slices.Contains(c.Variants, variant)
+}
+
+// ComponentPin is a pin of a component.
+type ComponentPin struct {
+	// Number is the pin number (e.g. "1" or "A3").
+	Number string
+	// X and Y are the location of the pin (in millimeters).
+	X, Y float64
+}
+
+// AddComponent adds a component to the design's assembly data
+// (written as Gerber X3 component layers) and returns it.
+func (g *Gerber) AddComponent(c *Component) *Component {
+	g.Components = append(g.Components, c)
+	return c
+}
+
+// componentFilename returns the filename of the component layer of a side.
+func (g *Gerber) componentFilename(bottom bool) string {
+	if bottom {
+		return g.FilenamePrefix + "-bottom-pos.gbr"
+	}
+	return g.FilenamePrefix + "-top-pos.gbr"
+}
+
+// hasComponents reports whether any component of the design's variant
+// is mounted on the side.
+func (g *Gerber) hasComponents(bottom bool) bool {
+	for _, c := range g.Components {
+		if c.Bottom == bottom && c.inVariant(g.Variant) {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteComponents writes the Gerber X3 component layer of the top (or
+// bottom) side with the centroid, outline and pins of every component
+// (of the design's variant) mounted on it, for pick-and-place machines.
+func (g *Gerber) WriteComponents(w io.Writer, bottom bool) error {
+	f := DefaultFormat
+	if g.Format != (Format{}) {
+		f = g.Format
+	}
+	if err := f.validate(); err != nil {
+		return err
+	}
+	if !g.Revision.atLeast(Revision2021) {
+		return fmt.Errorf("component layers need Gerber X3 (revision %v)", Revision2021)
+	}
+	fw := &writer{Writer: w, format: f, units: Millimeters}
+	w = fw
+
+	copper := g.layerCount()
+	if copper < 2 {
+		copper = 2
+	}
+	side := "L1,Top"
+	if bottom {
+		side = fmt.Sprintf("L%v,Bot", copper)
+	}
+
+	writeAttribute(w, "%%TF.FileFunction,Component,%v*%%\n", side)
+	writeAttribute(w, "%%TF.FilePolarity,Positive*%%\n")
+	if gen := (&Layer{g: g}).generator(); gen != nil {
+		writeAttribute(w, "%%TF.GenerationSoftware,%v*%%\n", gen)
+	}
+	fmt.Fprintf(w, "%%FSLAX%[1]v%[2]vY%[1]v%[2]v*%%\n", f.Integer, f.Decimal)
+	io.WriteString(w, "%MOMM*%\n")
+	io.WriteString(w, "%LPD*%\n")
+	writeAttribute(w, "%%TA.AperFunction,ComponentMain*%%\n")
+	fmt.Fprintf(w, "%%ADD10C,%v*%%\n", size(w, 0.3))
+	writeAttribute(w, "%%TA.AperFunction,ComponentOutline,Body*%%\n")
+	fmt.Fprintf(w, "%%ADD11C,%v*%%\n", size(w, 0.1))
+	writeAttribute(w, "%%TA.AperFunction,ComponentPin*%%\n")
+	fmt.Fprintf(w, "%%ADD12P,%vX4X0*%%\n", size(w, 0.36))
+	io.WriteString(w, "%ADD13C,0*%\n")
+	writeAttribute(w, "%%TD*%%\n")
+
+	for _, c := range g.Components {
+		if c.Bottom != bottom || !c.inVariant(g.Variant) {
+			continue
+		}
+		if strings.ContainsAny(c.Refdes, ",*%") {
+			return fmt.Errorf("component %q: invalid reference designator", c.Refdes)
+		}
+		writeAttribute(w, "%%TO.C,%v*%%\n", c.Refdes)
+		writeAttribute(w, "%%TO.CRot,%g*%%\n", c.Rotation)
+		for _, attr := range []struct{ name, value string }{
+			{"CVal", c.Value},
+			{"CFtp", c.Footprint},
+			{"CMnt", c.Mount},
+		} {
+			if attr.value != "" {
+				writeAttribute(w, "%%TO.%v,%v*%%\n", attr.name, attr.value)
+			}
+		}
+		io.WriteString(w, "D10*\n")
+		writeXY(w, toNM(c.X), toNM(c.Y), 3)
+
+		if outline := g.componentOutline(c); len(outline) > 0 {
+			io.WriteString(w, "D11*\n")
+			for i, pt := range append(outline, outline[0]) {
+				d := 1
+				if i == 0 {
+					d = 2
+				}
+				writeXY(w, toNM(pt.X), toNM(pt.Y), d)
+			}
+		}
+
+		for i, pin := range c.Pins {
+			// Pin 1 is flashed with a diamond, the others with a zero size circle.
+			if i == 0 {
+				io.WriteString(w, "D12*\n")
+			} else if i == 1 {
+				io.WriteString(w, "D13*\n")
+			}
+			writeAttribute(w, "%%TO.P,%v,%v*%%\n", c.Refdes, pin.Number)
+			writeXY(w, toNM(pin.X), toNM(pin.Y), 3)
+		}
+		writeAttribute(w, "%%TD*%%\n")
+	}
+	io.WriteString(w, "M02*\n")
+	return nil
+}
+
+// componentOutline returns the body outline of the component or the
+// bounding box of the primitives (of the design's variant) tagged with it.
+func (g *Gerber) componentOutline(c *Component) []Pt {
+	if len(c.Outline) > 0 {
+		outline := c.Outline
+		if n := len(outline); n > 1 && outline[0] == outline[n-1] {
+			outline = outline[:n-1]
+		}
+		return outline
+	}
+	var ops []op
+	for _, l := range g.Layers {
+		for _, p := range l.Primitives {
+			if p = forVariant(p, g.Variant); p == nil {
+				continue
+			}
+			for o, ok := p.(*ObjectT); ok; o, ok = o.p.(*ObjectT) {
+				if o.component == c.Refdes {
+					ops = append(ops, plot(o.p)...)
+					break
+				}
+			}
+		}
+	}
+	min, max, ok := bounds(ops)
+	if !ok {
+		return nil
+	}
+	return []Pt{min, {X: max.X, Y: min.Y}, max, {X: min.X, Y: max.Y}}
+}
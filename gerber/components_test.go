@@ -0,0 +1,85 @@
+package gerber
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGerber_WriteComponents(t *testing.T) {
+	g := New("board")
+	top := g.TopCopper()
+	top.Add(
+		Object(Line(-1, 0, -1, 0, RectShape, 0.8)).Component("R1"),
+		Object(Line(1, 0, 1, 0, RectShape, 0.8)).Component("R1"),
+	)
+	g.BottomCopper()
+	g.AddComponent(&Component{
+		Refdes:    "R1",
+		Value:     "10k",
+		Footprint: "R_0603",
+		Mount:     "SMD",
+		Rotation:  90,
+		Pins:      []*ComponentPin{{Number: "1", X: -1}, {Number: "2", X: 1}},
+	})
+	g.AddComponent(&Component{Refdes: "U1", X: 5, Y: 5, Bottom: true})
+
+	var buf bytes.Buffer
+	if err := g.WriteComponents(&buf, false); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"%TF.FileFunction,Component,L1,Top*%\n",
+		"%TO.C,R1*%\n%TO.CRot,90*%\n%TO.CVal,10k*%\n%TO.CFtp,R_0603*%\n%TO.CMnt,SMD*%\nD10*\nX000000Y000000D03*\n",
+		// The outline is the bounding box of the pads.
+		"D11*\nX-1400000Y-400000D02*\nX1400000Y-400000D01*\n",
+		"D12*\n%TO.P,R1,1*%\nX-1000000Y000000D03*\nD13*\n%TO.P,R1,2*%\nX1000000Y000000D03*\n%TD*%\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%v", want, got)
+		}
+	}
+	if strings.Contains(got, "U1") {
+		t.Error("top component layer must not contain bottom components")
+	}
+
+	buf.Reset()
+	g.WriteComponents(&buf, true)
+	if got := buf.String(); !strings.Contains(got, "%TF.FileFunction,Component,L2,Bot*%") || !strings.Contains(got, "%TO.C,U1*%") {
+		t.Errorf("bottom component layer:\n%v", got)
+	}
+}
+
+func TestGerber_WriteVariants_components(t *testing.T) {
+	t.Chdir(t.TempDir())
+	g := New("board")
+	g.TopCopper().Add(
+		Object(Flash(0, 0, RectShape, 1)).Component("R1"),
+		Object(Object(Flash(10, 0, RectShape, 1)).Component("U1")).Variants("pro"),
+		// Pads of other variants are left out of the outline.
+		Object(Object(Flash(3, 0, RectShape, 1)).Component("R1")).Variants("lite"),
+	)
+	g.AddComponent(&Component{Refdes: "R1"})
+	g.AddComponent(&Component{Refdes: "U1", X: 10, Variants: []string{"pro"}})
+	if err := g.WriteVariants("lite", "pro"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		variant     string
+		want, avoid string
+	}{
+		{"lite", "X3500000Y-500000D01*", "U1"},
+		{"pro", "%TO.C,U1*%", "X3500000Y-500000D01*"},
+	} {
+		data, err := os.ReadFile("board-" + tt.variant + "-top-pos.gbr")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(data); !strings.Contains(got, tt.want) || strings.Contains(got, tt.avoid) {
+			t.Errorf("variant %v: component layer contains %q or lacks %q:\n%v", tt.variant, tt.avoid, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package gerber
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+const (
+	nmPerMM   = 1e6        // nanometers per millimeter
+	nmPerInch = 25_400_000 // nanometers per inch
+)
+
+// nm is a fixed-point length in nanometers, the internal representation
+// of all primitive coordinates. Using integers eliminates accumulated
+// floating-point error and makes the emitted coordinates exact.
+type nm int64
+
+// toNM converts millimeters at the API boundary to nanometers.
+func toNM(mm float64) nm {
+	return nm(math.Round(mm * nmPerMM))
+}
+
+// mm converts nanometers back to millimeters.
+func (v nm) mm() float64 {
+	return float64(v) / nmPerMM
+}
+
+// point is a 2D point in nanometers.
+type point struct {
+	X, Y nm
+}
+
+// toPoint converts a point in millimeters to nanometers.
+func toPoint(pt Pt) point {
+	return point{X: toNM(pt.X), Y: toNM(pt.Y)}
+}
+
+// Format represents the Gerber coordinate format (%FS) used for output.
+type Format struct {
+	// Integer is the number of integer digits (1 to 6).
+	Integer int
+	// Decimal is the number of decimal digits (1 to 6).
+	Decimal int
+}
+
+// DefaultFormat is the coordinate format used when none is specified (3.6).
+var DefaultFormat = Format{Integer: 3, Decimal: 6}
+
+// Units are the units of the output coordinates and aperture sizes.
+type Units string
+
+const (
+	// Millimeters are the default units.
+	Millimeters Units = "MM"
+	// Inches are the legacy imperial units.
+	Inches Units = "IN"
+)
+
+// MM converts v in the units to millimeters, the units of all
+// coordinates and dimensions passed to the package.
+func (u Units) MM(v float64) float64 {
+	if u == Inches {
+		return v * 25.4
+	}
+	return v
+}
+
+// FromMM converts v in millimeters to the units.
+func (u Units) FromMM(v float64) float64 {
+	if u == Inches {
+		return v / 25.4
+	}
+	return v
+}
+
+// validate returns an error if the units are not supported.
+func (u Units) validate() error {
+	if u != Millimeters && u != Inches {
+		return fmt.Errorf("unsupported units %q", u)
+	}
+	return nil
+}
+
+// validate returns an error if the format is not supported.
+func (f Format) validate() error {
+	if f.Integer < 1 || f.Integer > 6 || f.Decimal < 1 || f.Decimal > 6 {
+		return fmt.Errorf("unsupported coordinate format %v.%v", f.Integer, f.Decimal)
+	}
+	return nil
+}
+
+// quantize converts nanometers to an integer coordinate in this format,
+// rounding half away from zero.
+func (f Format) quantize(v nm) int64 {
+	div := int64(math.Pow10(6 - f.Decimal))
+	n := int64(v)
+	if n < 0 {
+		return -((-n + div/2) / div)
+	}
+	return (n + div/2) / div
+}
+
+// decimal formats a decimal value (such as an aperture size) with
+// the number of decimal digits of this format.
+func (f Format) decimal(v float64) string {
+	return strconv.FormatFloat(v, 'f', f.Decimal, 64)
+}
+
+// writer wraps the output of Layer.WriteGerber with the coordinate
+// format and units in effect so that primitives emit coordinates consistently.
+type writer struct {
+	io.Writer
+	format Format
+	units  Units
+	// revision selects the constructs written (see Revision).
+	revision Revision
+	// cur is the current point (the end of the last coordinate block).
+	cur point
+	// offset translates all coordinates, e.g. to expand aperture blocks.
+	offset point
+	// codes maps the IDs of the apertures of the layer to their D codes
+	// and nextCode is the first unused D code.
+	codes    map[string]int
+	nextCode int
+	// repeating is true within a step and repeat block.
+	repeating bool
+	// strict makes warnings errors, the first one being err.
+	strict bool
+	err    error
+	// maxDev is the maximum deviation introduced by quantizing coordinates.
+	maxDev nm
+}
+
+// formatOf returns the coordinate format in effect for w.
+func formatOf(w io.Writer) Format {
+	if fw, ok := w.(*writer); ok {
+		return fw.format
+	}
+	return DefaultFormat
+}
+
+// unitsOf returns the units in effect for w.
+func unitsOf(w io.Writer) Units {
+	if fw, ok := w.(*writer); ok && fw.units != "" {
+		return fw.units
+	}
+	return Millimeters
+}
+
+// size formats a size in millimeters (such as an aperture diameter)
+// in the units and with the number of decimal digits in effect for w.
+// Positive sizes never round down to zero.
+func size(w io.Writer, mm float64) string {
+	mm = unitsOf(w).FromMM(mm)
+	f := formatOf(w)
+	if lsb := math.Pow10(-f.Decimal); mm > 0 && mm < 0.5*lsb {
+		mm = lsb
+	}
+	return f.decimal(mm)
+}
+
+// quantizeUnits converts nanometers to an integer coordinate in the
+// format and units, rounding half away from zero, and returns the
+// quantized value in nanometers alongside.
+func quantizeUnits(f Format, u Units, v nm) (int64, float64) {
+	if u != Inches {
+		q := f.quantize(v)
+		return q, float64(q) * math.Pow10(6-f.Decimal)
+	}
+	scale := int64(math.Pow10(f.Decimal))
+	n := int64(v) * scale
+	var q int64
+	if n < 0 {
+		q = -((-n + nmPerInch/2) / nmPerInch)
+	} else {
+		q = (n + nmPerInch/2) / nmPerInch
+	}
+	return q, float64(q) * nmPerInch / float64(scale)
+}
+
+// writeXY writes a coordinate data block with the given D code.
+func writeXY(w io.Writer, x, y nm, d int) {
+	f, u := formatOf(w), unitsOf(w)
+	if fw, ok := w.(*writer); ok {
+		fw.cur = point{X: x, Y: y}
+		x, y = x+fw.offset.X, y+fw.offset.Y
+	}
+	qx, vx := quantizeUnits(f, u, x)
+	qy, vy := quantizeUnits(f, u, y)
+	if fw, ok := w.(*writer); ok {
+		fw.track(x, vx)
+		fw.track(y, vy)
+	}
+	fmt.Fprintf(w, "X%06dY%06dD%02d*\n", qx, qy, d)
+}
+
+// writeArcXY writes a circular interpolation data block (G02 or G03)
+// to x,y around the center offset by i,j from the current point, or
+// line segments for revisions without circular arcs.
+func writeArcXY(w io.Writer, code string, x, y, i, j nm) {
+	if fw, ok := w.(*writer); ok && !fw.revision.atLeast(RevisionX2) {
+		writeArcSegments(fw, code, x, y, i, j)
+		return
+	}
+	if fw, ok := w.(*writer); ok {
+		fw.cur = point{X: x, Y: y}
+		x, y = x+fw.offset.X, y+fw.offset.Y
+	}
+	f, u := formatOf(w), unitsOf(w)
+	qx, vx := quantizeUnits(f, u, x)
+	qy, vy := quantizeUnits(f, u, y)
+	qi, _ := quantizeUnits(f, u, i)
+	qj, _ := quantizeUnits(f, u, j)
+	if fw, ok := w.(*writer); ok {
+		fw.track(x, vx)
+		fw.track(y, vy)
+	}
+	fmt.Fprintf(w, "%vX%06dY%06dI%06dJ%06dD01*\n", code, qx, qy, qi, qj)
+}
+
+// track records the deviation between a logical coordinate and its
+// quantized value (in nanometers).
+func (w *writer) track(v nm, q float64) {
+	dev := nm(math.Round(math.Abs(q - float64(v))))
+	if dev > w.maxDev {
+		w.maxDev = dev
+	}
+}
@@ -0,0 +1,53 @@
+package gerber
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestUnits(t *testing.T) {
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{name: "Inch", got: Inch(2).MM(), want: 50.8},
+		{name: "Mil", got: Mil(8).MM(), want: 0.2032},
+		{name: "Inches.MM", got: Inches.MM(0.5), want: 12.7},
+		{name: "Millimeters.MM", got: Millimeters.MM(0.5), want: 0.5},
+		{name: "Inches.FromMM", got: Inches.FromMM(12.7), want: 0.5},
+		{name: "Millimeters.FromMM", got: Millimeters.FromMM(12.7), want: 12.7},
+		{name: "round trip", got: Inches.FromMM(Inches.MM(0.123)), want: 0.123},
+	}
+	for _, tt := range tests {
+		if toNM(tt.got) != toNM(tt.want) {
+			t.Errorf("%v = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestNew_Units(t *testing.T) {
+	tests := []struct {
+		units []Units
+		want  []string
+	}{
+		{want: []string{"%MOMM*%", "%ADD12C,0.203200*%", "X12700000Y000000D01*"}},
+		{units: []Units{Millimeters}, want: []string{"%MOMM*%"}},
+		{units: []Units{Inches}, want: []string{"%MOIN*%", "%ADD12C,0.008000*%", "X500000Y000000D01*"}},
+	}
+	for _, tt := range tests {
+		g := New("board", tt.units...)
+		top := g.TopCopper()
+		top.Add(Line(0, 0, Mil(500), 0, CircleShape, Mil(8)))
+		var buf bytes.Buffer
+		if err := top.WriteGerber(&buf); err != nil {
+			t.Fatalf("%v: WriteGerber: %v", tt.units, err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("%v: missing %q in:\n%v", tt.units, want, buf.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package gerber
+
+// Coupon is a test coupon whose primitives span several layers,
+// placeable on panel rails.
+type Coupon struct {
+	Top, Bottom, Drill, Silkscreen []Primitive
+}
+
+// Add adds the coupon's primitives to the given layers.
+// Nil layers are skipped.
+func (c *Coupon) Add(top, bottom, drill, silkscreen *Layer) {
+	for _, v := range []struct {
+		layer *Layer
+		prims []Primitive
+	}{
+		{top, c.Top},
+		{bottom, c.Bottom},
+		{drill, c.Drill},
+		{silkscreen, c.Silkscreen},
+	} {
+		if v.layer != nil && len(v.prims) > 0 {
+			v.layer.Add(v.prims...)
+		}
+	}
+}
+
+// Label adds a silkscreen label above the coupon's copper.
+func (c *Coupon) Label(s, fontName string, pts float64) *Coupon {
+	var ops []op
+	for _, p := range append(c.Top, c.Bottom...) {
+		ops = append(ops, plot(p)...)
+	}
+	min, max, ok := bounds(ops)
+	if !ok {
+		return c
+	}
+	c.Silkscreen = append(c.Silkscreen, Text(min.X, max.Y+0.5*pts*mmPerPt, 1, s, fontName, pts))
+	return c
+}
+
+// via adds a plated through hole with pads on both sides.
+func (c *Coupon) via(x, y, pad, drill float64) {
+	c.Top = append(c.Top, Circle(x, y, pad))
+	c.Bottom = append(c.Bottom, Circle(x, y, pad))
+	c.Drill = append(c.Drill, Circle(x, y, drill))
+}
+
+// ImpedanceCoupon returns a controlled impedance test coupon: a top
+// trace of the given length and width (e.g. from a stackup calculation)
+// between two launch vias starting at x,y, each with a ground via at
+// pitch below it. When gap is positive, a differential pair with that
+// spacing is generated instead.
+// All dimensions are in millimeters.
+func ImpedanceCoupon(x, y, length, width, gap, pitch, pad, drill float64) *Coupon {
+	c := &Coupon{}
+	traces := []float64{y}
+	if gap > 0 {
+		traces = []float64{y, y + gap + width}
+	}
+	for _, ty := range traces {
+		c.via(x, ty, pad, drill)
+		c.via(x+length, ty, pad, drill)
+		c.Top = append(c.Top, Line(x, ty, x+length, ty, CircleShape, width))
+	}
+	c.via(x, y-pitch, pad, drill)
+	c.via(x+length, y-pitch, pad, drill)
+	return c
+}
+
+// DaisyChainCoupon returns a via reliability test coupon: a row of n vias
+// starting at x,y and spaced by pitch, chained by traces of the given width
+// alternating between the top and bottom copper layers so that the
+// resistance between the end vias measures all of the via barrels in series.
+// All dimensions are in millimeters.
+func DaisyChainCoupon(x, y float64, n int, pitch, pad, drill, width float64) *Coupon {
+	c := &Coupon{}
+	for i := 0; i < n; i++ {
+		vx := x + float64(i)*pitch
+		c.via(vx, y, pad, drill)
+		if i == n-1 {
+			break
+		}
+		link := Line(vx, y, vx+pitch, y, CircleShape, width)
+		if i%2 == 0 {
+			c.Top = append(c.Top, link)
+		} else {
+			c.Bottom = append(c.Bottom, link)
+		}
+	}
+	return c
+}
+
+// PlatingCoupon returns a plating thickness test coupon: a grid of rows
+// by cols plated holes starting at x,y and spaced by pitch, for
+// cross-sectioning.
+// All dimensions are in millimeters.
+func PlatingCoupon(x, y float64, rows, cols int, pitch, pad, drill float64) *Coupon {
+	c := &Coupon{}
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			c.via(x+float64(j)*pitch, y+float64(i)*pitch, pad, drill)
+		}
+	}
+	return c
+}
@@ -0,0 +1,30 @@
+package gerber
+
+import "testing"
+
+func TestCoupons(t *testing.T) {
+	tests := []struct {
+		name                     string
+		c                        *Coupon
+		top, bottom, drill, silk int
+	}{
+		{"impedance", ImpedanceCoupon(0, 0, 50, 0.3, 0, 1.27, 0.6, 0.3), 5, 4, 4, 0},
+		{"differential", ImpedanceCoupon(0, 0, 50, 0.2, 0.15, 1.27, 0.6, 0.3), 8, 6, 6, 0},
+		{"daisy chain", DaisyChainCoupon(0, 0, 5, 1, 0.6, 0.3, 0.25), 7, 7, 5, 0},
+		{"plating", PlatingCoupon(0, 0, 2, 3, 1, 0.6, 0.3).Label("PLATING", "latoregular", 6), 6, 6, 6, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := [4]int{len(tt.c.Top), len(tt.c.Bottom), len(tt.c.Drill), len(tt.c.Silkscreen)}; got != [4]int{tt.top, tt.bottom, tt.drill, tt.silk} {
+				t.Errorf("got %v top/bottom/drill/silkscreen primitives, want %v", got, [4]int{tt.top, tt.bottom, tt.drill, tt.silk})
+			}
+		})
+	}
+
+	g := New("coupon")
+	top, drill := g.TopCopper(), g.Drill()
+	DaisyChainCoupon(0, 0, 3, 1, 0.6, 0.3, 0.25).Add(top, nil, drill, nil)
+	if len(top.Primitives) != 4 || len(drill.Primitives) != 3 {
+		t.Errorf("Add: got %v top and %v drill primitives, want 4 and 3", len(top.Primitives), len(drill.Primitives))
+	}
+}
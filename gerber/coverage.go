@@ -0,0 +1,158 @@
+package gerber
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// UnicodeBlock represents a named range of Unicode code points.
+type UnicodeBlock struct {
+	Name     string
+	Lo, Hi   rune
+	graphics int // number of graphic code points in the block
+}
+
+// UnicodeBlocks lists the Unicode blocks considered by coverage reports.
+var UnicodeBlocks = []*UnicodeBlock{
+	{Name: "Basic Latin", Lo: 0x0000, Hi: 0x007f},
+	{Name: "Latin-1 Supplement", Lo: 0x0080, Hi: 0x00ff},
+	{Name: "Latin Extended-A", Lo: 0x0100, Hi: 0x017f},
+	{Name: "Latin Extended-B", Lo: 0x0180, Hi: 0x024f},
+	{Name: "IPA Extensions", Lo: 0x0250, Hi: 0x02af},
+	{Name: "Spacing Modifier Letters", Lo: 0x02b0, Hi: 0x02ff},
+	{Name: "Combining Diacritical Marks", Lo: 0x0300, Hi: 0x036f},
+	{Name: "Greek and Coptic", Lo: 0x0370, Hi: 0x03ff},
+	{Name: "Cyrillic", Lo: 0x0400, Hi: 0x04ff},
+	{Name: "Hebrew", Lo: 0x0590, Hi: 0x05ff},
+	{Name: "Arabic", Lo: 0x0600, Hi: 0x06ff},
+	{Name: "Latin Extended Additional", Lo: 0x1e00, Hi: 0x1eff},
+	{Name: "Greek Extended", Lo: 0x1f00, Hi: 0x1fff},
+	{Name: "General Punctuation", Lo: 0x2000, Hi: 0x206f},
+	{Name: "Superscripts and Subscripts", Lo: 0x2070, Hi: 0x209f},
+	{Name: "Currency Symbols", Lo: 0x20a0, Hi: 0x20cf},
+	{Name: "Letterlike Symbols", Lo: 0x2100, Hi: 0x214f},
+	{Name: "Number Forms", Lo: 0x2150, Hi: 0x218f},
+	{Name: "Arrows", Lo: 0x2190, Hi: 0x21ff},
+	{Name: "Mathematical Operators", Lo: 0x2200, Hi: 0x22ff},
+	{Name: "Miscellaneous Technical", Lo: 0x2300, Hi: 0x23ff},
+	{Name: "Box Drawing", Lo: 0x2500, Hi: 0x257f},
+	{Name: "Geometric Shapes", Lo: 0x25a0, Hi: 0x25ff},
+	{Name: "Miscellaneous Symbols", Lo: 0x2600, Hi: 0x26ff},
+	{Name: "Private Use Area", Lo: 0xe000, Hi: 0xf8ff},
+	{Name: "Alphabetic Presentation Forms", Lo: 0xfb00, Hi: 0xfb4f},
+	{Name: "Specials", Lo: 0xfff0, Hi: 0xffff},
+}
+
+// BlockCoverage represents how much of a Unicode block a font covers.
+type BlockCoverage struct {
+	Block *UnicodeBlock
+	// Covered is the number of code points in the block with a glyph.
+	Covered int
+	// Total is the number of graphic code points in the block.
+	Total int
+}
+
+// String returns the coverage as a single report line.
+func (b *BlockCoverage) String() string {
+	return fmt.Sprintf("%-30v U+%04X..U+%04X %4v/%-4v", b.Block.Name, b.Block.Lo, b.Block.Hi, b.Covered, b.Total)
+}
+
+// UnicodeCoverage returns the coverage of every Unicode block containing
+// at least one of the runes. Runes outside all known blocks are reported
+// in a final "Other" block.
+func UnicodeCoverage(runes []rune) []*BlockCoverage {
+	covered := map[*UnicodeBlock]int{}
+	var other int
+	for _, r := range runes {
+		if !unicode.IsGraphic(r) && !unicode.Is(unicode.Co, r) {
+			continue // e.g. tab and carriage return
+		}
+		if b := blockOf(r); b != nil {
+			covered[b]++
+		} else {
+			other++
+		}
+	}
+
+	var result []*BlockCoverage
+	for _, b := range UnicodeBlocks {
+		n, ok := covered[b]
+		if !ok {
+			continue
+		}
+		if b.graphics == 0 {
+			for r := b.Lo; r <= b.Hi; r++ {
+				if unicode.IsGraphic(r) || unicode.Is(unicode.Co, r) {
+					b.graphics++
+				}
+			}
+		}
+		result = append(result, &BlockCoverage{Block: b, Covered: n, Total: b.graphics})
+	}
+	if other > 0 {
+		result = append(result, &BlockCoverage{Block: &UnicodeBlock{Name: "Other"}, Covered: other})
+	}
+	return result
+}
+
+func blockOf(r rune) *UnicodeBlock {
+	i := sort.Search(len(UnicodeBlocks), func(i int) bool { return UnicodeBlocks[i].Hi >= r })
+	if i < len(UnicodeBlocks) && UnicodeBlocks[i].Lo <= r {
+		return UnicodeBlocks[i]
+	}
+	return nil
+}
+
+// Runes returns the sorted runes that have a glyph in the font.
+func (f *Font) Runes() []rune {
+	var runes []rune
+	for s := range f.Glyphs {
+		if r, n := utf8.DecodeRuneInString(s); n == len(s) && r != utf8.RuneError {
+			runes = append(runes, r)
+		}
+	}
+	sort.Slice(runes, func(a, b int) bool { return runes[a] < runes[b] })
+	return runes
+}
+
+// Coverage returns the Unicode block coverage of the font.
+func (f *Font) Coverage() []*BlockCoverage {
+	return UnicodeCoverage(f.Runes())
+}
+
+// Missing returns the distinct characters of s (other than line breaks)
+// that have no glyph in the font, in order of first appearance.
+func (f *Font) Missing(s string) []rune {
+	var missing []rune
+	seen := map[rune]bool{}
+	for _, r := range s {
+		if r == '\n' || seen[r] {
+			continue
+		}
+		seen[r] = true
+		if _, ok := f.Glyphs[string(r)]; !ok {
+			missing = append(missing, r)
+		}
+	}
+	return missing
+}
+
+// CoverageReport returns the Unicode block coverage of the font along
+// with the characters of required that the font is missing.
+func CoverageReport(name string, runes []rune, missing []rune) string {
+	lines := []string{fmt.Sprintf("%v: %v glyphs", name, len(runes))}
+	for _, b := range UnicodeCoverage(runes) {
+		lines = append(lines, "  "+b.String())
+	}
+	if len(missing) > 0 {
+		var names []string
+		for _, r := range missing {
+			names = append(names, fmt.Sprintf("%c (U+%04X)", r, r))
+		}
+		lines = append(lines, "  missing: "+strings.Join(names, ", "))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,217 @@
+package gerber
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+)
+
+// CurrentDensity returns an approximate current density heatmap of the
+// copper of the named net (or of all copper if net is empty) on the layer
+// when a current flows from terminal a to terminal b.
+//
+// The copper is rasterized at dpi dots per inch into a resistive mesh
+// whose node voltages are solved by relaxation until they are within 1µV
+// (of the 1V between the terminals) of the solution; the magnitude of
+// the voltage gradient is then mapped from blue (no current) to red
+// (highest density). Pixels without copper connected to the terminals
+// are transparent, and the image covers the same area as WritePBM of
+// the layer at the same resolution so that it can be overlaid on it
+// (see WriteCurrentDensityPNG).
+func (l *Layer) CurrentDensity(net string, a, b Pt, dpi float64) (image.Image, error) {
+	full, err := l.newRaster(dpi)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", l.Filename, err)
+	}
+	nl := &Layer{Filename: l.Filename, g: l.g}
+	for _, p := range l.Primitives {
+		if o, ok := p.(*ObjectT); net == "" || ok && o.net == net {
+			nl.Primitives = append(nl.Primitives, p)
+		}
+	}
+	r, err := nl.newRaster(dpi)
+	if err != nil {
+		return nil, fmt.Errorf("%v: net %q: %v", l.Filename, net, err)
+	}
+	r.width, r.height, r.origin = full.width, full.height, full.origin
+
+	w, h := r.width, r.height
+	copper := r.bitmap()
+	pixel := func(pt Pt) (int, error) {
+		i, ok := r.index(pt)
+		if !ok || !copper[i] {
+			return 0, fmt.Errorf("%v: terminal %v is not on the copper of net %q", l.Filename, pt, net)
+		}
+		return i, nil
+	}
+	ia, err := pixel(a)
+	if err != nil {
+		return nil, err
+	}
+	ib, err := pixel(b)
+	if err != nil {
+		return nil, err
+	}
+	// Only the copper connected to the terminals carries current.
+	copper = flood(copper, w, ia)
+	if !copper[ib] {
+		return nil, fmt.Errorf("%v: terminals %v and %v are not connected", l.Filename, a, b)
+	}
+
+	v := solveMesh(copper, w, ia, ib)
+	density := make([]float64, len(v))
+	var max float64
+	for i, c := range copper {
+		if !c {
+			continue
+		}
+		at := func(dx, dy int) float64 {
+			x, y := i%w+dx, i/w+dy
+			if x < 0 || x >= w || y < 0 || y >= h || !copper[y*w+x] {
+				return v[i]
+			}
+			return v[y*w+x]
+		}
+		density[i] = math.Hypot(at(1, 0)-at(-1, 0), at(0, 1)-at(0, -1))
+		if density[i] > max {
+			max = density[i]
+		}
+	}
+	if max == 0 {
+		return nil, errors.New("no current flows between the terminals")
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i, c := range copper {
+		if c {
+			img.Set(i%w, i/w, heat(density[i]/max))
+		}
+	}
+	return img, nil
+}
+
+// WriteCurrentDensityPNG writes the CurrentDensity heatmap of the net
+// overlaid on the copper of the layer (drawn in its SVGColors color) as
+// a PNG image, e.g. to review current crowding without a viewer.
+func (l *Layer) WriteCurrentDensityPNG(w io.Writer, net string, a, b Pt, dpi float64) error {
+	heatmap, err := l.CurrentDensity(net, a, b, dpi)
+	if err != nil {
+		return err
+	}
+	r, err := l.newRaster(dpi)
+	if err != nil {
+		return fmt.Errorf("%v: %v", l.Filename, err)
+	}
+	img := image.NewRGBA(heatmap.Bounds())
+	c := hexColor(SVGColors[l.extension()])
+	for i, dark := range r.bitmap() {
+		if dark {
+			img.Set(i%r.width, i/r.width, c)
+		}
+	}
+	draw.Draw(img, img.Bounds(), heatmap, image.Point{}, draw.Over)
+	return png.Encode(w, img)
+}
+
+// bitmap renders the raster into one value per pixel (row by row from
+// the top), true for dark pixels.
+func (r *raster) bitmap() []bool {
+	dark := make([]bool, r.width*r.height)
+	y := 0
+	r.render(func(row []byte) error {
+		for x := 0; x < r.width; x++ {
+			dark[y*r.width+x] = row[x/8]&(0x80>>uint(x%8)) != 0
+		}
+		y++
+		return nil
+	})
+	return dark
+}
+
+// index returns the bitmap index of the pixel containing pt.
+func (r *raster) index(pt Pt) (int, bool) {
+	x, y := int(math.Floor((pt.X-r.origin.X)/r.pixel)), int(math.Floor((r.origin.Y-pt.Y)/r.pixel))
+	if x < 0 || x >= r.width || y < 0 || y >= r.height {
+		return 0, false
+	}
+	return y*r.width + x, true
+}
+
+// flood returns the pixels of the bitmap connected to pixel a by copper.
+func flood(copper []bool, w, a int) []bool {
+	seen := make([]bool, len(copper))
+	seen[a] = true
+	queue := []int{a}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		for _, j := range []int{i - w, i + w, i - 1, i + 1} {
+			if j < 0 || j >= len(copper) || (j == i-1 || j == i+1) && j/w != i/w {
+				continue
+			}
+			if copper[j] && !seen[j] {
+				seen[j] = true
+				queue = append(queue, j)
+			}
+		}
+	}
+	return seen
+}
+
+// solveMesh returns the node voltages of the resistive mesh with a held at
+// 1V and b at 0V, solved by successive over-relaxation until they are
+// within tolerance of the solution. Every sweep shrinks the error by at
+// least the decay rate of the slowest mode of the mesh, about π²/(2n²)
+// for a mesh n pixels across, so the error is bounded by the largest
+// residual (the change of the voltages without over-relaxation) divided
+// by that rate.
+func solveMesh(copper []bool, w, a, b int) []float64 {
+	const tolerance = 1e-6
+	size := float64(max(w, len(copper)/w))
+	omega := 2 / (1 + math.Sin(math.Pi/size)) // optimal for a square mesh
+	rate := math.Pi * math.Pi / (2 * size * size)
+	v := make([]float64, len(copper))
+	for i := range v {
+		v[i] = 0.5
+	}
+	v[a], v[b] = 1, 0
+	for {
+		var residual float64
+		for i, c := range copper {
+			if !c || i == a || i == b {
+				continue
+			}
+			var sum float64
+			var n int
+			for _, j := range []int{i - w, i + w, i - 1, i + 1} {
+				if j < 0 || j >= len(copper) || (j == i-1 || j == i+1) && j/w != i/w || !copper[j] {
+					continue
+				}
+				sum += v[j]
+				n++
+			}
+			if n == 0 {
+				continue
+			}
+			r := sum/float64(n) - v[i]
+			v[i] += omega * r
+			residual = math.Max(residual, math.Abs(r))
+		}
+		if residual < tolerance*rate {
+			return v
+		}
+	}
+}
+
+// heat maps t in [0,1] to a blue-cyan-green-yellow-red color.
+func heat(t float64) color.NRGBA {
+	c := func(center float64) uint8 {
+		return uint8(255 * math.Max(0, math.Min(1, 1.5-math.Abs(4*t-center))))
+	}
+	return color.NRGBA{R: c(3), G: c(2), B: c(1), A: 255}
+}
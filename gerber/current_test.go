@@ -0,0 +1,71 @@
+package gerber
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestLayer_CurrentDensity(t *testing.T) {
+	g := New("board")
+	top := g.TopCopper()
+	// A wide pad necked down to a thin trace: the current crowds in the neck.
+	top.Add(
+		Object(Polygon(0, 0, true, []Pt{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 4}, {X: 0, Y: 4}, {X: 0, Y: 0}}, 0)).Net("VIN"),
+		Object(Line(4, 2, 10, 2, RectShape, 0.5)).Net("VIN"),
+		Line(0, 6, 10, 6, CircleShape, 0.2), // other copper
+	)
+
+	img, err := top.CurrentDensity("VIN", Point(1, 2), Point(9.8, 2), 127)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The layer spans 0,0 to 10.1,6.1 and pixels are 0.2mm.
+	at := func(pt Pt) (r, g, b, a uint32) {
+		return img.At(int(pt.X/0.2), int((6.1-pt.Y)/0.2)).RGBA()
+	}
+	if _, _, _, a := at(Point(2, 6)); a != 0 {
+		t.Errorf("other copper is not transparent")
+	}
+	if r, _, blue, _ := at(Point(7, 2)); r <= blue {
+		t.Errorf("neck is not hot: r=%v b=%v", r, blue)
+	}
+	if r, _, blue, _ := at(Point(2, 3.5)); blue <= r {
+		t.Errorf("pad corner is not cold: r=%v b=%v", r, blue)
+	}
+
+	if _, err := top.CurrentDensity("VIN", Point(1, 2), Point(5, 6), 127); err == nil {
+		t.Error("expected an error for a terminal off the net")
+	}
+}
+
+func TestLayer_WriteCurrentDensityPNG(t *testing.T) {
+	g := New("board")
+	top := g.TopCopper()
+	top.Add(
+		Object(Line(0, 0, 10, 0, RectShape, 1)).Net("VIN"),
+		Line(0, 3, 10, 3, RectShape, 1), // other copper
+	)
+	var buf bytes.Buffer
+	if err := top.WriteCurrentDensityPNG(&buf, "VIN", Point(0.2, 0), Point(9.8, 0), 127); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The layer spans -0.5,-0.5 to 10.5,3.5 and pixels are 0.2mm.
+	at := func(pt Pt) color.Color {
+		return img.At(int((pt.X+0.5)/0.2), int((3.5-pt.Y)/0.2))
+	}
+	if got, want := color.NRGBAModel.Convert(at(Point(5, 3))), hexColor(SVGColors["gtl"]); got != want {
+		t.Errorf("other copper = %v, want the layer color %v", got, want)
+	}
+	if _, _, _, a := at(Point(5, 1.5)).RGBA(); a != 0 {
+		t.Errorf("no copper is not transparent")
+	}
+	if got := color.NRGBAModel.Convert(at(Point(5, 0))); got == hexColor(SVGColors["gtl"]) {
+		t.Errorf("net copper is not a heatmap: %v", got)
+	}
+}
@@ -0,0 +1,156 @@
+package gerber
+
+import (
+	"fmt"
+	"math"
+)
+
+// Decimate simplifies the chains of connected line segments in the layer
+// (consecutive lines with the same aperture, each starting where the
+// previous one ends, as emitted by generative loops) by merging collinear
+// segments and dropping points that deviate less than tolerance (in mm)
+// from the simplified path.
+//
+// Connectivity is preserved: chain endpoints, junctions shared by other
+// lines and points at the centers of pads on any layer of the design
+// (circles and flashes, including vias and drills) are always kept.
+// It returns a description of every simplified chain.
+func (l *Layer) Decimate(tolerance float64) []string {
+	keep := l.junctions()
+	layers := []*Layer{l}
+	if l.g != nil {
+		layers = l.g.Layers
+	}
+	for _, layer := range layers {
+		for _, p := range layer.Primitives {
+			switch v := unwrap(p).(type) {
+			case *CircleT:
+				keep[point{X: v.x, Y: v.y}] = true
+			case *FlashT:
+				keep[point{X: v.x, Y: v.y}] = true
+			}
+		}
+	}
+
+	var changes []string
+	var prims []Primitive
+	var callers []string
+	add := func(p Primitive, i int) {
+		prims = append(prims, p)
+		if i < len(l.callers) {
+			callers = append(callers, l.callers[i])
+		}
+	}
+	for i := 0; i < len(l.Primitives); {
+		first, ok := l.Primitives[i].(*LineT)
+		if !ok {
+			add(l.Primitives[i], i)
+			i++
+			continue
+		}
+		pts := []point{{X: first.x1, Y: first.y1}, {X: first.x2, Y: first.y2}}
+		j := i + 1
+		for ; j < len(l.Primitives); j++ {
+			next, ok := l.Primitives[j].(*LineT)
+			if !ok || next.shape != first.shape || next.thickness != first.thickness || (point{X: next.x1, Y: next.y1}) != pts[len(pts)-1] {
+				break
+			}
+			pts = append(pts, point{X: next.x2, Y: next.y2})
+		}
+
+		simplified := decimate(pts, toNM(tolerance), keep)
+		if len(simplified) == len(pts) {
+			for k := i; k < j; k++ {
+				add(l.Primitives[k], k)
+			}
+			i = j
+			continue
+		}
+		for k := 1; k < len(simplified); k++ {
+			p1, p2 := simplified[k-1], simplified[k]
+			add(&LineT{x1: p1.X, y1: p1.Y, x2: p2.X, y2: p2.Y, shape: first.shape, thickness: first.thickness}, i)
+		}
+		changes = append(changes, l.wrapErr(i, fmt.Errorf("decimated %v segments to %v", len(pts)-1, len(simplified)-1)).Error())
+		i = j
+	}
+	l.Primitives = prims
+	if l.callers != nil {
+		l.callers = callers
+	}
+	return changes
+}
+
+// Decimate simplifies the chains of line segments on all layers of the
+// design and returns a description of every simplified chain.
+// See Layer.Decimate.
+func (g *Gerber) Decimate(tolerance float64) []string {
+	var changes []string
+	for _, l := range g.Layers {
+		changes = append(changes, l.Decimate(tolerance)...)
+	}
+	return changes
+}
+
+// junctions returns the line endpoints of the layer that are not
+// shared by exactly two line ends, i.e. the ends of chains and the
+// points where chains branch.
+func (l *Layer) junctions() map[point]bool {
+	count := map[point]int{}
+	for _, p := range l.Primitives {
+		if v, ok := unwrap(p).(*LineT); ok {
+			count[point{X: v.x1, Y: v.y1}]++
+			count[point{X: v.x2, Y: v.y2}]++
+		}
+	}
+	result := map[point]bool{}
+	for pt, n := range count {
+		if n != 2 {
+			result[pt] = true
+		}
+	}
+	return result
+}
+
+// decimate simplifies the polyline with the Ramer-Douglas-Peucker
+// algorithm, always keeping its endpoints and the points in keep.
+func decimate(pts []point, tolerance nm, keep map[point]bool) []point {
+	result := []point{pts[0]}
+	start := 0
+	for i := 1; i < len(pts); i++ {
+		if i == len(pts)-1 || keep[pts[i]] {
+			result = append(result, rdp(pts[start:i+1], float64(tolerance))[1:]...)
+			start = i
+		}
+	}
+	return result
+}
+
+// rdp simplifies the polyline with the Ramer-Douglas-Peucker algorithm.
+func rdp(pts []point, tolerance float64) []point {
+	if len(pts) < 3 {
+		return pts
+	}
+	a, b := pts[0], pts[len(pts)-1]
+	dx, dy := float64(b.X-a.X), float64(b.Y-a.Y)
+	length := math.Hypot(dx, dy)
+	var maxDist float64
+	index := 0
+	for i := 1; i < len(pts)-1; i++ {
+		px, py := float64(pts[i].X-a.X), float64(pts[i].Y-a.Y)
+		var d float64
+		if length == 0 {
+			d = math.Hypot(px, py)
+		} else {
+			d = math.Abs(px*dy-py*dx) / length
+		}
+		if d > maxDist {
+			maxDist, index = d, i
+		}
+	}
+	if maxDist <= tolerance {
+		return []point{a, b}
+	}
+	left := rdp(pts[:index+1], tolerance)
+	right := rdp(pts[index:], tolerance)
+	return append(left[:len(left)-1:len(left)-1], right...)
+}
@@ -0,0 +1,45 @@
+package gerber
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDecimate(t *testing.T) {
+	g := New("board")
+	top := g.TopCopper()
+	// A straight chain of 10 segments through a via at x=5,
+	// followed by a gentle arc.
+	for i := 0; i < 10; i++ {
+		top.Add(Line(float64(i), 0, float64(i+1), 0, CircleShape, 0.2))
+	}
+	for i := 0; i < 20; i++ {
+		a1, a2 := float64(i)*math.Pi/40, float64(i+1)*math.Pi/40
+		top.Add(Line(10+10*math.Sin(a1), 10-10*math.Cos(a1), 10+10*math.Sin(a2), 10-10*math.Cos(a2), CircleShape, 0.2))
+	}
+	g.Drill().Add(Circle(5, 0, 0.3))
+
+	min1, max1, _ := top.bounds()
+	changes := top.Decimate(0.05)
+	if len(changes) != 1 {
+		t.Errorf("Decimate = %v, want 1 change", changes)
+	}
+	// The straight part simplifies to two lines (split at the via)
+	// and the arc to about one segment per 0.2 radians.
+	if got := len(top.Primitives); got < 4 || got > 16 {
+		t.Errorf("got %v lines after Decimate, want 4-16", got)
+	}
+	var atVia bool
+	for _, p := range top.Primitives {
+		if l := p.(*LineT); l.x2 == toNM(5) && l.y2 == 0 {
+			atVia = true
+		}
+	}
+	if !atVia {
+		t.Error("Decimate must keep the point on the via")
+	}
+	min2, max2, _ := top.bounds()
+	if min1 != min2 || max1 != max2 {
+		t.Errorf("Decimate changed the bounds from %v-%v to %v-%v", min1, max1, min2, max2)
+	}
+}
@@ -0,0 +1,212 @@
+package gerber
+
+import "math"
+
+// derivedLayers are the extensions of the solder mask and paste layers
+// derived from each outer copper layer.
+var derivedLayers = map[string]struct{ mask, paste string }{
+	"gtl": {mask: "gts", paste: "gtp"},
+	"gbl": {mask: "gbs", paste: "gbp"},
+}
+
+// DeriveRules are the rules deriving the solder mask and paste openings
+// of the pads of the outer copper layers (see ObjectT.ExposeMask).
+// The expansions offset the outlines of the pads, so that the clearance
+// is the same along all of their edges; the percentages scale every
+// flash, draw and region of a pad about its center.
+type DeriveRules struct {
+	// MaskExpansion is the clearance (in millimeters) of the solder mask
+	// openings on each side of the pads.
+	MaskExpansion float64
+	// MaskPercent grows the width and height of the solder mask openings
+	// by the percentage of those of the pads.
+	MaskPercent float64
+	// PasteShrink is the amount (in millimeters) the paste openings are
+	// smaller than the pads on each side.
+	PasteShrink float64
+	// PastePercent shrinks the width and height of the paste openings by
+	// the percentage of those of the pads (e.g. 10 for openings of 90%).
+	PastePercent float64
+	// MaskFlashes and PasteFlashes, when true, derive the solder mask and
+	// paste openings of every flash of the outer copper layers, as if
+	// flagged with ExposeMask or ApplyPaste.
+	MaskFlashes, PasteFlashes bool
+}
+
+// derived returns the primitives of the outer copper layers flagged with
+// ObjectT.ExposeMask or ObjectT.ApplyPaste (or flashes, see DeriveRules)
+// for the solder mask or paste layer with the given extension, resized
+// by the DeriveRules of the design, followed by those of the pads of the
+// design on the layer.
+func (g *Gerber) derived(ext string) []Primitive {
+	var primitives []Primitive
+	r := g.Derive
+	for _, l := range g.Layers {
+		targets, ok := derivedLayers[l.extension()]
+		if !ok {
+			continue
+		}
+		for _, p := range l.Primitives {
+			o, _ := p.(*ObjectT)
+			_, flash := unwrap(p).(*FlashT)
+			mask := o != nil && o.mask || flash && r.MaskFlashes
+			paste := o != nil && o.paste || flash && r.PasteFlashes
+			var opening Primitive
+			switch {
+			case mask && targets.mask == ext:
+				opening = resize(unwrap(p), r.MaskExpansion, r.MaskPercent)
+			case paste && targets.paste == ext:
+				opening = resize(unwrap(p), -r.PasteShrink, -r.PastePercent)
+			}
+			if opening == nil {
+				continue
+			}
+			// Only the geometry (and variants) of the pad carry over.
+			d := &ObjectT{p: opening}
+			if o != nil {
+				d.variants = o.variants
+			}
+			primitives = append(primitives, d)
+		}
+	}
+	for _, p := range g.Pads {
+		primitives = append(primitives, p.primitives(ext)...)
+	}
+	return primitives
+}
+
+// resize returns the primitive with every flash, draw and region grown
+// by percent per cent about its center and by grow on each side, or nil
+// if nothing is left of it. Flashes and draws are replotted with their
+// apertures resized and the outlines of regions are offset by grow (and
+// their cutouts by -grow), so that the clearance is the same all around.
+func resize(p Primitive, grow, percent float64) Primitive {
+	if grow == 0 && percent == 0 {
+		return p
+	}
+	f := 1 + percent/100
+	resized := map[*Aperture]*Aperture{}
+	ops := plot(p)
+	var result []op
+	for i := 0; i < len(ops); {
+		j := i + 1
+		for j < len(ops) && ops[j].more {
+			j++
+		}
+		group := append([]op(nil), ops[i:j]...)
+		i = j
+		if o := group[0]; o.code != regionOp && o.aperture != nil {
+			if o.aperture.Custom == nil {
+				a := resized[o.aperture]
+				if a == nil {
+					a = &Aperture{Shape: o.aperture.Shape, Size: f*o.aperture.Size + 2*grow}
+					resized[o.aperture] = a
+				}
+				if a.Size > 0 {
+					o.aperture = a
+					result = append(result, o)
+				}
+				continue
+			}
+			group[0] = op{code: regionOp, clear: o.clear, pts: contours(o)[0]}
+		}
+		if min, max, ok := centerlineBounds(group); ok && f != 1 {
+			c := Pt{X: 0.5 * (min.X + max.X), Y: 0.5 * (min.Y + max.Y)}
+			group = transformOps(group, func(pt Pt) Pt { return Pt{X: c.X + (pt.X-c.X)*f, Y: c.Y + (pt.Y-c.Y)*f} })
+		}
+		for _, o := range group {
+			result = append(result, offsetRegion(o, grow)...)
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return replot(result)
+}
+
+// offsetRegion returns the operations of the region operation with its
+// outline offset outward by d (inward if d is negative, and the other
+// way round for clear polarity). A region whose contour can't be
+// resolved is left as it is.
+func offsetRegion(o op, d float64) []op {
+	if o.clear {
+		d = -d
+	}
+	if d == 0 {
+		return []op{o}
+	}
+	// The pen circumscribes a circle of radius d, so that polygonized
+	// corners keep at least the clearance, and has sides facing the axes,
+	// so that rectangles grow by exactly d.
+	n := 4 * ((len(circle(Pt{}, math.Abs(d))) + 3) / 4)
+	pen := make([]Pt, n)
+	for i := range pen {
+		sin, cos := math.Sincos(math.Pi * float64(2*i+1) / float64(n))
+		r := math.Abs(d) / math.Cos(math.Pi/float64(n))
+		pen[i] = Pt{X: r * cos, Y: r * sin}
+	}
+	region := []Primitive{Region(o.pts)}
+	var regions []Primitive
+	var err error
+	if d > 0 {
+		regions, err = Union(append(region, edgeBands(region, pen)...)...)
+	} else {
+		regions, err = Difference(region, edgeBands(region, pen))
+	}
+	if err != nil {
+		return []op{o}
+	}
+	var result []op
+	for _, r := range regions {
+		for _, ro := range plot(r) {
+			ro.clear = ro.clear != o.clear
+			result = append(result, ro)
+		}
+	}
+	return result
+}
+
+// withDerived returns the layers of the design with the flagged pads of
+// the outer copper layers added to copies of their solder mask and paste
+// layers and the pad stack instances added to copies of all their
+// layers, in the same order. Missing outer copper, drill, solder mask
+// and paste layers are added (after them) as needed.
+func (g *Gerber) withDerived() []*Layer {
+	var layers []*Layer
+	have := map[string]bool{}
+	for _, l := range g.Layers {
+		ext := l.extension()
+		have[ext] = true
+		if primitives := g.derived(ext); len(primitives) > 0 {
+			l = l.clone()
+			l.Add(primitives...)
+		}
+		layers = append(layers, l)
+	}
+	for _, ext := range []string{"gtl", "gbl", "xln", "nxln", "gts", "gtp", "gbs", "gbp"} {
+		if primitives := g.derived(ext); !have[ext] && len(primitives) > 0 {
+			l := &Layer{
+				Filename:    g.FilenamePrefix + "." + ext,
+				apertureMap: map[string]int{"default": -1},
+				g:           g,
+			}
+			l.Add(primitives...)
+			layers = append(layers, l)
+		}
+	}
+	return layers
+}
+
+// clone returns a copy of the layer that primitives can be added to
+// without modifying the original.
+func (l *Layer) clone() *Layer {
+	c := *l
+	c.Primitives = append([]Primitive(nil), l.Primitives...)
+	c.Apertures = append([]*Aperture(nil), l.Apertures...)
+	c.callers = append([]string(nil), l.callers...)
+	c.apertureMap = map[string]int{}
+	for id, i := range l.apertureMap {
+		c.apertureMap[id] = i
+	}
+	return &c
+}
@@ -0,0 +1,106 @@
+package gerber
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGerber_withDerived(t *testing.T) {
+	g := New("board")
+	top, bottom := g.TopCopper(), g.BottomCopper()
+	bottomMask := g.BottomSolderMask()
+	bottomMask.Add(Circle(9, 9, 2)) // drawn explicitly
+	top.Add(
+		Object(Flash(1, 1, RectShape, 1)).ExposeMask().ApplyPaste().Net("A"),
+		Object(Circle(2, 2, 0.5)).ExposeMask(), // via: mask only
+		Line(0, 0, 5, 5, CircleShape, 0.2),     // trace: neither
+	)
+	bottom.Add(Object(Circle(3, 3, 1)).ExposeMask())
+
+	got := map[string]int{}
+	for _, l := range g.withDerived() {
+		got[l.extension()] = len(l.Primitives)
+	}
+	want := map[string]int{"gtl": 3, "gbl": 1, "gbs": 2, "gts": 2, "gtp": 1}
+	if len(got) != len(want) {
+		t.Errorf("layers = %v, want %v", got, want)
+	}
+	for ext, n := range want {
+		if got[ext] != n {
+			t.Errorf("%v has %v primitives, want %v", ext, got[ext], n)
+		}
+	}
+	if len(g.Layers) != 3 || len(bottomMask.Primitives) != 1 {
+		t.Error("withDerived modified the design")
+	}
+}
+
+func TestGerber_withDerived_Rules(t *testing.T) {
+	g := New("board")
+	g.TopCopper().Add(
+		Object(Flash(1, 1, RectShape, 1)).ExposeMask().ApplyPaste(),
+		Flash(5, 5, RectShape, 2),
+		Line(0, 0, 5, 5, CircleShape, 0.2),
+	)
+	g.Derive = DeriveRules{MaskExpansion: 0.1, MaskPercent: 10, PastePercent: 10, MaskFlashes: true}
+
+	sizes := func(ext string) []Pt {
+		var got []Pt
+		for _, l := range g.withDerived() {
+			if l.extension() != ext {
+				continue
+			}
+			for _, p := range l.Primitives {
+				min, max, _ := bounds(plot(p))
+				got = append(got, Pt{X: max.X - min.X, Y: max.Y - min.Y})
+			}
+		}
+		return got
+	}
+	near := func(got []Pt, want ...float64) bool {
+		if len(got) != len(want) {
+			return false
+		}
+		for i, v := range want {
+			if math.Abs(got[i].X-v) > 1e-6 || math.Abs(got[i].Y-v) > 1e-6 {
+				return false
+			}
+		}
+		return true
+	}
+	if got := sizes("gts"); !near(got, 1.3, 2.4) {
+		t.Errorf("mask openings = %v, want 1.3 and 2.4 wide", got)
+	}
+	if got := sizes("gtp"); !near(got, 0.9) {
+		t.Errorf("paste openings = %v, want 0.9 wide", got)
+	}
+	g.Derive.PasteShrink = 0.5
+	if got := sizes("gtp"); len(got) != 0 {
+		t.Errorf("paste openings = %v, want none left", got)
+	}
+}
+
+func TestResize(t *testing.T) {
+	// Draws and flashes grow by their apertures, whatever their direction
+	// and wherever the other pads of the object are.
+	ops := plot(resize(Line(0, 0, 3, 4, CircleShape, 0.2), 0.05, 0))
+	if len(ops) != 1 || math.Abs(ops[0].aperture.Size-0.3) > 1e-9 || ops[0].pts[1] != (Pt{X: 3, Y: 4}) {
+		t.Errorf("resized trace = %+v, want a 0.3mm wide draw to {3 4}", ops)
+	}
+	ops = plot(resize(Group(Flash(0, 0, RectShape, 1), Flash(10, 0, RectShape, 1)), 0.05, 10))
+	if len(ops) != 2 || ops[1].pts[0] != (Pt{X: 10}) || math.Abs(ops[0].aperture.Size-1.2) > 1e-9 {
+		t.Errorf("resized pads = %+v, want 1.2mm pads at {0 0} and {10 0}", ops)
+	}
+
+	// Regions are offset, and their cutouts shrink.
+	pad := Region([]Pt{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 4}, {X: 0, Y: 4}}, []Pt{{X: 1, Y: 1}, {X: 3, Y: 1}, {X: 3, Y: 3}, {X: 1, Y: 3}})
+	l := New("resize").TopSolderMask()
+	l.Add(resize(pad, 0.1, 0))
+	if min, max, _ := bounds(plot(l.Primitives[0])); toPoint(min) != toPoint(Pt{X: -0.1, Y: -0.1}) || toPoint(max) != toPoint(Pt{X: 4.1, Y: 4.1}) {
+		t.Errorf("resized region spans %v..%v, want {-0.1 -0.1}..{4.1 4.1}", min, max)
+	}
+	want := 4.2*4.2 - (4-math.Pi)*0.01 - 1.8*1.8
+	if got, err := l.Area(1016); err != nil || math.Abs(got-want) > 0.05 {
+		t.Errorf("resized region has area %v, %v, want %v", got, err, want)
+	}
+}
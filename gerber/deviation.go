@@ -0,0 +1,42 @@
+package gerber
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Deviation returns the maximum deviation (in mm) between the logical
+// geometry of the layer and the geometry emitted after quantizing
+// coordinates to the configured coordinate format.
+func (l *Layer) Deviation() (float64, error) {
+	w, err := l.newWriter(io.Discard)
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range l.Primitives {
+		if err := p.WriteGerber(w, 0); err != nil {
+			return 0, err
+		}
+	}
+	return w.maxDev.mm(), nil
+}
+
+// DeviationReport returns a per-layer report of the maximum deviation
+// introduced by quantizing coordinates, flagging layers that exceed
+// MaxDeviation.
+func (g *Gerber) DeviationReport() (string, error) {
+	var lines []string
+	for _, l := range g.Layers {
+		dev, err := l.Deviation()
+		if err != nil {
+			return "", err
+		}
+		line := fmt.Sprintf("%v: max deviation %.6fmm", l.Filename, dev)
+		if g.MaxDeviation > 0 && dev > g.MaxDeviation {
+			line += fmt.Sprintf(" (exceeds %.6fmm)", g.MaxDeviation)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
@@ -0,0 +1,38 @@
+package gerber
+
+import (
+	"io"
+	"math"
+	"testing"
+)
+
+func TestLayer_Deviation(t *testing.T) {
+	g := New("test")
+	top := g.TopCopper()
+	top.Add(Line(0.0004, 0, 1.2345678, -2.00049, CircleShape, 0.1))
+
+	tests := []struct {
+		format Format
+		want   float64
+	}{
+		{DefaultFormat, 0},
+		{Format{Integer: 3, Decimal: 3}, 0.00049},
+		{Format{Integer: 3, Decimal: 2}, 0.004568},
+	}
+
+	for _, tt := range tests {
+		g.Format = tt.format
+		got, err := top.Deviation()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Deviation(%v) = %v, want %v", tt.format, got, tt.want)
+		}
+	}
+
+	g.MaxDeviation = 0.001
+	if err := top.WriteGerber(io.Discard); err == nil {
+		t.Errorf("WriteGerber = nil, want deviation error")
+	}
+}
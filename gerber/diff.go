@@ -0,0 +1,209 @@
+package gerber
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	diffTolerance = 0.001 // mm
+)
+
+// Change represents a single difference between two revisions of a design.
+type Change struct {
+	// Layer is the layer filename extension (e.g. "gtl"), or empty
+	// for changes (such as moved components) that span layers.
+	Layer string
+	// Kind is one of "added", "removed", "moved", "width" or "outline".
+	Kind string
+	// Description is a human-readable description of the change.
+	Description string
+}
+
+// String returns the change as a single changelog line.
+func (c *Change) String() string {
+	if c.Layer == "" {
+		return fmt.Sprintf("%v: %v", c.Kind, c.Description)
+	}
+	return fmt.Sprintf("%v: %v: %v", c.Layer, c.Kind, c.Description)
+}
+
+// Diff compares two revisions of a design and returns the list of
+// added/removed/moved components, changed trace widths, added/removed
+// primitives and outline changes.
+// Layers are matched by their filename extension.
+func Diff(old, new *Gerber) []*Change {
+	var changes []*Change
+
+	oldComps, newComps := componentCenters(old), componentCenters(new)
+	for _, refdes := range sortedKeys(oldComps) {
+		n, ok := newComps[refdes]
+		if !ok {
+			changes = append(changes, &Change{Kind: "removed", Description: fmt.Sprintf("component %v", refdes)})
+			continue
+		}
+		if o := oldComps[refdes]; math.Hypot(n.X-o.X, n.Y-o.Y) > diffTolerance {
+			changes = append(changes, &Change{Kind: "moved", Description: fmt.Sprintf("component %v from (%.3f,%.3f) to (%.3f,%.3f)", refdes, o.X, o.Y, n.X, n.Y)})
+		}
+	}
+	for _, refdes := range sortedKeys(newComps) {
+		if _, ok := oldComps[refdes]; !ok {
+			changes = append(changes, &Change{Kind: "added", Description: fmt.Sprintf("component %v", refdes)})
+		}
+	}
+
+	newLayers := map[string]*Layer{}
+	for _, l := range new.Layers {
+		newLayers[l.extension()] = l
+	}
+	seen := map[string]bool{}
+	for _, ol := range old.Layers {
+		ext := ol.extension()
+		seen[ext] = true
+		nl, ok := newLayers[ext]
+		if !ok {
+			changes = append(changes, &Change{Layer: ext, Kind: "removed", Description: "layer"})
+			continue
+		}
+		changes = append(changes, diffLayers(ext, ol, nl)...)
+	}
+	for _, nl := range new.Layers {
+		if ext := nl.extension(); !seen[ext] {
+			changes = append(changes, &Change{Layer: ext, Kind: "added", Description: "layer"})
+		}
+	}
+
+	return changes
+}
+
+// ChangeReport formats the changes as text suitable for a changelog.
+func ChangeReport(changes []*Change) string {
+	if len(changes) == 0 {
+		return "No changes.\n"
+	}
+	var lines []string
+	for _, c := range changes {
+		lines = append(lines, "* "+c.String())
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func diffLayers(ext string, ol, nl *Layer) []*Change {
+	var changes []*Change
+
+	oldWidths, newWidths := traceWidths(ol), traceWidths(nl)
+	var widthKeys []string
+	for k, ow := range oldWidths {
+		if nw, ok := newWidths[k]; ok && math.Abs(nw-ow) > diffTolerance {
+			widthKeys = append(widthKeys, k)
+		}
+	}
+	sort.Strings(widthKeys)
+	for _, k := range widthKeys {
+		changes = append(changes, &Change{Layer: ext, Kind: "width", Description: fmt.Sprintf("trace %v from %.3fmm to %.3fmm", k, oldWidths[k], newWidths[k])})
+	}
+
+	oldKeys, newKeys := primitiveKeys(ol), primitiveKeys(nl)
+	var removed, added int
+	for k, n := range oldKeys {
+		if d := n - newKeys[k]; d > 0 {
+			removed += d
+		}
+	}
+	for k, n := range newKeys {
+		if d := n - oldKeys[k]; d > 0 {
+			added += d
+		}
+	}
+	if ext == "gko" && (added > 0 || removed > 0) {
+		omin, omax, _ := ol.bounds()
+		nmin, nmax, _ := nl.bounds()
+		return append(changes, &Change{Layer: ext, Kind: "outline", Description: fmt.Sprintf("size from %.3fx%.3fmm to %.3fx%.3fmm", omax.X-omin.X, omax.Y-omin.Y, nmax.X-nmin.X, nmax.Y-nmin.Y)})
+	}
+	// Width changes and moved components are already reported.
+	added -= len(widthKeys)
+	removed -= len(widthKeys)
+	if removed > 0 {
+		changes = append(changes, &Change{Layer: ext, Kind: "removed", Description: fmt.Sprintf("%v primitive(s)", removed)})
+	}
+	if added > 0 {
+		changes = append(changes, &Change{Layer: ext, Kind: "added", Description: fmt.Sprintf("%v primitive(s)", added)})
+	}
+	return changes
+}
+
+// extension returns the layer's filename extension (e.g. "gtl").
+func (l *Layer) extension() string {
+	if i := strings.LastIndex(l.Filename, "."); i >= 0 {
+		return l.Filename[i+1:]
+	}
+	return l.Filename
+}
+
+// bounds returns the minimum bounding box of all primitives in the layer.
+func (l *Layer) bounds() (min, max Pt, ok bool) {
+	var ops []op
+	for _, p := range l.Primitives {
+		ops = append(ops, plot(p)...)
+	}
+	return bounds(ops)
+}
+
+// componentCenters returns the center of each component's bounding box
+// across all layers of the design.
+func componentCenters(g *Gerber) map[string]Pt {
+	ops := map[string][]op{}
+	for _, l := range g.Layers {
+		for _, p := range l.Primitives {
+			if o, ok := p.(*ObjectT); ok && o.component != "" {
+				ops[o.component] = append(ops[o.component], plot(o.p)...)
+			}
+		}
+	}
+	result := map[string]Pt{}
+	for refdes, v := range ops {
+		if min, max, ok := bounds(v); ok {
+			result[refdes] = Pt{X: 0.5 * (min.X + max.X), Y: 0.5 * (min.Y + max.Y)}
+		}
+	}
+	return result
+}
+
+// traceWidths returns the thickness of every line in the layer, keyed by its endpoints.
+func traceWidths(l *Layer) map[string]float64 {
+	result := map[string]float64{}
+	for _, p := range l.Primitives {
+		if line, ok := unwrap(p).(*LineT); ok {
+			key := fmt.Sprintf("(%.3f,%.3f)-(%.3f,%.3f)", line.x1.mm(), line.y1.mm(), line.x2.mm(), line.y2.mm())
+			result[key] = line.thickness
+		}
+	}
+	return result
+}
+
+// primitiveKeys counts the primitives in the layer by their rendered Gerber data.
+// Component primitives are left out since they are reported separately.
+func primitiveKeys(l *Layer) map[string]int {
+	result := map[string]int{}
+	for _, p := range l.Primitives {
+		if o, ok := p.(*ObjectT); ok && o.component != "" {
+			continue
+		}
+		var buf bytes.Buffer
+		unwrap(p).WriteGerber(&buf, 0)
+		result[p.Aperture().ID()+"\n"+buf.String()]++
+	}
+	return result
+}
+
+func sortedKeys(m map[string]Pt) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,38 @@
+package gerber
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	rev := func(x, width float64) *Gerber {
+		g := New("board")
+		top := g.TopCopper()
+		top.Add(
+			Object(Circle(x, 0, 1)).Component("R1"),
+			Line(0, 0, 10, 0, RectShape, width),
+		)
+		outline := g.Outline()
+		outline.Add(Line(0, 0, 10+x, 0, CircleShape, 0.1))
+		return g
+	}
+
+	if got := Diff(rev(1, 0.2), rev(1, 0.2)); len(got) != 0 {
+		t.Errorf("Diff of identical designs = %v, want none", ChangeReport(got))
+	}
+
+	got := ChangeReport(Diff(rev(1, 0.2), rev(2, 0.3)))
+	for _, want := range []string{
+		"moved: component R1 from (1.000,0.000) to (2.000,0.000)",
+		"gtl: width: trace (0.000,0.000)-(10.000,0.000) from 0.200mm to 0.300mm",
+		"gko: outline: size from 11.100x0.100mm to 12.100x0.100mm",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("ChangeReport missing %q:\n%v", want, got)
+		}
+	}
+	if strings.Contains(got, "gtl: added") || strings.Contains(got, "gtl: removed") {
+		t.Errorf("ChangeReport reported spurious primitive changes:\n%v", got)
+	}
+}
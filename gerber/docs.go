@@ -0,0 +1,254 @@
+package gerber
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// TitleBlockInfo is the board metadata shown in a title block.
+// Empty fields are left out.
+type TitleBlockInfo struct {
+	Title    string
+	Company  string
+	Revision string
+	Date     string
+	Author   string
+	Sheet    string
+}
+
+// TitleBlock returns a table primitive listing the board metadata,
+// for fabrication and assembly drawings.
+// x and y are the top left corner of the title block.
+// All dimensions are in millimeters.
+func TitleBlock(x, y float64, info *TitleBlockInfo, fontName string, pts, thickness float64) *TableT {
+	var rows [][]string
+	for _, field := range []struct{ name, value string }{
+		{"Title", info.Title},
+		{"Company", info.Company},
+		{"Revision", info.Revision},
+		{"Date", info.Date},
+		{"Author", info.Author},
+		{"Sheet", info.Sheet},
+	} {
+		if field.value != "" {
+			rows = append(rows, []string{field.name, field.value})
+		}
+	}
+	return Table(x, y, 1, rows, fontName, pts, 0.5*thickness+0.2, thickness)
+}
+
+// ScaleBarT represents a scale bar and satisfies the Primitive interface.
+type ScaleBarT struct {
+	prims     []Primitive
+	thickness float64
+}
+
+// ScaleBar returns a scale bar primitive of the given length divided into
+// alternating filled and hollow blocks, labeled with its length in mm.
+// x and y are the lower left corner of the bar.
+// All dimensions are in millimeters.
+func ScaleBar(x, y, length, height float64, divisions int, fontName string, pts, thickness float64) *ScaleBarT {
+	if divisions < 1 {
+		divisions = 1
+	}
+	s := &ScaleBarT{thickness: thickness}
+	step := length / float64(divisions)
+	for i := 0; i < divisions; i++ {
+		x0 := x + float64(i)*step
+		if i%2 == 0 {
+			s.prims = append(s.prims, Polygon(x0, y, true, []Pt{{X: 0, Y: 0}, {X: step, Y: 0}, {X: step, Y: height}, {X: 0, Y: height}, {X: 0, Y: 0}}, 0))
+		}
+	}
+	s.prims = append(s.prims,
+		Line(x, y, x+length, y, CircleShape, thickness),
+		Line(x+length, y, x+length, y+height, CircleShape, thickness),
+		Line(x+length, y+height, x, y+height, CircleShape, thickness),
+		Line(x, y+height, x, y, CircleShape, thickness),
+	)
+	labelY := y + height + thickness + 0.5
+	s.prims = append(s.prims,
+		Text(x, labelY, 1, "0", fontName, pts),
+		Text(x+length, labelY, 1, fmt.Sprintf("%g mm", length), fontName, pts),
+	)
+	return s
+}
+
+// WriteGerber writes the primitive to the Gerber file.
+func (s *ScaleBarT) WriteGerber(w io.Writer, apertureIndex int) error {
+	return writePrimitives(w, apertureIndex, s.prims)
+}
+
+// Aperture returns the primitive's desired aperture.
+func (s *ScaleBarT) Aperture() *Aperture {
+	return &Aperture{
+		Shape: CircleShape,
+		Size:  s.thickness,
+	}
+}
+
+// NorthArrowT represents an orientation marker and satisfies the Primitive interface.
+type NorthArrowT struct {
+	prims     []Primitive
+	thickness float64
+}
+
+// NorthArrow returns an orientation marker primitive: an arrow of the
+// given size centered at x,y and pointing in the direction of angle
+// (in degrees, 90 is up), with half of the arrowhead filled and the
+// letter "N" beyond its tip.
+// All dimensions are in millimeters.
+func NorthArrow(x, y, size, angle float64, fontName string, pts, thickness float64) *NorthArrowT {
+	sin, cos := math.Sincos(math.Pi * angle / 180.0)
+	at := func(along, across float64) Pt {
+		return Pt{X: x + along*cos - across*sin, Y: y + along*sin + across*cos}
+	}
+	tip, tail := at(0.5*size, 0), at(-0.5*size, 0)
+	left, right, notch := at(-0.5*size, 0.3*size), at(-0.5*size, -0.3*size), at(-0.25*size, 0)
+
+	n := &NorthArrowT{thickness: thickness}
+	n.prims = append(n.prims,
+		Polygon(0, 0, true, []Pt{tip, left, notch, tip}, 0),
+		Line(tip.X, tip.Y, left.X, left.Y, CircleShape, thickness),
+		Line(left.X, left.Y, notch.X, notch.Y, CircleShape, thickness),
+		Line(notch.X, notch.Y, right.X, right.Y, CircleShape, thickness),
+		Line(right.X, right.Y, tip.X, tip.Y, CircleShape, thickness),
+		Line(notch.X, notch.Y, tail.X, tail.Y, CircleShape, thickness),
+	)
+	// Center the label beyond the tip.
+	label := Text(0, 0, 1, "N", fontName, pts)
+	w, h := label.Measure()
+	c := at(0.5*size+thickness+0.5+0.5*math.Max(w, h), 0)
+	if min, _, ok := bounds(plot(label)); ok {
+		n.prims = append(n.prims, Text(c.X-0.5*w-min.X, c.Y-0.5*h-min.Y, 1, "N", fontName, pts))
+	}
+	return n
+}
+
+// WriteGerber writes the primitive to the Gerber file.
+func (n *NorthArrowT) WriteGerber(w io.Writer, apertureIndex int) error {
+	return writePrimitives(w, apertureIndex, n.prims)
+}
+
+// Aperture returns the primitive's desired aperture.
+func (n *NorthArrowT) Aperture() *Aperture {
+	return &Aperture{
+		Shape: CircleShape,
+		Size:  n.thickness,
+	}
+}
+
+// writePrimitives writes the parts of a compound primitive that share
+// its aperture.
+func writePrimitives(w io.Writer, apertureIndex int, prims []Primitive) error {
+	for _, p := range prims {
+		if err := p.WriteGerber(w, apertureIndex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GridT represents a labeled coordinate grid or ruler
+// and satisfies the Primitive interface.
+type GridT struct {
+	prims     []Primitive
+	thickness float64
+}
+
+// Grid returns a coordinate grid primitive with lines every pitch
+// millimeters covering the rectangle from min to max. Lines at multiples
+// of pitch are drawn and every labelEvery'th line is labeled with its
+// coordinate outside the bottom and left edges (no labels if labelEvery < 1).
+// All dimensions are in millimeters.
+func Grid(min, max Pt, pitch float64, labelEvery int, fontName string, pts, thickness float64) *GridT {
+	return newGrid(min, max, pitch, labelEvery, fontName, pts, thickness, false)
+}
+
+// Ruler returns a ruler primitive with ticks every pitch millimeters along
+// the bottom and left edges of the rectangle from min to max. Every
+// labelEvery'th tick is longer and labeled with its coordinate.
+// All dimensions are in millimeters.
+func Ruler(min, max Pt, pitch float64, labelEvery int, fontName string, pts, thickness float64) *GridT {
+	return newGrid(min, max, pitch, labelEvery, fontName, pts, thickness, true)
+}
+
+func newGrid(min, max Pt, pitch float64, labelEvery int, fontName string, pts, thickness float64, ticks bool) *GridT {
+	g := &GridT{thickness: thickness}
+	size := pts * mmPerPt
+	gap := thickness + 0.5*size
+
+	// label adds a text label aligned by fractions of its size relative to x,y.
+	label := func(s string, x, y, ax, ay float64) {
+		text := Text(0, 0, 1, s, fontName, pts)
+		min, max, ok := bounds(plot(text))
+		if !ok {
+			return
+		}
+		g.prims = append(g.prims, Text(x-min.X-ax*(max.X-min.X), y-min.Y-ay*(max.Y-min.Y), 1, s, fontName, pts))
+	}
+	labeled := func(k int) bool {
+		return labelEvery > 0 && k%labelEvery == 0
+	}
+	tick := func(k int) float64 {
+		if labeled(k) {
+			return size
+		}
+		return 0.5 * size
+	}
+
+	if ticks {
+		g.prims = append(g.prims,
+			Line(min.X, min.Y, max.X, min.Y, CircleShape, thickness),
+			Line(min.X, min.Y, min.X, max.Y, CircleShape, thickness),
+		)
+	}
+	for _, k := range gridSteps(min.X, max.X, pitch) {
+		x := float64(k) * pitch
+		y := max.Y
+		if ticks {
+			y = min.Y + tick(k)
+		}
+		g.prims = append(g.prims, Line(x, min.Y, x, y, CircleShape, thickness))
+		if labeled(k) {
+			label(fmt.Sprintf("%g", x), x, min.Y-gap, 0.5, 1)
+		}
+	}
+	for _, k := range gridSteps(min.Y, max.Y, pitch) {
+		y := float64(k) * pitch
+		x := max.X
+		if ticks {
+			x = min.X + tick(k)
+		}
+		g.prims = append(g.prims, Line(min.X, y, x, y, CircleShape, thickness))
+		if labeled(k) {
+			label(fmt.Sprintf("%g", y), min.X-gap, y, 1, 0.5)
+		}
+	}
+	return g
+}
+
+// gridSteps returns the indices k of the multiples k*pitch within [lo,hi].
+func gridSteps(lo, hi, pitch float64) []int {
+	if pitch <= 0 {
+		return nil
+	}
+	var result []int
+	for k := int(math.Ceil(lo/pitch - 1e-9)); float64(k)*pitch <= hi+1e-9; k++ {
+		result = append(result, k)
+	}
+	return result
+}
+
+// WriteGerber writes the primitive to the Gerber file.
+func (g *GridT) WriteGerber(w io.Writer, apertureIndex int) error {
+	return writePrimitives(w, apertureIndex, g.prims)
+}
+
+// Aperture returns the primitive's desired aperture.
+func (g *GridT) Aperture() *Aperture {
+	return &Aperture{
+		Shape: CircleShape,
+		Size:  g.thickness,
+	}
+}
@@ -0,0 +1,74 @@
+package gerber
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTitleBlock(t *testing.T) {
+	tb := TitleBlock(0, 0, &TitleBlockInfo{Title: "Coil", Revision: "B"}, "latoregular", 8, 0.1)
+	if got := len(tb.cells); got != 4 {
+		t.Errorf("TitleBlock has %v cells, want 4 (empty fields left out)", got)
+	}
+}
+
+func TestScaleBar(t *testing.T) {
+	min, max, ok := bounds(plot(ScaleBar(0, 0, 10, 1, 5, "latoregular", 8, 0.1)))
+	if !ok {
+		t.Fatal("ScaleBar must draw")
+	}
+	if min.X > -0.05+tol || min.Y > -0.05+tol || max.Y <= 1.05 {
+		t.Errorf("ScaleBar bounds = %v-%v", min, max)
+	}
+}
+
+func TestNorthArrow(t *testing.T) {
+	tests := []struct {
+		angle float64
+		want  Pt // direction of the label from the center
+	}{
+		{90, Pt{X: 0, Y: 1}},
+		{0, Pt{X: 1, Y: 0}},
+	}
+	for _, tt := range tests {
+		n := NorthArrow(0, 0, 10, tt.angle, "latoregular", 12, 0.2)
+		label := n.prims[len(n.prims)-1]
+		min, max, _ := bounds(plot(label))
+		c := Pt{X: 0.5 * (min.X + max.X), Y: 0.5 * (min.Y + max.Y)}
+		if d := c.X*tt.want.X + c.Y*tt.want.Y; d < 5 || math.Abs(c.X*tt.want.Y-c.Y*tt.want.X) > 1e-3 {
+			t.Errorf("NorthArrow(%v) label centered at %v, want beyond the tip along %v", tt.angle, c, tt.want)
+		}
+	}
+}
+
+func TestGrid(t *testing.T) {
+	tests := []struct {
+		name  string
+		g     *GridT
+		lines int
+		texts int
+	}{
+		// 11 vertical and 6 horizontal lines, labeled every 5mm.
+		{"grid", Grid(Pt{X: 0, Y: 0}, Pt{X: 10, Y: 5}, 1, 5, "latoregular", 6, 0.1), 17, 5},
+		// Lines at multiples of the pitch only.
+		{"offset", Grid(Pt{X: 0.5, Y: 0.5}, Pt{X: 2.5, Y: 1.5}, 1, 0, "latoregular", 6, 0.1), 3, 0},
+		// Two edges plus ticks.
+		{"ruler", Ruler(Pt{X: 0, Y: 0}, Pt{X: 10, Y: 5}, 1, 5, "latoregular", 6, 0.1), 19, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lines, texts int
+			for _, p := range tt.g.prims {
+				switch p.(type) {
+				case *LineT:
+					lines++
+				case *TextT:
+					texts++
+				}
+			}
+			if lines != tt.lines || texts != tt.texts {
+				t.Errorf("got %v lines and %v labels, want %v and %v", lines, texts, tt.lines, tt.texts)
+			}
+		})
+	}
+}
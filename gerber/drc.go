@@ -0,0 +1,621 @@
+package gerber
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severity is the severity of a design rule violation.
+type Severity string
+
+const (
+	// SeverityError fails the design.
+	SeverityError Severity = "error"
+	// SeverityWarning flags a likely problem.
+	SeverityWarning Severity = "warning"
+	// SeverityNote is informational.
+	SeverityNote Severity = "note"
+)
+
+// RuleCheck checks the primitives of a layer against a design rule with
+// the given parameters, calling report for every violating primitive
+// with the point where it violates the rule (in millimeters).
+type RuleCheck func(l *Layer, params map[string]float64, report func(index int, at Pt, msg string)) error
+
+// ruleKinds are the registered kinds of design rules, by name.
+var ruleKinds = map[string]RuleCheck{
+	"min-width":    checkMinWidth,
+	"drill-size":   checkDrillSize,
+	"clearance":    checkClearance,
+	"annular-ring": checkAnnularRing,
+}
+
+// RegisterRule registers a custom kind of design rule
+// so that rules of that kind can be configured and run.
+func RegisterRule(kind string, check RuleCheck) error {
+	if _, ok := ruleKinds[kind]; ok {
+		return fmt.Errorf("rule kind %q already registered", kind)
+	}
+	ruleKinds[kind] = check
+	return nil
+}
+
+// Rule is a configured design rule.
+type Rule struct {
+	// Name identifies the rule in reports and waivers (e.g. "signal-width").
+	Name string `json:"name"`
+	// Kind is the registered kind of the rule: "min-width" (params: width)
+	// checks draws, "drill-size" (params: min, max) checks holes,
+	// "clearance" (params: clearance) checks the gaps between copper
+	// primitives and "annular-ring" (params: ring) checks the copper
+	// around plated holes.
+	Kind string `json:"kind"`
+	// Layers are the filename extensions of the layers checked
+	// (e.g. "gtl"), or all layers if empty.
+	Layers []string `json:"layers,omitempty"`
+	// Severity is the severity of violations (SeverityError if unset).
+	Severity Severity `json:"severity,omitempty"`
+	// Params are the parameters of the rule, in millimeters.
+	Params map[string]float64 `json:"params,omitempty"`
+}
+
+// LoadRules reads a JSON array of rules (e.g. from a config file).
+func LoadRules(r io.Reader) ([]*Rule, error) {
+	var rules []*Rule
+	if err := json.NewDecoder(r).Decode(&rules); err != nil {
+		return nil, fmt.Errorf("rules: %v", err)
+	}
+	for i, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("rule #%v: %v", i, err)
+		}
+	}
+	return rules, nil
+}
+
+// validate checks that the rule is of a known kind and severity.
+func (r *Rule) validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if _, ok := ruleKinds[r.Kind]; !ok {
+		return fmt.Errorf("%v: unknown kind %q", r.Name, r.Kind)
+	}
+	switch r.Severity {
+	case "", SeverityError, SeverityWarning, SeverityNote:
+		return nil
+	}
+	return fmt.Errorf("%v: unknown severity %q", r.Name, r.Severity)
+}
+
+// applies reports whether the rule checks the layer.
+func (r *Rule) applies(l *Layer) bool {
+	if len(r.Layers) == 0 {
+		return true
+	}
+	for _, ext := range r.Layers {
+		if ext == l.extension() {
+			return true
+		}
+	}
+	return false
+}
+
+// Violation is a primitive violating a design rule.
+type Violation struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	// Layer is the filename of the layer of the primitive.
+	Layer string `json:"layer"`
+	// Index is the index of the primitive in the layer.
+	Index int `json:"index"`
+	// Path names the objects enclosing the primitive (see Error).
+	Path string `json:"path,omitempty"`
+	// Caller is the call site that added the primitive (see Gerber.Provenance).
+	Caller string `json:"caller,omitempty"`
+	// X and Y locate the violation (in millimeters): the middle of the
+	// gap between the closest points of the primitive and the other one
+	// for clearances and shorts, the center of the offending draw or hole
+	// for widths, drills and annular rings, and the offending point for
+	// audits (see Gerber.Audit).
+	X       float64 `json:"x"`
+	Y       float64 `json:"y"`
+	Message string  `json:"message"`
+}
+
+// DRCReport is the result of running design rules.
+type DRCReport struct {
+	Rules      []*Rule      `json:"rules"`
+	Violations []*Violation `json:"violations"`
+	// Waived is the number of violations suppressed by waivers.
+	Waived int `json:"waived"`
+}
+
+// DRC runs the design rules over every layer of the design as it is
+// written, including the pads derived from flagged objects and pad stacks.
+func (g *Gerber) DRC(rules []*Rule) (*DRCReport, error) {
+	layers := g.withDerived()
+	for i, l := range layers {
+		layers[i] = l.forVariant()
+	}
+	report := &DRCReport{Rules: rules, Violations: []*Violation{}}
+	for _, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return nil, err
+		}
+		severity := rule.Severity
+		if severity == "" {
+			severity = SeverityError
+		}
+		for _, l := range layers {
+			if !rule.applies(l) {
+				continue
+			}
+			if err := ruleKinds[rule.Kind](l, rule.Params, func(i int, at Pt, msg string) {
+				if waived(l.Primitives[i], rule.Name) {
+					report.Waived++
+					return
+				}
+				e := l.wrapErr(i, errors.New(msg)).(*Error)
+				report.Violations = append(report.Violations, &Violation{
+					Rule:     rule.Name,
+					Severity: severity,
+					Layer:    l.Filename,
+					Index:    i,
+					Path:     e.Path,
+					Caller:   e.Caller,
+					X:        at.X,
+					Y:        at.Y,
+					Message:  msg,
+				})
+			}); err != nil {
+				return nil, fmt.Errorf("rule %v: %v: %v", rule.Name, l.Filename, err)
+			}
+		}
+	}
+	return report, nil
+}
+
+// forVariant returns the layer without the primitives of other variants
+// than the design's (see forVariant), which are replaced by empty groups
+// to keep the indices of the others.
+func (l *Layer) forVariant() *Layer {
+	if l.variant() == "" {
+		return l
+	}
+	c := l.clone()
+	for i, p := range c.Primitives {
+		if c.Primitives[i] = forVariant(p, l.variant()); c.Primitives[i] == nil {
+			c.Primitives[i] = Group()
+		}
+	}
+	return c
+}
+
+// Count returns the number of violations of the given severity.
+func (r *DRCReport) Count(severity Severity) int {
+	var n int
+	for _, v := range r.Violations {
+		if v.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteJSON writes the report as JSON.
+func (r *DRCReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteSARIF writes the report in the SARIF 2.1.0 format used by code
+// scanning tools. Violations of primitives with a known call site are
+// located in the generating program; others in the layer file.
+func (r *DRCReport) WriteSARIF(w io.Writer) error {
+	type object = map[string]interface{}
+	var rules []object
+	for _, rule := range r.Rules {
+		rules = append(rules, object{
+			"id":               rule.Name,
+			"shortDescription": object{"text": fmt.Sprintf("%v %v", rule.Kind, formatParams(rule.Params))},
+		})
+	}
+	results := []object{}
+	for _, v := range r.Violations {
+		location := object{"artifactLocation": object{"uri": v.Layer}}
+		if i := strings.LastIndex(v.Caller, ":"); i > 0 {
+			if line, err := strconv.Atoi(v.Caller[i+1:]); err == nil {
+				location = object{
+					"artifactLocation": object{"uri": v.Caller[:i]},
+					"region":           object{"startLine": line},
+				}
+			}
+		}
+		results = append(results, object{
+			"ruleId":    v.Rule,
+			"level":     string(v.Severity),
+			"message":   object{"text": (&Error{Layer: v.Layer, Index: v.Index, Path: v.Path, Err: errors.New(v.Message)}).Error()},
+			"locations": []object{{"physicalLocation": location}},
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(object{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []object{{
+			"tool":    object{"driver": object{"name": "go-gerber", "rules": rules}},
+			"results": results,
+		}},
+	})
+}
+
+// formatParams returns the parameters as sorted key=value pairs.
+func formatParams(params map[string]float64) string {
+	var parts []string
+	for k, v := range params {
+		parts = append(parts, fmt.Sprintf("%v=%v", k, v))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, " ")
+}
+
+// checkMinWidth reports draws narrower than the "width" parameter.
+func checkMinWidth(l *Layer, params map[string]float64, report func(int, Pt, string)) error {
+	width, ok := params["width"]
+	if !ok {
+		return errors.New(`missing parameter "width"`)
+	}
+	for i, p := range l.Primitives {
+		for _, o := range plot(p) {
+			if o.code == drawOp && o.aperture != defaultAperture && o.aperture.Size < width {
+				a, b := o.pts[0], o.pts[len(o.pts)-1]
+				report(i, Pt{X: 0.5 * (a.X + b.X), Y: 0.5 * (a.Y + b.Y)}, fmt.Sprintf("width %vmm is below %vmm", o.aperture.Size, width))
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// checkDrillSize reports holes on drill layers outside the "min" and
+// "max" parameters (either optional).
+func checkDrillSize(l *Layer, params map[string]float64, report func(int, Pt, string)) error {
+	if !l.drill() {
+		return nil
+	}
+	min, hasMin := params["min"]
+	max, hasMax := params["max"]
+	for i, p := range l.Primitives {
+		lo, hi, ok := bounds(plot(p))
+		if !ok {
+			continue
+		}
+		center := Pt{X: 0.5 * (lo.X + hi.X), Y: 0.5 * (lo.Y + hi.Y)}
+		switch d := hi.X - lo.X; {
+		case hasMin && d < min-1e-9:
+			report(i, center, fmt.Sprintf("drill %.3fmm is below %vmm", d, min))
+		case hasMax && d > max+1e-9:
+			report(i, center, fmt.Sprintf("drill %.3fmm exceeds %vmm", d, max))
+		}
+	}
+	return nil
+}
+
+// checkClearance reports copper primitives closer than the "clearance"
+// parameter to another primitive of the layer, and primitives of
+// different nets touching each other. Primitives that touch are
+// otherwise assumed to be connected and primitives of the same net are
+// not checked against each other. Clear polarity is ignored.
+func checkClearance(l *Layer, params map[string]float64, report func(int, Pt, string)) error {
+	clearance, ok := params["clearance"]
+	if !ok {
+		return errors.New(`missing parameter "clearance"`)
+	}
+	if !l.copper() {
+		return nil
+	}
+	nets := make([]string, len(l.Primitives))
+	polygons := make([][][]Pt, len(l.Primitives))
+	var all []feature // capsules and the edges of polygons
+	var owners []int  // the index of the primitive of each feature
+	for i, p := range l.Primitives {
+		if o, ok := p.(*ObjectT); ok {
+			nets[i] = o.net
+		}
+		for _, f := range features(plot(p)) {
+			if f.polygon == nil {
+				all = append(all, f)
+				owners = append(owners, i)
+				continue
+			}
+			polygons[i] = append(polygons[i], f.polygon)
+			for j, pt := range f.polygon {
+				all = append(all, feature{a: pt, b: f.polygon[(j+1)%len(f.polygon)]})
+				owners = append(owners, i)
+			}
+		}
+	}
+	// inside reports whether primitive b lies (at least partly) within a
+	// polygon of primitive a, which their edges alone cannot tell.
+	inside := func(a, b int) bool {
+		for _, polygon := range polygons[a] {
+			for _, f := range features(plot(l.Primitives[b])) {
+				pt := f.a
+				if f.polygon != nil {
+					pt = f.polygon[0]
+				}
+				if insidePolygon(polygon, pt) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	// Only features sharing a cell of the grid (after growing them by
+	// the clearance) are compared.
+	type cell struct{ x, y int }
+	bounds := make([][2]Pt, len(all))
+	cells := func(i int) []cell {
+		min, max := bounds[i][0], bounds[i][1]
+		var result []cell
+		for x := int(math.Floor(min.X - clearance)); x <= int(math.Floor(max.X+clearance)); x++ {
+			for y := int(math.Floor(min.Y - clearance)); y <= int(math.Floor(max.Y+clearance)); y++ {
+				result = append(result, cell{x, y})
+			}
+		}
+		return result
+	}
+	grid := map[cell][]int{}
+	for i, f := range all {
+		bounds[i][0], bounds[i][1] = f.bounds()
+		for _, c := range cells(i) {
+			grid[c] = append(grid[c], i)
+		}
+	}
+	// gaps are the smallest distances between pairs of primitives, and
+	// at the middle of each gap.
+	gaps := map[[2]int]float64{}
+	at := map[[2]int]Pt{}
+	seen := make([]int, len(all))
+	for i := range all {
+		a := owners[i]
+		for _, c := range cells(i) {
+			for _, j := range grid[c] {
+				b := owners[j]
+				if b <= a || seen[j] == i+1 || nets[a] != "" && nets[a] == nets[b] {
+					continue
+				}
+				seen[j] = i + 1
+				if bounds[j][0].X > bounds[i][1].X+clearance || bounds[i][0].X > bounds[j][1].X+clearance ||
+					bounds[j][0].Y > bounds[i][1].Y+clearance || bounds[i][0].Y > bounds[j][1].Y+clearance {
+					continue
+				}
+				d := all[i].distance(all[j])
+				if gap, ok := gaps[[2]int{a, b}]; !ok || d < gap {
+					gaps[[2]int{a, b}] = d
+					p, q := all[i].closest(all[j])
+					at[[2]int{a, b}] = Pt{X: 0.5 * (p.X + q.X), Y: 0.5 * (p.Y + q.Y)}
+				}
+			}
+		}
+	}
+
+	pairs := make([][2]int, 0, len(gaps))
+	for pair := range gaps {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][1] != pairs[j][1] {
+			return pairs[i][1] < pairs[j][1]
+		}
+		return pairs[i][0] < pairs[j][0]
+	})
+	reported := map[int]bool{}
+	for _, pair := range pairs {
+		first, second := pair[0], pair[1]
+		if reported[second] {
+			continue
+		}
+		d := gaps[pair]
+		if d > 0 && (inside(first, second) || inside(second, first)) {
+			d = 0
+		}
+		switch {
+		case d <= 0 && nets[first] != "" && nets[second] != "":
+			report(second, at[pair], fmt.Sprintf("net %v shorts to net %v of primitive #%v", nets[second], nets[first], first))
+		case d > 0 && d < clearance-1e-9:
+			report(second, at[pair], fmt.Sprintf("clearance %.3fmm to primitive #%v is below %vmm", d, first, clearance))
+		default:
+			continue
+		}
+		reported[second] = true
+	}
+	return nil
+}
+
+// checkAnnularRing reports plated holes whose copper ring on an outer
+// copper layer of the design is narrower than the "ring" parameter.
+// The ring is the distance from the edge of the hole to the edge of the
+// widest copper primitive around its center.
+func checkAnnularRing(l *Layer, params map[string]float64, report func(int, Pt, string)) error {
+	ring, ok := params["ring"]
+	if !ok {
+		return errors.New(`missing parameter "ring"`)
+	}
+	if l.extension() != "xln" || l.g == nil {
+		return nil
+	}
+	var outer []*Layer
+	for _, c := range l.g.withDerived() {
+		if ext := c.extension(); ext == "gtl" || ext == "gbl" {
+			outer = append(outer, c)
+		}
+	}
+	copper := make([][]feature, len(outer))
+	for i, c := range outer {
+		for _, p := range c.Primitives {
+			if p = forVariant(p, l.g.Variant); p != nil {
+				copper[i] = append(copper[i], features(plot(p))...)
+			}
+		}
+	}
+	for i, p := range l.Primitives {
+		min, max, ok := bounds(plot(p))
+		if !ok {
+			continue
+		}
+		center, radius := Pt{X: 0.5 * (min.X + max.X), Y: 0.5 * (min.Y + max.Y)}, 0.5*(max.X-min.X)
+		for j, c := range outer {
+			width := math.Inf(-1)
+			for _, f := range copper[j] {
+				width = math.Max(width, f.depth(center))
+			}
+			switch w := width - radius; {
+			case w < 0:
+				report(i, center, fmt.Sprintf("hole has no pad on %v", c.Filename))
+			case w < ring-1e-9:
+				report(i, center, fmt.Sprintf("annular ring %.3fmm on %v is below %vmm", w, c.Filename, ring))
+			default:
+				continue
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// feature is the area covered by an operation for design rule checks:
+// a polygon or, for round apertures, the capsule of radius r around the
+// segment from a to b.
+type feature struct {
+	polygon []Pt
+	a, b    Pt
+	r       float64
+}
+
+// features returns the features of the dark operations.
+func features(ops []op) []feature {
+	var result []feature
+	for _, o := range ops {
+		switch {
+		case o.clear || len(o.pts) == 0:
+		case o.code != regionOp && o.aperture != nil && o.aperture.Shape == CircleShape:
+			result = append(result, feature{a: o.pts[0], b: o.pts[len(o.pts)-1], r: 0.5 * o.aperture.Size})
+		default:
+			for _, c := range contours(o) {
+				result = append(result, feature{polygon: c})
+			}
+		}
+	}
+	return result
+}
+
+// bounds returns the bounding box of the capsule.
+func (f feature) bounds() (min, max Pt) {
+	return Pt{X: math.Min(f.a.X, f.b.X) - f.r, Y: math.Min(f.a.Y, f.b.Y) - f.r},
+		Pt{X: math.Max(f.a.X, f.b.X) + f.r, Y: math.Max(f.a.Y, f.b.Y) + f.r}
+}
+
+// distance returns the gap between the capsules, or zero if they touch.
+func (f feature) distance(g feature) float64 {
+	return math.Max(0, segmentDistance(f.a, f.b, g.a, g.b)-f.r-g.r)
+}
+
+// closest returns the closest points of the edges of the capsules
+// (which pass each other where the capsules overlap).
+func (f feature) closest(g feature) (Pt, Pt) {
+	p, q := closestPoints(f.a, f.b, g.a, g.b)
+	dx, dy := q.X-p.X, q.Y-p.Y
+	if d := math.Hypot(dx, dy); d > 0 {
+		dx, dy = dx/d, dy/d
+	}
+	return Pt{X: p.X + f.r*dx, Y: p.Y + f.r*dy}, Pt{X: q.X - g.r*dx, Y: q.Y - g.r*dy}
+}
+
+// depth returns the distance from pt (inside the feature) to the edge of
+// the feature, or -Inf if pt is outside of it.
+func (f feature) depth(pt Pt) float64 {
+	if f.polygon == nil {
+		if d := f.r - pointSegmentDistance(pt, f.a, f.b); d >= 0 {
+			return d
+		}
+		return math.Inf(-1)
+	}
+	if !insidePolygon(f.polygon, pt) {
+		return math.Inf(-1)
+	}
+	d := math.Inf(1)
+	for i := range f.polygon {
+		d = math.Min(d, pointSegmentDistance(pt, f.polygon[i], f.polygon[(i+1)%len(f.polygon)]))
+	}
+	return d
+}
+
+// segmentDistance returns the distance between the segments ab and cd.
+func segmentDistance(a, b, c, d Pt) float64 {
+	cross := func(o, p, q Pt) float64 { return (p.X-o.X)*(q.Y-o.Y) - (p.Y-o.Y)*(q.X-o.X) }
+	d1, d2 := cross(a, b, c), cross(a, b, d)
+	d3, d4 := cross(c, d, a), cross(c, d, b)
+	if (d1 > 0) != (d2 > 0) && d1 != 0 && d2 != 0 && (d3 > 0) != (d4 > 0) && d3 != 0 && d4 != 0 {
+		return 0 // The segments cross.
+	}
+	return math.Min(
+		math.Min(pointSegmentDistance(a, c, d), pointSegmentDistance(b, c, d)),
+		math.Min(pointSegmentDistance(c, a, b), pointSegmentDistance(d, a, b)),
+	)
+}
+
+// closestPoints returns the closest points of the segments ab and cd.
+func closestPoints(a, b, c, d Pt) (Pt, Pt) {
+	if segmentDistance(a, b, c, d) == 0 {
+		// The segments cross (or touch) where the lines do.
+		rx, ry, sx, sy := b.X-a.X, b.Y-a.Y, d.X-c.X, d.Y-c.Y
+		if den := rx*sy - ry*sx; den != 0 {
+			t := ((c.X-a.X)*sy - (c.Y-a.Y)*sx) / den
+			if t >= 0 && t <= 1 {
+				pt := Pt{X: a.X + t*rx, Y: a.Y + t*ry}
+				return pt, pt
+			}
+		}
+	}
+	candidates := [][2]Pt{
+		{a, closestPoint(a, c, d)},
+		{b, closestPoint(b, c, d)},
+		{closestPoint(c, a, b), c},
+		{closestPoint(d, a, b), d},
+	}
+	best := math.Inf(1)
+	for _, pq := range candidates {
+		best = math.Min(best, math.Hypot(pq[1].X-pq[0].X, pq[1].Y-pq[0].Y))
+	}
+	// Parallel segments are closest along a stretch: take its middle.
+	var p, q Pt
+	var n float64
+	for _, pq := range candidates {
+		if math.Hypot(pq[1].X-pq[0].X, pq[1].Y-pq[0].Y) <= best+1e-9 {
+			p, q = Pt{X: p.X + pq[0].X, Y: p.Y + pq[0].Y}, Pt{X: q.X + pq[1].X, Y: q.Y + pq[1].Y}
+			n++
+		}
+	}
+	return Pt{X: p.X / n, Y: p.Y / n}, Pt{X: q.X / n, Y: q.Y / n}
+}
+
+// pointSegmentDistance returns the distance from pt to the segment ab.
+func pointSegmentDistance(pt, a, b Pt) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = math.Max(0, math.Min(1, ((pt.X-a.X)*dx+(pt.Y-a.Y)*dy)/l))
+	}
+	return math.Hypot(pt.X-a.X-t*dx, pt.Y-a.Y-t*dy)
+}
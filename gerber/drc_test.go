@@ -0,0 +1,157 @@
+package gerber
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestGerber_DRC(t *testing.T) {
+	g := New("board")
+	g.Provenance = true
+	top, drill := g.TopCopper(), g.Drill()
+	top.Add(
+		Line(0, 0, 5, 0, CircleShape, 0.1),
+		Object(Line(0, 1, 5, 1, CircleShape, 0.1)).Name("neckdown").Waive("signal-width"),
+		Line(0, 2, 5, 2, CircleShape, 0.2),
+	)
+	drill.Add(Circle(1, 1, 0.15), Circle(2, 2, 0.3))
+
+	rules, err := LoadRules(strings.NewReader(`[
+		{"name": "signal-width", "kind": "min-width", "layers": ["gtl"], "params": {"width": 0.15}},
+		{"name": "drill", "kind": "drill-size", "severity": "warning", "params": {"min": 0.2, "max": 6.35}}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := g.DRC(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Violations) != 2 || report.Waived != 1 {
+		t.Fatalf("got %+v, want 2 violations and 1 waived", report)
+	}
+	if v := report.Violations[0]; v.Rule != "signal-width" || v.Index != 0 || v.Severity != SeverityError || !strings.Contains(v.Caller, "drc_test.go:") {
+		t.Errorf("violation #0 = %+v", v)
+	}
+	if report.Count(SeverityError) != 1 || report.Count(SeverityWarning) != 1 {
+		t.Errorf("got %v errors and %v warnings, want 1 and 1", report.Count(SeverityError), report.Count(SeverityWarning))
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteSARIF(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var sarif struct {
+		Version string
+		Runs    []struct {
+			Results []struct {
+				RuleID    string
+				Level     string
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct{ URI string }
+						Region           struct{ StartLine int }
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &sarif); err != nil {
+		t.Fatal(err)
+	}
+	if sarif.Version != "2.1.0" || len(sarif.Runs) != 1 || len(sarif.Runs[0].Results) != 2 {
+		t.Fatalf("SARIF = %v", buf.String())
+	}
+	if r := sarif.Runs[0].Results[1]; r.Level != "warning" || !strings.HasSuffix(r.Locations[0].PhysicalLocation.ArtifactLocation.URI, "drc_test.go") || r.Locations[0].PhysicalLocation.Region.StartLine == 0 {
+		t.Errorf("SARIF result #1 = %+v", r)
+	}
+
+	buf.Reset()
+	if err := report.WriteJSON(&buf); err != nil || !strings.Contains(buf.String(), `"waived": 1`) {
+		t.Errorf("WriteJSON = %v, %v", buf.String(), err)
+	}
+}
+
+func TestLoadRules_Errors(t *testing.T) {
+	for _, config := range []string{
+		`[{"kind": "min-width"}]`,
+		`[{"name": "x", "kind": "no-such-kind"}]`,
+		`[{"name": "x", "kind": "min-width", "severity": "fatal"}]`,
+		`{`,
+	} {
+		if _, err := LoadRules(strings.NewReader(config)); err == nil {
+			t.Errorf("LoadRules(%v): expected an error", config)
+		}
+	}
+}
+
+func TestGerber_DRC_Clearance(t *testing.T) {
+	g := New("board")
+	top := g.TopCopper()
+	top.Add(
+		Line(0, 0, 10, 0, CircleShape, 0.2),                                     // #0
+		Line(0, 0.35, 10, 0.35, CircleShape, 0.2),                               // #1: 0.15mm from #0
+		Line(10, 0, 10, 5, CircleShape, 0.2),                                    // #2: connected to #0
+		Object(Flash(20, 0, RectShape, 1)).Net("A"),                             // #3
+		Object(Flash(20.9, 0, CircleShape, 1)).Net("B"),                         // #4: shorts to #3
+		Object(Flash(20, 1.2, CircleShape, 1)).Net("A"),                         // #5: same net as #3
+		Polygon(30, 0, true, []Pt{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 2}}, 0), // #6
+		Flash(32.6, 0, CircleShape, 1),                                          // #7: 0.1mm from #6
+	)
+	rules := []*Rule{{Name: "clearance", Kind: "clearance", Params: map[string]float64{"clearance": 0.2}}}
+	report, err := g.DRC(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]string{
+		1: "clearance 0.150mm to primitive #0 is below 0.2mm",
+		4: "net B shorts to net A of primitive #3",
+		7: "clearance 0.100mm to primitive #6 is below 0.2mm",
+	}
+	if len(report.Violations) != len(want) {
+		t.Errorf("got %v violations, want %v", len(report.Violations), len(want))
+	}
+	for _, v := range report.Violations {
+		if want[v.Index] != v.Message {
+			t.Errorf("violation of #%v: %q, want %q", v.Index, v.Message, want[v.Index])
+		}
+	}
+	// Violations are located in the middle of the gaps.
+	for _, v := range report.Violations {
+		want := map[int]Pt{1: {X: 5, Y: 0.175}, 4: {X: 20.45, Y: 0}, 7: {X: 32.05, Y: 0}}[v.Index]
+		if math.Abs(v.X-want.X) > 1e-6 || math.Abs(v.Y-want.Y) > 1e-6 {
+			t.Errorf("violation of #%v at %v,%v, want %v", v.Index, v.X, v.Y, want)
+		}
+	}
+}
+
+func TestGerber_DRC_AnnularRing(t *testing.T) {
+	g := New("board")
+	top, bottom, drill := g.TopCopper(), g.BottomCopper(), g.Drill()
+	top.Add(Flash(0, 0, CircleShape, 1.6), Flash(5, 0, CircleShape, 1.2), Flash(10, 0, RectShape, 1.6))
+	bottom.Add(Flash(0, 0, CircleShape, 1.6), Flash(5, 0, CircleShape, 1.6))
+	drill.Add(Circle(0, 0, 1), Circle(5, 0, 1), Circle(10, 0, 1))
+	stack := &PadStack{Top: &PadShape{Shape: CircleShape, Width: 1.4}, Bottom: &PadShape{Shape: CircleShape, Width: 1.4}, Drill: 1}
+	g.AddPad(stack.At(15, 0))
+
+	rules := []*Rule{{Name: "ring", Kind: "annular-ring", Params: map[string]float64{"ring": 0.15}}}
+	report, err := g.DRC(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]string{
+		1: "annular ring 0.100mm on board.gtl is below 0.15mm",
+		2: "hole has no pad on board.gbl",
+	}
+	if len(report.Violations) != len(want) {
+		t.Errorf("got %v violations, want %v", len(report.Violations), len(want))
+	}
+	for _, v := range report.Violations {
+		if want[v.Index] != v.Message {
+			t.Errorf("violation of #%v: %q, want %q", v.Index, v.Message, want[v.Index])
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package gerber
+
+import (
+	"fmt"
+	"math"
+)
+
+// Duplicate identifies a primitive that coincides with an earlier one.
+type Duplicate struct {
+	// Index is the index of the duplicate primitive in the layer.
+	Index int
+	// Of is the index of the earlier primitive it duplicates.
+	Of int
+}
+
+// Duplicates returns the primitives of the layer that exactly or nearly
+// (within tolerance mm) coincide with an earlier primitive: same
+// aperture, polarity and geometry, with lines matching in either
+// direction. Primitives of different components are never duplicates.
+func (l *Layer) Duplicates(tolerance float64) []Duplicate {
+	type entry struct {
+		index     int
+		component string
+		aperture  string
+		ops       []op
+	}
+	cell := 2*tolerance + 1e-6
+	grid := map[[2]int64][]*entry{}
+	cellOf := func(ops []op) [2]int64 {
+		min, max, _ := bounds(ops)
+		return [2]int64{int64(math.Floor(0.5 * (min.X + max.X) / cell)), int64(math.Floor(0.5 * (min.Y + max.Y) / cell))}
+	}
+
+	var result []Duplicate
+	for i, p := range l.Primitives {
+		e := &entry{index: i, aperture: p.Aperture().ID(), ops: plot(unwrap(p))}
+		if o, ok := p.(*ObjectT); ok {
+			e.component = o.component
+		}
+		if len(e.ops) == 0 {
+			continue
+		}
+		c := cellOf(e.ops)
+	search:
+		for dx := int64(-1); dx <= 1; dx++ {
+			for dy := int64(-1); dy <= 1; dy++ {
+				for _, other := range grid[[2]int64{c[0] + dx, c[1] + dy}] {
+					if other.component == e.component && other.aperture == e.aperture && sameOps(other.ops, e.ops, tolerance) {
+						result = append(result, Duplicate{Index: i, Of: other.index})
+						break search
+					}
+				}
+			}
+		}
+		grid[c] = append(grid[c], e)
+	}
+	return result
+}
+
+// MergeDuplicates removes the primitives that duplicate earlier ones
+// (see Duplicates) and returns a description of every removal.
+func (l *Layer) MergeDuplicates(tolerance float64) []string {
+	var changes []string
+	remove := map[int]bool{}
+	for _, d := range l.Duplicates(tolerance) {
+		changes = append(changes, l.wrapErr(d.Index, fmt.Errorf("removed duplicate of primitive #%v", d.Of)).Error())
+		remove[d.Index] = true
+	}
+	l.remove(remove)
+	return changes
+}
+
+// MergeDuplicates removes duplicate primitives from all layers of the
+// design and returns a description of every removal.
+func (g *Gerber) MergeDuplicates(tolerance float64) []string {
+	var changes []string
+	for _, l := range g.Layers {
+		changes = append(changes, l.MergeDuplicates(tolerance)...)
+	}
+	return changes
+}
+
+// remove removes the primitives at the given indices from the layer.
+func (l *Layer) remove(indices map[int]bool) {
+	if len(indices) == 0 {
+		return
+	}
+	var prims []Primitive
+	var callers []string
+	for i, p := range l.Primitives {
+		if indices[i] {
+			continue
+		}
+		prims = append(prims, p)
+		if i < len(l.callers) {
+			callers = append(callers, l.callers[i])
+		}
+	}
+	l.Primitives = prims
+	if l.callers != nil {
+		l.callers = callers
+	}
+}
+
+// sameOps reports whether two decoded primitives coincide within tolerance.
+func sameOps(a, b []op, tolerance float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	near := func(p, q Pt) bool {
+		return math.Abs(p.X-q.X) <= tolerance && math.Abs(p.Y-q.Y) <= tolerance
+	}
+	for i := range a {
+		if a[i].code != b[i].code || a[i].clear != b[i].clear || len(a[i].pts) != len(b[i].pts) {
+			return false
+		}
+		forward, backward := true, a[i].code == drawOp
+		n := len(a[i].pts)
+		for j := range a[i].pts {
+			forward = forward && near(a[i].pts[j], b[i].pts[j])
+			backward = backward && near(a[i].pts[j], b[i].pts[n-1-j])
+		}
+		if !forward && !backward {
+			return false
+		}
+	}
+	return true
+}
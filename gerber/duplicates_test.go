@@ -0,0 +1,32 @@
+package gerber
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDuplicates(t *testing.T) {
+	l := New("board").TopCopper()
+	l.Add(
+		Line(0, 0, 1, 1, CircleShape, 0.2),      // #0
+		Line(0, 0, 1, 1, CircleShape, 0.2),      // #1: exact duplicate of #0
+		Line(1, 1, 0.0004, 0, CircleShape, 0.2), // #2: reversed near duplicate of #0
+		Line(0, 0, 1, 1, CircleShape, 0.3),      // #3: different aperture
+		Circle(5, 5, 1),                         // #4
+		Object(Circle(5, 5, 1)).Component("R1"), // #5: different component
+		Circle(5.01, 5, 1),                      // #6: beyond tolerance
+	)
+
+	want := []Duplicate{{Index: 1, Of: 0}, {Index: 2, Of: 0}}
+	if got := l.Duplicates(0.001); !reflect.DeepEqual(got, want) {
+		t.Errorf("Duplicates = %v, want %v", got, want)
+	}
+
+	changes := l.MergeDuplicates(0.001)
+	if len(changes) != 2 || len(l.Primitives) != 5 {
+		t.Errorf("MergeDuplicates = %v leaving %v primitives, want 2 changes leaving 5", changes, len(l.Primitives))
+	}
+	if got := l.Duplicates(0.001); len(got) != 0 {
+		t.Errorf("Duplicates after merge = %v, want none", got)
+	}
+}
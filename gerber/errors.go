@@ -0,0 +1,78 @@
+package gerber
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Error is a render or validation error annotated with the provenance
+// of the offending primitive so that it can be found in large generated
+// designs.
+type Error struct {
+	// Layer is the filename of the layer containing the primitive.
+	Layer string
+	// Index is the index of the primitive in the layer.
+	Index int
+	// Path is the slash-separated path of the names of the objects
+	// enclosing the primitive (e.g. "U1/pad3"), if any.
+	Path string
+	// Caller is the file:line of the Layer.Add call that added the
+	// primitive, if Gerber.Provenance was enabled at the time.
+	Caller string
+	// Err is the underlying error.
+	Err error
+}
+
+// Error returns the error message with its provenance.
+func (e *Error) Error() string {
+	parts := []string{e.Layer, fmt.Sprintf("primitive #%v", e.Index)}
+	if e.Path != "" {
+		parts = append(parts, e.Path)
+	}
+	if e.Caller != "" {
+		parts = append(parts, "added at "+e.Caller)
+	}
+	return fmt.Sprintf("%v: %v", strings.Join(parts, ": "), e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// wrapErr annotates err with the provenance of the layer's i-th primitive.
+func (l *Layer) wrapErr(i int, err error) error {
+	e := &Error{Layer: l.Filename, Index: i, Err: err}
+	if i < len(l.Primitives) {
+		e.Path = objectPath(l.Primitives[i])
+	}
+	if i < len(l.callers) {
+		e.Caller = l.callers[i]
+	}
+	return e
+}
+
+// objectPath returns the slash-separated names of the objects wrapping p.
+func objectPath(p Primitive) string {
+	var names []string
+	for {
+		o, ok := p.(*ObjectT)
+		if !ok {
+			return strings.Join(names, "/")
+		}
+		if o.name != "" {
+			names = append(names, o.name)
+		}
+		p = o.p
+	}
+}
+
+// caller returns the file:line of the caller skip frames above its caller.
+func caller(skip int) string {
+	_, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%v:%v", file, line)
+}
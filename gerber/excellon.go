@@ -0,0 +1,130 @@
+package gerber
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// excellonHole is a drilled hole (or routed slot from pts[0] to pts[1])
+// collected from a drill layer.
+type excellonHole struct {
+	diameter nm
+	pts      []Pt
+}
+
+// drill reports whether the layer is a plated or non-plated drill layer.
+func (l *Layer) drill() bool {
+	ext := l.extension()
+	return ext == "xln" || ext == "nxln"
+}
+
+// ExcellonFilename returns the filename of the Excellon drill file of a
+// drill layer: "<prefix>.drl" for plated holes and "<prefix>-NPTH.drl"
+// for non-plated holes.
+func (l *Layer) ExcellonFilename() string {
+	ext := l.extension()
+	base := strings.TrimSuffix(l.Filename, "."+ext)
+	if ext == "nxln" {
+		return base + "-NPTH.drl"
+	}
+	return base + ".drl"
+}
+
+// WriteExcellon writes the holes of a drill layer as an Excellon drill
+// file. Each flashed aperture or zero-length line is a hole of the
+// aperture's diameter and each longer line is a routed slot (G85).
+// Identical diameters share a single tool in the tool table.
+func (l *Layer) WriteExcellon(w io.Writer) error {
+	if !l.drill() {
+		return fmt.Errorf("%v: not a drill layer", l.Filename)
+	}
+	units := Millimeters
+	if l.g != nil && l.g.Units != "" {
+		units = l.g.Units
+	}
+	if l.Units != "" {
+		units = l.Units
+	}
+	if err := units.validate(); err != nil {
+		return err
+	}
+
+	var holes []*excellonHole
+	for i, p := range l.Primitives {
+		if p = forVariant(p, l.variant()); p == nil {
+			continue
+		}
+		for _, o := range plot(p) {
+			if o.code == regionOp || o.aperture == nil {
+				return l.wrapErr(i, fmt.Errorf("regions cannot be drilled"))
+			}
+			if o.clear {
+				continue
+			}
+			h := &excellonHole{diameter: toNM(o.aperture.Size), pts: o.pts}
+			if o.code == drawOp && o.pts[0] == o.pts[1] {
+				h.pts = o.pts[:1]
+			}
+			holes = append(holes, h)
+		}
+	}
+
+	// The tool table has one tool per distinct diameter, smallest first.
+	var diameters []nm
+	seen := map[nm]bool{}
+	for _, h := range holes {
+		if !seen[h.diameter] {
+			seen[h.diameter] = true
+			diameters = append(diameters, h.diameter)
+		}
+	}
+	sort.Slice(diameters, func(i, j int) bool { return diameters[i] < diameters[j] })
+
+	scale := 1.0
+	if units == Inches {
+		scale = nmPerMM / nmPerInch
+	}
+	num := func(mm float64) string {
+		return strconv.FormatFloat(math.Round(mm*scale*1e6)/1e6, 'f', -1, 64)
+	}
+	xy := func(pt Pt) string {
+		return "X" + num(pt.X) + "Y" + num(pt.Y)
+	}
+
+	io.WriteString(w, "M48\n")
+	if f := l.fileFunction(); f != "" {
+		fmt.Fprintf(w, "; #@! TF.FileFunction,%v\n", f)
+	}
+	if gen := l.generator(); gen != nil {
+		fmt.Fprintf(w, "; #@! TF.GenerationSoftware,%v\n", gen)
+	}
+	io.WriteString(w, "FMAT,2\n")
+	if units == Inches {
+		io.WriteString(w, "INCH\n")
+	} else {
+		io.WriteString(w, "METRIC\n")
+	}
+	for i, d := range diameters {
+		fmt.Fprintf(w, "T%vC%v\n", i+1, num(d.mm()))
+	}
+	io.WriteString(w, "%\nG90\nG05\n")
+	for i, d := range diameters {
+		fmt.Fprintf(w, "T%v\n", i+1)
+		for _, h := range holes {
+			if h.diameter != d {
+				continue
+			}
+			if len(h.pts) == 1 {
+				fmt.Fprintf(w, "%v\n", xy(h.pts[0]))
+				continue
+			}
+			fmt.Fprintf(w, "%vG85%v\n", xy(h.pts[0]), xy(h.pts[1]))
+		}
+	}
+	io.WriteString(w, "T0\nM30\n")
+	return nil
+}
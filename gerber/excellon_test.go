@@ -0,0 +1,116 @@
+package gerber
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLayer_WriteExcellon(t *testing.T) {
+	g := New("board")
+	g.Generator = &Generator{Vendor: "acme", Application: "boards", Version: "1.0"}
+	g.TopCopper()
+	g.BottomCopper()
+	drill, npth := g.Drill(), g.NonPlatedDrill()
+	drill.Add(
+		Circle(1, 2, 0.3),
+		Flash(3, 4, CircleShape, 0.8),
+		Circle(5, 6, 0.3), // shares tool T1
+		Line(0, 0, 2, 0, CircleShape, 1),
+	)
+	npth.Add(Circle(-1.5, 2.25, 3.2))
+
+	tests := []struct {
+		l        *Layer
+		filename string
+		want     []string
+	}{
+		{
+			l:        drill,
+			filename: "board.drl",
+			want: []string{
+				"M48",
+				"; #@! TF.FileFunction,Plated,1,2,PTH",
+				"; #@! TF.GenerationSoftware,acme,boards,1.0",
+				"FMAT,2",
+				"METRIC",
+				"T1C0.3",
+				"T2C0.8",
+				"T3C1",
+				"%",
+				"G90",
+				"G05",
+				"T1",
+				"X1Y2",
+				"X5Y6",
+				"T2",
+				"X3Y4",
+				"T3",
+				"X0Y0G85X2Y0",
+				"T0",
+				"M30",
+			},
+		},
+		{
+			l:        npth,
+			filename: "board-NPTH.drl",
+			want: []string{
+				"M48",
+				"; #@! TF.FileFunction,NonPlated,1,2,NPTH",
+				"; #@! TF.GenerationSoftware,acme,boards,1.0",
+				"FMAT,2",
+				"METRIC",
+				"T1C3.2",
+				"%",
+				"G90",
+				"G05",
+				"T1",
+				"X-1.5Y2.25",
+				"T0",
+				"M30",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			if got := tt.l.ExcellonFilename(); got != tt.filename {
+				t.Errorf("ExcellonFilename = %q, want %q", got, tt.filename)
+			}
+			var buf bytes.Buffer
+			if err := tt.l.WriteExcellon(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := buf.String(), strings.Join(tt.want, "\n")+"\n"; got != want {
+				t.Errorf("WriteExcellon =\n%v\nwant\n%v", got, want)
+			}
+		})
+	}
+}
+
+func TestLayer_WriteExcellon_Errors(t *testing.T) {
+	g := New("board")
+	if err := g.TopCopper().WriteExcellon(&bytes.Buffer{}); err == nil {
+		t.Error("WriteExcellon of a copper layer: want error")
+	}
+	drill := g.Drill()
+	drill.Add(Polygon(0, 0, true, []Pt{{0, 0}, {1, 0}, {0, 1}}, 0))
+	if err := drill.WriteExcellon(&bytes.Buffer{}); err == nil {
+		t.Error("WriteExcellon of a region: want error")
+	}
+}
+
+func TestLayer_WriteExcellon_Inches(t *testing.T) {
+	g := New("board")
+	g.Units = Inches
+	drill := g.Drill()
+	drill.Add(Circle(25.4, 12.7, 0.254))
+	var buf bytes.Buffer
+	if err := drill.WriteExcellon(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"\nINCH\n", "\nT1C0.01\n", "\nX1Y0.5\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("WriteExcellon missing %q:\n%v", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package gerber
+
+import "sort"
+
+// FillRule is the SVG rule deciding which areas enclosed by the contours
+// of a shape are filled.
+type FillRule string
+
+const (
+	// NonZero fills areas around which the contours wind a nonzero
+	// number of times (the SVG default, used by most fonts: counters
+	// wind opposite to the outer contour).
+	NonZero FillRule = "nonzero"
+	// EvenOdd fills areas enclosed by an odd number of contours.
+	EvenOdd FillRule = "evenodd"
+)
+
+// filled reports whether the winding number w is filled by the rule.
+func (r FillRule) filled(w int) bool {
+	if r == EvenOdd {
+		return w%2 != 0
+	}
+	return w != 0
+}
+
+// fillContour is a contour to paint with the given polarity.
+type fillContour struct {
+	pts  []Pt
+	dark bool
+}
+
+// fillContours converts contours filled by the rule into contours painted
+// in order with dark or clear polarity, which is how Gerber renders holes:
+// enclosing contours are painted before the contours within them, and a
+// contour is dark when the area just inside it is filled. Contours are
+// normalized to the internal convention of counterclockwise dark contours
+// and clockwise clear ones. Contours must not cross each other.
+func fillContours(contours [][]Pt, rule FillRule) []*fillContour {
+	type entry struct {
+		c     *fillContour
+		depth int
+	}
+	var entries []entry
+	for i, pts := range contours {
+		if len(pts) < 3 {
+			continue
+		}
+		in, ok := insidePoint(pts)
+		if !ok {
+			continue
+		}
+		var w, depth int
+		for j, other := range contours {
+			if len(other) < 3 {
+				continue
+			}
+			w += windingNumber(other, in)
+			if j != i && insidePolygon(other, in) {
+				depth++
+			}
+		}
+		c := &fillContour{pts: pts, dark: rule.filled(w)}
+		if ccw := signedArea(pts) > 0; ccw != c.dark {
+			c.pts = reversed(pts)
+		}
+		entries = append(entries, entry{c, depth})
+	}
+	sort.SliceStable(entries, func(a, b int) bool { return entries[a].depth < entries[b].depth })
+	result := make([]*fillContour, len(entries))
+	for i, e := range entries {
+		result[i] = e.c
+	}
+	return result
+}
+
+// insidePoint returns a point just inside the contour, next to the
+// midpoint of its longest edge.
+func insidePoint(pts []Pt) (Pt, bool) {
+	area := signedArea(pts)
+	if area == 0 {
+		return Pt{}, false
+	}
+	var a, b Pt
+	var best float64
+	for i, p := range pts {
+		q := pts[(i+1)%len(pts)]
+		if d := (q.X-p.X)*(q.X-p.X) + (q.Y-p.Y)*(q.Y-p.Y); d > best {
+			a, b, best = p, q, d
+		}
+	}
+	// The interior is to the left of counterclockwise edges.
+	const eps = 1e-4
+	nx, ny := -(b.Y - a.Y), b.X-a.X
+	if area < 0 {
+		nx, ny = -nx, -ny
+	}
+	return Pt{X: 0.5*(a.X+b.X) + eps*nx, Y: 0.5*(a.Y+b.Y) + eps*ny}, true
+}
+
+// windingNumber returns the number of times the closed contour winds
+// counterclockwise around pt.
+func windingNumber(pts []Pt, pt Pt) int {
+	var w int
+	for i, a := range pts {
+		b := pts[(i+1)%len(pts)]
+		isLeft := (b.X-a.X)*(pt.Y-a.Y) - (pt.X-a.X)*(b.Y-a.Y)
+		switch {
+		case a.Y <= pt.Y && b.Y > pt.Y && isLeft > 0:
+			w++
+		case a.Y > pt.Y && b.Y <= pt.Y && isLeft < 0:
+			w--
+		}
+	}
+	return w
+}
+
+// reversed returns the points in reverse order.
+func reversed(pts []Pt) []Pt {
+	result := make([]Pt, len(pts))
+	for i, pt := range pts {
+		result[len(pts)-1-i] = pt
+	}
+	return result
+}
@@ -0,0 +1,60 @@
+package gerber
+
+import "testing"
+
+// square returns the path steps of a closed square contour.
+func square(x0, y0, x1, y1 float64, ccw bool) []*PathStep {
+	pts := []float64{x1, y0, x1, y1, x0, y1}
+	if !ccw {
+		pts = []float64{x0, y1, x1, y1, x1, y0}
+	}
+	return []*PathStep{{C: 'M', P: []float64{x0, y0}}, {C: 'L', P: pts}, {C: 'Z'}}
+}
+
+func TestGlyph_FillRule(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      FillRule
+		counterCW bool
+		gerberLP  string
+		wantClear int
+	}{
+		{"nonzero opposite winding", NonZero, true, "", 1},
+		{"nonzero same winding", NonZero, false, "", 0},
+		{"evenodd same winding", EvenOdd, false, "", 1},
+		{"hand-tuned polarities", EvenOdd, false, "dd", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The counter comes first in the path: it must still be painted last.
+			steps := append(square(200, 200, 800, 800, !tt.counterCW), square(0, 0, 1000, 1000, true)...)
+			Fonts["filltest"] = &Font{
+				HorizAdvX: 1000,
+				FillRule:  tt.rule,
+				Glyphs:    map[string]*Glyph{"O": {HorizAdvX: 1000, Unicode: "O", GerberLP: tt.gerberLP, PathSteps: steps}},
+			}
+			defer delete(Fonts, "filltest")
+
+			ops := plot(Text(0, 0, 1, "O", "filltest", 72))
+			var clear int
+			for i, o := range ops {
+				if !o.clear {
+					continue
+				}
+				clear++
+				if i != len(ops)-1 {
+					t.Errorf("clear contour #%v painted before the outer contour", i)
+				}
+				if signedArea(o.pts[:len(o.pts)-1]) >= 0 {
+					t.Error("clear contour is not clockwise")
+				}
+			}
+			if clear != tt.wantClear {
+				t.Errorf("got %v clear contours, want %v", clear, tt.wantClear)
+			}
+			if len(ops) != 2 {
+				t.Errorf("got %v contours, want 2", len(ops))
+			}
+		})
+	}
+}
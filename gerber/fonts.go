@@ -1,16 +1,49 @@
 // Auto-generated - DO NOT EDIT!
 
+// latoregular:
+//   Copyright: Copyright c 20102011 by tyPoland Lukasz Dziedzic with Reserved Font Name Lato Licensed under the SIL Open Font License Version 11
+//   Designer: Lukasz Dziedzic
+//   Foundry: tyPoland Lukasz Dziedzic
+//   Foundry URL: httpwwwtypolandcom
+
+// overlockregular:
+//   Copyright: Copyright c 2011 Dario Manuel Muhafara httpwwwtiponetar with Reserved Font Name Overlock
+//   Designer: Dario Muhafara
+//   Foundry: Dario Manuel Muhafara
+//   Foundry URL: wwwtiponetar
+
+// pacifico:
+//   Copyright: Copyright c 2011 by vernon adams All rights reserved
+//   Designer: vernon adams
+//   Foundry: vernon adams
+
+// snickles:
+//   Copyright: Typeface  Tup Wanders 2009
+//   Designer: Tup Wanders
+//   Foundry: Free font Do not sell
+
+// ubuntumonoregular:
+//   Copyright: Copyright 2011 Canonical Ltd  Licensed under the Ubuntu Font Licence 10
+//   Designer: Dalton Maag Ltd
+//   Foundry: Dalton Maag Ltd
+//   Foundry URL: httpwwwdaltonmaagcom
+
 package gerber
 
 // Font represents a webfont.
 type Font struct {
-	ID               string
-	HorizAdvX        float64
-	UnitsPerEm       float64
-	Ascent           float64
-	Descent          float64
-	MissingHorizAdvX float64
-	Glyphs           map[string]*Glyph
+	ID                 string
+	HorizAdvX          float64
+	UnitsPerEm         float64
+	Ascent             float64
+	Descent            float64
+	UnderlinePosition  float64
+	UnderlineThickness float64
+	CapHeight          float64
+	XHeight            float64
+	MissingHorizAdvX   float64
+	FillRule           FillRule
+	Glyphs             map[string]*Glyph
 }
 
 // Glyph represents an individual character of the webfont data.
@@ -41,11 +74,15 @@ type PathStep struct {
 var Fonts = map[string]*Font{
 	"aaarghnormal": {
 		// ID: "aaarghnormal",
-		HorizAdvX:        629,
-		UnitsPerEm:       1000,
-		Ascent:           800,
-		Descent:          -200,
-		MissingHorizAdvX: 467,
+		HorizAdvX:          629,
+		UnitsPerEm:         1000,
+		Ascent:             800,
+		Descent:            -200,
+		UnderlinePosition:  -100,
+		UnderlineThickness: 50,
+		CapHeight:          797,
+		XHeight:            577,
+		MissingHorizAdvX:   467,
 		Glyphs: map[string]*Glyph{
 			"\r": {
 				HorizAdvX: 467,
@@ -4234,11 +4271,15 @@ var Fonts = map[string]*Font{
 	},
 	"fascinate_inlineregular": {
 		// ID: "fascinate_inlineregular",
-		HorizAdvX:        500,
-		UnitsPerEm:       1000,
-		Ascent:           800,
-		Descent:          -200,
-		MissingHorizAdvX: 300,
+		HorizAdvX:          500,
+		UnitsPerEm:         1000,
+		Ascent:             800,
+		Descent:            -200,
+		UnderlinePosition:  -100,
+		UnderlineThickness: 50,
+		CapHeight:          701,
+		XHeight:            500,
+		MissingHorizAdvX:   300,
 		Glyphs: map[string]*Glyph{
 			"\ufb01": {
 				HorizAdvX: 948,
@@ -14535,11 +14576,15 @@ var Fonts = map[string]*Font{
 	},
 	"gooddogregular": {
 		// ID: "gooddogregular",
-		HorizAdvX:        359,
-		UnitsPerEm:       1000,
-		Ascent:           800,
-		Descent:          -200,
-		MissingHorizAdvX: 185,
+		HorizAdvX:          359,
+		UnitsPerEm:         1000,
+		Ascent:             800,
+		Descent:            -200,
+		UnderlinePosition:  -100,
+		UnderlineThickness: 50,
+		CapHeight:          626,
+		XHeight:            470,
+		MissingHorizAdvX:   185,
 		Glyphs: map[string]*Glyph{
 			"\r": {
 				HorizAdvX: 1000,
@@ -22152,11 +22197,15 @@ var Fonts = map[string]*Font{
 	},
 	"helsinkiregular": {
 		// ID: "helsinkiregular",
-		HorizAdvX:        1463,
-		UnitsPerEm:       2048,
-		Ascent:           1638,
-		Descent:          -410,
-		MissingHorizAdvX: 569,
+		HorizAdvX:          1463,
+		UnitsPerEm:         2048,
+		Ascent:             1638,
+		Descent:            -410,
+		UnderlinePosition:  -205,
+		UnderlineThickness: 102.4,
+		CapHeight:          1504,
+		XHeight:            1470,
+		MissingHorizAdvX:   569,
 		Glyphs: map[string]*Glyph{
 			"\r": {
 				HorizAdvX: 569,
@@ -26773,11 +26822,15 @@ var Fonts = map[string]*Font{
 	},
 	"latoregular": {
 		// ID: "latoregular",
-		HorizAdvX:        1187,
-		UnitsPerEm:       2048,
-		Ascent:           1649,
-		Descent:          -399,
-		MissingHorizAdvX: 395,
+		HorizAdvX:          1187,
+		UnitsPerEm:         2048,
+		Ascent:             1649,
+		Descent:            -399,
+		UnderlinePosition:  -199.5,
+		UnderlineThickness: 102.4,
+		CapHeight:          1467,
+		XHeight:            1037,
+		MissingHorizAdvX:   395,
 		Glyphs: map[string]*Glyph{
 			" ": {
 				HorizAdvX: 395,
@@ -31096,11 +31149,15 @@ var Fonts = map[string]*Font{
 	},
 	"overlockregular": {
 		// ID: "overlockregular",
-		HorizAdvX:        1095,
-		UnitsPerEm:       2048,
-		Ascent:           1638,
-		Descent:          -410,
-		MissingHorizAdvX: 471,
+		HorizAdvX:          1095,
+		UnitsPerEm:         2048,
+		Ascent:             1638,
+		Descent:            -410,
+		UnderlinePosition:  -205,
+		UnderlineThickness: 102.4,
+		CapHeight:          1364,
+		XHeight:            973,
+		MissingHorizAdvX:   471,
 		Glyphs: map[string]*Glyph{
 			" ": {
 				HorizAdvX: 471,
@@ -34984,11 +35041,15 @@ var Fonts = map[string]*Font{
 	},
 	"pacifico": {
 		// ID: "pacifico",
-		HorizAdvX:        970,
-		UnitsPerEm:       2048,
-		Ascent:           1638,
-		Descent:          -410,
-		MissingHorizAdvX: 545,
+		HorizAdvX:          970,
+		UnitsPerEm:         2048,
+		Ascent:             1638,
+		Descent:            -410,
+		UnderlinePosition:  -205,
+		UnderlineThickness: 102.4,
+		CapHeight:          1824,
+		XHeight:            996,
+		MissingHorizAdvX:   545,
 		Glyphs: map[string]*Glyph{
 			" ": {
 				HorizAdvX: 545,
@@ -44046,11 +44107,15 @@ var Fonts = map[string]*Font{
 	},
 	"snickles": {
 		// ID: "snickles",
-		HorizAdvX:        1013,
-		UnitsPerEm:       2048,
-		Ascent:           1638,
-		Descent:          -410,
-		MissingHorizAdvX: 440,
+		HorizAdvX:          1013,
+		UnitsPerEm:         2048,
+		Ascent:             1638,
+		Descent:            -410,
+		UnderlinePosition:  -205,
+		UnderlineThickness: 102.4,
+		CapHeight:          1531,
+		XHeight:            1149,
+		MissingHorizAdvX:   440,
 		Glyphs: map[string]*Glyph{
 			" ": {
 				HorizAdvX: 440,
@@ -48138,11 +48203,15 @@ var Fonts = map[string]*Font{
 	},
 	"ubuntumonoregular": {
 		// ID: "ubuntumonoregular",
-		HorizAdvX:        1024,
-		UnitsPerEm:       2048,
-		Ascent:           1638,
-		Descent:          -410,
-		MissingHorizAdvX: 500,
+		HorizAdvX:          1024,
+		UnitsPerEm:         2048,
+		Ascent:             1638,
+		Descent:            -410,
+		UnderlinePosition:  -205,
+		UnderlineThickness: 102.4,
+		CapHeight:          1268,
+		XHeight:            950,
+		MissingHorizAdvX:   500,
 		Glyphs: map[string]*Glyph{
 			" ": {
 				HorizAdvX: 0,
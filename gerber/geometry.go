@@ -0,0 +1,96 @@
+package gerber
+
+import "math"
+
+// The adapters below convert to and from the point, ring and polygon
+// types of common Go geometry packages without depending on them:
+// any point type whose underlying type is [2]float64 (e.g. orb.Point,
+// geom.Point or go3d's vec2.T), any ring that is a slice of such points
+// (e.g. orb.Ring or orb.LineString) and any polygon that is a slice of
+// such rings (outer ring first, then holes, e.g. orb.Polygon) work.
+// For example:
+//
+//	ring := gerber.ToPoints[orb.Point](pts)
+//	poly := gerber.ToPolygon[orb.Polygon](dark)
+//	layer.Add(gerber.FromPolygon(poly))
+
+// ToPoints converts points to points of another geometry package.
+func ToPoints[P ~[2]float64](pts []Pt) []P {
+	result := make([]P, len(pts))
+	for i, pt := range pts {
+		result[i] = P{pt.X, pt.Y}
+	}
+	return result
+}
+
+// FromPoints converts points of another geometry package to points.
+func FromPoints[P ~[2]float64](pts []P) []Pt {
+	result := make([]Pt, len(pts))
+	for i, pt := range pts {
+		result[i] = Pt{X: pt[0], Y: pt[1]}
+	}
+	return result
+}
+
+// ToPolygon converts rings (outer ring first, then holes)
+// to a polygon of another geometry package.
+func ToPolygon[Poly ~[]R, R ~[]P, P ~[2]float64](rings [][]Pt) Poly {
+	result := make(Poly, len(rings))
+	for i, ring := range rings {
+		result[i] = R(ToPoints[P](ring))
+	}
+	return result
+}
+
+// FromPolygon returns a filled region primitive of a polygon of another
+// geometry package: its first ring is filled and the others (its
+// holes) are cleared. Rings are closed if necessary.
+func FromPolygon[Poly ~[]R, R ~[]P, P ~[2]float64](poly Poly) Primitive {
+	r := &replotT{}
+	for i, ring := range poly {
+		pts := FromPoints(ring)
+		if len(pts) < 3 {
+			continue
+		}
+		if pts[0] != pts[len(pts)-1] {
+			pts = append(pts, pts[0])
+		}
+		r.ops = append(r.ops, op{code: regionOp, clear: i > 0, pts: pts})
+	}
+	return r
+}
+
+// Outlines returns the closed outlines (in mm) of the area a primitive
+// covers as it will be written: dark contours add material and clear
+// contours (drawn later with clear polarity) remove it.
+// Draws are approximated by the convex hull of their aperture
+// at both ends.
+func Outlines(p Primitive) (dark, clear [][]Pt) {
+	for _, o := range plot(p) {
+		if o.clear {
+			clear = append(clear, contours(o)...)
+		} else {
+			dark = append(dark, contours(o)...)
+		}
+	}
+	return dark, clear
+}
+
+// ToInt64 converts points to integer coordinates scaled by scale (e.g. 1e6
+// for nanometers), as used by integer geometry engines such as Clipper.
+func ToInt64(pts []Pt, scale float64) [][2]int64 {
+	result := make([][2]int64, len(pts))
+	for i, pt := range pts {
+		result[i] = [2]int64{int64(math.Round(pt.X * scale)), int64(math.Round(pt.Y * scale))}
+	}
+	return result
+}
+
+// FromInt64 converts integer coordinates scaled by scale back to points.
+func FromInt64(pts [][2]int64, scale float64) []Pt {
+	result := make([]Pt, len(pts))
+	for i, pt := range pts {
+		result[i] = Pt{X: float64(pt[0]) / scale, Y: float64(pt[1]) / scale}
+	}
+	return result
+}
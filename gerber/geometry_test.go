@@ -0,0 +1,38 @@
+package gerber
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gmlewis/go3d/float64/vec2"
+)
+
+// ring and polygon mimic the types of geometry packages such as orb.
+type (
+	ring    []vec2.T
+	polygon []ring
+)
+
+func TestGeometryAdapters(t *testing.T) {
+	pts := []Pt{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 4}, {X: 0, Y: 4}, {X: 0, Y: 0}}
+	if got := FromPoints(ToPoints[vec2.T](pts)); !reflect.DeepEqual(got, pts) {
+		t.Errorf("points round trip = %v, want %v", got, pts)
+	}
+	if got := FromInt64(ToInt64(pts, 1e6), 1e6); !reflect.DeepEqual(got, pts) {
+		t.Errorf("int64 round trip = %v, want %v", got, pts)
+	}
+
+	hole := []Pt{{X: 1, Y: 1}, {X: 1, Y: 3}, {X: 3, Y: 3}, {X: 3, Y: 1}}
+	poly := ToPolygon[polygon]([][]Pt{pts, hole})
+	if len(poly) != 2 || poly[1][2] != (vec2.T{3, 3}) {
+		t.Fatalf("ToPolygon = %v", poly)
+	}
+
+	dark, clear := Outlines(FromPolygon(poly))
+	if len(dark) != 1 || len(clear) != 1 {
+		t.Fatalf("got %v dark and %v clear outlines, want 1 and 1", len(dark), len(clear))
+	}
+	if !reflect.DeepEqual(dark[0], pts) || !reflect.DeepEqual(clear[0], append(hole, hole[0])) {
+		t.Errorf("Outlines = %v, %v", dark, clear)
+	}
+}
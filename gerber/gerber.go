@@ -3,7 +3,12 @@ package gerber
 
 import (
 	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
 	"os"
+	"runtime"
+	"strings"
 )
 
 // Gerber represents the layers needed to build a PCB.
@@ -12,43 +17,277 @@ type Gerber struct {
 	FilenamePrefix string
 	// Layers represents the layers making up the Gerber design.
 	Layers []*Layer
+	// UniqueIDs, when true, assigns a stable UUID to every object
+	// (emitted as a Gerber X2 object attribute) for CAM traceability,
+	// derived from its component (or name, or else its geometry) so that
+	// it survives changes to the rest of the layer.
+	UniqueIDs bool
+	// Variant, when non-empty, selects the board variant to write.
+	// Objects tagged with other variants are left out of the output.
+	Variant string
+	// Format is the coordinate format of the output (DefaultFormat if unset).
+	Format Format
+	// Units are the units of the output (Millimeters if unset).
+	Units Units
+	// Revision is the revision of the Gerber specification the output
+	// is compatible with (Revision2021 if unset).
+	Revision Revision
+	// MaxDeviation, when positive, is the maximum deviation (in mm) between
+	// the logical and the emitted (quantized) geometry allowed on any layer.
+	// Writing a layer that exceeds it returns an error.
+	MaxDeviation float64
+	// Components are the placed components written as Gerber X3
+	// component layers.
+	Components []*Component
+	// Strict, when true, makes the warnings about the design (such as
+	// missing fonts or glyphs, glyphs with mismatched polarities and pads
+	// off the PlacementGrid) errors returned when writing it, e.g. so that
+	// CI builds fail instead of shipping subtly broken artwork.
+	Strict bool
+	// Pads are the pad stack instances of the design (see PadStack),
+	// expanded into its layers when it is written.
+	Pads []*PadT
+	// Provenance, when true, records the call site of every Layer.Add
+	// so that errors can point to the code that created a primitive.
+	Provenance bool
+	// PlacementGrid, when positive, is the placement grid (in mm) of the
+	// design: Layer.Add logs a warning for every pad added off the grid.
+	// See Gerber.OffGrid.
+	PlacementGrid float64
+	// Generator is the software the files of the design are attributed
+	// to (DefaultGenerator if nil). Set it to &Generator{} to leave the
+	// GenerationSoftware attribute out of the files.
+	Generator *Generator
+	// Derive are the rules deriving the solder mask and paste openings
+	// of the pads of the outer copper layers.
+	Derive DeriveRules
+	// OutlineContours is the number of closed contours of the outline
+	// layer checked by Validate (e.g. 2 for a board with a cutout), or 0
+	// for one.
+	OutlineContours int
 }
 
 // New returns a new Gerber design.
 // filenamePrefix is the base filename for all gerber files (e.g. "bifilar-coil").
-func New(filenamePrefix string) *Gerber {
-	return &Gerber{
+// The optional units set the units of the output (see Gerber.Units);
+// coordinates are always given in millimeters (converted from other
+// units with Units.MM) or as Lengths (such as Inch(0.1) or Mil(8), see
+// Length).
+func New(filenamePrefix string, units ...Units) *Gerber {
+	g := &Gerber{
 		FilenamePrefix: filenamePrefix,
 	}
+	if len(units) > 0 {
+		g.Units = units[0]
+	}
+	return g
 }
 
 // WriteGerber writes all the Gerber layers to their respective files
-// then zips them all together into a ZIP file with the same prefix
-// for sending to PCB manufacturers.
-func (g *Gerber) WriteGerber() error {
-	zf, err := os.Create(g.FilenamePrefix + ".zip")
+// (and the drill layers to Excellon drill files as well) then zips them
+// all together into a ZIP file with the same prefix for sending to PCB
+// manufacturers.
+// The files are rendered concurrently, one layer per goroutine. The ZIP
+// file is removed if any of them fails.
+func (g *Gerber) WriteGerber() (err error) {
+	files, err := g.files(g.withDerived(), true)
+	if err != nil {
+		return err
+	}
+	name := g.FilenamePrefix + ".zip"
+	zf, err := os.Create(name)
 	if err != nil {
 		return err
 	}
-	zw := zip.NewWriter(zf)
-	for _, layer := range g.Layers {
-		f, err := zw.Create(layer.Filename)
+	defer func() {
 		if err != nil {
-			return err
+			zf.Close()
+			os.Remove(name)
 		}
-		if err := layer.WriteGerber(f); err != nil {
-			return err
+	}()
+	if err := writeZip(zf, files); err != nil {
+		return err
+	}
+	return zf.Close()
+}
+
+// writeZip renders the files, writing each to its own file and to the
+// ZIP file written to w.
+func writeZip(w io.Writer, files []file) error {
+	zw := zip.NewWriter(w)
+	rendered, stop := render(files)
+	defer stop()
+	for _, f := range rendered {
+		<-f.done
+		if f.err != nil {
+			return f.err
 		}
-		w, err := os.Create(layer.Filename)
+		w, err := zw.Create(f.name)
 		if err != nil {
 			return err
 		}
-		if err := layer.WriteGerber(w); err != nil {
+		if _, err := w.Write(f.data); err != nil {
 			return err
 		}
-		if err := w.Close(); err != nil {
+		if err := os.WriteFile(f.name, f.data, 0666); err != nil {
 			return err
 		}
+		f.release()
 	}
 	return zw.Close()
 }
+
+// file is a file of the design to render.
+type file struct {
+	name  string
+	write func(w io.Writer) error
+}
+
+// files returns the files of the layers (with the Excellon files of the
+// drill layers) and, if components is true, of the component layers.
+func (g *Gerber) files(layers []*Layer, components bool) ([]file, error) {
+	var files []file
+	for _, layer := range layers {
+		files = append(files, file{layer.Filename, layer.WriteGerber})
+		if layer.drill() {
+			files = append(files, file{layer.ExcellonFilename(), layer.WriteExcellon})
+		}
+	}
+	for _, bottom := range []bool{false, true} {
+		if !components || !g.hasComponents(bottom) {
+			continue
+		}
+		if !g.Revision.atLeast(Revision2021) {
+			if g.Strict {
+				return nil, fmt.Errorf("component layers need Gerber X3 (revision %v)", Revision2021)
+			}
+			logger.Warn("component layers need Gerber X3: skipping", "revision", g.Revision)
+			break
+		}
+		files = append(files, file{g.componentFilename(bottom), func(w io.Writer) error {
+			return g.WriteComponents(w, bottom)
+		}})
+	}
+	return files, nil
+}
+
+// rendered is a file rendered in memory.
+type rendered struct {
+	name  string
+	data  []byte
+	err   error
+	done  chan struct{} // closed once rendered
+	slots chan struct{} // of the files rendering or rendered (see render)
+}
+
+// release drops the data of the consumed file, letting another one render.
+func (r *rendered) release() {
+	r.data = nil
+	<-r.slots
+}
+
+// render renders the files concurrently and returns them in order, to be
+// waited for one by one and released once consumed. At most GOMAXPROCS
+// files are rendering or rendered but not yet released at any time, which
+// bounds the memory they hold. stop must be called once done with them.
+func render(files []file) (result []*rendered, stop func()) {
+	result = make([]*rendered, len(files))
+	slots := make(chan struct{}, runtime.GOMAXPROCS(0))
+	for i, f := range files {
+		result[i] = &rendered{name: f.name, done: make(chan struct{}), slots: slots}
+	}
+	stopped := make(chan struct{})
+	go func() {
+		for i, f := range files {
+			// Files are started in order, so that the one waited for
+			// always gets a slot.
+			select {
+			case slots <- struct{}{}:
+			case <-stopped:
+				return
+			}
+			r := result[i]
+			go func() {
+				var buf bytes.Buffer
+				r.err = f.write(&buf)
+				r.data = buf.Bytes()
+				close(r.done)
+			}()
+		}
+	}()
+	return result, func() { close(stopped) }
+}
+
+// LayerFilter selects layers, e.g. for WriteLayers.
+type LayerFilter func(l *Layer) bool
+
+// CopperLayers selects the copper layers.
+func CopperLayers(l *Layer) bool {
+	return l.copper()
+}
+
+// OuterLayers selects all layers but the inner copper layers.
+func OuterLayers(l *Layer) bool {
+	ext := l.extension()
+	return !l.copper() || ext == "gtl" || ext == "gbl"
+}
+
+// WriteLayers writes only the layers selected by all the filters (e.g.
+// CopperLayers and OuterLayers for the outer copper layers) to their
+// respective files, without the ZIP file, to quickly regenerate them.
+func (g *Gerber) WriteLayers(filters ...LayerFilter) error {
+	var layers []*Layer
+	for _, layer := range g.withDerived() {
+		selected := true
+		for _, f := range filters {
+			selected = selected && f(layer)
+		}
+		if selected {
+			layers = append(layers, layer)
+		}
+	}
+	files, err := g.files(layers, false)
+	if err != nil {
+		return err
+	}
+	rendered, stop := render(files)
+	defer stop()
+	for _, f := range rendered {
+		<-f.done
+		if f.err != nil {
+			return f.err
+		}
+		if err := os.WriteFile(f.name, f.data, 0666); err != nil {
+			return err
+		}
+		f.release()
+	}
+	return nil
+}
+
+// WriteVariants writes one complete set of Gerber files (and ZIP) per
+// named variant, using the filename prefix "<prefix>-<variant>".
+func (g *Gerber) WriteVariants(variants ...string) error {
+	prefix, variant := g.FilenamePrefix, g.Variant
+	filenames := make([]string, len(g.Layers))
+	for i, layer := range g.Layers {
+		filenames[i] = layer.Filename
+	}
+	defer func() {
+		g.FilenamePrefix, g.Variant = prefix, variant
+		for i, layer := range g.Layers {
+			layer.Filename = filenames[i]
+		}
+	}()
+
+	for _, v := range variants {
+		g.FilenamePrefix, g.Variant = prefix+"-"+v, v
+		for i, layer := range g.Layers {
+			layer.Filename = g.FilenamePrefix + strings.TrimPrefix(filenames[i], prefix)
+		}
+		if err := g.WriteGerber(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
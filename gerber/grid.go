@@ -0,0 +1,83 @@
+package gerber
+
+import (
+	"fmt"
+	"math"
+)
+
+// SnapToGrid returns v rounded to the nearest multiple of grid.
+// If grid is not positive, v is returned unchanged.
+func SnapToGrid(v, grid float64) float64 {
+	if grid <= 0 {
+		return v
+	}
+	return grid * math.Round(v/grid)
+}
+
+// SnapPt returns pt with both coordinates snapped to grid.
+func SnapPt(pt Pt, grid float64) Pt {
+	return Pt{X: SnapToGrid(pt.X, grid), Y: SnapToGrid(pt.Y, grid)}
+}
+
+// SnapPrimitive returns a primitive that draws p with every point
+// (line endpoints, flash positions and polygon vertices) snapped to grid.
+// Apertures keep their size and object attributes are preserved.
+// All dimensions are in millimeters.
+func SnapPrimitive(p Primitive, grid float64) Primitive {
+	if o, ok := p.(*ObjectT); ok {
+		c := *o
+		c.p = SnapPrimitive(o.p, grid)
+		return &c
+	}
+	return replot(transformOps(plot(p), func(pt Pt) Pt { return SnapPt(pt, grid) }))
+}
+
+// OffGrid returns a description of every pad of the design lying off
+// its PlacementGrid (see Layer.OffGrid), or nil if PlacementGrid is unset.
+func (g *Gerber) OffGrid() []string {
+	var pads []string
+	for _, l := range g.Layers {
+		pads = append(pads, l.OffGrid(g.PlacementGrid)...)
+	}
+	return pads
+}
+
+// OffGrid returns a description of every pad of the layer (a flash or a
+// zero-length draw, such as a circle or a dot) whose center does not lie
+// on a multiple of grid, annotated with the provenance of the primitive
+// as in Error. If grid is not positive, it returns nil.
+func (l *Layer) OffGrid(grid float64) []string {
+	return l.offGrid(0, grid)
+}
+
+// offGrid checks the pads of the primitives from index start on.
+func (l *Layer) offGrid(start int, grid float64) []string {
+	if grid <= 0 {
+		return nil
+	}
+	var pads []string
+	for i := start; i < len(l.Primitives); i++ {
+		for _, o := range plot(l.Primitives[i]) {
+			if o.code == regionOp || o.code == drawOp && o.pts[0] != o.pts[len(o.pts)-1] {
+				continue
+			}
+			c := o.pts[0]
+			if s := SnapPt(c, grid); toNM(s.X) != toNM(c.X) || toNM(s.Y) != toNM(c.Y) {
+				err := fmt.Errorf("pad at (%v,%v) is off the %vmm grid", c.X, c.Y, grid)
+				pads = append(pads, l.wrapErr(i, err).Error())
+			}
+		}
+	}
+	return pads
+}
+
+// warnOffGrid logs a warning for every pad added from index start on
+// that lies off the design's PlacementGrid.
+func (l *Layer) warnOffGrid(start int) {
+	if l.g == nil {
+		return
+	}
+	for _, pad := range l.offGrid(start, l.g.PlacementGrid) {
+		logger.Warn("off-grid pad", "pad", pad)
+	}
+}
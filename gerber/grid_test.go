@@ -0,0 +1,72 @@
+package gerber
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSnapToGrid(t *testing.T) {
+	tests := []struct {
+		v, grid float64
+		want    float64
+	}{
+		{v: 1.26, grid: 0.5, want: 1.5},
+		{v: -1.26, grid: 0.5, want: -1.5},
+		{v: 1.24, grid: 0.5, want: 1},
+		{v: 2.538, grid: 1.27, want: 2.54},
+		{v: 1.23, grid: 0, want: 1.23},
+	}
+	for _, tt := range tests {
+		if got := SnapToGrid(tt.v, tt.grid); toNM(got) != toNM(tt.want) {
+			t.Errorf("SnapToGrid(%v, %v) = %v, want %v", tt.v, tt.grid, got, tt.want)
+		}
+	}
+}
+
+func TestSnapPrimitive(t *testing.T) {
+	p := SnapPrimitive(Object(Line(0.1, 0.2, 1.9, 1.1, CircleShape, 0.2)).Name("trace"), 0.5)
+	o, ok := p.(*ObjectT)
+	if !ok || o.name != "trace" {
+		t.Fatalf("SnapPrimitive must keep object attributes, got %#v", p)
+	}
+	if a := p.Aperture(); a == nil || a.Size != 0.2 {
+		t.Errorf("SnapPrimitive aperture = %+v, want size 0.2", a)
+	}
+	ops := plot(p)
+	if len(ops) != 1 || ops[0].pts[0] != (Pt{X: 0, Y: 0}) || ops[0].pts[1] != (Pt{X: 2, Y: 1}) {
+		t.Errorf("SnapPrimitive = %+v, want a line from (0,0) to (2,1)", ops)
+	}
+}
+
+func TestGerber_OffGrid(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(l Logger) { logger = l }(logger)
+	SetLogger(NewLogger(&buf, false))
+
+	g := New("board")
+	g.PlacementGrid = 1.27
+	top := g.TopCopper()
+	top.Add(
+		Flash(2.54, 1.27, RectShape, 1),
+		Object(Flash(1, 1.27, CircleShape, 1)).Name("pad1"),
+		Circle(3.81, 0.5, 0.6),
+		Line(0.1, 0.1, 2.3, 0.1, CircleShape, 0.2), // traces may be off grid
+	)
+
+	want := []string{
+		"board.gtl: primitive #1: pad1: pad at (1,1.27) is off the 1.27mm grid",
+		"board.gtl: primitive #2: pad at (3.81,0.5) is off the 1.27mm grid",
+	}
+	if got := strings.Join(g.OffGrid(), "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("OffGrid =\n%v\nwant\n%v", got, strings.Join(want, "\n"))
+	}
+	if got := strings.Count(buf.String(), "off-grid pad"); got != 2 {
+		t.Errorf("Add logged %v off-grid warnings, want 2:\n%v", got, buf.String())
+	}
+
+	g.PlacementGrid = 0
+	if pads := g.OffGrid(); pads != nil {
+		t.Errorf("OffGrid without a PlacementGrid = %v, want nil", pads)
+	}
+}
@@ -0,0 +1,131 @@
+package gerber
+
+import (
+	"io"
+	"math"
+)
+
+// GroupT represents primitives (such as a word of text or a footprint)
+// rotated, mirrored, scaled and translated as a unit, and satisfies the
+// Primitive interface. Transforms apply in the order they are called.
+//
+// Flashes and draws whose apertures would no longer match the
+// transformed geometry (scaled apertures, and square apertures rotated
+// by other than a multiple of 90 degrees) are written as regions of
+// their outlines instead.
+type GroupT struct {
+	primitives []Primitive
+	m          affine
+}
+
+// affine is the 2D affine transform x' = a*x + b*y + e, y' = c*x + d*y + f.
+type affine struct {
+	a, b, c, d, e, f float64
+}
+
+// identity is the affine transform leaving points unchanged.
+var identity = affine{a: 1, d: 1}
+
+// apply returns the transformed point.
+func (m affine) apply(pt Pt) Pt {
+	return Pt{X: m.a*pt.X + m.b*pt.Y + m.e, Y: m.c*pt.X + m.d*pt.Y + m.f}
+}
+
+// then returns the transform applying m then n.
+func (m affine) then(n affine) affine {
+	return affine{
+		a: n.a*m.a + n.b*m.c, b: n.a*m.b + n.b*m.d,
+		c: n.c*m.a + n.d*m.c, d: n.c*m.b + n.d*m.d,
+		e: n.a*m.e + n.b*m.f + n.e, f: n.c*m.e + n.d*m.f + n.f,
+	}
+}
+
+// keeps reports whether the transform maps the shape of the aperture
+// onto itself, so that the aperture can be kept.
+func (m affine) keeps(a *Aperture) bool {
+	const eps = 1e-9
+	// The transform must preserve lengths and angles.
+	if math.Abs(m.a*m.a+m.c*m.c-1) > eps || math.Abs(m.b*m.b+m.d*m.d-1) > eps || math.Abs(m.a*m.b+m.c*m.d) > eps {
+		return false
+	}
+	return a.Shape != RectShape || math.Abs(m.a*m.b) < eps && math.Abs(m.c*m.d) < eps
+}
+
+// Group returns the primitives as a group to transform together.
+func Group(primitives ...Primitive) *GroupT {
+	return &GroupT{primitives: primitives, m: identity}
+}
+
+// Translate moves the group by dx,dy.
+// All dimensions are in millimeters.
+func (g *GroupT) Translate(dx, dy float64) *GroupT {
+	g.m = g.m.then(affine{a: 1, d: 1, e: dx, f: dy})
+	return g
+}
+
+// Rotate rotates the group counterclockwise by degrees about pivot.
+func (g *GroupT) Rotate(degrees float64, pivot Pt) *GroupT {
+	sin, cos := math.Sincos(math.Pi * degrees / 180.0)
+	// Exact quarter turns keep rectangular apertures.
+	sin, cos = math.Round(sin*1e12)/1e12, math.Round(cos*1e12)/1e12
+	g.m = g.m.then(affine{
+		a: cos, b: -sin, c: sin, d: cos,
+		e: pivot.X - cos*pivot.X + sin*pivot.Y,
+		f: pivot.Y - sin*pivot.X - cos*pivot.Y,
+	})
+	return g
+}
+
+// Scale scales the group by factor about pivot.
+func (g *GroupT) Scale(factor float64, pivot Pt) *GroupT {
+	g.m = g.m.then(affine{a: factor, d: factor, e: pivot.X * (1 - factor), f: pivot.Y * (1 - factor)})
+	return g
+}
+
+// Mirror mirrors the group left to right about the vertical line at x,
+// e.g. for text on a bottom layer to read correctly from the bottom.
+// All dimensions are in millimeters.
+func (g *GroupT) Mirror(x float64) *GroupT {
+	g.m = g.m.then(affine{a: -1, d: 1, e: 2 * x})
+	return g
+}
+
+// Center returns the center of the bounding box of the transformed
+// group (e.g. the pivot to rotate a word about its middle).
+func (g *GroupT) Center() Pt {
+	min, max, _ := bounds(plot(g))
+	return Pt{X: 0.5 * (min.X + max.X), Y: 0.5 * (min.Y + max.Y)}
+}
+
+// WriteGerber writes the primitive to the Gerber file.
+func (g *GroupT) WriteGerber(w io.Writer, apertureIndex int) error {
+	return writeChildren(w, g.children())
+}
+
+// Aperture returns nil: the grouped primitives use their own apertures.
+func (g *GroupT) Aperture() *Aperture {
+	return nil
+}
+
+// children returns the transformed primitives, keeping the attributes
+// of objects (except their UUIDs, which must stay unique).
+func (g *GroupT) children() []Primitive {
+	var result []Primitive
+	for _, p := range g.primitives {
+		if o, ok := p.(*ObjectT); ok {
+			c := *o
+			c.p = &GroupT{primitives: []Primitive{o.p}, m: g.m}
+			c.uuid = ""
+			result = append(result, &c)
+			continue
+		}
+		ops := plot(p)
+		for i, o := range ops {
+			if o.code != regionOp && o.aperture != nil && !g.m.keeps(o.aperture) {
+				ops[i] = op{code: regionOp, clear: o.clear, pts: contours(o)[0]}
+			}
+		}
+		result = append(result, replotRuns(transformOps(ops, g.m.apply))...)
+	}
+	return result
+}
@@ -0,0 +1,75 @@
+package gerber
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	near := func(a, b Pt) bool { return math.Abs(a.X-b.X) < 1e-6 && math.Abs(a.Y-b.Y) < 1e-6 }
+	parts := func() []Primitive {
+		return []Primitive{
+			Line(0, 0, 4, 0, CircleShape, 0.2),
+			Flash(4, 0, RectShape, 1),
+			Object(Flash(0, 2, CircleShape, 1)).Net("GND"),
+		}
+	}
+
+	// A quarter turn keeps every aperture.
+	g := Group(parts()...).Rotate(90, Pt{}).Translate(10, 0)
+	ops := plot(g)
+	if len(ops) != 3 || ops[0].code != drawOp || ops[1].code != flashOp || ops[2].code != flashOp {
+		t.Fatalf("rotated ops = %+v, want a draw and two flashes", ops)
+	}
+	if !near(ops[0].pts[1], Pt{X: 10, Y: 4}) || !near(ops[1].pts[0], Pt{X: 10, Y: 4}) || !near(ops[2].pts[0], Pt{X: 8, Y: 0}) {
+		t.Errorf("rotated points = %v %v %v", ops[0].pts, ops[1].pts, ops[2].pts)
+	}
+	if o, ok := g.children()[2].(*ObjectT); !ok || o.net != "GND" {
+		t.Errorf("object child = %#v, want the GND object", g.children()[2])
+	}
+
+	// Other angles turn the square flash into a region.
+	ops = plot(Group(parts()...).Rotate(45, Pt{X: 4}))
+	if ops[0].code != drawOp || ops[1].code != regionOp || ops[2].code != flashOp {
+		t.Errorf("45 degree ops = %v %v %v, want a draw, a region and a flash", ops[0].code, ops[1].code, ops[2].code)
+	}
+	if min, max, _ := bounds(ops[1:2]); !near(min, Pt{X: 4 - math.Sqrt2/2, Y: -math.Sqrt2 / 2}) || !near(max, Pt{X: 4 + math.Sqrt2/2, Y: math.Sqrt2 / 2}) {
+		t.Errorf("rotated square = %v..%v", min, max)
+	}
+
+	// Scaling scales the apertures too.
+	scaled := Group(parts()...).Scale(2, Pt{})
+	for _, o := range plot(scaled) {
+		if o.code != regionOp {
+			t.Fatalf("scaled op %+v, want only regions", o)
+		}
+	}
+	if min, max, _ := bounds(plot(scaled)); !near(min, Pt{X: -1, Y: -1}) || !near(max, Pt{X: 9, Y: 5}) {
+		t.Errorf("scaled bounds = %v..%v, want {-1 -1}..{9 5}", min, max)
+	}
+
+	// Mirrored text reads right to left.
+	text := Text(0, 0, 1, "AB", "aaarghnormal", 12)
+	tmin, tmax, _ := bounds(plot(text))
+	mirrored := Group(text).Mirror(0)
+	if min, max, _ := bounds(plot(mirrored)); !near(min, Pt{X: -tmax.X, Y: tmin.Y}) || !near(max, Pt{X: -tmin.X, Y: tmax.Y}) {
+		t.Errorf("mirrored text = %v..%v, want %v..%v mirrored", min, max, tmin, tmax)
+	}
+	if c := mirrored.Center(); !near(c, Pt{X: -0.5 * (tmin.X + tmax.X), Y: 0.5 * (tmin.Y + tmax.Y)}) {
+		t.Errorf("Center = %v", c)
+	}
+
+	l := New("group").TopCopper()
+	l.Add(g, Group(parts()...).Rotate(30, Pt{}))
+	var buf bytes.Buffer
+	if err := l.WriteGerber(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(l.plot()); got != 6 {
+		t.Errorf("layer has %v ops, want 6", got)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(".N,GND")) {
+		t.Error("layer lost the net of the grouped object")
+	}
+}
@@ -0,0 +1,224 @@
+package gerber
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Heal merges the regions, flashes and draws of the layer separated by
+// hairline gaps of up to gap mm (as left by tiling them
+// programmatically, where rounding keeps neighboring tiles from abutting
+// exactly), which would otherwise be written as slivers of missing
+// copper or mask. Tiles within gap of each other are replaced by the
+// regions of their union (see Union) at the position of the first one,
+// grown by half of gap and shrunk back again, which fills the gaps (and
+// notches) narrower than gap and leaves the rest of the outline in
+// place. Flashes and draws are outlined by the shapes of their
+// apertures for this, circles as polygons with 0.1mm sides.
+//
+// Only plain dark primitives are merged: objects keep their attributes
+// and tiles separated in the layer by clear polarity are left apart.
+// It returns a description of every merge (or of the error that kept
+// tiles apart).
+func (l *Layer) Heal(gap float64) []string {
+	type item struct {
+		index    int
+		contours [][]Pt
+		min, max Pt
+		segment  int // of the layer between clear polarity primitives
+	}
+	var items []*item
+	segment := 0
+	for i, p := range l.Primitives {
+		ops := plot(p)
+		it := &item{index: i, segment: segment}
+		for _, o := range ops {
+			if o.clear {
+				segment++
+				it = nil
+				break
+			}
+			it.contours = append(it.contours, contours(o)...)
+		}
+		if _, ok := p.(*ObjectT); ok || it == nil || len(it.contours) == 0 {
+			continue
+		}
+		it.min, it.max, _ = bounds(ops)
+		items = append(items, it)
+	}
+
+	parent := make([]int, len(items))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	widest := make([]float64, len(items)) // the widest gap closed by each item
+	// distance returns the distance between the outlines of a and b.
+	distance := func(a, b *item) float64 {
+		best := math.Inf(1)
+		for _, ca := range a.contours {
+			for _, v := range ca {
+				for _, cb := range b.contours {
+					for j := range cb {
+						c := closestPoint(v, cb[j], cb[(j+1)%len(cb)])
+						best = math.Min(best, math.Hypot(c.X-v.X, c.Y-v.Y))
+					}
+				}
+			}
+		}
+		return best
+	}
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return items[order[a]].min.X < items[order[b]].min.X })
+	for k, i := range order {
+		a := items[i]
+		for _, j := range order[k+1:] {
+			b := items[j]
+			if b.min.X > a.max.X+gap {
+				break
+			}
+			if a.segment != b.segment || b.min.Y > a.max.Y+gap || a.min.Y > b.max.Y+gap {
+				continue
+			}
+			// Gaps below the nanometer grid are already closed.
+			if w := math.Min(distance(a, b), distance(b, a)); w > 1e-6 && w <= gap {
+				parent[find(i)] = find(j)
+				widest[i], widest[j] = math.Max(widest[i], w), math.Max(widest[j], w)
+			}
+		}
+	}
+
+	// The items are grouped in the order of their first primitive.
+	groups := map[int][]*item{}
+	gaps := map[int]float64{}
+	var roots []int
+	for i, it := range items {
+		r := find(i)
+		if groups[r] == nil {
+			roots = append(roots, r)
+		}
+		groups[r] = append(groups[r], it)
+		gaps[r] = math.Max(gaps[r], widest[i])
+	}
+	replace := map[int][]Primitive{}
+	remove := map[int]bool{}
+	var changes []string
+	for _, root := range roots {
+		group := groups[root]
+		if len(group) < 2 {
+			continue
+		}
+		var regions []Primitive
+		var merged []string
+		for _, it := range group {
+			for _, c := range it.contours {
+				regions = append(regions, Region(c))
+			}
+			merged = append(merged, fmt.Sprintf("#%v", it.index))
+		}
+		first := group[0].index
+		union, err := closing(regions, 0.5*gap+1e-6)
+		if err != nil {
+			changes = append(changes, l.wrapErr(first, fmt.Errorf("left primitives %v apart: %v", strings.Join(merged, ", "), err)).Error())
+			continue
+		}
+		for _, it := range group {
+			remove[it.index] = true
+		}
+		replace[first] = union
+		changes = append(changes, l.wrapErr(first, fmt.Errorf("merged primitives %v across gaps of up to %.4fmm", strings.Join(merged, ", "), gaps[root])).Error())
+	}
+	if len(remove) == 0 {
+		return changes
+	}
+
+	var prims []Primitive
+	var callers []string
+	for i, p := range l.Primitives {
+		add := []Primitive{p}
+		if remove[i] {
+			add = replace[i]
+		}
+		for _, p := range add {
+			prims = append(prims, p)
+			if i < len(l.callers) {
+				callers = append(callers, l.callers[i])
+			}
+		}
+	}
+	l.Primitives = prims
+	if l.callers != nil {
+		l.callers = callers
+	}
+	return changes
+}
+
+// Heal merges the tiles separated by hairline gaps on all layers of
+// the design (see Layer.Heal) and returns a description of every merge.
+func (g *Gerber) Heal(gap float64) []string {
+	var changes []string
+	for _, l := range g.Layers {
+		changes = append(changes, l.Heal(gap)...)
+	}
+	return changes
+}
+
+// closing returns the regions covering the primitives grown by a square
+// of half side r and shrunk back again (their morphological closing),
+// which fills the gaps narrower than 2r between and within them.
+func closing(primitives []Primitive, r float64) ([]Primitive, error) {
+	square := []Pt{{X: -r, Y: -r}, {X: r, Y: -r}, {X: r, Y: r}, {X: -r, Y: r}}
+	grown, err := Union(append(append([]Primitive(nil), primitives...), edgeBands(primitives, square)...)...)
+	if err != nil {
+		return nil, err
+	}
+	return Difference(grown, edgeBands(grown, square))
+}
+
+// edgeBands returns the regions swept by the convex pen (centered on the
+// origin) along the edges of the outlines of the primitives.
+func edgeBands(primitives []Primitive, pen []Pt) []Primitive {
+	var result []Primitive
+	for _, p := range primitives {
+		for _, o := range plot(p) {
+			for _, c := range contours(o) {
+				for i, a := range c {
+					b := c[(i+1)%len(c)]
+					if a == b {
+						continue
+					}
+					var pts []Pt
+					for _, e := range []Pt{a, b} {
+						for _, d := range pen {
+							pts = append(pts, Pt{X: e.X + d.X, Y: e.Y + d.Y})
+						}
+					}
+					hull := convexHull(pts)
+					result = append(result, Region(append(hull, hull[0])))
+				}
+			}
+		}
+	}
+	return result
+}
+
+// closestPoint returns the point of the segment ab closest to pt.
+func closestPoint(pt, a, b Pt) Pt {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = math.Max(0, math.Min(1, ((pt.X-a.X)*dx+(pt.Y-a.Y)*dy)/l))
+	}
+	return Pt{X: a.X + t*dx, Y: a.Y + t*dy}
+}
@@ -0,0 +1,80 @@
+package gerber
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestLayer_Heal(t *testing.T) {
+	tile := func(x0, x1 float64) *RegionT {
+		return Region([]Pt{{X: x0, Y: 0}, {X: x1, Y: 0}, {X: x1, Y: 1}, {X: x0, Y: 1}})
+	}
+	g := New("heal")
+	l := g.TopCopper()
+	l.Add(
+		tile(0, 1),
+		tile(1.0003, 2),
+		Flash(1, 2, CircleShape, 0.5),
+		tile(2.0002, 3),
+		tile(3.1, 4), // a real gap
+	)
+	changes := g.Heal(0.001)
+	if len(changes) != 1 || !strings.Contains(changes[0], "primitive #0: merged primitives #0, #1, #3 across gaps of up to 0.0003mm") {
+		t.Fatalf("Heal = %q, want one merge of #0, #1 and #3", changes)
+	}
+	if len(l.Primitives) != 3 {
+		t.Fatalf("got %v primitives, want the merged region, the flash and the last tile", len(l.Primitives))
+	}
+	r, ok := l.Primitives[0].(*RegionT)
+	if min, max, _ := bounds(plot(l.Primitives[0])); !ok || len(r.outline) != 5 || min != (Pt{}) || max != (Pt{X: 3, Y: 1}) {
+		t.Errorf("merged = %+v (%v..%v), want the rectangle from {0 0} to {3 1}", l.Primitives[0], min, max)
+	}
+	if _, ok := l.Primitives[1].(*FlashT); !ok {
+		t.Errorf("primitive #1 = %T, want the flash", l.Primitives[1])
+	}
+	if changes := l.Heal(0.001); changes != nil {
+		t.Errorf("second Heal = %q, want no changes", changes)
+	}
+
+	// Tiles separated by clear polarity or within objects are left alone.
+	l = New("heal").TopCopper()
+	l.Add(
+		tile(0, 1),
+		Region([]Pt{{X: 5, Y: 0}, {X: 6, Y: 0}, {X: 6, Y: 1}}, []Pt{{X: 5.5, Y: 0.1}, {X: 5.9, Y: 0.1}, {X: 5.9, Y: 0.5}}),
+		tile(1.0003, 2),
+		Object(tile(2.0002, 3)).Net("GND"),
+	)
+	if changes := l.Heal(0.001); changes != nil || len(l.Primitives) != 4 {
+		t.Errorf("Heal = %q, want no changes", changes)
+	}
+}
+
+func TestLayer_Heal_flashes(t *testing.T) {
+	// A grid of square pads and a trace, each a hairline apart.
+	l := New("heal").TopCopper()
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			l.Add(Flash(1.0003*float64(i), 1.0002*float64(j), RectShape, 1))
+		}
+	}
+	l.Add(Line(2.5004, 0, 5, 0, RectShape, 1))
+	want, err := l.Area(1016)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := l.Heal(0.001)
+	if len(changes) != 1 || !strings.Contains(changes[0], "merged primitives #0, #1, #2, #3, #4, #5, #6, #7, #8, #9") {
+		t.Fatalf("Heal = %q, want one merge of all primitives", changes)
+	}
+	if len(l.Primitives) != 1 {
+		t.Fatalf("got %v primitives, want one region", len(l.Primitives))
+	}
+	r, ok := l.Primitives[0].(*RegionT)
+	if !ok || len(r.cutouts) != 0 {
+		t.Fatalf("merged = %+v, want a region without cutouts", l.Primitives[0])
+	}
+	if got, err := l.Area(1016); err != nil || math.Abs(got-want) > 0.05 {
+		t.Errorf("merged area = %v, %v, want %v", got, err, want)
+	}
+}
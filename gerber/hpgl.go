@@ -0,0 +1,56 @@
+package gerber
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+const (
+	hpglUnitsPerMM = 40 // HPGL plotter units are 0.025mm
+)
+
+// WriteHPGL writes the outlines of all the shapes in the layer as HPGL
+// cut paths, for cutting vinyl resist masks or stencils on a plotter
+// or vinyl cutter.
+// Shapes are cut separately: overlapping shapes are not merged.
+func (l *Layer) WriteHPGL(w io.Writer) error {
+	io.WriteString(w, "IN;SP1;\n")
+	for _, o := range l.plot() {
+		for _, c := range contours(o) {
+			writeHPGLPath(w, c)
+		}
+	}
+	io.WriteString(w, "PU;SP0;\n")
+	return nil
+}
+
+// WriteHPGLFile writes the layer as HPGL cut paths to the named file.
+func (l *Layer) WriteHPGLFile(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := l.WriteHPGL(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeHPGLPath writes a single path: pen up to its start, then pen down along it.
+func writeHPGLPath(w io.Writer, pts []Pt) {
+	if len(pts) < 2 {
+		return
+	}
+	units := func(pt Pt) string {
+		return fmt.Sprintf("%d,%d", int64(math.Round(pt.X*hpglUnitsPerMM)), int64(math.Round(pt.Y*hpglUnitsPerMM)))
+	}
+	var down []string
+	for _, pt := range pts[1:] {
+		down = append(down, units(pt))
+	}
+	fmt.Fprintf(w, "PU%v;PD%v;\n", units(pts[0]), strings.Join(down, ","))
+}
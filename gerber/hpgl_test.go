@@ -0,0 +1,33 @@
+package gerber
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLayer_WriteHPGL(t *testing.T) {
+	l := New("board").TopCopper()
+	l.Add(
+		Line(0, 0, 10, 0, RectShape, 1),
+		Polygon(0, 0, true, []Pt{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 0}}, 0),
+	)
+	var buf bytes.Buffer
+	if err := l.WriteHPGL(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "IN;SP1;\n" +
+		"PU-20,-20;PD420,-20,420,20,-20,20,-20,-20;\n" +
+		"PU0,0;PD40,0,40,40,0,0;\n" +
+		"PU;SP0;\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteHPGL =\n%v\nwant\n%v", got, want)
+	}
+
+	buf.Reset()
+	l.Add(Circle(0, 0, 2))
+	l.WriteHPGL(&buf)
+	if n := strings.Count(buf.String(), "PU"); n != 4 {
+		t.Errorf("got %v paths, want 3 plus the final pen up", n)
+	}
+}
@@ -0,0 +1,136 @@
+package gerber
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// testPoint is a pad or hole tagged with a net, for the IPC-D-356A netlist.
+type testPoint struct {
+	net, refdes    string
+	center, size   Pt
+	drill          float64 // zero for surface pads
+	access         int     // 0 for both sides, else the copper layer number
+	throughMatched bool    // a surface pad already covered by a hole
+}
+
+// WriteIPC356 writes an IPC-D-356A netlist of the design for electrical
+// testing by the fab. Test points are the primitives tagged with a net
+// (see ObjectT.Net): holes on the drill layer become through-hole records
+// (with the size of the matching outer copper pad) and pads on the outer
+// copper layers become surface mount records, including those of the
+// pads of the design (see Gerber.AddPad). Objects tagged with a
+// component (or a pin) use it as their reference designator; others are
+// vias.
+// Dimensions are written in microns (UNITS CUST 1).
+func (g *Gerber) WriteIPC356(w io.Writer) error {
+	var holes, pads []*testPoint
+	copper := g.layerCount()
+	if copper < 2 {
+		copper = 2
+	}
+	for _, l := range g.withDerived() {
+		ext := l.extension()
+		access := 0
+		switch ext {
+		case "gtl":
+			access = 1
+		case "gbl":
+			access = copper
+		case "xln":
+		default:
+			continue
+		}
+		for _, p := range l.Primitives {
+			if p = forVariant(p, g.Variant); p == nil {
+				continue
+			}
+			o, ok := p.(*ObjectT)
+			if !ok || o.net == "" {
+				continue
+			}
+			min, max, ok := bounds(plot(o.p))
+			if !ok {
+				continue
+			}
+			tp := &testPoint{
+				net:    o.net,
+				refdes: o.component,
+				center: Pt{X: 0.5 * (min.X + max.X), Y: 0.5 * (min.Y + max.Y)},
+				size:   Pt{X: max.X - min.X, Y: max.Y - min.Y},
+				access: access,
+			}
+			if tp.refdes == "" && len(o.pin) == 2 {
+				tp.refdes = o.pin[0]
+			}
+			if tp.refdes == "" {
+				tp.refdes = "VIA"
+			}
+			if ext == "xln" {
+				tp.drill = tp.size.X
+				holes = append(holes, tp)
+				continue
+			}
+			pads = append(pads, tp)
+		}
+	}
+	for _, h := range holes {
+		for _, p := range pads {
+			if p.net == h.net && math.Hypot(p.center.X-h.center.X, p.center.Y-h.center.Y) < 0.001 {
+				h.size = Pt{X: math.Max(h.size.X, p.size.X), Y: math.Max(h.size.Y, p.size.Y)}
+				p.throughMatched = true
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "C  IPC-D-356A netlist\n")
+	fmt.Fprintf(w, "P  JOB   %v\n", g.FilenamePrefix)
+	io.WriteString(w, "P  UNITS CUST 1\n")
+
+	// Net names longer than 14 characters are replaced by aliases.
+	names := map[string]string{}
+	var long []string
+	for _, tp := range append(holes, pads...) {
+		if len(tp.net) > 14 && names[tp.net] == "" {
+			names[tp.net] = "x"
+			long = append(long, tp.net)
+		}
+	}
+	sort.Strings(long)
+	for i, net := range long {
+		names[net] = fmt.Sprintf("NNAME%v", i+1)
+		fmt.Fprintf(w, "P  %-8v%v\n", names[net], net)
+	}
+	netName := func(net string) string {
+		if alias, ok := names[net]; ok {
+			return alias
+		}
+		return net
+	}
+
+	for _, h := range holes {
+		io.WriteString(w, ipcRecord(317, netName(h.net), h.refdes, h.drill, 0, h.center, h.size)+"\n")
+	}
+	for _, p := range pads {
+		if !p.throughMatched {
+			io.WriteString(w, ipcRecord(327, netName(p.net), p.refdes, 0, p.access, p.center, p.size)+"\n")
+		}
+	}
+	io.WriteString(w, "999\n")
+	return nil
+}
+
+// ipcRecord formats a fixed-column IPC-D-356A test record in microns.
+func ipcRecord(code int, net, refdes string, drill float64, access int, center, size Pt) string {
+	um := func(mm float64) int64 {
+		return int64(math.Round(1000 * mm))
+	}
+	hole := "      "
+	if drill > 0 {
+		hole = fmt.Sprintf("D%04dP", um(drill))
+	}
+	return fmt.Sprintf("%03d%-14.14s   %-6.6s %-4.4s %vA%02dX%+07dY%+07dX%04dY%04dR000 S0",
+		code, net, refdes, "", hole, access, um(center.X), um(center.Y), um(size.X), um(size.Y))
+}
@@ -0,0 +1,43 @@
+package gerber
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGerber_WriteIPC356(t *testing.T) {
+	g := New("board")
+	top, bottom, drill := g.TopCopper(), g.BottomCopper(), g.Drill()
+	top.Add(
+		Object(Line(1, 2, 1, 2, RectShape, 0.6)).Component("U1").Net("SDA"),
+		Object(Circle(5, 5, 0.6)).Net("GND"),
+		Line(0, 0, 5, 5, CircleShape, 0.2), // untagged trace
+	)
+	bottom.Add(Object(Circle(5, 5, 0.6)).Net("GND"))
+	drill.Add(Object(Circle(5, 5, 0.3)).Net("GND"))
+	top.Add(Object(Circle(8, 1, 1)).Net("A_VERY_LONG_NET_NAME"))
+
+	var buf bytes.Buffer
+	if err := g.WriteIPC356(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"C  IPC-D-356A netlist",
+		"P  JOB   board",
+		"P  UNITS CUST 1",
+		"P  NNAME1  A_VERY_LONG_NET_NAME",
+		"317GND              VIA         D0300PA00X+005000Y+005000X0600Y0600R000 S0",
+		"327SDA              U1                A01X+001000Y+002000X0600Y0600R000 S0",
+		"327NNAME1           VIA               A01X+008000Y+001000X1000Y1000R000 S0",
+		"999",
+	}, "\n") + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteIPC356 =\n%v\nwant\n%v", got, want)
+	}
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, "3") && len(line) != 74 {
+			t.Errorf("record %q is %v columns, want 74", line, len(line))
+		}
+	}
+}
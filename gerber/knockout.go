@@ -0,0 +1,70 @@
+package gerber
+
+import (
+	"io"
+	"math"
+)
+
+// KnockoutT represents text knocked out of a filled (optionally rounded)
+// rectangle (i.e. inverse text) and satisfies the Primitive interface.
+type KnockoutT struct {
+	text *TextT
+	box  *PolygonT
+}
+
+// TextKnockout returns a primitive that fills the bounding box of the text,
+// enlarged by margin on all sides and with corners rounded by radius,
+// and subtracts the text from it.
+// All dimensions are in millimeters.
+func TextKnockout(text *TextT, margin, radius float64) *KnockoutT {
+	min, max, _ := bounds(plot(text))
+	min = Pt{X: min.X - margin, Y: min.Y - margin}
+	max = Pt{X: max.X + margin, Y: max.Y + margin}
+	knockout := *text
+	knockout.knockout = true
+	return &KnockoutT{
+		text: &knockout,
+		box:  Polygon(0, 0, true, roundedRect(min, max, radius), 0),
+	}
+}
+
+// WriteGerber writes the primitive to the Gerber file.
+func (k *KnockoutT) WriteGerber(w io.Writer, apertureIndex int) error {
+	if err := k.box.WriteGerber(w, apertureIndex); err != nil {
+		return err
+	}
+	return k.text.WriteGerber(w, apertureIndex)
+}
+
+// Aperture returns the aperture of the knocked out text.
+func (k *KnockoutT) Aperture() *Aperture {
+	return k.text.Aperture()
+}
+
+// roundedRect returns the closed counterclockwise contour of a rectangle
+// with corners rounded by radius.
+func roundedRect(min, max Pt, radius float64) []Pt {
+	radius = math.Min(radius, 0.5*math.Min(max.X-min.X, max.Y-min.Y))
+	if radius <= 0 {
+		return []Pt{min, {X: max.X, Y: min.Y}, max, {X: min.X, Y: max.Y}, min}
+	}
+	corners := []struct {
+		c     Pt
+		start float64
+	}{
+		{Pt{X: max.X - radius, Y: min.Y + radius}, -0.5 * math.Pi},
+		{Pt{X: max.X - radius, Y: max.Y - radius}, 0},
+		{Pt{X: min.X + radius, Y: max.Y - radius}, 0.5 * math.Pi},
+		{Pt{X: min.X + radius, Y: min.Y + radius}, math.Pi},
+	}
+	// Resolution of corner segments is 0.1mm.
+	steps := int(0.5+0.5*math.Pi*radius*10.0) + 1
+	var pts []Pt
+	for _, corner := range corners {
+		for i := 0; i <= steps; i++ {
+			angle := corner.start + 0.5*math.Pi*float64(i)/float64(steps)
+			pts = append(pts, Pt{X: corner.c.X + radius*math.Cos(angle), Y: corner.c.Y + radius*math.Sin(angle)})
+		}
+	}
+	return append(pts, pts[0])
+}
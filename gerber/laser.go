@@ -0,0 +1,126 @@
+package gerber
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// lightBurnColors are the colors of the first LightBurn layers (C00-C07),
+// which it assigns to imported SVG shapes by their color.
+var lightBurnColors = []string{"#000000", "#0000FF", "#FF0000", "#00E000", "#D0D000", "#FF8000", "#00E0E0", "#FF00FF"}
+
+// LaserLayer maps a layer to a laser engraving layer.
+type LaserLayer struct {
+	Layer *Layer
+	// Power is the laser power in percent.
+	Power float64
+	// Speed is the engraving speed in mm/s.
+	Speed float64
+}
+
+// WriteLaserSVG writes the layers as a LightBurn-compatible SVG for
+// laser-ablating resist: each layer is a group of filled shapes in the
+// color of a separate LightBurn layer (C00, C01, ...), labeled with its
+// power and speed. Shapes are filled with the nonzero rule, so that
+// overlapping dark primitives are engraved as their union. Clear
+// polarity is subtracted from what the layer drew before it (see
+// Union): the clear areas within primitives (such as the counters of
+// glyphs) and between them are holes in the shapes.
+// Coordinates are in millimeters with the Y axis pointing up, as in Gerber.
+func WriteLaserSVG(w io.Writer, layers ...*LaserLayer) error {
+	if len(layers) > len(lightBurnColors) {
+		return fmt.Errorf("too many laser layers: %v (maximum %v)", len(layers), len(lightBurnColors))
+	}
+	// The contours of the shapes of each layer, by shape.
+	shapes := make([][][][]Pt, len(layers))
+	var all []op
+	for i, ll := range layers {
+		var prims []Primitive
+		var ops [][]op
+		var clear bool
+		for _, p := range ll.Layer.Primitives {
+			if p = forVariant(p, ll.Layer.variant()); p == nil {
+				continue
+			}
+			prims = append(prims, p)
+			ops = append(ops, plot(p))
+			for _, o := range ops[len(ops)-1] {
+				clear = clear || o.clear
+			}
+			all = append(all, ops[len(ops)-1]...)
+		}
+		if !clear {
+			// Without clear polarity the primitives are drawn as they are,
+			// counterclockwise so that they add up.
+			for _, ops := range ops {
+				var shape [][]Pt
+				for _, o := range ops {
+					for _, c := range contours(o) {
+						if signedArea(c) < 0 {
+							c = reversed(c)
+						}
+						shape = append(shape, c)
+					}
+				}
+				if len(shape) > 0 {
+					shapes[i] = append(shapes[i], shape)
+				}
+			}
+			continue
+		}
+		regions, err := Union(prims...)
+		if err != nil {
+			return fmt.Errorf("%v: %v", ll.Layer.Filename, err)
+		}
+		// The holes of the regions run clockwise.
+		for _, p := range regions {
+			r := p.(*RegionT)
+			var shape [][]Pt
+			for _, c := range append([][]point{r.outline}, r.cutouts...) {
+				pts := make([]Pt, len(c))
+				for j, pt := range c {
+					pts[j] = pt.pt()
+				}
+				shape = append(shape, pts)
+			}
+			shapes[i] = append(shapes[i], shape)
+		}
+	}
+	min, max, _ := bounds(all)
+	if len(all) == 0 {
+		min, max = Pt{}, Pt{}
+	}
+
+	io.WriteString(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%.3fmm\" height=\"%.3fmm\" viewBox=\"%.4f %.4f %.4f %.4f\">\n",
+		max.X-min.X, max.Y-min.Y, min.X, -max.Y, max.X-min.X, max.Y-min.Y)
+	for i, ll := range layers {
+		fmt.Fprintf(w, "<!-- %v: LightBurn layer C%02d, power %g%%, speed %gmm/s -->\n", ll.Layer.Filename, i, ll.Power, ll.Speed)
+		fmt.Fprintf(w, "<g id=\"%v\" fill=\"%v\" stroke=\"none\" fill-rule=\"nonzero\" data-power=\"%g\" data-speed=\"%g\">\n", ll.Layer.extension(), lightBurnColors[i], ll.Power, ll.Speed)
+		for _, shape := range shapes[i] {
+			var path []string
+			for _, c := range shape {
+				path = append(path, svgPath(c))
+			}
+			fmt.Fprintf(w, "<path d=\"%v\"/>\n", strings.Join(path, ""))
+		}
+		io.WriteString(w, "</g>\n")
+	}
+	io.WriteString(w, "</svg>\n")
+	return nil
+}
+
+// svgPath returns the SVG path data of a closed contour, flipping the Y axis.
+func svgPath(pts []Pt) string {
+	var b strings.Builder
+	for i, pt := range pts {
+		cmd := "L"
+		if i == 0 {
+			cmd = "M"
+		}
+		fmt.Fprintf(&b, "%v%.4f %.4f", cmd, pt.X, 0-pt.Y) // 0-y avoids printing -0
+	}
+	b.WriteString("Z")
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package gerber
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteLaserSVG(t *testing.T) {
+	g := New("board")
+	top, bottom := g.TopCopper(), g.BottomCopper()
+	top.Add(Polygon(0, 0, true, []Pt{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 1}, {X: 0, Y: 0}}, 0))
+	bottom.Add(Text(0, 0, 1, "o", "latoregular", 12))
+
+	var buf bytes.Buffer
+	if err := WriteLaserSVG(&buf, &LaserLayer{Layer: top, Power: 20, Speed: 100}, &LaserLayer{Layer: bottom, Power: 30, Speed: 80}); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		`<g id="gtl" fill="#000000" stroke="none" fill-rule="nonzero" data-power="20" data-speed="100">`,
+		`<path d="M0.0000 0.0000L2.0000 0.0000L2.0000 -1.0000L0.0000 0.0000Z"/>`,
+		`<g id="gbl" fill="#0000FF"`,
+		"power 30%, speed 80mm/s",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%v", want, got)
+		}
+	}
+	// The counter of the "o" is a hole in the same path.
+	if i := strings.Index(got, `<g id="gbl"`); strings.Count(got[i:], "<path") != 1 || strings.Count(got[i:], "Z") != 2 {
+		t.Errorf("glyph must be a single path with two contours:\n%v", got[i:])
+	}
+}
+
+func TestWriteLaserSVG_clear(t *testing.T) {
+	g := New("board")
+	top := g.TopCopper()
+	square := func(x, y, d float64) []Pt {
+		return []Pt{{X: x - d, Y: y - d}, {X: x + d, Y: y - d}, {X: x + d, Y: y + d}, {X: x - d, Y: y + d}}
+	}
+	top.Add(
+		Region(square(0, 0, 2), square(0, 0, 1)), // cuts its hole in clear polarity
+		Flash(0, 0, RectShape, 1),                // an island within the hole
+	)
+
+	var buf bytes.Buffer
+	if err := WriteLaserSVG(&buf, &LaserLayer{Layer: top, Power: 20, Speed: 100}); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		// The hole runs opposite to the outline for the nonzero rule.
+		`<path d="M-2.0000 2.0000L2.0000 2.0000L2.0000 -2.0000L-2.0000 -2.0000L-2.0000 2.0000ZM-1.0000 1.0000L-1.0000 -1.0000L1.0000 -1.0000L1.0000 1.0000L-1.0000 1.0000Z"/>`,
+		`<path d="M-0.5000 0.5000L0.5000 0.5000L0.5000 -0.5000L-0.5000 -0.5000L-0.5000 0.5000Z"/>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%v", want, got)
+		}
+	}
+	if n := strings.Count(got, "<path"); n != 2 {
+		t.Errorf("got %v paths, want the ring and the island:\n%v", n, got)
+	}
+}
@@ -1,7 +1,10 @@
 package gerber
 
 import (
+	"errors"
+	"fmt"
 	"io"
+	"sort"
 )
 
 // Layer represents a printed circuit board layer.
@@ -12,55 +15,285 @@ type Layer struct {
 	Primitives []Primitive
 	// Apertures represents the apertures used in the layer.
 	Apertures []*Aperture
+	// Format, when set, overrides the coordinate format of the design
+	// for this layer (e.g. 3.3 for the outline and 4.6 for copper).
+	Format Format
+	// Units, when set, overrides the units of the design for this layer.
+	Units Units
+	// Attributes are the Gerber X2 file attributes of the layer.
+	Attributes Attributes
 
 	// apertureMap maps an aperture to its index in the Apertures slice.
 	apertureMap map[string]int
+	// callers records the call site that added each primitive
+	// when the design has Provenance enabled.
+	callers []string
 	// g is the root Gerber object.
 	g *Gerber
+	// spec describes the kind of layer, if it was added by name.
+	spec *LayerSpec
+}
+
+// LayerSpec describes a kind of layer.
+type LayerSpec struct {
+	// Name is the name of the layer (e.g. "TopGlue").
+	Name string
+	// Extension is the filename extension of the layer (e.g. "gtg").
+	Extension string
+	// Negative, when true, marks the layer's image as negative
+	// (e.g. a peelable mask where dark means no material).
+	Negative bool
+	// FileFunction is the Gerber X2 file function (e.g. "Glue,Top").
+	// When set, it is emitted with the file polarity as X2 file attributes.
+	FileFunction string
+}
+
+// layerSpecs are the registered kinds of layers, by name.
+var layerSpecs = map[string]*LayerSpec{}
+
+func init() {
+	for _, spec := range []*LayerSpec{
+		{Name: "TopCopper", Extension: "gtl"},
+		{Name: "TopSolderMask", Extension: "gts"},
+		{Name: "TopSilkscreen", Extension: "gto"},
+		{Name: "TopPaste", Extension: "gtp"},
+		{Name: "BottomCopper", Extension: "gbl"},
+		{Name: "BottomSolderMask", Extension: "gbs"},
+		{Name: "BottomSilkscreen", Extension: "gbo"},
+		{Name: "BottomPaste", Extension: "gbp"},
+		{Name: "Layer2", Extension: "g2l"},
+		{Name: "Layer3", Extension: "g3l"},
+		{Name: "Drill", Extension: "xln"},
+		{Name: "NonPlatedDrill", Extension: "nxln"},
+		{Name: "Outline", Extension: "gko"},
+		{Name: "Stiffener", Extension: "gst", FileFunction: "Other,Stiffener"},
+		{Name: "VScore", Extension: "gvs", FileFunction: "Other,V-Score"},
+	} {
+		layerSpecs[spec.Name] = spec
+	}
+}
+
+// RegisterLayer registers a custom kind of layer (e.g. "CarbonPrint")
+// so that it can be added to designs with AddLayer.
+func RegisterLayer(spec *LayerSpec) error {
+	if spec.Name == "" || spec.Extension == "" {
+		return fmt.Errorf("layer spec %+v: name and extension are required", spec)
+	}
+	if _, ok := layerSpecs[spec.Name]; ok {
+		return fmt.Errorf("layer %q already registered", spec.Name)
+	}
+	for _, other := range layerSpecs {
+		if other.Extension == spec.Extension {
+			return fmt.Errorf("layer %q: extension %q already used by layer %q", spec.Name, spec.Extension, other.Name)
+		}
+	}
+	layerSpecs[spec.Name] = spec
+	return nil
+}
+
+// LayerExtensions returns the sorted filename extensions of all the
+// registered kinds of layers.
+func LayerExtensions() []string {
+	var exts []string
+	for _, spec := range layerSpecs {
+		exts = append(exts, spec.Extension)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+// AddLayer adds a layer of the named registered kind to the design
+// and returns the layer.
+func (g *Gerber) AddLayer(name string) (*Layer, error) {
+	spec, ok := layerSpecs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown layer %q", name)
+	}
+	layer := g.makeLayer(spec.Extension)
+	layer.spec = spec
+	return layer, nil
 }
 
 // Add adds primitives to a layer.
 // It generates new apertures as necessary.
 func (l *Layer) Add(primitives ...Primitive) {
 	for _, p := range primitives {
-		a := p.Aperture()
-		if a == nil {
-			continue // use the default layer
+		for _, a := range apertures(p) {
+			id := a.ID()
+			if _, ok := l.apertureMap[id]; ok {
+				continue
+			}
+			l.apertureMap[id] = len(l.Apertures)
+			l.Apertures = append(l.Apertures, a)
 		}
-		id := a.ID()
-		if _, ok := l.apertureMap[id]; ok {
-			continue
+	}
+	if l.g != nil && l.g.Provenance {
+		for len(l.callers) < len(l.Primitives) {
+			l.callers = append(l.callers, "")
+		}
+		site := caller(1)
+		for range primitives {
+			l.callers = append(l.callers, site)
 		}
-		l.apertureMap[id] = len(l.Apertures)
-		l.Apertures = append(l.Apertures, a)
 	}
+	start := len(l.Primitives)
 	l.Primitives = append(l.Primitives, primitives...)
+	l.warnOffGrid(start)
 }
 
 // WriteGerber writes a layer to its corresponding Gerber layer file.
+// The region contours of every primitive are checked before it is
+// written (see ValidateRegions): invalid ones are logged, or returned
+// as an *Error in strict mode (see Gerber.Strict).
 func (l *Layer) WriteGerber(w io.Writer) error {
-	io.WriteString(w, "%FSLAX36Y36*%\n")
-	io.WriteString(w, "%MOMM*%\n")
-	io.WriteString(w, "%LPD*%\n")
+	fw, err := l.newWriter(w)
+	if err != nil {
+		return err
+	}
+	w = fw
 
-	io.WriteString(w, "%ADD11C,0.00100*%\n")
+	l.writeHeader(fw)
+	writeMacros(w, l.Apertures)
+	defaultAperture.WriteGerber(w, 11)
 	for i, a := range l.Apertures {
 		a.WriteGerber(w, 12+i)
 	}
 
-	for _, p := range l.Primitives {
+	uuids := map[string]int{}
+	for i, p := range l.Primitives {
+		if p = forVariant(p, l.variant()); p == nil {
+			continue
+		}
+		if errs := regionErrors(p); len(errs) > 0 {
+			err := l.wrapErr(i, errors.Join(errs...))
+			if fw.strict {
+				return err
+			}
+			logger.Warn("invalid region", "error", err)
+		}
 		ai := l.apertureMap[p.Aperture().ID()]
-		p.WriteGerber(w, 12+ai)
+		if l.g != nil && l.g.UniqueIDs {
+			p = withUUID(p, l.Filename, uuids)
+		}
+		if err := p.WriteGerber(w, 12+ai); err != nil {
+			return l.wrapErr(i, err)
+		}
+		if fw.err != nil {
+			return l.wrapErr(i, fw.err)
+		}
 	}
+	if fw.strict && l.g.PlacementGrid > 0 {
+		if pads := l.offGrid(0, l.g.PlacementGrid); len(pads) > 0 {
+			return fmt.Errorf("off-grid pad: %v", pads[0])
+		}
+	}
+	return l.writeTrailer(fw)
+}
+
+// writeHeader writes the attributes, coordinate format, units and
+// polarity of the layer.
+func (l *Layer) writeHeader(fw *writer) {
+	l.writeAttributes(fw)
+	fmt.Fprintf(fw, "%%FSLAX%[1]v%[2]vY%[1]v%[2]v*%%\n", fw.format.Integer, fw.format.Decimal)
+	fmt.Fprintf(fw, "%%MO%v*%%\n", fw.units)
+	io.WriteString(fw, "%LPD*%\n")
+}
 
-	io.WriteString(w, "M02*\n")
+// writeTrailer ends the layer file and checks the deviation of its
+// coordinates.
+func (l *Layer) writeTrailer(fw *writer) error {
+	io.WriteString(fw, "M02*\n")
+
+	if l.g != nil && l.g.MaxDeviation > 0 && fw.maxDev.mm() > l.g.MaxDeviation {
+		return fmt.Errorf("%v: coordinate deviation %vmm exceeds maximum of %vmm", l.Filename, fw.maxDev.mm(), l.g.MaxDeviation)
+	}
 	return nil
 }
 
+// newWriter returns a writer with the coordinate format and units of the
+// layer, which override those of the design.
+func (l *Layer) newWriter(w io.Writer) (*writer, error) {
+	f, u := DefaultFormat, Millimeters
+	if l.g != nil && l.g.Format != (Format{}) {
+		f = l.g.Format
+	}
+	if l.g != nil && l.g.Units != "" {
+		u = l.g.Units
+	}
+	if l.Format != (Format{}) {
+		f = l.Format
+	}
+	if l.Units != "" {
+		u = l.Units
+	}
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+	if err := u.validate(); err != nil {
+		return nil, err
+	}
+	var rev Revision
+	var strict bool
+	if l.g != nil {
+		rev, strict = l.g.Revision, l.g.Strict
+	}
+	if err := rev.validate(); err != nil {
+		return nil, err
+	}
+	codes := map[string]int{}
+	for id, i := range l.apertureMap {
+		codes[id] = 12 + i // The default aperture (-1) is D11.
+	}
+	return &writer{Writer: w, format: f, units: u, revision: rev, strict: strict, codes: codes, nextCode: 12 + len(l.Apertures)}, nil
+}
+
+// layerCount returns the number of copper layers of the design.
+func (g *Gerber) layerCount() int {
+	var n int
+	for _, l := range g.Layers {
+		if l.copper() {
+			n++
+		}
+	}
+	return n
+}
+
+// variant returns the variant of the layer's design to write
+// (empty for layers without a design).
+func (l *Layer) variant() string {
+	if l.g == nil {
+		return ""
+	}
+	return l.g.Variant
+}
+
+// copper reports whether the layer is a copper layer
+// (including inner and negative plane layers).
+func (l *Layer) copper() bool {
+	return copperExtension(l.extension())
+}
+
+// copperExtension reports whether ext is the extension of a copper layer.
+func copperExtension(ext string) bool {
+	if ext == "gtl" || ext == "gbl" {
+		return true
+	}
+	if len(ext) < 3 || ext[0] != 'g' || ext[len(ext)-1] != 'l' {
+		return false
+	}
+	for _, c := range ext[1 : len(ext)-1] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 func (g *Gerber) makeLayer(extension string) *Layer {
 	layer := &Layer{
 		Filename:    g.FilenamePrefix + "." + extension,
 		apertureMap: map[string]int{"default": -1},
+		g:           g,
 	}
 	g.Layers = append(g.Layers, layer)
 	return layer
@@ -84,6 +317,12 @@ func (g *Gerber) TopSilkscreen() *Layer {
 	return g.makeLayer("gto")
 }
 
+// TopPaste adds a top solder paste layer to the design
+// and returns the layer.
+func (g *Gerber) TopPaste() *Layer {
+	return g.makeLayer("gtp")
+}
+
 // BottomCopper adds a bottom copper layer to the design
 // and returns the layer.
 func (g *Gerber) BottomCopper() *Layer {
@@ -102,6 +341,12 @@ func (g *Gerber) BottomSilkscreen() *Layer {
 	return g.makeLayer("gbo")
 }
 
+// BottomPaste adds a bottom solder paste layer to the design
+// and returns the layer.
+func (g *Gerber) BottomPaste() *Layer {
+	return g.makeLayer("gbp")
+}
+
 // Layer2 adds a layer-2 copper layer to a four-layer design
 // and returns the layer.
 func (g *Gerber) Layer2() *Layer {
@@ -114,14 +359,41 @@ func (g *Gerber) Layer3() *Layer {
 	return g.makeLayer("g3l")
 }
 
+// NegativePlane adds an inner copper plane layer n (e.g. 2 for "g2l")
+// drawn in negative convention to the design and returns the layer.
+// Everything dark on the layer is a clearance in the copper plane,
+// which fills the whole board.
+func (g *Gerber) NegativePlane(n int) *Layer {
+	layer := g.makeLayer(fmt.Sprintf("g%vl", n))
+	layer.spec = &LayerSpec{
+		Name:         fmt.Sprintf("Layer%vPlane", n),
+		Extension:    fmt.Sprintf("g%vl", n),
+		Negative:     true,
+		FileFunction: fmt.Sprintf("Copper,L%v,Inr,Plane", n),
+	}
+	return layer
+}
+
+// Negative reports whether the layer is drawn in negative convention.
+func (l *Layer) Negative() bool {
+	return l.spec != nil && l.spec.Negative
+}
+
 // Drill adds a drill layer to the design
 // and returns the layer.
 func (g *Gerber) Drill() *Layer {
 	return g.makeLayer("xln")
 }
 
-// Outline adds an outline layer to the design
+// NonPlatedDrill adds a non-plated drill layer to the design
 // and returns the layer.
+func (g *Gerber) NonPlatedDrill() *Layer {
+	return g.makeLayer("nxln")
+}
+
+// Outline adds an outline layer to the design
+// and returns the layer. It is written as the board profile, which can
+// be drawn with RoundedRect, CircleOutline, Stadium or RoundedPolygon.
 func (g *Gerber) Outline() *Layer {
 	return g.makeLayer("gko")
 }
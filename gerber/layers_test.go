@@ -0,0 +1,176 @@
+package gerber
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRegisterLayer(t *testing.T) {
+	spec := &LayerSpec{Name: "PeelableMask", Extension: "gpm", Negative: true, FileFunction: "Peelablemask,Top"}
+	if err := RegisterLayer(spec); err != nil {
+		t.Fatalf("RegisterLayer: %v", err)
+	}
+	defer delete(layerSpecs, spec.Name)
+
+	for _, bad := range []*LayerSpec{
+		{Name: "PeelableMask", Extension: "gpx"},
+		{Name: "Glue", Extension: "gtl"},
+		{Name: "NoExtension"},
+	} {
+		if err := RegisterLayer(bad); err == nil {
+			t.Errorf("RegisterLayer(%+v) = nil, want error", bad)
+		}
+	}
+
+	g := New("board")
+	g.Generator = &Generator{}
+	l, err := g.AddLayer("PeelableMask")
+	if err != nil {
+		t.Fatalf("AddLayer: %v", err)
+	}
+	if l.Filename != "board.gpm" {
+		t.Errorf("Filename = %q, want board.gpm", l.Filename)
+	}
+	var buf bytes.Buffer
+	if err := l.WriteGerber(&buf); err != nil {
+		t.Fatalf("WriteGerber: %v", err)
+	}
+	want := "%TF.FileFunction,Peelablemask,Top*%\n%TF.FilePolarity,Negative*%\n%TF.Part,Single*%\n%FSLAX"
+	if !strings.HasPrefix(buf.String(), want) {
+		t.Errorf("WriteGerber = %q, want prefix %q", buf.String(), want)
+	}
+
+	if _, err := g.AddLayer("CarbonPrint"); err == nil {
+		t.Error("AddLayer of an unregistered layer must fail")
+	}
+	if l, err := g.AddLayer("TopCopper"); err != nil || l.Filename != "board.gtl" {
+		t.Errorf("AddLayer(TopCopper) = %v, %v", l, err)
+	}
+}
+
+func TestLayer_FormatOverride(t *testing.T) {
+	g := New("board")
+	g.Format = Format{Integer: 4, Decimal: 6}
+	top := g.TopCopper()
+	outline := g.Outline()
+	outline.Format = Format{Integer: 3, Decimal: 3}
+	outline.Units = Inches
+	for _, l := range []*Layer{top, outline} {
+		l.Add(Line(0, 0, 25.4, 12.7, CircleShape, 0.254))
+	}
+
+	tests := []struct {
+		layer *Layer
+		want  []string
+	}{
+		{top, []string{"%FSLAX46Y46*%", "%MOMM*%", "%ADD12C,0.254000*%", "X25400000Y12700000D01*"}},
+		{outline, []string{"%FSLAX33Y33*%", "%MOIN*%", "%ADD11C,0.001*%", "%ADD12C,0.010*%", "X001000Y000500D01*"}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := tt.layer.WriteGerber(&buf); err != nil {
+			t.Fatalf("%v: WriteGerber: %v", tt.layer.Filename, err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("%v: missing %q in:\n%v", tt.layer.Filename, want, buf.String())
+			}
+		}
+	}
+
+	outline.Units = "FT"
+	if err := outline.WriteGerber(&bytes.Buffer{}); err == nil {
+		t.Error("WriteGerber with unsupported units must fail")
+	}
+}
+
+func TestLayer_Attributes(t *testing.T) {
+	g := New("board")
+	top, inner, bottom, drill := g.TopCopper(), g.Layer2(), g.BottomCopper(), g.Drill()
+	g.Layer3()
+	drill.Attributes = Attributes{Part: "Array", Custom: map[string]string{"ProjectId": "demo", ".SameCoordinates": "0"}}
+
+	tests := []struct {
+		layer *Layer
+		want  []string
+	}{
+		{top, []string{"%TF.FileFunction,Copper,L1,Top*%", "%TF.FilePolarity,Positive*%", "%TF.Part,Single*%", "%TF.GenerationSoftware,gmlewis,go-gerber"}},
+		{inner, []string{"%TF.FileFunction,Copper,L2,Inr*%"}},
+		{bottom, []string{"%TF.FileFunction,Copper,L4,Bot*%"}},
+		{drill, []string{"%TF.FileFunction,Plated,1,4,PTH*%", "%TF.Part,Array*%", "%TF.SameCoordinates,0*%\n%TFProjectId,demo*%"}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := tt.layer.WriteGerber(&buf); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("%v: missing %q in:\n%v", tt.layer.Filename, want, buf.String())
+			}
+		}
+	}
+}
+
+func TestGerber_Generator(t *testing.T) {
+	tests := []struct {
+		name      string
+		generator *Generator
+		want      string
+	}{
+		{name: "custom", generator: &Generator{Vendor: "Acme, Inc.", Application: "boards", Version: "1.2*"}, want: "%TF.GenerationSoftware,Acme Inc.,boards,1.2*%\n"},
+		{name: "no version", generator: &Generator{Application: "boards"}, want: "%TF.GenerationSoftware,,boards*%\n"},
+		{name: "disabled", generator: &Generator{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("board")
+			g.Generator = tt.generator
+			var buf bytes.Buffer
+			if err := g.TopCopper().WriteGerber(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == "" {
+				if strings.Contains(buf.String(), "GenerationSoftware") {
+					t.Errorf("unexpected GenerationSoftware attribute in:\n%v", buf.String())
+				}
+			} else if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("missing %q in:\n%v", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestGerber_Strict(t *testing.T) {
+	var logged bytes.Buffer
+	defer func(l Logger) { logger = l }(logger)
+	SetLogger(NewLogger(&logged, false))
+
+	tests := []struct {
+		name      string
+		primitive Primitive
+		grid      float64
+		want      string
+	}{
+		{name: "missing glyph", primitive: Text(0, 0, 1, "AΩ", "latoregular", 12), want: `board.gto: primitive #0: missing glyph (glyph='\u03a9')`},
+		{name: "missing font", primitive: Text(0, 0, 1, "A", "nosuchfont", 12), want: "board.gto: primitive #0: could not find font (font=nosuchfont)"},
+		{name: "off-grid pad", primitive: Flash(1, 1, CircleShape, 1), grid: 1.27, want: "off-grid pad: board.gto: primitive #0: pad at (1,1) is off the 1.27mm grid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("board")
+			g.PlacementGrid = tt.grid
+			silk := g.TopSilkscreen()
+			silk.Add(tt.primitive)
+			if err := silk.WriteGerber(&bytes.Buffer{}); err != nil {
+				t.Fatalf("WriteGerber = %v, want only a warning", err)
+			}
+			g.Strict = true
+			err := silk.WriteGerber(&bytes.Buffer{})
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("WriteGerber = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
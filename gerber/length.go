@@ -0,0 +1,87 @@
+package gerber
+
+import (
+	"math"
+	"sort"
+)
+
+// speedOfLight is the speed of light in vacuum in mm/ps.
+const speedOfLight = 0.299792458
+
+// NetLength is the routed length of a net.
+type NetLength struct {
+	// Net is the name of the net.
+	Net string
+	// Length is the routed length in mm: the total length of the traces
+	// tagged with the net (see ObjectT.Net) on the copper layers.
+	Length float64
+	// Delay is the estimated propagation delay in ps along the routed length.
+	Delay float64
+}
+
+// PairLength is the routed length of a differential pair.
+type PairLength struct {
+	// Pos and Neg are the lengths of the positive and negative nets.
+	Pos, Neg NetLength
+	// Skew is the length mismatch (Pos-Neg) in mm.
+	Skew float64
+	// DelaySkew is the propagation delay mismatch (Pos-Neg) in ps.
+	DelaySkew float64
+}
+
+// NetLengths returns the routed lengths of all the nets of the design,
+// sorted by net name. dk is the (effective) dielectric constant of the
+// stackup, used to estimate the propagation delay; a dk of 0 is vacuum.
+// Pads, vias and regions don't add to the length.
+func (g *Gerber) NetLengths(dk float64) []NetLength {
+	lengths := map[string]float64{}
+	for _, l := range g.Layers {
+		if !l.copper() {
+			continue
+		}
+		for _, p := range l.Primitives {
+			if p = forVariant(p, g.Variant); p == nil {
+				continue
+			}
+			o, ok := p.(*ObjectT)
+			if !ok || o.net == "" {
+				continue
+			}
+			for _, op := range plot(o.p) {
+				if op.code == drawOp {
+					lengths[o.net] += math.Hypot(op.pts[1].X-op.pts[0].X, op.pts[1].Y-op.pts[0].Y)
+				}
+			}
+		}
+	}
+	var result []NetLength
+	for net, length := range lengths {
+		result = append(result, newNetLength(net, length, dk))
+	}
+	sort.Slice(result, func(a, b int) bool { return result[a].Net < result[b].Net })
+	return result
+}
+
+// PairLength returns the routed lengths of the differential pair made of
+// the pos and neg nets. See NetLengths.
+func (g *Gerber) PairLength(pos, neg string, dk float64) PairLength {
+	pair := PairLength{Pos: newNetLength(pos, 0, dk), Neg: newNetLength(neg, 0, dk)}
+	for _, nl := range g.NetLengths(dk) {
+		switch nl.Net {
+		case pos:
+			pair.Pos = nl
+		case neg:
+			pair.Neg = nl
+		}
+	}
+	pair.Skew = pair.Pos.Length - pair.Neg.Length
+	pair.DelaySkew = pair.Pos.Delay - pair.Neg.Delay
+	return pair
+}
+
+func newNetLength(net string, length, dk float64) NetLength {
+	if dk < 1 {
+		dk = 1
+	}
+	return NetLength{Net: net, Length: length, Delay: length * math.Sqrt(dk) / speedOfLight}
+}
@@ -0,0 +1,44 @@
+package gerber
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGerber_NetLengths(t *testing.T) {
+	g := New("board")
+	top, inner, silk := g.TopCopper(), g.Layer2(), g.TopSilkscreen()
+	top.Add(
+		Object(Line(0, 0, 30, 40, CircleShape, 0.2)).Net("D+"),
+		Object(Circle(30, 40, 0.6)).Net("D+"), // pad
+		Object(Line(0, 1, 40, 1, CircleShape, 0.2)).Net("D-"),
+		Line(0, 0, 10, 0, CircleShape, 0.2), // untagged
+	)
+	inner.Add(Object(Line(40, 1, 40, 11, CircleShape, 0.2)).Net("D-"))
+	silk.Add(Object(Line(0, 0, 100, 0, CircleShape, 0.2)).Net("D+"))
+
+	got := g.NetLengths(4)
+	if len(got) != 2 || got[0].Net != "D+" || got[1].Net != "D-" {
+		t.Fatalf("NetLengths = %+v, want D+ and D-", got)
+	}
+	tests := []struct {
+		got, want float64
+	}{
+		{got[0].Length, 50},
+		{got[1].Length, 50},
+		{got[0].Delay, 50 * 2 / speedOfLight},
+	}
+	for i, tt := range tests {
+		if math.Abs(tt.got-tt.want) > 1e-6 {
+			t.Errorf("test #%v: got %v, want %v", i, tt.got, tt.want)
+		}
+	}
+
+	pair := g.PairLength("D+", "D-", 4)
+	if math.Abs(pair.Skew) > 1e-6 || math.Abs(pair.DelaySkew) > 1e-6 {
+		t.Errorf("PairLength skew = %v mm, %v ps, want 0", pair.Skew, pair.DelaySkew)
+	}
+	if pair := g.PairLength("D+", "NC", 1); pair.Neg.Length != 0 || math.Abs(pair.Skew-50) > 1e-6 {
+		t.Errorf("PairLength with unrouted net = %+v, want skew 50", pair)
+	}
+}
@@ -0,0 +1,55 @@
+package gerber
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Logger is the interface used by the package to report warnings
+// and errors. It is satisfied by *slog.Logger.
+type Logger interface {
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// logger is the Logger used by the package.
+var logger Logger = slog.Default()
+
+// SetLogger sets the logger used by the package.
+// A nil logger enables quiet mode, discarding all messages.
+func SetLogger(l Logger) {
+	if l == nil {
+		l = NewLogger(io.Discard, false)
+	}
+	logger = l
+}
+
+// NewLogger returns a structured logger that writes to w,
+// either as machine-readable JSON (e.g. for CI pipelines) or as text.
+func NewLogger(w io.Writer, json bool) Logger {
+	if json {
+		return slog.New(slog.NewJSONHandler(w, nil))
+	}
+	return slog.New(slog.NewTextHandler(w, nil))
+}
+
+// strictError records the warning as the error of the layer written to
+// w and returns true in strict mode (see Gerber.Strict). Otherwise, the
+// caller logs it.
+func strictError(w io.Writer, msg string, args ...any) bool {
+	fw, ok := w.(*writer)
+	if !ok || !fw.strict {
+		return false
+	}
+	if fw.err == nil {
+		var attrs []string
+		for i := 0; i+1 < len(args); i += 2 {
+			attrs = append(attrs, fmt.Sprintf("%v=%v", args[i], args[i+1]))
+		}
+		fw.err = fmt.Errorf("%v (%v)", msg, strings.Join(attrs, ", "))
+	}
+	return true
+}
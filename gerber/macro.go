@@ -0,0 +1,344 @@
+package gerber
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// outline is the shape of an aperture that Aperture cannot represent
+// (such as an oblong rectangle, an obround, a polygon or an aperture
+// macro) as contours relative to the aperture center in millimeters.
+type outline struct {
+	contours [][]Pt
+	clear    []bool // exposure off
+}
+
+// flash returns the regions of the outline flashed at c.
+func (o *outline) flash(c Pt, clear bool) []op {
+	var ops []op
+	for i, contour := range o.contours {
+		pts := make([]Pt, len(contour))
+		for j, pt := range contour {
+			pts[j] = Pt{X: c.X + pt.X, Y: c.Y + pt.Y}
+		}
+		ops = append(ops, op{code: regionOp, clear: clear != o.clear[i], pts: pts, more: i > 0})
+	}
+	return ops
+}
+
+// draw returns the region swept by the outline drawn from a to b,
+// approximated by the convex hull of the outline at both ends.
+func (o *outline) draw(a, b Pt, clear bool) op {
+	var pts []Pt
+	for i, contour := range o.contours {
+		if o.clear[i] {
+			continue
+		}
+		for _, pt := range contour {
+			pts = append(pts, Pt{X: a.X + pt.X, Y: a.Y + pt.Y}, Pt{X: b.X + pt.X, Y: b.Y + pt.Y})
+		}
+	}
+	return op{code: regionOp, clear: clear, pts: closeContour(convexHull(pts))}
+}
+
+// parseAD parses an aperture definition such as "%ADD12C,0.254*%" or
+// "%ADD13RoundRect,0.25X-0.5X-0.5X0.5X-0.5X0.5X0.5X-0.5X0.5*%" in units
+// of mm millimeters. Circles and squares are returned as apertures,
+// other shapes (including the macros by name) as outlines.
+func parseAD(line string, mm float64, macros map[string][]string) (int, *Aperture, *outline, bool) {
+	def := strings.TrimSuffix(strings.TrimPrefix(line, "%ADD"), "*%")
+	i := 0
+	for i < len(def) && def[i] >= '0' && def[i] <= '9' {
+		i++
+	}
+	n, err := strconv.Atoi(def[:i])
+	if err != nil {
+		return 0, nil, nil, false
+	}
+	name, args, _ := strings.Cut(def[i:], ",")
+	var params []float64
+	if args != "" {
+		for _, s := range strings.Split(args, "X") {
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return 0, nil, nil, false
+			}
+			params = append(params, v)
+		}
+	}
+	param := func(i int) float64 {
+		if i < len(params) {
+			return params[i] * mm
+		}
+		return 0
+	}
+
+	switch name {
+	case "C":
+		return n, &Aperture{Shape: CircleShape, Size: param(0)}, nil, len(params) > 0
+	case "R", "O":
+		w, h := param(0), param(1)
+		if len(params) < 2 || w == h {
+			shape := RectShape
+			if name == "O" {
+				shape = CircleShape
+			}
+			return n, &Aperture{Shape: shape, Size: w}, nil, len(params) > 0
+		}
+		rect := []Pt{{X: -w / 2, Y: -h / 2}, {X: w / 2, Y: -h / 2}, {X: w / 2, Y: h / 2}, {X: -w / 2, Y: h / 2}}
+		if name == "R" {
+			return n, nil, &outline{contours: [][]Pt{closeContour(rect)}, clear: []bool{false}}, true
+		}
+		// An obround is the hull of the circles at both ends of its long side.
+		r := 0.5 * math.Min(w, h)
+		d := Pt{X: w/2 - r, Y: h/2 - r}
+		hull := convexHull(append(circle(Pt{X: -d.X, Y: -d.Y}, r), circle(d, r)...))
+		return n, nil, &outline{contours: [][]Pt{closeContour(hull)}, clear: []bool{false}}, true
+	case "P":
+		if len(params) < 2 {
+			return 0, nil, nil, false
+		}
+		return n, nil, &outline{contours: [][]Pt{regularPolygon(Pt{}, param(0)/2, int(params[1]), rotation(params, 2))}, clear: []bool{false}}, true
+	}
+	body, ok := macros[name]
+	if !ok {
+		return 0, nil, nil, false
+	}
+	return n, nil, evalMacro(body, params, mm), true
+}
+
+// rotation returns the rotation (in degrees) at index i of params, if any.
+func rotation(params []float64, i int) float64 {
+	if i < len(params) {
+		return params[i]
+	}
+	return 0
+}
+
+// closeContour returns the contour closed by repeating its first point.
+func closeContour(pts []Pt) []Pt {
+	return append(pts, pts[0])
+}
+
+// regularPolygon returns the closed regular polygon with n vertices
+// inscribed in the circle of radius r, the first vertex rotated by
+// angle degrees from the positive X axis.
+func regularPolygon(c Pt, r float64, n int, angle float64) []Pt {
+	if n < 3 {
+		n = 3
+	}
+	pts := make([]Pt, n)
+	for i := range pts {
+		sin, cos := math.Sincos((angle + 360*float64(i)/float64(n)) * math.Pi / 180)
+		pts[i] = Pt{X: c.X + r*cos, Y: c.Y + r*sin}
+	}
+	return closeContour(pts)
+}
+
+// evalMacro evaluates the blocks of an aperture macro with the
+// parameters ($1, $2, ...) of an aperture definition.
+// The circle (1), vector line (20), center line (21), outline (4),
+// polygon (5) and thermal (7) primitives are supported; other
+// primitives are skipped.
+func evalMacro(body []string, params []float64, mm float64) *outline {
+	vars := map[int]float64{}
+	for i, v := range params {
+		vars[i+1] = v
+	}
+	o := &outline{}
+	for _, block := range body {
+		block = strings.TrimSpace(block)
+		if block == "" || block == "0" || strings.HasPrefix(block, "0 ") {
+			continue // comment
+		}
+		if strings.HasPrefix(block, "$") {
+			if name, expr, ok := strings.Cut(block[1:], "="); ok {
+				if n, err := strconv.Atoi(name); err == nil {
+					vars[n] = evalExpr(expr, vars)
+				}
+			}
+			continue
+		}
+		var args []float64
+		for _, field := range strings.Split(block, ",") {
+			args = append(args, evalExpr(field, vars))
+		}
+		arg := func(i int) float64 {
+			if i < len(args) {
+				return args[i]
+			}
+			return 0
+		}
+		var pts []Pt
+		var angle float64
+		switch int(arg(0)) {
+		case 1: // exposure, diameter, center x, y[, rotation]
+			r, c := 0.5*arg(2)*mm, Pt{X: arg(3) * mm, Y: arg(4) * mm}
+			pts, angle = closeContour(circle(c, r)), arg(5)
+		case 20: // exposure, width, start x, y, end x, y, rotation
+			w, a, b := arg(2)*mm, Pt{X: arg(3) * mm, Y: arg(4) * mm}, Pt{X: arg(5) * mm, Y: arg(6) * mm}
+			l := math.Hypot(b.X-a.X, b.Y-a.Y)
+			if l == 0 {
+				continue
+			}
+			nx, ny := -(b.Y-a.Y)/l*w/2, (b.X-a.X)/l*w/2
+			pts = []Pt{{X: a.X - nx, Y: a.Y - ny}, {X: b.X - nx, Y: b.Y - ny}, {X: b.X + nx, Y: b.Y + ny}, {X: a.X + nx, Y: a.Y + ny}, {X: a.X - nx, Y: a.Y - ny}}
+			angle = arg(7)
+		case 21: // exposure, width, height, center x, y, rotation
+			w, h, c := arg(2)*mm, arg(3)*mm, Pt{X: arg(4) * mm, Y: arg(5) * mm}
+			pts = closeContour([]Pt{{X: c.X - w/2, Y: c.Y - h/2}, {X: c.X + w/2, Y: c.Y - h/2}, {X: c.X + w/2, Y: c.Y + h/2}, {X: c.X - w/2, Y: c.Y + h/2}})
+			angle = arg(6)
+		case 4: // exposure, vertices, x0, y0, ..., xn, yn, rotation
+			n := int(arg(2))
+			for i := 0; i <= n; i++ {
+				pts = append(pts, Pt{X: arg(3+2*i) * mm, Y: arg(4+2*i) * mm})
+			}
+			angle = arg(5 + 2*n)
+		case 5: // exposure, vertices, center x, y, diameter, rotation
+			pts = regularPolygon(Pt{X: arg(3) * mm, Y: arg(4) * mm}, 0.5*arg(5)*mm, int(arg(2)), 0)
+			angle = arg(6)
+		case 7: // center x, y, outer diameter, inner diameter, gap, rotation
+			c := Pt{X: arg(1) * mm, Y: arg(2) * mm}
+			for _, q := range thermalQuadrants(0.5*arg(3)*mm, 0.5*arg(4)*mm, 0.5*arg(5)*mm, arg(6)) {
+				for i, pt := range q {
+					q[i] = Pt{X: c.X + pt.X, Y: c.Y + pt.Y}
+				}
+				o.contours = append(o.contours, q)
+				o.clear = append(o.clear, false)
+			}
+			continue
+		default:
+			continue
+		}
+		if angle != 0 {
+			// Macro primitives rotate about the aperture center.
+			sin, cos := math.Sincos(angle * math.Pi / 180)
+			for i, pt := range pts {
+				pts[i] = Pt{X: pt.X*cos - pt.Y*sin, Y: pt.X*sin + pt.Y*cos}
+			}
+		}
+		o.contours = append(o.contours, pts)
+		o.clear = append(o.clear, arg(1) == 0)
+	}
+	return o
+}
+
+// thermalQuadrants returns the closed contours of the four pieces of
+// the ring between radii inner and outer broken by gaps of half width
+// g along the axes rotated by angle degrees.
+func thermalQuadrants(outer, inner, g, angle float64) [][]Pt {
+	if g >= outer {
+		return nil
+	}
+	const steps = 16
+	var result [][]Pt
+	for q := 0; q < 4; q++ {
+		base := angle*math.Pi/180 + float64(q)*math.Pi/2
+		var pts []Pt
+		a1 := math.Asin(g / outer)
+		for i := 0; i <= steps; i++ {
+			a := base + a1 + (math.Pi/2-2*a1)*float64(i)/steps
+			pts = append(pts, Pt{X: outer * math.Cos(a), Y: outer * math.Sin(a)})
+		}
+		if g >= inner {
+			// The gaps meet inside the ring, at the inner corner of the piece.
+			sin, cos := math.Sincos(base + math.Pi/4)
+			d := g * math.Sqrt2
+			pts = append(pts, Pt{X: d * cos, Y: d * sin})
+		} else {
+			a1 := math.Asin(g / inner)
+			for i := steps; i >= 0; i-- {
+				a := base + a1 + (math.Pi/2-2*a1)*float64(i)/steps
+				pts = append(pts, Pt{X: inner * math.Cos(a), Y: inner * math.Sin(a)})
+			}
+		}
+		result = append(result, closeContour(pts))
+	}
+	return result
+}
+
+// evalExpr evaluates an aperture macro arithmetic expression with the
+// operators +, -, x (or X), / and parentheses over numbers and
+// variables ($1, $2, ...). Invalid expressions evaluate to zero.
+func evalExpr(s string, vars map[int]float64) float64 {
+	e := &exprParser{s: strings.ReplaceAll(s, " ", ""), vars: vars}
+	return e.sum()
+}
+
+// exprParser is a recursive descent parser of macro expressions.
+type exprParser struct {
+	s    string
+	vars map[int]float64
+}
+
+// sum parses terms separated by + and -.
+func (e *exprParser) sum() float64 {
+	v := e.product()
+	for len(e.s) > 0 && (e.s[0] == '+' || e.s[0] == '-') {
+		op := e.s[0]
+		e.s = e.s[1:]
+		if op == '+' {
+			v += e.product()
+		} else {
+			v -= e.product()
+		}
+	}
+	return v
+}
+
+// product parses factors separated by x (or X) and /.
+func (e *exprParser) product() float64 {
+	v := e.factor()
+	for len(e.s) > 0 && (e.s[0] == 'x' || e.s[0] == 'X' || e.s[0] == '/') {
+		op := e.s[0]
+		e.s = e.s[1:]
+		if op == '/' {
+			if d := e.factor(); d != 0 {
+				v /= d
+			}
+		} else {
+			v *= e.factor()
+		}
+	}
+	return v
+}
+
+// factor parses a number, a variable, a parenthesized expression
+// or a factor with a unary sign.
+func (e *exprParser) factor() float64 {
+	if len(e.s) == 0 {
+		return 0
+	}
+	switch c := e.s[0]; {
+	case c == '-':
+		e.s = e.s[1:]
+		return -e.factor()
+	case c == '+':
+		e.s = e.s[1:]
+		return e.factor()
+	case c == '(':
+		e.s = e.s[1:]
+		v := e.sum()
+		e.s = strings.TrimPrefix(e.s, ")")
+		return v
+	case c == '$':
+		i := 1
+		for i < len(e.s) && e.s[i] >= '0' && e.s[i] <= '9' {
+			i++
+		}
+		n, _ := strconv.Atoi(e.s[1:i])
+		e.s = e.s[i:]
+		return e.vars[n]
+	}
+	i := 0
+	for i < len(e.s) && (e.s[i] == '.' || e.s[i] >= '0' && e.s[i] <= '9') {
+		i++
+	}
+	v, _ := strconv.ParseFloat(e.s[:i], 64)
+	e.s = e.s[i:]
+	if i == 0 {
+		e.s = "" // Stop at an invalid character.
+	}
+	return v
+}
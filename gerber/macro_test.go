@@ -0,0 +1,61 @@
+package gerber
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEvalExpr(t *testing.T) {
+	vars := map[int]float64{1: 0.25, 2: -3}
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"1.5", 1.5},
+		{"$1+$1", 0.5},
+		{"-$2x2", 6},
+		{"$2/(1+2)", -1},
+		{"2X3-1", 5},
+		{"$9", 0},
+	}
+	for _, tt := range tests {
+		if got := evalExpr(tt.expr, vars); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("evalExpr(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseAD(t *testing.T) {
+	macros := map[string][]string{"Box": {"0 centered box", "$3=$1x2", "21,1,$3,$2,0,0,90"}}
+	tests := []struct {
+		line     string
+		aperture *Aperture
+		min, max Pt // of the outline
+	}{
+		{line: "%ADD10C,0.5*%", aperture: &Aperture{Shape: CircleShape, Size: 0.5}},
+		{line: "%ADD11R,0.1X0.1*%", aperture: &Aperture{Shape: RectShape, Size: 0.1}},
+		{line: "%ADD12R,2X1*%", min: Pt{X: -1, Y: -0.5}, max: Pt{X: 1, Y: 0.5}},
+		{line: "%ADD13P,2X4X45*%", min: Pt{X: -0.7071, Y: -0.7071}, max: Pt{X: 0.7071, Y: 0.7071}},
+		{line: "%ADD14Box,1X0.5*%", min: Pt{X: -0.25, Y: -1}, max: Pt{X: 0.25, Y: 1}},
+	}
+	for _, tt := range tests {
+		_, a, o, ok := parseAD(tt.line, 1, macros)
+		if !ok {
+			t.Errorf("parseAD(%q) failed", tt.line)
+			continue
+		}
+		if tt.aperture != nil {
+			if a == nil || *a != *tt.aperture {
+				t.Errorf("parseAD(%q) = %+v, want %+v", tt.line, a, tt.aperture)
+			}
+			continue
+		}
+		min, max, _ := bounds(o.flash(Pt{}, false))
+		if math.Hypot(min.X-tt.min.X, min.Y-tt.min.Y) > 1e-3 || math.Hypot(max.X-tt.max.X, max.Y-tt.max.Y) > 1e-3 {
+			t.Errorf("parseAD(%q) outline = %v-%v, want %v-%v", tt.line, min, max, tt.min, tt.max)
+		}
+	}
+	if _, _, _, ok := parseAD("%ADD15Missing,1*%", 1, macros); ok {
+		t.Error("undefined macro: want failure")
+	}
+}
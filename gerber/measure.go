@@ -0,0 +1,118 @@
+package gerber
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Counts are the numbers of primitives of a layer or design and of the
+// graphics operations they are written as.
+type Counts struct {
+	// Primitives is the number of primitives added.
+	Primitives int
+	// Flashes, Draws and Regions are the numbers of flashes, draws (with
+	// arcs split into segments) and regions written.
+	Flashes, Draws, Regions int
+}
+
+// add adds the primitive and its graphics operations to the counts.
+func (c *Counts) add(ops []op) {
+	c.Primitives++
+	for _, o := range ops {
+		switch o.code {
+		case flashOp:
+			c.Flashes++
+		case drawOp:
+			c.Draws++
+		case regionOp:
+			c.Regions++
+		}
+	}
+}
+
+// MBB returns the minimum bounding box of the layer as it is written (in
+// the design's variant), including the extent of the apertures.
+// ok is false for an empty layer.
+func (l *Layer) MBB() (min, max Pt, ok bool) {
+	return bounds(l.plot())
+}
+
+// Area returns the dark area of the layer (in mm²) as it is written:
+// overlapping primitives are counted once and clear polarity is
+// subtracted. It is measured by rasterizing the layer at dpi dots per
+// inch, so its accuracy is that of the pixel size.
+func (l *Layer) Area(dpi float64) (float64, error) {
+	if _, _, ok := l.MBB(); !ok {
+		return 0, nil
+	}
+	r, err := l.newRaster(dpi)
+	if err != nil {
+		return 0, fmt.Errorf("%v: %v", l.Filename, err)
+	}
+	var n int
+	r.render(func(row []byte) error {
+		for _, b := range row {
+			n += bits.OnesCount8(b)
+		}
+		return nil
+	})
+	return float64(n) * r.pixel * r.pixel, nil
+}
+
+// Counts returns the numbers of primitives of the layer (in the design's
+// variant) and of their graphics operations.
+func (l *Layer) Counts() Counts {
+	var c Counts
+	for _, p := range l.Primitives {
+		if p = forVariant(p, l.variant()); p == nil {
+			continue
+		}
+		c.add(plot(p))
+	}
+	return c
+}
+
+// MBB returns the minimum bounding box of all layers of the design as it
+// is written (including its pad stacks), e.g. to center the artwork or
+// check that it fits a panel.
+// ok is false for an empty design.
+func (g *Gerber) MBB() (min, max Pt, ok bool) {
+	var ops []op
+	for _, l := range g.withDerived() {
+		ops = append(ops, l.plot()...)
+	}
+	return bounds(ops)
+}
+
+// CopperArea returns the total dark area (in mm²) of the copper layers
+// of the design as it is written (including its pad stacks), measured
+// at dpi dots per inch (see Layer.Area), e.g. for plating cost estimates.
+func (g *Gerber) CopperArea(dpi float64) (float64, error) {
+	var area float64
+	for _, l := range g.withDerived() {
+		if !l.copper() {
+			continue
+		}
+		a, err := l.Area(dpi)
+		if err != nil {
+			return 0, err
+		}
+		area += a
+	}
+	return area, nil
+}
+
+// Counts returns the total numbers of primitives of the layers of the
+// design as it is written (including its pad stacks) and of their
+// graphics operations.
+func (g *Gerber) Counts() Counts {
+	var c Counts
+	for _, l := range g.withDerived() {
+		lc := l.Counts()
+		c.Primitives += lc.Primitives
+		c.Flashes += lc.Flashes
+		c.Draws += lc.Draws
+		c.Regions += lc.Regions
+	}
+	return c
+}
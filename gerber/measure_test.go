@@ -0,0 +1,46 @@
+package gerber
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGerber_Measure(t *testing.T) {
+	g := New("board")
+	top, bottom, silk := g.TopCopper(), g.BottomCopper(), g.TopSilkscreen()
+	square := func(x, y, size float64) *PolygonT {
+		return Polygon(x, y, true, []Pt{{X: 0, Y: 0}, {X: size, Y: 0}, {X: size, Y: size}, {X: 0, Y: size}, {X: 0, Y: 0}}, 0)
+	}
+	// Two overlapping 10x10mm pours count 150mm² once.
+	top.Add(square(0, 0, 10), square(5, 0, 10))
+	bottom.Add(square(0, 0, 2), Line(0, 5, 10, 5, CircleShape, 1))
+	silk.Add(Line(-5, -5, 30, 20, CircleShape, 0.2))
+
+	if min, max, ok := top.MBB(); !ok || min != (Pt{}) || max != (Pt{X: 15, Y: 10}) {
+		t.Errorf("top MBB = %v, %v, %v, want {0 0}, {15 10}", min, max, ok)
+	}
+	if min, max, ok := g.MBB(); !ok || math.Abs(min.X+5.1) > 1e-9 || math.Abs(max.Y-20.1) > 1e-9 {
+		t.Errorf("design MBB = %v, %v, %v, want the silkscreen extent", min, max, ok)
+	}
+	if a, err := top.Area(254); err != nil || math.Abs(a-150) > 1 {
+		t.Errorf("top Area = %v, %v, want 150", a, err)
+	}
+	// The 1mm line is 10mm long between the centers of its round caps.
+	want := 150 + 4 + 10 + math.Pi/4
+	if a, err := g.CopperArea(254); err != nil || math.Abs(a-want) > 2 {
+		t.Errorf("CopperArea = %v, %v, want %v", a, err, want)
+	}
+	if _, err := top.Area(0); err == nil {
+		t.Error("Area(0) = nil error, want invalid resolution")
+	}
+	if a, err := g.BottomSolderMask().Area(254); err != nil || a != 0 {
+		t.Errorf("empty Area = %v, %v, want 0", a, err)
+	}
+
+	if got, want := bottom.Counts(), (Counts{Primitives: 2, Draws: 1, Regions: 1}); got != want {
+		t.Errorf("bottom Counts = %+v, want %+v", got, want)
+	}
+	if got := g.Counts(); got.Primitives != 5 || got.Draws != 2 || got.Regions != 3 {
+		t.Errorf("design Counts = %+v, want 5 primitives, 2 draws and 3 regions", got)
+	}
+}
@@ -0,0 +1,285 @@
+package gerber
+
+import (
+	"math"
+	"sort"
+)
+
+// Ratsline is a missing connection of a net: the shortest link between
+// the centers of the tagged primitives of two of its copper islands.
+type Ratsline struct {
+	Net      string
+	From, To Pt
+}
+
+// Connectivity is the connectivity of the copper of a design.
+type Connectivity struct {
+	// Unconnected are the nets whose copper is split into several
+	// islands, sorted by name.
+	Unconnected []string
+	// Shorts are the groups of nets whose copper touches, sorted by name
+	// within and across groups.
+	Shorts [][]string
+	// Ratsnest are the connections missing to complete the unconnected
+	// nets, along their minimum spanning trees.
+	Ratsnest []Ratsline
+}
+
+// copperItem is a primitive of a copper layer for connectivity.
+type copperItem struct {
+	layer    int
+	net      string
+	center   Pt
+	min, max Pt
+	features []feature
+}
+
+// touches reports whether the copper of the items touches.
+func (a *copperItem) touches(b *copperItem) bool {
+	const eps = 1e-6
+	if b.min.X > a.max.X+eps || a.min.X > b.max.X+eps || b.min.Y > a.max.Y+eps || a.min.Y > b.max.Y+eps {
+		return false
+	}
+	edges := func(fs []feature) []feature {
+		var result []feature
+		for _, f := range fs {
+			if f.polygon == nil {
+				result = append(result, f)
+				continue
+			}
+			for i, pt := range f.polygon {
+				result = append(result, feature{a: pt, b: f.polygon[(i+1)%len(f.polygon)]})
+			}
+		}
+		return result
+	}
+	for _, f := range edges(a.features) {
+		for _, g := range edges(b.features) {
+			if f.distance(g) <= eps {
+				return true
+			}
+		}
+	}
+	// Either may lie within a polygon of the other.
+	inside := func(a, b *copperItem) bool {
+		for _, f := range a.features {
+			if f.polygon == nil {
+				continue
+			}
+			for _, g := range b.features {
+				pt := g.a
+				if g.polygon != nil {
+					pt = g.polygon[0]
+				}
+				if insidePolygon(f.polygon, pt) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return inside(a, b) || inside(b, a)
+}
+
+// covers reports whether the copper of the item covers pt.
+func (a *copperItem) covers(pt Pt) bool {
+	for _, f := range a.features {
+		if f.depth(pt) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Connectivity computes the connectivity of the copper of the design as
+// it is written (including its pad stacks): primitives of a copper layer
+// are connected when they touch and the plated holes of the drill layer
+// connect the copper of all layers covering their centers. Nets are the
+// names the primitives are tagged with (see ObjectT.Net, also written as
+// their X2 .N attribute); untagged copper connects the primitives it
+// touches. Clear polarity is ignored.
+func (g *Gerber) Connectivity() *Connectivity {
+	var items []*copperItem
+	var holes []Pt
+	for li, l := range g.withDerived() {
+		ext := l.extension()
+		if !l.copper() && ext != "xln" {
+			continue
+		}
+		for _, p := range l.Primitives {
+			if p = forVariant(p, g.Variant); p == nil {
+				continue
+			}
+			ops := plot(p)
+			min, max, ok := bounds(ops)
+			if !ok {
+				continue
+			}
+			center := Pt{X: 0.5 * (min.X + max.X), Y: 0.5 * (min.Y + max.Y)}
+			if ext == "xln" {
+				holes = append(holes, center)
+				continue
+			}
+			item := &copperItem{layer: li, center: center, min: min, max: max, features: features(ops)}
+			if o, ok := p.(*ObjectT); ok {
+				item.net = o.net
+			}
+			items = append(items, item)
+		}
+	}
+
+	parent := make([]int, len(items))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(i, j int) { parent[find(i)] = find(j) }
+
+	// Items of the same layer are swept by their left edges.
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return items[order[a]].min.X < items[order[b]].min.X })
+	for a, i := range order {
+		for _, j := range order[a+1:] {
+			if items[j].min.X > items[i].max.X+1e-6 {
+				break
+			}
+			if items[i].layer == items[j].layer && find(i) != find(j) && items[i].touches(items[j]) {
+				union(i, j)
+			}
+		}
+	}
+	for _, h := range holes {
+		first := -1
+		for i, item := range items {
+			if !item.covers(h) {
+				continue
+			}
+			if first < 0 {
+				first = i
+			} else {
+				union(i, first)
+			}
+		}
+	}
+
+	c := &Connectivity{}
+	islandNets := map[int]map[string]bool{}
+	netIslands := map[string]map[int][]Pt{} // the centers of the net's items by island
+	for i, item := range items {
+		if item.net == "" {
+			continue
+		}
+		root := find(i)
+		if islandNets[root] == nil {
+			islandNets[root] = map[string]bool{}
+		}
+		islandNets[root][item.net] = true
+		if netIslands[item.net] == nil {
+			netIslands[item.net] = map[int][]Pt{}
+		}
+		netIslands[item.net][root] = append(netIslands[item.net][root], item.center)
+	}
+
+	// Nets shorted together across islands are merged into one group.
+	groups := map[string]string{}
+	var group func(string) string
+	group = func(net string) string {
+		if up, ok := groups[net]; ok && up != net {
+			groups[net] = group(up)
+			return groups[net]
+		}
+		return net
+	}
+	for _, nets := range islandNets {
+		var first string
+		for net := range nets {
+			if first == "" {
+				first = net
+			} else if a, b := group(net), group(first); a != b {
+				groups[a] = b
+			}
+		}
+	}
+	shorts := map[string][]string{}
+	for net := range netIslands {
+		shorts[group(net)] = append(shorts[group(net)], net)
+	}
+	for _, nets := range shorts {
+		if len(nets) > 1 {
+			sort.Strings(nets)
+			c.Shorts = append(c.Shorts, nets)
+		}
+	}
+	sort.Slice(c.Shorts, func(i, j int) bool { return c.Shorts[i][0] < c.Shorts[j][0] })
+
+	for net, islands := range netIslands {
+		if len(islands) > 1 {
+			c.Unconnected = append(c.Unconnected, net)
+		}
+	}
+	sort.Strings(c.Unconnected)
+	for _, net := range c.Unconnected {
+		c.Ratsnest = append(c.Ratsnest, ratsnest(net, netIslands[net])...)
+	}
+	return c
+}
+
+// ratsnest returns the minimum spanning tree (by Prim's algorithm) of
+// the islands of the net, linking the closest centers of two islands.
+func ratsnest(net string, islands map[int][]Pt) []Ratsline {
+	var roots []int
+	for root := range islands {
+		roots = append(roots, root)
+	}
+	sort.Ints(roots)
+	closest := func(a, b []Pt) (Pt, Pt, float64) {
+		best := math.Inf(1)
+		var from, to Pt
+		for _, p := range a {
+			for _, q := range b {
+				if d := math.Hypot(p.X-q.X, p.Y-q.Y); d < best {
+					best, from, to = d, p, q
+				}
+			}
+		}
+		return from, to, best
+	}
+	var lines []Ratsline
+	connected := []int{roots[0]}
+	rest := roots[1:]
+	for len(rest) > 0 {
+		best, bi := Ratsline{Net: net}, -1
+		bestD := math.Inf(1)
+		for i, r := range rest {
+			for _, c := range connected {
+				if from, to, d := closest(islands[c], islands[r]); d < bestD {
+					best.From, best.To, bestD, bi = from, to, d, i
+				}
+			}
+		}
+		lines = append(lines, best)
+		connected = append(connected, rest[bi])
+		rest = append(rest[:bi], rest[bi+1:]...)
+	}
+	return lines
+}
+
+// Lines returns the ratsnest as line primitives of the given thickness,
+// e.g. to add to a documentation layer.
+// All dimensions are in millimeters.
+func (c *Connectivity) Lines(thickness float64) []Primitive {
+	var result []Primitive
+	for _, r := range c.Ratsnest {
+		result = append(result, Line(r.From.X, r.From.Y, r.To.X, r.To.Y, CircleShape, thickness))
+	}
+	return result
+}
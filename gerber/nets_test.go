@@ -0,0 +1,52 @@
+package gerber
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGerber_Connectivity(t *testing.T) {
+	g := New("board")
+	top, bottom, drill := g.TopCopper(), g.BottomCopper(), g.Drill()
+	pad := func(l *Layer, x, y float64, net string) {
+		l.Add(Object(Flash(x, y, CircleShape, 1)).Net(net))
+	}
+	// A: two pads joined by a trace.
+	pad(top, 0, 0, "A")
+	pad(top, 10, 0, "A")
+	top.Add(Object(Line(0, 0, 10, 0, CircleShape, 0.25)).Net("A"))
+	// B: a top pad and a bottom pad stitched by an untagged via.
+	pad(top, 0, 5, "B")
+	pad(bottom, 10, 5, "B")
+	top.Add(Line(0, 5, 5, 5, CircleShape, 0.25), Flash(5, 5, CircleShape, 0.6))
+	bottom.Add(Line(5, 5, 10, 5, CircleShape, 0.25), Flash(5, 5, CircleShape, 0.6))
+	drill.Add(Flash(5, 5, CircleShape, 0.3))
+	// C: three pads, the third one unrouted.
+	pad(top, 0, 10, "C")
+	pad(top, 10, 10, "C")
+	pad(top, 20, 10, "C")
+	top.Add(Line(0, 10, 10, 10, CircleShape, 0.25))
+	// D shorted to E.
+	pad(top, 0, -5, "D")
+	top.Add(Object(Line(0.3, -5, 5, -5, CircleShape, 0.25)).Net("E"))
+
+	c := g.Connectivity()
+	if want := []string{"C"}; !reflect.DeepEqual(c.Unconnected, want) {
+		t.Errorf("Unconnected = %v, want %v", c.Unconnected, want)
+	}
+	if want := [][]string{{"D", "E"}}; !reflect.DeepEqual(c.Shorts, want) {
+		t.Errorf("Shorts = %v, want %v", c.Shorts, want)
+	}
+	if want := []Ratsline{{Net: "C", From: Pt{X: 10, Y: 10}, To: Pt{X: 20, Y: 10}}}; !reflect.DeepEqual(c.Ratsnest, want) {
+		t.Errorf("Ratsnest = %v, want %v", c.Ratsnest, want)
+	}
+	if lines := c.Lines(0.1); len(lines) != 1 {
+		t.Errorf("got %v ratsnest lines, want 1", len(lines))
+	}
+
+	// Without the via, B is split between the layers.
+	drill.Primitives = nil
+	if c := g.Connectivity(); !reflect.DeepEqual(c.Unconnected, []string{"B", "C"}) {
+		t.Errorf("Unconnected without the via = %v, want [B C]", c.Unconnected)
+	}
+}
@@ -0,0 +1,290 @@
+package gerber
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// uuidNamespace is the RFC 4122 URL namespace used to derive
+// stable name-based (version 5) UUIDs.
+var uuidNamespace = [16]byte{0x6b, 0xa7, 0xb8, 0x11, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+
+// ObjectT wraps a primitive with Gerber X2 object attributes
+// and satisfies the Primitive interface.
+type ObjectT struct {
+	p         Primitive
+	name      string
+	component string
+	net       string
+	pin       []string
+	function  string
+	attrs     [][]string
+	uuid      string
+	variants  []string
+	waivers   []string
+	mask      bool
+	paste     bool
+}
+
+// Object returns a primitive that wraps p so that Gerber X2
+// object attributes can be attached to it.
+func Object(p Primitive) *ObjectT {
+	return &ObjectT{p: p}
+}
+
+// Name sets the name of the object, used to identify it (and the
+// primitives it wraps) in error messages.
+func (o *ObjectT) Name(name string) *ObjectT {
+	o.name = name
+	return o
+}
+
+// Component sets the component reference designator (e.g. "R1")
+// of the object, emitted as the .C object attribute.
+func (o *ObjectT) Component(refdes string) *ObjectT {
+	o.component = refdes
+	return o
+}
+
+// Net sets the name of the net the object belongs to (e.g. "GND"),
+// emitted as the .N object attribute.
+func (o *ObjectT) Net(name string) *ObjectT {
+	o.net = name
+	return o
+}
+
+// Pin marks the object as the pad of pin number of the component refdes,
+// emitted as the .P object attribute.
+func (o *ObjectT) Pin(refdes, number string) *ObjectT {
+	o.pin = []string{refdes, number}
+	return o
+}
+
+// Function sets the Gerber X2 aperture function of the object's aperture
+// (e.g. "SMDPad,CuDef" or "Conductor").
+func (o *ObjectT) Function(function string) *ObjectT {
+	o.function = function
+	return o
+}
+
+// Attribute adds an object attribute with the given values: names of
+// standard attributes start with a dot (e.g. ".CRot"), others are
+// user-defined.
+func (o *ObjectT) Attribute(name string, values ...string) *ObjectT {
+	o.attrs = append(o.attrs, append([]string{name}, values...))
+	return o
+}
+
+// UUID sets the unique ID of the object, emitted as the UUID object attribute.
+func (o *ObjectT) UUID(uuid string) *ObjectT {
+	o.uuid = uuid
+	return o
+}
+
+// Variants tags the object as belonging only to the named board variants
+// (e.g. "lite", "pro"). Untagged objects belong to all variants. The
+// tags apply wherever the object is nested, e.g. in other objects or in
+// a Group.
+func (o *ObjectT) Variants(names ...string) *ObjectT {
+	o.variants = append(o.variants, names...)
+	return o
+}
+
+// ExposeMask flags the object (on an outer copper layer) as a pad that
+// is exposed through the solder mask: it is added to the solder mask
+// layer of the same side when the design is written, grown as set by
+// Gerber.Derive.
+func (o *ObjectT) ExposeMask() *ObjectT {
+	o.mask = true
+	return o
+}
+
+// ApplyPaste flags the object (on an outer copper layer) as a pad that
+// receives solder paste: it is added to the paste layer of the same
+// side when the design is written, shrunk as set by Gerber.Derive.
+func (o *ObjectT) ApplyPaste() *ObjectT {
+	o.paste = true
+	return o
+}
+
+// Waive suppresses violations of the named design rules (see Rule)
+// by the object. No names waive all rules.
+func (o *ObjectT) Waive(rules ...string) *ObjectT {
+	o.waivers = append(o.waivers, rules...)
+	if len(rules) == 0 {
+		o.waivers = append(o.waivers, "*")
+	}
+	return o
+}
+
+// waived reports whether p (or any object wrapping it) waives the named rule.
+func waived(p Primitive, rule string) bool {
+	for {
+		o, ok := p.(*ObjectT)
+		if !ok {
+			return false
+		}
+		for _, w := range o.waivers {
+			if w == rule || w == "*" {
+				return true
+			}
+		}
+		p = o.p
+	}
+}
+
+// forVariant returns p without the primitives of other variants nested
+// in it (in objects, groups, step and repeat blocks and aperture blocks),
+// or nil if p itself is not part of the named variant. All primitives are
+// part of the empty (default) variant.
+func forVariant(p Primitive, variant string) Primitive {
+	if variant == "" {
+		return p
+	}
+	switch t := p.(type) {
+	case *ObjectT:
+		if len(t.variants) > 0 && !slices.Contains(t.variants, variant) {
+			return nil
+		}
+		c := forVariant(t.p, variant)
+		if c == nil {
+			return nil
+		}
+		if c != t.p {
+			o := *t
+			o.p = c
+			return &o
+		}
+	case *GroupT:
+		if primitives, ok := variantPrimitives(t.primitives, variant); ok {
+			return &GroupT{primitives: primitives, m: t.m}
+		}
+	case *StepRepeatT:
+		if primitives, ok := variantPrimitives(t.primitives, variant); ok {
+			s := *t
+			s.primitives = primitives
+			return &s
+		}
+	case *BlockT:
+		if primitives, ok := variantPrimitives(t.primitives, variant); ok {
+			return &BlockT{at: t.at, primitives: primitives}
+		}
+	}
+	return p
+}
+
+// variantPrimitives returns the primitives of the named variant (see
+// forVariant) and whether they differ from the given ones.
+func variantPrimitives(primitives []Primitive, variant string) ([]Primitive, bool) {
+	var result []Primitive
+	var changed bool
+	for _, p := range primitives {
+		c := forVariant(p, variant)
+		changed = changed || c != p
+		if c != nil {
+			result = append(result, c)
+		}
+	}
+	return result, changed
+}
+
+// WriteGerber writes the primitive to the Gerber file.
+func (o *ObjectT) WriteGerber(w io.Writer, apertureIndex int) error {
+	if o.component != "" {
+		writeAttribute(w, "%%TO.C,%v*%%\n", o.component)
+	}
+	if o.net != "" {
+		writeAttribute(w, "%%TO.N,%v*%%\n", o.net)
+	}
+	if len(o.pin) > 0 {
+		writeAttribute(w, "%%TO.P,%v*%%\n", strings.Join(o.pin, ","))
+	}
+	for _, attr := range o.attrs {
+		writeAttribute(w, "%%TO%v*%%\n", strings.Join(attr, ","))
+	}
+	if o.uuid != "" {
+		writeAttribute(w, "%%TOUUID,%v*%%\n", o.uuid)
+	}
+	if err := o.p.WriteGerber(w, apertureIndex); err != nil {
+		return err
+	}
+	if o.component != "" || o.net != "" || len(o.pin) > 0 || len(o.attrs) > 0 || o.uuid != "" {
+		writeAttribute(w, "%%TD*%%\n")
+	}
+	return nil
+}
+
+// Aperture returns the wrapped primitive's desired aperture
+// with the object's aperture function, if any.
+func (o *ObjectT) Aperture() *Aperture {
+	a := o.p.Aperture()
+	if a == nil || o.function == "" {
+		return a
+	}
+	c := *a
+	c.Function = o.function
+	return &c
+}
+
+// unwrap returns the primitive wrapped by any objects.
+func unwrap(p Primitive) Primitive {
+	for {
+		o, ok := p.(*ObjectT)
+		if !ok {
+			return p
+		}
+		p = o.p
+	}
+}
+
+// withUUID returns o (or p wrapped in a new object) with a stable UUID
+// derived from the layer and the component of the object (or its name,
+// see ObjectT.Name, or else the geometry of the primitive), numbered
+// among the primitives of the layer sharing them in seen, so that adding
+// or removing other primitives leaves it unchanged.
+func withUUID(p Primitive, layer string, seen map[string]int) Primitive {
+	o, ok := p.(*ObjectT)
+	if ok && o.uuid != "" {
+		return o
+	}
+	var name string
+	switch path := objectPath(p); {
+	case ok && o.component != "":
+		name = o.component
+	case path != "":
+		name = "name/" + path
+	default:
+		var buf bytes.Buffer
+		unwrap(p).WriteGerber(&buf, 0)
+		name = fmt.Sprintf("geometry/%v/%x", p.Aperture().ID(), sha1.Sum(buf.Bytes()))
+	}
+	n := seen[name]
+	seen[name]++
+	name = fmt.Sprintf("%v/%v", layer, name)
+	if n > 0 {
+		name = fmt.Sprintf("%v/%v", name, n)
+	}
+	if !ok {
+		return Object(p).UUID(NameUUID(name))
+	}
+	c := *o
+	c.uuid = NameUUID(name)
+	return &c
+}
+
+// NameUUID returns a stable name-based (version 5) UUID for the given name.
+// The same name always results in the same UUID, making it suitable
+// for tracing features back to the generating program across revisions.
+func NameUUID(name string) string {
+	h := sha1.New()
+	h.Write(uuidNamespace[:])
+	io.WriteString(h, "github.com/gmlewis/go-gerber/"+name)
+	s := h.Sum(nil)
+	s[6] = (s[6] & 0x0f) | 0x50 // version 5
+	s[8] = (s[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", s[0:4], s[4:6], s[6:8], s[8:10], s[10:16])
+}
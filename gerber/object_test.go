@@ -0,0 +1,143 @@
+package gerber
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNameUUID(t *testing.T) {
+	a, b := NameUUID("top/R1"), NameUUID("top/R1")
+	if a != b {
+		t.Errorf("NameUUID not stable: %v != %v", a, b)
+	}
+	if c := NameUUID("top/R2"); c == a {
+		t.Errorf("NameUUID(top/R2) = NameUUID(top/R1) = %v", c)
+	}
+	if len(a) != 36 || a[14] != '5' {
+		t.Errorf("NameUUID = %q, want version 5 UUID", a)
+	}
+}
+
+func TestObjectT_WriteGerber(t *testing.T) {
+	g := New("test")
+	g.UniqueIDs = true
+	top := g.TopCopper()
+	top.Add(
+		Object(Circle(0, 0, 1)).Component("R1").Net("GND"),
+		Line(0, 0, 1, 1, CircleShape, 0.1),
+	)
+
+	var buf bytes.Buffer
+	if err := top.WriteGerber(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"%TO.C,R1*%\n%TO.N,GND*%\n",
+		"%TOUUID," + NameUUID("test.gtl/R1") + "*%\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("WriteGerber missing %q:\n%v", want, got)
+		}
+	}
+	if n := strings.Count(got, "%TD*%"); n != 2 {
+		t.Errorf("WriteGerber emitted %v %%TD*%% commands, want 2", n)
+	}
+}
+
+func TestWithUUID(t *testing.T) {
+	uuids := func(primitives ...Primitive) []string {
+		seen := map[string]int{}
+		var result []string
+		for _, p := range primitives {
+			result = append(result, withUUID(p, "test.gtl", seen).(*ObjectT).uuid)
+		}
+		return result
+	}
+	pad1, pad2 := Object(Flash(0, 0, RectShape, 1)).Component("R1"), Object(Flash(2, 0, RectShape, 1)).Component("R1")
+	trace := Line(0, 0, 2, 0, CircleShape, 0.2)
+	got := uuids(pad1, pad2, trace)
+	if got[0] == got[1] || got[0] == got[2] || got[1] == got[2] {
+		t.Errorf("UUIDs = %v, want distinct ones", got)
+	}
+	// Other primitives added before them leave them unchanged.
+	if moved := uuids(Circle(5, 5, 1), pad1, pad2, trace); moved[1] != got[0] || moved[2] != got[1] || moved[3] != got[2] {
+		t.Errorf("UUIDs after adding a primitive = %v, want %v", moved[1:], got)
+	}
+	if named := uuids(Object(trace).Name("feed")); named[0] == got[2] {
+		t.Error("named object has the UUID of its geometry")
+	}
+}
+
+func TestObjectT_Variants(t *testing.T) {
+	g := New("test")
+	top := g.TopCopper()
+	top.Add(
+		Object(Circle(1, 1, 1)).Variants("pro"),
+		Object(Circle(2, 2, 1)).Variants("lite", "pro"),
+		Circle(3, 3, 1),
+		// Tags of nested objects and of objects in groups apply too.
+		Object(Object(Circle(7, 7, 1)).Variants("pro")).Net("GND"),
+		Group(Object(Line(9, 9, 10, 9, CircleShape, 0.1)).Variants("pro")),
+	)
+
+	tests := []struct {
+		variant string
+		want    int
+	}{
+		{"", 5},
+		{"lite", 2},
+		{"pro", 5},
+		{"other", 1},
+	}
+
+	for _, tt := range tests {
+		g.Variant = tt.variant
+		var buf bytes.Buffer
+		if err := top.WriteGerber(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Count(buf.String(), "D01*"); got != tt.want {
+			t.Errorf("variant %q: got %v objects, want %v", tt.variant, got, tt.want)
+		}
+	}
+
+	rules := []*Rule{{Name: "width", Kind: "min-width", Params: map[string]float64{"width": 0.15}}}
+	for variant, want := range map[string]int{"lite": 0, "pro": 1} {
+		g.Variant = variant
+		report, err := g.DRC(rules)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Violations) != want {
+			t.Errorf("variant %q: got %v violations, want %v", variant, len(report.Violations), want)
+		}
+	}
+}
+
+func TestObjectT_PadAttributes(t *testing.T) {
+	g := New("test")
+	top := g.TopCopper()
+	top.Add(
+		Object(Flash(1, 2, RectShape, 0.6)).Component("U1").Pin("U1", "3").Function("SMDPad,CuDef").Attribute("Vendor", "acme", "x"),
+		Object(Flash(3, 2, RectShape, 0.6)).Function("SMDPad,CuDef"),
+		Flash(5, 2, RectShape, 0.6),
+	)
+	if len(top.Apertures) != 2 {
+		t.Errorf("got %v apertures, want pad and plain apertures", len(top.Apertures))
+	}
+
+	var buf bytes.Buffer
+	if err := top.WriteGerber(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"%TA.AperFunction,SMDPad,CuDef*%\n%ADD12R,",
+		"%TO.C,U1*%\n%TO.P,U1,3*%\n%TOVendor,acme,x*%\nG54D12*\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("WriteGerber missing %q:\n%v", want, buf.String())
+		}
+	}
+}
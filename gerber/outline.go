@@ -0,0 +1,69 @@
+package gerber
+
+import "math"
+
+// RoundedRect returns the closed outline of the width by height rectangle
+// centered at x,y with corners rounded to radius (square for a zero
+// radius), such as the profile of a board on the Outline layer.
+// All dimensions are in millimeters (or Lengths).
+func RoundedRect[L Lengths](x, y, width, height, radius, thickness L) *TraceT {
+	c := Point(x, y)
+	w, h := 0.5*float64(width), 0.5*float64(height)
+	return RoundedPolygon([]Pt{
+		{X: c.X - w, Y: c.Y - h},
+		{X: c.X + w, Y: c.Y - h},
+		{X: c.X + w, Y: c.Y + h},
+		{X: c.X - w, Y: c.Y + h},
+	}, radius, thickness)
+}
+
+// CircleOutline returns the closed outline of the circle of the given
+// diameter centered at x,y, drawn as four quarter arcs.
+// All dimensions are in millimeters (or Lengths).
+func CircleOutline[L Lengths](x, y, diameter, thickness L) *TraceT {
+	d := float64(diameter)
+	return RoundedRect(float64(x), float64(y), d, d, 0.5*d, float64(thickness))
+}
+
+// Stadium returns the closed outline of the slot (stadium) of the given
+// width whose semicircular ends are centered at x1,y1 and x2,y2, such as
+// a routed slot or a board with round ends.
+// All dimensions are in millimeters (or Lengths).
+func Stadium[L Lengths](x1, y1, x2, y2, width, thickness L) *TraceT {
+	r := 0.5 * float64(width)
+	p1, p2 := Point(x1, y1), Point(x2, y2)
+	d := math.Hypot(p2.X-p1.X, p2.Y-p1.Y)
+	u := Pt{X: 1}
+	if d > 0 {
+		u = Pt{X: (p2.X - p1.X) / d, Y: (p2.Y - p1.Y) / d}
+	}
+	n := Pt{X: -u.Y * r, Y: u.X * r} // to the left
+	a := Pt{X: p1.X - u.X*r, Y: p1.Y - u.Y*r}
+	b := Pt{X: p2.X + u.X*r, Y: p2.Y + u.Y*r}
+	return RoundedPolygon([]Pt{
+		{X: a.X - n.X, Y: a.Y - n.Y},
+		{X: b.X - n.X, Y: b.Y - n.Y},
+		{X: b.X + n.X, Y: b.Y + n.Y},
+		{X: a.X + n.X, Y: a.Y + n.Y},
+	}, r, float64(thickness))
+}
+
+// RoundedPolygon returns the closed outline of the polygon through the
+// points with every corner rounded by a tangent arc of the given radius
+// (see TraceT.Fillet; sharp corners for a zero radius). The last point
+// should not repeat the first one.
+// All dimensions are in millimeters (or Lengths).
+func RoundedPolygon[L Lengths](points []Pt, radius, thickness L) *TraceT {
+	if len(points) > 1 && points[0] == points[len(points)-1] {
+		points = points[:len(points)-1]
+	}
+	if len(points) < 3 {
+		return Trace(points, thickness)
+	}
+	// The trace starts and ends halfway along the closing segment so that
+	// every corner of the polygon is a corner of the trace.
+	last := points[len(points)-1]
+	mid := Pt{X: 0.5 * (last.X + points[0].X), Y: 0.5 * (last.Y + points[0].Y)}
+	pts := append(append([]Pt{mid}, points...), mid)
+	return Trace(pts, thickness).Fillet(float64(radius))
+}
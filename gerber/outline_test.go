@@ -0,0 +1,51 @@
+package gerber
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOutlines(t *testing.T) {
+	tests := []struct {
+		name     string
+		outline  *TraceT
+		min, max Pt
+	}{
+		{name: "rectangle", outline: RoundedRect(5, 5, 10, 6, 0, 0.1), min: Pt{X: 0, Y: 2}, max: Pt{X: 10, Y: 8}},
+		{name: "rounded rectangle", outline: RoundedRect(0, 0, 20, 10, 2, 0.1), min: Pt{X: -10, Y: -5}, max: Pt{X: 10, Y: 5}},
+		{name: "circle", outline: CircleOutline(1, 2, 8, 0.1), min: Pt{X: -3, Y: -2}, max: Pt{X: 5, Y: 6}},
+		{name: "stadium", outline: Stadium(0, 0, 10, 0, 4, 0.1), min: Pt{X: -2, Y: -2}, max: Pt{X: 12, Y: 2}},
+		{name: "vertical stadium", outline: Stadium(3, 0, 3, 5, 2, 0.1), min: Pt{X: 2, Y: -1}, max: Pt{X: 4, Y: 6}},
+		{name: "rounded L", outline: RoundedPolygon([]Pt{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 4}, {X: 4, Y: 4}, {X: 4, Y: 10}, {X: 0, Y: 10}}, 1, 0.1), min: Pt{X: 0, Y: 0}, max: Pt{X: 10, Y: 10}},
+		{name: "sharp triangle", outline: RoundedPolygon([]Pt{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 0, Y: 10}, {X: 0, Y: 0}}, 0, 0.1), min: Pt{X: 0, Y: 0}, max: Pt{X: 10, Y: 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := plot(tt.outline)
+			if len(ops) == 0 {
+				t.Fatal("nothing drawn")
+			}
+			for i, o := range ops {
+				next := ops[(i+1)%len(ops)]
+				if d := math.Hypot(o.pts[1].X-next.pts[0].X, o.pts[1].Y-next.pts[0].Y); d > 1e-6 {
+					t.Fatalf("outline is not closed at %v", o.pts[1])
+				}
+			}
+			min, max, _ := bounds(ops)
+			const r = 0.05 // of the aperture
+			if math.Abs(min.X+r-tt.min.X) > 1e-3 || math.Abs(min.Y+r-tt.min.Y) > 1e-3 || math.Abs(max.X-r-tt.max.X) > 1e-3 || math.Abs(max.Y-r-tt.max.Y) > 1e-3 {
+				t.Errorf("bounds = %v..%v, want %v..%v", min, max, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestCircleOutline(t *testing.T) {
+	for _, o := range plot(CircleOutline(1, 2, 8, 0.1)) {
+		for _, pt := range o.pts {
+			if r := math.Hypot(pt.X-1, pt.Y-2); math.Abs(r-4) > 1e-3 {
+				t.Fatalf("point %v is %v from the center, want 4", pt, r)
+			}
+		}
+	}
+}
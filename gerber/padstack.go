@@ -0,0 +1,274 @@
+package gerber
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// PadShape is the shape of a pad of a PadStack on one layer.
+type PadShape struct {
+	// Shape is CircleShape for round and obround pads or RectShape for
+	// square and rectangular pads.
+	Shape Shape
+	// Width and Height are the size of the pad (in millimeters) along
+	// the X and Y axes of the unrotated stack. A zero Height makes the
+	// pad round or square.
+	Width, Height float64
+}
+
+// PadStack defines a pad across the layers of the design once for all
+// of its instances (see PadStack.At and Gerber.AddPad): the instances
+// are expanded into the copper, drill, solder mask and paste layers
+// when the design is written, so changing the stack updates them all.
+// The pads of the footprints of package footprints share their stacks
+// this way. Stacks are exported to KiCad with PadStack.KiCad (and whole
+// footprints with their Footprint.WriteKiCad); ODB++ is not supported.
+type PadStack struct {
+	// Name identifies the stack (e.g. "SMD_1x0.6" or "TH_1.7_1.0"),
+	// such as for exporting it as a CAD padstack.
+	Name string
+	// Top, Inner and Bottom are the copper pads on the top, inner and
+	// bottom copper layers. Nil ones leave the layers without a pad.
+	Top, Inner, Bottom *PadShape
+	// Drill is the diameter of the hole (in millimeters), zero for
+	// surface mount pads.
+	Drill float64
+	// NonPlated puts the hole on the non-plated drill layer.
+	NonPlated bool
+	// MaskExpansion is the clearance (in millimeters) of the solder mask
+	// openings around the outer pads on each side. Negative values make
+	// the openings smaller than the pads.
+	MaskExpansion float64
+	// Tented covers the outer pads with solder mask.
+	Tented bool
+	// Paste applies solder paste to the outer pads, reduced by
+	// PasteShrink (in millimeters) on each side.
+	Paste       bool
+	PasteShrink float64
+}
+
+// PadT is an instance of a pad stack at a location of the design.
+type PadT struct {
+	stack    *PadStack
+	x, y     float64
+	rotation float64
+	net      string
+	pin      []string
+}
+
+// At returns an instance of the pad stack centered at x,y, to be added
+// to a design with Gerber.AddPad.
+// All dimensions are in millimeters.
+func (s *PadStack) At(x, y float64) *PadT {
+	return &PadT{stack: s, x: x, y: y}
+}
+
+// Rotate rotates the pad by the given angle (in degrees, counterclockwise).
+func (p *PadT) Rotate(degrees float64) *PadT {
+	p.rotation = degrees
+	return p
+}
+
+// Net sets the name of the net the pad belongs to.
+func (p *PadT) Net(name string) *PadT {
+	p.net = name
+	return p
+}
+
+// Pin marks the pad as pin number of the component refdes.
+func (p *PadT) Pin(refdes, number string) *PadT {
+	p.pin = []string{refdes, number}
+	return p
+}
+
+// Stack returns the pad stack of the pad.
+func (p *PadT) Stack() *PadStack {
+	return p.stack
+}
+
+// AddPad adds the pad to the design and returns it. Its primitives are
+// added to (copies of) the outer copper, drill, solder mask and paste
+// layers, which are added as needed, and to the inner copper layers of
+// the design when it is written.
+func (g *Gerber) AddPad(p *PadT) *PadT {
+	g.Pads = append(g.Pads, p)
+	return p
+}
+
+// PadStacks returns the distinct pad stacks of the pads of the design
+// in the order they are first used.
+func (g *Gerber) PadStacks() []*PadStack {
+	var stacks []*PadStack
+	seen := map[*PadStack]bool{}
+	for _, p := range g.Pads {
+		if !seen[p.stack] {
+			seen[p.stack] = true
+			stacks = append(stacks, p.stack)
+		}
+	}
+	return stacks
+}
+
+// KiCad returns the stack as the pad of a KiCad footprint with the given
+// number at x,y rotated by rotation degrees, e.g.
+//
+//	(pad "1" smd rect (at 1 -2 90) (size 1.5 0.6) (layers "F.Cu" "F.Paste" "F.Mask") (solder_mask_margin 0.05) (solder_paste_margin -0.05))
+//
+// KiCad's Y axis points down, so y is negated. KiCad pads have one size
+// on all copper layers: that of the top pad (or of the bottom one if there
+// is no top pad).
+// All dimensions are in millimeters.
+func (s *PadStack) KiCad(number string, x, y, rotation float64) string {
+	pad, side := s.Top, "F"
+	if pad == nil {
+		pad, side = s.Bottom, "B"
+	}
+	if pad == nil {
+		pad = s.Inner
+	}
+	if pad == nil {
+		pad = &PadShape{Shape: CircleShape, Width: s.Drill}
+	}
+	w, h := pad.Width, pad.Height
+	if h == 0 {
+		h = w
+	}
+	shape := "rect"
+	if pad.Shape == CircleShape {
+		shape = "circle"
+		if w != h {
+			shape = "oval"
+		}
+	}
+	kind, layers := "smd", []string{side + ".Cu"}
+	if s.Paste {
+		layers = append(layers, side+".Paste")
+	}
+	if s.Drill > 0 {
+		kind, layers = "thru_hole", []string{"*.Cu"}
+		if s.NonPlated {
+			kind = "np_thru_hole"
+		}
+		side = "*"
+	}
+	if !s.Tented {
+		layers = append(layers, side+".Mask")
+	}
+	v := func(f float64) string { return fmt.Sprintf("%v", math.Round(f*1e6)/1e6+0) } // +0 turns -0 into 0
+	var b strings.Builder
+	fmt.Fprintf(&b, "(pad %q %v %v (at %v %v", number, kind, shape, v(x), v(-y))
+	if rotation != 0 {
+		fmt.Fprintf(&b, " %v", v(rotation))
+	}
+	fmt.Fprintf(&b, ") (size %v %v)", v(w), v(h))
+	if s.Drill > 0 {
+		fmt.Fprintf(&b, " (drill %v)", v(s.Drill))
+	}
+	b.WriteString(" (layers")
+	for _, l := range layers {
+		fmt.Fprintf(&b, " %q", l)
+	}
+	b.WriteString(")")
+	if s.MaskExpansion != 0 {
+		fmt.Fprintf(&b, " (solder_mask_margin %v)", v(s.MaskExpansion))
+	}
+	if s.Paste && s.PasteShrink != 0 {
+		fmt.Fprintf(&b, " (solder_paste_margin %v)", v(-s.PasteShrink))
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+// primitives returns the primitives of the pad on the layer with the
+// given extension.
+func (p *PadT) primitives(ext string) []Primitive {
+	s := p.stack
+	object := func(prim Primitive, function string) Primitive {
+		if prim == nil {
+			return nil
+		}
+		o := Object(prim).Function(function)
+		o.net = p.net
+		o.pin = p.pin
+		return o
+	}
+	copper := "SMDPad,CuDef"
+	if s.Drill > 0 {
+		copper = "ComponentPad"
+	}
+	var prim Primitive
+	switch ext {
+	case "gtl":
+		prim = object(p.shape(s.Top, 0), copper)
+	case "gbl":
+		prim = object(p.shape(s.Bottom, 0), copper)
+	case "gts", "gbs":
+		if !s.Tented {
+			prim = p.shape(p.outer(ext), s.MaskExpansion)
+		}
+	case "gtp", "gbp":
+		if s.Paste {
+			prim = p.shape(p.outer(ext), -s.PasteShrink)
+		}
+	case "xln", "nxln":
+		if s.Drill > 0 && s.NonPlated == (ext == "nxln") {
+			function := "ComponentDrill"
+			if s.NonPlated {
+				function = "MechanicalDrill"
+			}
+			prim = object(Flash(p.x, p.y, CircleShape, s.Drill), function)
+		}
+	default:
+		if copperExtension(ext) {
+			prim = object(p.shape(s.Inner, 0), copper)
+		}
+	}
+	if prim == nil {
+		return nil
+	}
+	return []Primitive{prim}
+}
+
+// outer returns the copper pad on the side of the solder mask or paste
+// layer with the given extension.
+func (p *PadT) outer(ext string) *PadShape {
+	if ext[1] == 'b' {
+		return p.stack.Bottom
+	}
+	return p.stack.Top
+}
+
+// shape returns the primitive of the pad shape grown by grow on each
+// side (or nil if there is nothing left of it). Round and square pads
+// are flashed, obround pads are drawn and rectangular pads are regions.
+func (p *PadT) shape(s *PadShape, grow float64) Primitive {
+	if s == nil {
+		return nil
+	}
+	w, h := s.Width+2*grow, s.Height+2*grow
+	if s.Height == 0 {
+		h = w
+	}
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+	sin, cos := math.Sincos(p.rotation * math.Pi / 180)
+	square := math.Abs(math.Remainder(p.rotation, 90)) < 1e-9
+	switch {
+	case w == h && (s.Shape == CircleShape || square):
+		return Flash(p.x, p.y, s.Shape, w)
+	case s.Shape == CircleShape:
+		// The obround is drawn along its long axis.
+		d, dx, dy := h, 0.5*(w-h)*cos, 0.5*(w-h)*sin
+		if h > w {
+			d, dx, dy = w, -0.5*(h-w)*sin, 0.5*(h-w)*cos
+		}
+		return Line(p.x-dx, p.y-dy, p.x+dx, p.y+dy, CircleShape, d)
+	}
+	var corners []Pt
+	for _, c := range []Pt{{X: -w / 2, Y: -h / 2}, {X: w / 2, Y: -h / 2}, {X: w / 2, Y: h / 2}, {X: -w / 2, Y: h / 2}} {
+		corners = append(corners, Pt{X: c.X*cos - c.Y*sin, Y: c.X*sin + c.Y*cos})
+	}
+	return Polygon(p.x, p.y, true, corners, 0)
+}
@@ -0,0 +1,166 @@
+package gerber
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGerber_AddPad(t *testing.T) {
+	g := New("board")
+	g.TopCopper()
+	g.Layer2()
+	smd := &PadStack{Name: "SMD", Top: &PadShape{Shape: RectShape, Width: 1.5, Height: 0.6}, MaskExpansion: 0.05, Paste: true, PasteShrink: 0.05}
+	th := &PadStack{Name: "TH", Top: &PadShape{Shape: CircleShape, Width: 1.7}, Inner: &PadShape{Shape: CircleShape, Width: 1.2}, Bottom: &PadShape{Shape: CircleShape, Width: 1.7}, Drill: 1}
+	g.AddPad(smd.At(1, 1).Pin("R1", "1"))
+	g.AddPad(smd.At(3, 1).Pin("R1", "2"))
+	g.AddPad(th.At(5, 5).Net("GND"))
+	g.AddPad(smd.At(1, 3).Rotate(90))
+
+	got := map[string]int{}
+	for _, l := range g.withDerived() {
+		got[l.extension()] = len(l.Primitives)
+	}
+	want := map[string]int{"gtl": 4, "g2l": 1, "gbl": 1, "xln": 1, "gts": 4, "gbs": 1, "gtp": 3}
+	if len(got) != len(want) {
+		t.Errorf("layers = %v, want %v", got, want)
+	}
+	for ext, n := range want {
+		if got[ext] != n {
+			t.Errorf("%v has %v primitives, want %v", ext, got[ext], n)
+		}
+	}
+	if len(g.Layers) != 2 {
+		t.Error("withDerived modified the design")
+	}
+	if stacks := g.PadStacks(); len(stacks) != 2 || stacks[0] != smd || stacks[1] != th {
+		t.Errorf("PadStacks = %v, want [SMD TH]", stacks)
+	}
+
+	// Changing the stack updates all its instances.
+	smd.Top = &PadShape{Shape: RectShape, Width: 0.8}
+	smd.Paste = false
+	var buf bytes.Buffer
+	if err := g.withDerived()[0].WriteGerber(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"%TA.AperFunction,SMDPad,CuDef*%\n%ADD12R,0.800000X0.800000*%",
+		"%TA.AperFunction,ComponentPad*%\n%ADD13C,1.700000*%",
+		"%TO.P,R1,2*%\nG54D12*\nX3000000Y1000000D03*",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("missing %q:\n%v", want, buf.String())
+		}
+	}
+	for _, l := range g.withDerived() {
+		if l.extension() == "gtp" {
+			t.Errorf("paste layer written for pads without paste")
+		}
+	}
+}
+
+func apertureID(p Primitive) string {
+	if a := p.Aperture(); a != nil {
+		return a.ID()
+	}
+	return ""
+}
+
+func TestPadT_shape(t *testing.T) {
+	tests := []struct {
+		name     string
+		shape    PadShape
+		rotation float64
+		grow     float64
+		want     Primitive
+	}{
+		{name: "round", shape: PadShape{Shape: CircleShape, Width: 1}, grow: 0.1, want: Flash(2, 3, CircleShape, 1.2)},
+		{name: "square", shape: PadShape{Shape: RectShape, Width: 1, Height: 1}, rotation: 90, want: Flash(2, 3, RectShape, 1)},
+		{name: "obround", shape: PadShape{Shape: CircleShape, Width: 2, Height: 1}, want: Line(1.5, 3, 2.5, 3, CircleShape, 1)},
+		{name: "tall obround", shape: PadShape{Shape: CircleShape, Width: 1, Height: 2}, want: Line(2, 2.5, 2, 3.5, CircleShape, 1)},
+		{name: "rectangle", shape: PadShape{Shape: RectShape, Width: 2, Height: 1}, want: Polygon(2, 3, true, []Pt{{X: -1, Y: -0.5}, {X: 1, Y: -0.5}, {X: 1, Y: 0.5}, {X: -1, Y: 0.5}}, 0)},
+		{name: "shrunk away", shape: PadShape{Shape: RectShape, Width: 0.2}, grow: -0.1, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := (&PadStack{}).At(2, 3).Rotate(tt.rotation)
+			got := p.shape(&tt.shape, tt.grow)
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("shape = %#v, want nil", got)
+				}
+				return
+			}
+			var gotBuf, wantBuf bytes.Buffer
+			got.WriteGerber(&gotBuf, 12)
+			tt.want.WriteGerber(&wantBuf, 12)
+			if gotBuf.String() != wantBuf.String() || apertureID(got) != apertureID(tt.want) {
+				t.Errorf("shape =\n%v\nwant\n%v", gotBuf.String(), wantBuf.String())
+			}
+		})
+	}
+}
+
+func TestGerber_AddPad_holes(t *testing.T) {
+	// Pad stack holes are seen by planes, the netlist and panels.
+	g := panelDesign()
+	th := &PadStack{Name: "TH", Top: &PadShape{Shape: CircleShape, Width: 1.7}, Bottom: &PadShape{Shape: CircleShape, Width: 1.7}, Drill: 1}
+	g.AddPad(th.At(12, 12).Net("GND").Pin("J1", "1"))
+	if holes := g.holes(); len(holes) != 2 || holes[1].net != "GND" || holes[1].drill != 1 {
+		t.Errorf("holes = %+v, want the via and the pad hole", holes)
+	}
+	var buf bytes.Buffer
+	if err := g.WriteIPC356(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := "317GND              J1          D1000PA00X+012000Y+012000X1700Y1700R000 S0"; !strings.Contains(buf.String(), want) {
+		t.Errorf("WriteIPC356 =\n%v\nwant %q", buf.String(), want)
+	}
+	panel, err := NewPanel(g, 2, 1).Gerber("panel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(panelLayerOps(t, panel, "xln")); got != 4 {
+		t.Errorf("got %v panel holes, want the vias and pad holes of both boards", got)
+	}
+	if got := len(panelLayerOps(t, panel, "gts")); got != 5 {
+		t.Errorf("got %v panel mask openings, want 2 pads and 3 fiducials", got)
+	}
+}
+
+func TestPadStack_KiCad(t *testing.T) {
+	tests := []struct {
+		name  string
+		stack PadStack
+		want  string
+	}{
+		{
+			name:  "smd",
+			stack: PadStack{Top: &PadShape{Shape: RectShape, Width: 1.5, Height: 0.6}, MaskExpansion: 0.05, Paste: true, PasteShrink: 0.05},
+			want:  `(pad "1" smd rect (at 1 -2 90) (size 1.5 0.6) (layers "F.Cu" "F.Paste" "F.Mask") (solder_mask_margin 0.05) (solder_paste_margin -0.05))`,
+		},
+		{
+			name:  "through hole",
+			stack: PadStack{Top: &PadShape{Shape: CircleShape, Width: 1.7, Height: 1.2}, Drill: 1},
+			want:  `(pad "1" thru_hole oval (at 1 -2 90) (size 1.7 1.2) (drill 1) (layers "*.Cu" "*.Mask"))`,
+		},
+		{
+			name:  "tented bottom",
+			stack: PadStack{Bottom: &PadShape{Shape: CircleShape, Width: 0.5}, Tented: true},
+			want:  `(pad "1" smd circle (at 1 -2 90) (size 0.5 0.5) (layers "B.Cu"))`,
+		},
+		{
+			name:  "mounting hole",
+			stack: PadStack{Drill: 3.2, NonPlated: true},
+			want:  `(pad "1" np_thru_hole circle (at 1 -2 90) (size 3.2 3.2) (drill 3.2) (layers "*.Cu" "*.Mask"))`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stack.KiCad("1", 1, 2, 90); got != tt.want {
+				t.Errorf("KiCad =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
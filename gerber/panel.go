@@ -0,0 +1,404 @@
+package gerber
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Separation is the way the boards of a panel are separated.
+type Separation int
+
+const (
+	// MouseBites holds the boards by tabs perforated with small
+	// non-plated holes across routed gaps.
+	MouseBites Separation = iota
+	// VScore butts the boards together and scores V-grooves along the
+	// board edges on the V-score layer. The boards must be rectangular.
+	VScore
+)
+
+// Panel arrays a finished design into a manufacturing panel.
+type Panel struct {
+	// Design is the design arrayed on the panel. Its outline layer sets
+	// the size of each board.
+	Design *Gerber
+	// Columns and Rows are the number of boards across and up the panel.
+	Columns, Rows int
+	// Separation is the way the boards are separated.
+	Separation Separation
+	// Spacing is the width of the routed gaps between the boards (and
+	// between the boards and the rails) with MouseBites.
+	Spacing float64
+	// Rail is the width of the rails along the bottom and top edges of
+	// the panel, or 0 for none.
+	Rail float64
+	// Fiducials, when true, places three fiducials (1mm copper dots with
+	// 2mm solder mask openings) on the rails.
+	Fiducials bool
+	// FiducialInset is the distance of the fiducials from the left and
+	// right edges of the panel.
+	FiducialInset float64
+	// ToolingHole is the diameter of the non-plated tooling holes in the
+	// corners of the rails, or 0 for none.
+	ToolingHole float64
+	// TabWidth is the width of the mouse-bite tabs.
+	TabWidth float64
+	// BiteDiameter and BitePitch are the diameter and the spacing of the
+	// mouse-bite holes.
+	BiteDiameter, BitePitch float64
+}
+
+// NewPanel returns a panel of columns by rows copies of the design
+// separated by mouse-bite tabs, with 2mm gaps and 5mm rails carrying
+// fiducials 10mm from the panel edges and 3mm tooling holes.
+func NewPanel(design *Gerber, columns, rows int) *Panel {
+	return &Panel{
+		Design:        design,
+		Columns:       columns,
+		Rows:          rows,
+		Spacing:       2,
+		Rail:          5,
+		Fiducials:     true,
+		FiducialInset: 10,
+		ToolingHole:   3,
+		TabWidth:      3,
+		BiteDiameter:  0.5,
+		BitePitch:     0.8,
+	}
+}
+
+// panelEdge is a straight stretch of a board edge (horizontal at y = at
+// from lo to hi, or vertical at x = at) interrupted by a tab.
+type panelEdge struct {
+	vertical bool
+	at       float64
+	lo, hi   float64
+}
+
+// Gerber returns a new design with the given filename prefix holding the
+// panel: every layer of the design (in its Variant, if any, with its
+// derived solder mask and paste openings and its pads) copied onto the
+// panel, the rails, fiducials and tooling holes, and the separation.
+// Mouse-bite tabs are placed in the middle of the straight edges of the
+// board's bounding box facing a gap; the board outline is broken at the
+// tabs and the holes are added to the non-plated drill layer.
+// Components are not copied.
+// All dimensions are in millimeters.
+func (p *Panel) Gerber(filenamePrefix string) (*Gerber, error) {
+	d := p.Design
+	if p.Columns < 1 || p.Rows < 1 {
+		return nil, fmt.Errorf("invalid panel of %vx%v boards", p.Columns, p.Rows)
+	}
+	// The panel is made of the layers as written, with their derived
+	// openings and pads.
+	design := d.withDerived()
+	var outline *Layer
+	for _, l := range design {
+		if l.extension() == "gko" {
+			outline = l
+		}
+	}
+	if outline == nil {
+		return nil, errors.New("design has no outline layer")
+	}
+	var outlineOps []op
+	for _, prim := range outline.Primitives {
+		outlineOps = append(outlineOps, plot(prim)...)
+	}
+	bmin, bmax, ok := centerlineBounds(outlineOps)
+	if !ok {
+		return nil, errors.New("design has an empty outline layer")
+	}
+	w, h := bmax.X-bmin.X, bmax.Y-bmin.Y
+
+	gap := p.Spacing
+	if p.Separation == VScore {
+		gap = 0
+	} else if gap <= 0 || p.TabWidth <= 0 || p.TabWidth >= math.Min(w, h) || p.BiteDiameter <= 0 || p.BitePitch <= 0 {
+		return nil, errors.New("mouse bites need a positive spacing, bite diameter and pitch and tabs narrower than the board")
+	}
+	if p.Rail < 0 || p.Rail == 0 && (p.Fiducials || p.ToolingHole > 0) {
+		return nil, errors.New("fiducials and tooling holes need rails")
+	}
+	if p.ToolingHole >= p.Rail && p.ToolingHole > 0 {
+		return nil, fmt.Errorf("tooling holes of %vmm do not fit on %vmm rails", p.ToolingHole, p.Rail)
+	}
+
+	// The lower left corner of board (i,j) and the size of the panel.
+	base := 0.0
+	if p.Rail > 0 {
+		base = p.Rail + gap
+	}
+	corner := func(i, j int) Pt {
+		return Pt{X: float64(i) * (w + gap), Y: base + float64(j)*(h+gap)}
+	}
+	width := float64(p.Columns)*w + float64(p.Columns-1)*gap
+	height := 2*base + float64(p.Rows)*h + float64(p.Rows-1)*gap
+	if p.Fiducials && (p.FiducialInset < 1 || p.FiducialInset > width/2) {
+		return nil, fmt.Errorf("fiducials %vmm from the edges do not fit on a %vmm wide panel", p.FiducialInset, width)
+	}
+
+	g := New(filenamePrefix)
+	g.Format, g.Units = d.Format, d.Units
+	layers := map[*Layer]*Layer{}
+	for _, l := range design {
+		c := g.makeLayer(l.extension())
+		c.spec, c.Units, c.Attributes = l.spec, l.Units, l.Attributes
+		layers[l] = c
+	}
+	for j := 0; j < p.Rows; j++ {
+		for i := 0; i < p.Columns; i++ {
+			c := corner(i, j)
+			xf := func(pt Pt) Pt { return Pt{X: pt.X - bmin.X + c.X, Y: pt.Y - bmin.Y + c.Y} }
+			for _, l := range design {
+				if l == outline {
+					continue
+				}
+				for _, prim := range l.Primitives {
+					if prim = forVariant(prim, d.Variant); prim == nil {
+						continue
+					}
+					// The openings are already derived.
+					c := transformPrimitive(prim, xf)
+					if o, ok := c.(*ObjectT); ok {
+						o.mask, o.paste = false, false
+					}
+					layers[l].Add(c)
+				}
+			}
+		}
+	}
+
+	// The outline aperture of the routed (or scored) edges.
+	thickness := 0.1
+	for _, o := range outlineOps {
+		if o.code == drawOp && o.aperture != nil {
+			thickness = o.aperture.Size
+			break
+		}
+	}
+	out := layers[outline]
+	if p.Separation == VScore {
+		out.Add(rectangle(Pt{}, Pt{X: width, Y: height}, thickness)...)
+		score := g.panelLayer("VScore")
+		for i := 1; i < p.Columns; i++ {
+			x := corner(i, 0).X
+			score.Add(Line(x, 0, x, height, CircleShape, thickness))
+		}
+		for j := 0; j <= p.Rows; j++ {
+			if p.Rail == 0 && (j == 0 || j == p.Rows) {
+				continue // the edges of the panel
+			}
+			y := base + float64(j)*h
+			score.Add(Line(0, y, width, y, CircleShape, thickness))
+		}
+	} else {
+		p.routeMouseBites(g, out, outlineOps, bmin, w, h, gap, width, height, thickness, corner)
+	}
+
+	if p.Rail > 0 {
+		var fiducials []Pt
+		if p.Fiducials {
+			in := p.FiducialInset
+			fiducials = []Pt{{X: in, Y: p.Rail / 2}, {X: width - in, Y: p.Rail / 2}, {X: in, Y: height - p.Rail/2}}
+		}
+		for _, pt := range fiducials {
+			for _, side := range []struct{ copper, mask string }{{"gtl", "gts"}, {"gbl", "gbs"}} {
+				var has bool
+				for _, c := range g.Layers {
+					has = has || c.extension() == side.copper
+				}
+				if !has {
+					continue
+				}
+				g.layerFor(side.copper).Add(Object(Flash(pt.X, pt.Y, CircleShape, 1)).Function("FiducialPad,Global"))
+				g.layerFor(side.mask).Add(Flash(pt.X, pt.Y, CircleShape, 2))
+			}
+		}
+		if p.ToolingHole > 0 {
+			npth := g.panelLayer("NonPlatedDrill")
+			for _, pt := range []Pt{{X: 5, Y: p.Rail / 2}, {X: width - 5, Y: p.Rail / 2}, {X: 5, Y: height - p.Rail/2}, {X: width - 5, Y: height - p.Rail/2}} {
+				npth.Add(Flash(pt.X, pt.Y, CircleShape, p.ToolingHole))
+			}
+		}
+	}
+	return g, nil
+}
+
+// routeMouseBites adds the routed outline of the boards and rails,
+// broken at the tabs, and the mouse-bite holes to the panel.
+func (p *Panel) routeMouseBites(g *Gerber, out *Layer, outlineOps []op, bmin Pt, w, h, gap, width, height, thickness float64, corner func(i, j int) Pt) {
+	var edges []panelEdge
+	npth := g.panelLayer("NonPlatedDrill")
+	// tab joins the edges at a and b (either side of a gap) around mid.
+	tab := func(vertical bool, a, b, mid float64) {
+		lo, hi := mid-p.TabWidth/2, mid+p.TabWidth/2
+		for _, at := range []float64{a, b} {
+			edges = append(edges, panelEdge{vertical: vertical, at: at, lo: lo, hi: hi})
+			n := int(p.TabWidth / p.BitePitch)
+			for k := 0; k < n; k++ {
+				t := mid + (float64(k)-0.5*float64(n-1))*p.BitePitch
+				if vertical {
+					npth.Add(Flash(at, t, CircleShape, p.BiteDiameter))
+				} else {
+					npth.Add(Flash(t, at, CircleShape, p.BiteDiameter))
+				}
+			}
+		}
+		for _, t := range []float64{lo, hi} {
+			if vertical {
+				out.Add(Line(a, t, b, t, CircleShape, thickness))
+			} else {
+				out.Add(Line(t, a, t, b, CircleShape, thickness))
+			}
+		}
+	}
+	for j := 0; j < p.Rows; j++ {
+		for i := 0; i < p.Columns; i++ {
+			c := corner(i, j)
+			if i+1 < p.Columns {
+				tab(true, c.X+w, c.X+w+gap, c.Y+h/2)
+			}
+			if j+1 < p.Rows || p.Rail > 0 {
+				tab(false, c.Y+h, c.Y+h+gap, c.X+w/2)
+			}
+			if j == 0 && p.Rail > 0 {
+				tab(false, c.Y-gap, c.Y, c.X+w/2)
+			}
+		}
+	}
+
+	var segments [][2]Pt
+	for j := 0; j < p.Rows; j++ {
+		for i := 0; i < p.Columns; i++ {
+			c := corner(i, j)
+			for _, o := range outlineOps {
+				if o.code != drawOp {
+					continue
+				}
+				for k := 1; k < len(o.pts); k++ {
+					a, b := o.pts[k-1], o.pts[k]
+					segments = append(segments, [2]Pt{
+						{X: a.X - bmin.X + c.X, Y: a.Y - bmin.Y + c.Y},
+						{X: b.X - bmin.X + c.X, Y: b.Y - bmin.Y + c.Y},
+					})
+				}
+			}
+		}
+	}
+	if p.Rail > 0 {
+		for _, y := range [][2]float64{{0, p.Rail}, {height - p.Rail, height}} {
+			r := rectangle(Pt{X: 0, Y: y[0]}, Pt{X: width, Y: y[1]}, thickness)
+			for _, prim := range r {
+				o := plot(prim)[0]
+				segments = append(segments, [2]Pt{o.pts[0], o.pts[1]})
+			}
+		}
+	}
+	for _, s := range segments {
+		for _, piece := range breakSegment(s[0], s[1], edges) {
+			out.Add(Line(piece[0].X, piece[0].Y, piece[1].X, piece[1].Y, CircleShape, thickness))
+		}
+	}
+}
+
+// breakSegment returns the pieces of the segment from a to b left after
+// removing the parts lying along the tabbed edges.
+func breakSegment(a, b Pt, edges []panelEdge) [][2]Pt {
+	const eps = 1e-6
+	pieces := [][2]Pt{{a, b}}
+	for _, e := range edges {
+		var next [][2]Pt
+		for _, s := range pieces {
+			a, b := s[0], s[1]
+			// u is the coordinate along the edge, v across it.
+			u := func(pt Pt) float64 { return pt.X }
+			v := func(pt Pt) float64 { return pt.Y }
+			at := func(u float64) Pt { return Pt{X: u, Y: e.at} }
+			if e.vertical {
+				u, v = v, u
+				at = func(u float64) Pt { return Pt{X: e.at, Y: u} }
+			}
+			if math.Abs(v(a)-e.at) > eps || math.Abs(v(b)-e.at) > eps {
+				next = append(next, s)
+				continue
+			}
+			lo, hi := math.Min(u(a), u(b)), math.Max(u(a), u(b))
+			if hi <= e.lo+eps || lo >= e.hi-eps {
+				next = append(next, s)
+				continue
+			}
+			if lo < e.lo-eps {
+				next = append(next, [2]Pt{at(lo), at(e.lo)})
+			}
+			if hi > e.hi+eps {
+				next = append(next, [2]Pt{at(e.hi), at(hi)})
+			}
+		}
+		pieces = next
+	}
+	return pieces
+}
+
+// rectangle returns the lines around the rectangle from min to max.
+func rectangle(min, max Pt, thickness float64) []Primitive {
+	return []Primitive{
+		Line(min.X, min.Y, max.X, min.Y, CircleShape, thickness),
+		Line(max.X, min.Y, max.X, max.Y, CircleShape, thickness),
+		Line(max.X, max.Y, min.X, max.Y, CircleShape, thickness),
+		Line(min.X, max.Y, min.X, min.Y, CircleShape, thickness),
+	}
+}
+
+// centerlineBounds returns the bounding box of the points of the
+// operations, ignoring the size of their apertures.
+func centerlineBounds(ops []op) (min, max Pt, ok bool) {
+	stripped := make([]op, len(ops))
+	for i, o := range ops {
+		stripped[i] = op{code: o.code, pts: o.pts}
+	}
+	return bounds(stripped)
+}
+
+// transformPrimitive returns a primitive that draws p with every point
+// transformed by xf, keeping the attributes of objects (except their
+// UUIDs, which must stay unique).
+func transformPrimitive(p Primitive, xf func(Pt) Pt) Primitive {
+	if o, ok := p.(*ObjectT); ok {
+		c := *o
+		c.p = transformPrimitive(o.p, xf)
+		c.uuid = ""
+		return &c
+	}
+	return replot(transformOps(plot(p), xf))
+}
+
+// layerFor returns the design's layer with the given extension, adding
+// it if needed.
+func (g *Gerber) layerFor(ext string) *Layer {
+	for _, l := range g.Layers {
+		if l.extension() == ext {
+			return l
+		}
+	}
+	for name, spec := range layerSpecs {
+		if spec.Extension == ext {
+			return g.panelLayer(name)
+		}
+	}
+	return g.makeLayer(ext)
+}
+
+// panelLayer returns the design's layer of the named built-in kind,
+// adding it if needed.
+func (g *Gerber) panelLayer(name string) *Layer {
+	spec := layerSpecs[name]
+	for _, l := range g.Layers {
+		if l.extension() == spec.Extension {
+			return l
+		}
+	}
+	l, _ := g.AddLayer(name)
+	return l
+}
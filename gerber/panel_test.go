@@ -0,0 +1,134 @@
+package gerber
+
+import (
+	"math"
+	"testing"
+)
+
+// panelDesign returns a 10x20mm board with a pad and a via.
+func panelDesign() *Gerber {
+	g := New("board")
+	g.TopCopper().Add(Flash(15, 15, RectShape, 1.5))
+	g.Drill().Add(Flash(15, 25, CircleShape, 0.4))
+	g.Outline().Add(rectangle(Pt{X: 10, Y: 10}, Pt{X: 20, Y: 30}, 0.1)...)
+	return g
+}
+
+// panelLayerOps returns the decoded operations of the panel layer with
+// the given extension.
+func panelLayerOps(t *testing.T, g *Gerber, ext string) []op {
+	t.Helper()
+	for _, l := range g.Layers {
+		if l.extension() == ext {
+			var ops []op
+			for _, p := range l.Primitives {
+				ops = append(ops, plot(p)...)
+			}
+			return ops
+		}
+	}
+	t.Fatalf("panel has no %v layer", ext)
+	return nil
+}
+
+func TestPanel_MouseBites(t *testing.T) {
+	g, err := NewPanel(panelDesign(), 2, 2).Gerber("panel")
+	if err != nil {
+		t.Fatalf("Gerber: %v", err)
+	}
+
+	// 4 pads and 3 fiducials.
+	if got := len(panelLayerOps(t, g, "gtl")); got != 7 {
+		t.Errorf("got %v top copper flashes, want 7", got)
+	}
+	if got := len(panelLayerOps(t, g, "gts")); got != 3 {
+		t.Errorf("got %v fiducial mask openings, want 3", got)
+	}
+	if got := len(panelLayerOps(t, g, "xln")); got != 4 {
+		t.Errorf("got %v vias, want 4", got)
+	}
+	// 4 tooling holes and 8 tabs of 2x3 holes.
+	if got := len(panelLayerOps(t, g, "nxln")); got != 52 {
+		t.Errorf("got %v non-plated holes, want 52", got)
+	}
+
+	outline := panelLayerOps(t, g, "gko")
+	min, max, _ := centerlineBounds(outline)
+	if min != (Pt{}) || math.Abs(max.X-22) > 1e-9 || math.Abs(max.Y-56) > 1e-9 {
+		t.Errorf("panel outline bounds = %v-%v, want (0,0)-(22,56)", min, max)
+	}
+	// The routed outline must be closed: every end point is shared by an
+	// even number of lines.
+	ends := map[point]int{}
+	for _, o := range outline {
+		ends[toPoint(o.pts[0])]++
+		ends[toPoint(o.pts[len(o.pts)-1])]++
+	}
+	for pt, n := range ends {
+		if n%2 != 0 {
+			t.Errorf("outline end point %v is shared by %v lines, want an even number", pt, n)
+		}
+	}
+
+	// Board (1,1) is offset by one board and one gap in each direction.
+	for _, o := range panelLayerOps(t, g, "gtl")[:4] {
+		if o.pts[0] == (Pt{X: 15 - 10 + 12, Y: 15 - 10 + 7 + 22}) {
+			return
+		}
+	}
+	t.Error("missing the pad of board (1,1) at (17,34)")
+}
+
+func TestPanel_VScore(t *testing.T) {
+	p := NewPanel(panelDesign(), 3, 2)
+	p.Separation = VScore
+	p.Fiducials, p.ToolingHole = false, 0
+	g, err := p.Gerber("panel")
+	if err != nil {
+		t.Fatalf("Gerber: %v", err)
+	}
+	if got := len(panelLayerOps(t, g, "gko")); got != 4 {
+		t.Errorf("got %v outline lines, want 4", got)
+	}
+	// 2 lines between the columns, 1 between the rows and 2 along the rails.
+	if got := len(panelLayerOps(t, g, "gvs")); got != 5 {
+		t.Errorf("got %v V-score lines, want 5", got)
+	}
+}
+
+func TestPanel_FiducialInset(t *testing.T) {
+	p := NewPanel(panelDesign(), 2, 2)
+	p.FiducialInset = 4
+	g, err := p.Gerber("panel")
+	if err != nil {
+		t.Fatalf("Gerber: %v", err)
+	}
+	var got []Pt
+	for _, o := range panelLayerOps(t, g, "gts") {
+		got = append(got, o.pts[0])
+	}
+	if want := []Pt{{X: 4, Y: 2.5}, {X: 18, Y: 2.5}, {X: 4, Y: 53.5}}; len(got) != 3 || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("fiducials at %v, want %v", got, want)
+	}
+}
+
+func TestPanel_Errors(t *testing.T) {
+	if _, err := NewPanel(New("empty"), 2, 2).Gerber("panel"); err == nil {
+		t.Error("expected an error for a design without an outline")
+	}
+	p := NewPanel(panelDesign(), 2, 2)
+	p.Rail = 0
+	if _, err := p.Gerber("panel"); err == nil {
+		t.Error("expected an error for fiducials without rails")
+	}
+	p = NewPanel(panelDesign(), 2, 2)
+	p.FiducialInset = 20
+	if _, err := p.Gerber("panel"); err == nil {
+		t.Error("expected an error for fiducials beyond the middle of the panel")
+	}
+	p = NewPanel(panelDesign(), 2, 2)
+	p.TabWidth = 15
+	if _, err := p.Gerber("panel"); err == nil {
+		t.Error("expected an error for tabs wider than the board")
+	}
+}
@@ -0,0 +1,213 @@
+package gerber
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// PatternFill tiles the tile primitives (drawn around 0,0) across the
+// region polygon on a grid of pitchX by pitchY rotated by angle (in
+// degrees), rotating the tiles alike. Tiles lying entirely within the
+// region are kept as they are and those crossing its boundary are
+// clipped to it (as the regions of their Intersection with it), so that
+// the pattern fills the region up to its boundary. Boundary tiles the
+// boolean engine fails to clip are left out (and logged).
+// It returns the primitives to add to a layer.
+// Apertures do not rotate, so rotated tiles should use circular apertures
+// or regions.
+// All dimensions are in millimeters.
+func PatternFill(region []Pt, tile []Primitive, pitchX, pitchY, angle float64) []Primitive {
+	if pitchX <= 0 || pitchY <= 0 || len(region) < 3 {
+		return nil
+	}
+	var tileOps [][]op
+	var all []op
+	for _, p := range tile {
+		ops := plot(p)
+		tileOps = append(tileOps, ops)
+		all = append(all, ops...)
+	}
+	tmin, tmax, ok := bounds(all)
+	if !ok {
+		return nil
+	}
+	rmin, rmax, _ := bounds([]op{{code: regionOp, pts: region}})
+	clip := []Primitive{Region(region)}
+
+	sin, cos := math.Sincos(math.Pi * angle / 180.0)
+	rotate := func(pt Pt) Pt {
+		return Pt{X: pt.X*cos - pt.Y*sin, Y: pt.X*sin + pt.Y*cos}
+	}
+	// The grid indices covering the region in the rotated frame.
+	var imin, imax, jmin, jmax = math.Inf(1), math.Inf(-1), math.Inf(1), math.Inf(-1)
+	for _, c := range []Pt{{X: rmin.X, Y: rmin.Y}, {X: rmax.X, Y: rmin.Y}, {X: rmax.X, Y: rmax.Y}, {X: rmin.X, Y: rmax.Y}} {
+		u := Pt{X: c.X*cos + c.Y*sin, Y: -c.X*sin + c.Y*cos} // inverse rotation
+		imin, imax = math.Min(imin, u.X/pitchX), math.Max(imax, u.X/pitchX)
+		jmin, jmax = math.Min(jmin, u.Y/pitchY), math.Max(jmax, u.Y/pitchY)
+	}
+
+	var result []Primitive
+	for j := math.Floor(jmin) - 1; j <= math.Ceil(jmax)+1; j++ {
+		for i := math.Floor(imin) - 1; i <= math.Ceil(imax)+1; i++ {
+			offset := rotate(Pt{X: i * pitchX, Y: j * pitchY})
+			xf := func(pt Pt) Pt {
+				r := rotate(pt)
+				return Pt{X: r.X + offset.X, Y: r.Y + offset.Y}
+			}
+			corners := []Pt{xf(tmin), xf(Pt{X: tmax.X, Y: tmin.Y}), xf(tmax), xf(Pt{X: tmin.X, Y: tmax.Y})}
+			var prims []Primitive
+			for _, ops := range tileOps {
+				prims = append(prims, replot(transformOps(ops, xf)))
+			}
+			if polygonContains(region, corners) {
+				result = append(result, prims...)
+				continue
+			}
+			if min, max, _ := bounds([]op{{code: regionOp, pts: corners}}); min.X > rmax.X || max.X < rmin.X || min.Y > rmax.Y || max.Y < rmin.Y {
+				continue
+			}
+			clipped, err := Intersection(prims, clip)
+			if err != nil {
+				logger.Warn("could not clip pattern tile: skipping", "at", offset, "error", err)
+				continue
+			}
+			result = append(result, clipped...)
+		}
+	}
+	return result
+}
+
+// polygonContains reports whether the convex quadrilateral lies entirely
+// within the polygon: all of its corners are inside and none of the
+// polygon's vertices are inside it.
+func polygonContains(polygon, quad []Pt) bool {
+	for _, c := range quad {
+		if !insidePolygon(polygon, c) {
+			return false
+		}
+	}
+	closed := append(append([]Pt(nil), quad...), quad[0])
+	for _, v := range polygon {
+		if insidePolygon(closed, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// insidePolygon reports whether pt lies inside the polygon (even-odd rule).
+func insidePolygon(polygon []Pt, pt Pt) bool {
+	inside := false
+	n := len(polygon)
+	for i := 0; i < n; i++ {
+		a, b := polygon[i], polygon[(i+1)%n]
+		if (a.Y > pt.Y) != (b.Y > pt.Y) && pt.X < a.X+(pt.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// transformOps returns a copy of the operations with all points transformed.
+func transformOps(ops []op, xf func(Pt) Pt) []op {
+	result := make([]op, len(ops))
+	for i, o := range ops {
+		result[i] = o
+		result[i].pts = make([]Pt, len(o.pts))
+		for j, pt := range o.pts {
+			result[i].pts[j] = xf(pt)
+		}
+	}
+	return result
+}
+
+// replotT re-emits decoded graphics operations (e.g. after transforming
+// them) and satisfies the Primitive interface.
+type replotT struct {
+	ops      []op
+	aperture *Aperture
+}
+
+// WriteGerber writes the primitive to the Gerber file.
+func (r *replotT) WriteGerber(w io.Writer, apertureIndex int) error {
+	clear := false
+	for _, o := range r.ops {
+		if o.clear != clear {
+			if o.clear {
+				io.WriteString(w, "%LPC*%\n")
+			} else {
+				io.WriteString(w, "%LPD*%\n")
+			}
+			clear = o.clear
+		}
+		switch o.code {
+		case drawOp:
+			fmt.Fprintf(w, "G54D%d*\n", apertureIndex)
+			writeXY(w, toNM(o.pts[0].X), toNM(o.pts[0].Y), 2)
+			writeXY(w, toNM(o.pts[1].X), toNM(o.pts[1].Y), 1)
+		case flashOp:
+			fmt.Fprintf(w, "G54D%d*\n", apertureIndex)
+			writeXY(w, toNM(o.pts[0].X), toNM(o.pts[0].Y), 3)
+		case regionOp:
+			if err := Polygon(0, 0, true, o.pts, 0).WriteGerber(w, apertureIndex); err != nil {
+				return err
+			}
+		}
+	}
+	if clear {
+		io.WriteString(w, "%LPD*%\n")
+	}
+	return nil
+}
+
+// Aperture returns the aperture of the original primitive.
+func (r *replotT) Aperture() *Aperture {
+	return r.aperture
+}
+
+// replot returns a primitive re-emitting the operations with their own
+// apertures: a replotT if they share one and a replotsT otherwise (e.g.
+// for the operations of a step and repeat block).
+func replot(ops []op) Primitive {
+	runs := replotRuns(ops)
+	if len(runs) == 1 {
+		return runs[0]
+	}
+	return &replotsT{runs: runs}
+}
+
+// replotRuns returns a replotT for every run of operations with the same
+// aperture.
+func replotRuns(ops []op) []Primitive {
+	var result []Primitive
+	var run *replotT
+	for _, o := range ops {
+		if run == nil || run.aperture != o.aperture {
+			run = &replotT{aperture: o.aperture}
+			result = append(result, run)
+		}
+		run.ops = append(run.ops, o)
+	}
+	return result
+}
+
+// replotsT re-emits runs of decoded graphics operations made with
+// different apertures and satisfies the Primitive interface.
+type replotsT struct {
+	runs []Primitive
+}
+
+// WriteGerber writes the primitive to the Gerber file.
+func (r *replotsT) WriteGerber(w io.Writer, apertureIndex int) error {
+	return writeChildren(w, r.runs)
+}
+
+// Aperture returns nil: the runs use their own apertures.
+func (r *replotsT) Aperture() *Aperture {
+	return nil
+}
+
+func (r *replotsT) children() []Primitive {
+	return r.runs
+}
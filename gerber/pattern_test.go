@@ -0,0 +1,50 @@
+package gerber
+
+import "testing"
+
+func TestPatternFill(t *testing.T) {
+	square := []Pt{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}, {X: 0, Y: 0}}
+	tile := []Primitive{Circle(0, 0, 1), Line(-0.5, 0, 0.5, 0, CircleShape, 0.2)}
+
+	tests := []struct {
+		name  string
+		angle float64
+		want  int // number of tiles, if known
+	}{
+		// Tiles at 1..9 in both directions fit (0 and 10 are clipped).
+		{"grid", 0, 81},
+		{"rotated", 45, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prims := PatternFill(square, tile, 1, 1, tt.angle)
+			// The 40 tiles along the boundary are clipped into regions.
+			if tt.want > 0 && len(prims) != 2*tt.want+40 {
+				t.Errorf("got %v primitives, want %v", len(prims), 2*tt.want+40)
+			}
+			l := New("board").TopCopper()
+			l.Add(prims...)
+			if len(prims) == 0 {
+				t.Fatal("PatternFill must place tiles")
+			}
+			min, max, _ := l.bounds()
+			if min.X < -1e-6 || min.Y < -1e-6 || max.X > 10+1e-6 || max.Y > 10+1e-6 {
+				t.Errorf("pattern %v-%v not clipped to the region", min, max)
+			}
+			if min.X > 1e-6 || min.Y > 1e-6 || max.X < 10-1e-6 || max.Y < 10-1e-6 {
+				t.Errorf("pattern %v-%v does not reach the boundary of the region", min, max)
+			}
+			if tt.want > 0 {
+				var lines int
+				for _, p := range prims {
+					if ops := plot(p); len(ops) == 1 && ops[0].aperture != nil && ops[0].aperture.Size == 0.2 {
+						lines++
+					}
+				}
+				if lines != tt.want {
+					t.Errorf("%v tile lines kept their aperture, want %v", lines, tt.want)
+				}
+			}
+		})
+	}
+}
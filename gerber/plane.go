@@ -0,0 +1,218 @@
+package gerber
+
+import (
+	"io"
+	"math"
+)
+
+// hole is a drilled hole of the design.
+type hole struct {
+	center Pt
+	drill  float64
+	net    string
+}
+
+// holes returns all holes on the drill layers of the design, including
+// those of its pads (see Gerber.AddPad).
+func (g *Gerber) holes() []hole {
+	var result []hole
+	for _, l := range g.withDerived() {
+		if !l.drill() {
+			continue
+		}
+		for _, p := range l.Primitives {
+			if p = forVariant(p, g.Variant); p == nil {
+				continue
+			}
+			min, max, ok := bounds(plot(unwrap(p)))
+			if !ok {
+				continue
+			}
+			h := hole{center: Pt{X: 0.5 * (min.X + max.X), Y: 0.5 * (min.Y + max.Y)}, drill: max.X - min.X}
+			if o, ok := p.(*ObjectT); ok {
+				h.net = o.net
+			}
+			result = append(result, h)
+		}
+	}
+	return result
+}
+
+// PlaneT represents a copper plane of a net on an inner layer with
+// automatic anti-pads and thermal reliefs and satisfies the Primitive interface.
+type PlaneT struct {
+	g          *Gerber
+	outline    []Pt
+	net        string
+	clearance  float64
+	gap        float64
+	spokeWidth float64
+	annular    float64
+	negative   bool
+}
+
+// Plane returns a copper plane of the named net filling the outline.
+// When written, every hole of the design (on its plated and non-plated
+// drill layers) within the outline is given an anti-pad of clearance mm
+// around it, except holes of the same net, which are connected by
+// thermal reliefs (see Thermal).
+// All dimensions are in millimeters.
+func (g *Gerber) Plane(outline []Pt, net string, clearance float64) *PlaneT {
+	return &PlaneT{g: g, outline: outline, net: net, clearance: clearance, gap: clearance, spokeWidth: 0.3, annular: 0.25}
+}
+
+// Thermal sets the thermal reliefs connecting holes of the plane's net:
+// an annular ring of annular mm around the hole separated from the plane
+// by a gap of gap mm bridged by four spokes of spokeWidth mm.
+// A zero gap connects the holes solidly.
+func (p *PlaneT) Thermal(gap, spokeWidth, annular float64) *PlaneT {
+	p.gap, p.spokeWidth, p.annular = gap, spokeWidth, annular
+	return p
+}
+
+// Negative draws the plane in negative convention for a NegativePlane
+// layer: only the anti-pads and thermal gaps are drawn (dark). The plane
+// fills the whole board and the outline (if any) only limits the holes
+// of the plane.
+func (p *PlaneT) Negative() *PlaneT {
+	p.negative = true
+	return p
+}
+
+// WriteGerber writes the primitive to the Gerber file.
+func (p *PlaneT) WriteGerber(w io.Writer, apertureIndex int) error {
+	copper, clearance := "%LPD*%\n", "%LPC*%\n"
+	if p.negative {
+		copper, clearance = clearance, copper
+	} else if err := Polygon(0, 0, true, p.outline, 0).WriteGerber(w, apertureIndex); err != nil {
+		return err
+	}
+	region := func(pts []Pt) {
+		Polygon(0, 0, true, append(pts, pts[0]), 0).WriteGerber(w, apertureIndex)
+	}
+	var thermals []hole
+	io.WriteString(w, clearance)
+	for _, h := range p.g.holes() {
+		if len(p.outline) >= 3 && !insidePolygon(p.outline, h.center) {
+			continue
+		}
+		if p.net != "" && h.net == p.net {
+			if p.gap > 0 {
+				thermals = append(thermals, h)
+				region(circle(h.center, 0.5*h.drill+p.annular+p.gap))
+			}
+			continue
+		}
+		region(circle(h.center, 0.5*h.drill+p.clearance))
+	}
+	io.WriteString(w, copper)
+	for _, h := range thermals {
+		ring := 0.5*h.drill + p.annular
+		region(circle(h.center, ring))
+		// Spokes reach across the gap into the plane.
+		r, hw := ring+p.gap+0.5*p.spokeWidth, 0.5*p.spokeWidth
+		for i := 0; i < 4; i++ {
+			sin, cos := math.Sincos(0.5 * math.Pi * float64(i))
+			at := func(along, across float64) Pt {
+				return Pt{X: h.center.X + along*cos - across*sin, Y: h.center.Y + along*sin + across*cos}
+			}
+			region([]Pt{at(0, -hw), at(r, -hw), at(r, hw), at(0, hw)})
+		}
+	}
+	if p.negative {
+		io.WriteString(w, "%LPD*%\n")
+	}
+	return nil
+}
+
+// Aperture returns nil for PlaneT because it uses the default aperture.
+func (p *PlaneT) Aperture() *Aperture {
+	return nil
+}
+
+// Island is a part of a split plane connected to a net.
+type Island struct {
+	// Net is the name of the net of the island (e.g. "3V3").
+	Net string
+	// Outline is the closed outline of the island.
+	Outline []Pt
+}
+
+// SplitPlaneT represents a plane divided into islands of different nets
+// separated by moats and satisfies the Primitive interface.
+type SplitPlaneT struct {
+	islands  []*PlaneT
+	moat     float64
+	negative bool
+}
+
+// SplitPlane returns a plane divided into the given islands, each
+// separated from its neighbors by a moat of moat mm (centered on the
+// island outlines). Holes are connected to the island of their net by
+// thermal reliefs and given anti-pads of clearance mm in all other islands.
+// All dimensions are in millimeters.
+func (g *Gerber) SplitPlane(islands []Island, moat, clearance float64) *SplitPlaneT {
+	s := &SplitPlaneT{moat: moat}
+	for _, island := range islands {
+		s.islands = append(s.islands, g.Plane(island.Outline, island.Net, clearance))
+	}
+	return s
+}
+
+// Thermal sets the thermal reliefs of all the islands (see PlaneT.Thermal).
+func (s *SplitPlaneT) Thermal(gap, spokeWidth, annular float64) *SplitPlaneT {
+	for _, p := range s.islands {
+		p.Thermal(gap, spokeWidth, annular)
+	}
+	return s
+}
+
+// Negative draws the split plane in negative convention for a
+// NegativePlane layer: only the moats, anti-pads and thermal gaps are drawn.
+func (s *SplitPlaneT) Negative() *SplitPlaneT {
+	s.negative = true
+	return s
+}
+
+// WriteGerber writes the primitive to the Gerber file.
+func (s *SplitPlaneT) WriteGerber(w io.Writer, apertureIndex int) error {
+	for _, p := range s.islands {
+		island := *p
+		island.negative = s.negative
+		if err := island.WriteGerber(w, apertureIndex); err != nil {
+			return err
+		}
+	}
+	if s.moat <= 0 {
+		return nil
+	}
+	moat := "%LPC*%\n"
+	if s.negative {
+		moat = "%LPD*%\n"
+	}
+	io.WriteString(w, moat)
+	region := func(pts []Pt) {
+		Polygon(0, 0, true, append(pts, pts[0]), 0).WriteGerber(w, apertureIndex)
+	}
+	hw := 0.5 * s.moat
+	for _, p := range s.islands {
+		for i := 0; i+1 < len(p.outline); i++ {
+			a, b := p.outline[i], p.outline[i+1]
+			dx, dy := b.X-a.X, b.Y-a.Y
+			l := math.Hypot(dx, dy)
+			if l == 0 {
+				continue
+			}
+			nx, ny := -dy*hw/l, dx*hw/l
+			region([]Pt{{X: a.X - nx, Y: a.Y - ny}, {X: b.X - nx, Y: b.Y - ny}, {X: b.X + nx, Y: b.Y + ny}, {X: a.X + nx, Y: a.Y + ny}})
+			region(circle(b, hw))
+		}
+	}
+	io.WriteString(w, "%LPD*%\n")
+	return nil
+}
+
+// Aperture returns nil for SplitPlaneT because it uses the default aperture.
+func (s *SplitPlaneT) Aperture() *Aperture {
+	return nil
+}
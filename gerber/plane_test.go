@@ -0,0 +1,129 @@
+package gerber
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGerber_Plane(t *testing.T) {
+	g := New("board")
+	inner, drill := g.Layer2(), g.Drill()
+	Via(5, 5, 0.3, 0.6).Net("GND").Add(nil, nil, drill, nil, nil)
+	Via(2, 2, 0.3, 0.6).Net("VCC").Add(nil, nil, drill, nil, nil)
+	drill.Add(Circle(8, 8, 1))
+	g.NonPlatedDrill().Add(Circle(8, 2, 1))
+
+	outline := []Pt{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}, {X: 0, Y: 0}}
+	plane := g.Plane(outline, "GND", 0.2)
+	inner.Add(plane)
+
+	var clear, dark int
+	for _, o := range plot(plane) {
+		if o.clear {
+			clear++
+		} else {
+			dark++
+		}
+	}
+	// Anti-pads for the VCC via, the unconnected and non-plated holes and
+	// the GND thermal gap; the plane itself plus the GND ring and 4 spokes.
+	if clear != 4 || dark != 6 {
+		t.Errorf("got %v clear and %v dark regions, want 4 and 6", clear, dark)
+	}
+
+	// The anti-pad of the 1mm hole has a radius of 0.5+0.2mm.
+	var found bool
+	for _, o := range plot(plane) {
+		if min, max, _ := bounds([]op{o}); o.clear && max.X > 8.69 && max.X-min.X > 1.39 {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("missing anti-pad around the unconnected hole")
+	}
+
+	if ops := plot(g.Plane(outline, "GND", 0.2).Thermal(0, 0, 0)); len(ops) != 4 {
+		t.Errorf("solid connection: got %v regions, want the plane and 3 anti-pads", len(ops))
+	}
+}
+
+func TestGerber_NegativePlane(t *testing.T) {
+	g := New("board")
+	inner, drill := g.NegativePlane(2), g.Drill()
+	Via(5, 5, 0.3, 0.6).Net("GND").Add(nil, nil, drill, nil, nil)
+	drill.Add(Circle(8, 8, 1))
+	plane := g.Plane(nil, "GND", 0.2).Negative()
+	inner.Add(plane)
+
+	if !inner.Negative() || inner.Filename != "board.g2l" {
+		t.Errorf("got negative %v, filename %q, want a negative g2l layer", inner.Negative(), inner.Filename)
+	}
+	var buf bytes.Buffer
+	if err := inner.WriteGerber(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"%TF.FileFunction,Copper,L2,Inr,Plane*%", "%TF.FilePolarity,Negative*%"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("missing %v in:\n%v", want, buf.String())
+		}
+	}
+
+	// Dark anti-pads for the hole and the thermal gap; clear ring and 4 spokes.
+	var clear, dark int
+	for _, o := range plot(plane) {
+		if o.clear {
+			clear++
+		} else {
+			dark++
+		}
+	}
+	if clear != 5 || dark != 2 {
+		t.Errorf("got %v clear and %v dark regions, want 5 and 2", clear, dark)
+	}
+	if !strings.HasSuffix(buf.String(), "%LPD*%\nM02*\n") {
+		t.Error("polarity not restored to dark after the plane")
+	}
+}
+
+func TestGerber_SplitPlane(t *testing.T) {
+	g := New("board")
+	inner, drill := g.Layer2(), g.Drill()
+	Via(2, 5, 0.3, 0.6).Net("GND").Add(nil, nil, drill, nil, nil)
+	Via(8, 5, 0.3, 0.6).Net("3V3").Add(nil, nil, drill, nil, nil)
+	Via(7, 2, 0.3, 0.6).Net("GND").Add(nil, nil, drill, nil, nil)
+
+	islands := []Island{
+		{Net: "GND", Outline: []Pt{{X: 0, Y: 0}, {X: 5, Y: 0}, {X: 5, Y: 10}, {X: 0, Y: 10}, {X: 0, Y: 0}}},
+		{Net: "3V3", Outline: []Pt{{X: 5, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 5, Y: 10}, {X: 5, Y: 0}}},
+	}
+	plane := g.SplitPlane(islands, 0.5, 0.2)
+	inner.Add(plane)
+
+	var clear, dark int
+	for _, o := range plot(plane) {
+		if o.clear {
+			clear++
+		} else {
+			dark++
+		}
+	}
+	// GND island: plane, thermal gap, ring and 4 spokes.
+	// 3V3 island: plane, thermal gap, ring and 4 spokes, GND anti-pad.
+	// Moats: 4 edges and 4 joins per island.
+	if clear != 2+1+16 || dark != 12 {
+		t.Errorf("got %v clear and %v dark regions, want 19 and 12", clear, dark)
+	}
+
+	clear, dark = 0, 0
+	for _, o := range plot(g.SplitPlane(islands, 0.5, 0.2).Negative()) {
+		if o.clear {
+			clear++
+		} else {
+			dark++
+		}
+	}
+	if clear != 10 || dark != 2+1+16 {
+		t.Errorf("negative: got %v clear and %v dark regions, want 10 and 19", clear, dark)
+	}
+}
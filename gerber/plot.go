@@ -0,0 +1,523 @@
+package gerber
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// opCode represents the kind of graphics operation decoded from Gerber data.
+type opCode int
+
+const (
+	drawOp opCode = iota
+	flashOp
+	regionOp
+)
+
+// op is a single graphics operation decoded from the Gerber data emitted
+// by a primitive. Sharing this decoding with the writer guarantees that
+// analyses see exactly the geometry that ends up in the files.
+type op struct {
+	code     opCode
+	aperture *Aperture // nil for regions
+	clear    bool      // clear (LPC) polarity
+	pts      []Pt      // draw: start and end, flash: center, region: contour
+	// more is true for the contours after the first one of an
+	// aperture outline flashed as regions.
+	more bool
+}
+
+// plot renders a primitive and decodes its graphics operations.
+func plot(p Primitive) []op {
+	var buf bytes.Buffer
+	fw := &writer{Writer: &buf, format: DefaultFormat, units: Millimeters, codes: map[string]int{"default": 11}}
+	decoded := map[int]*Aperture{11: defaultAperture}
+	fw.nextCode = 12
+	for _, a := range apertures(p) {
+		if _, ok := fw.codes[a.ID()]; ok {
+			continue
+		}
+		fw.codes[a.ID()] = fw.nextCode
+		if a.Custom != nil {
+			// Macro apertures are decoded from their definitions.
+			a.Custom.Macro.WriteGerber(fw)
+			a.WriteGerber(fw, fw.nextCode)
+		} else {
+			decoded[fw.nextCode] = a
+		}
+		fw.nextCode++
+	}
+	p.WriteGerber(fw, fw.codes[p.Aperture().ID()])
+	ops, _ := decode(buf.Bytes(), decoded)
+	return ops
+}
+
+// plot decodes the graphics operations of all the primitives of the
+// layer (in the design's variant) as they are written to its Gerber file.
+func (l *Layer) plot() []op {
+	var ops []op
+	for _, p := range l.Primitives {
+		if p = forVariant(p, l.variant()); p == nil {
+			continue
+		}
+		ops = append(ops, plot(p)...)
+	}
+	return ops
+}
+
+// defaultAperture is the tiny aperture used by regions (D11).
+var defaultAperture = &Aperture{Shape: CircleShape, Size: 0.001}
+
+// decode decodes the RS274X commands used by this package and by common
+// CAD exporters (such as KiCad): standard and macro apertures, linear and
+// circular interpolation, regions, polarity, step and repeat and
+// aperture blocks.
+// Apertures defined in the data are added to the apertures map.
+// Constructs decoded incorrectly otherwise (coordinate formats other than
+// leading zero omission with absolute coordinates, and arcs in single
+// quadrant mode) return an error.
+func decode(data []byte, apertures map[int]*Aperture) ([]op, error) {
+	var ops []op
+	var cur *Aperture
+	var x, y float64
+	var region []Pt
+	var inRegion, clear bool
+	var arc int // interpolation mode: 0 for linear, 2 for clockwise, 3 for counterclockwise
+	var singleQuadrant bool
+	var function string
+	var shape *outline // outline of the current aperture, if it has one
+	outlines := map[int]*outline{}
+	macros := map[string][]string{}
+	lastD := -1
+	scale, mm := sf, 1.0   // coordinate units per mm unit, mm per unit
+	var repeat *stepRepeat // the open step and repeat block, if any
+	var blockStack []openBlock
+	blocks := map[int][]op{} // operations of the aperture blocks
+	var blockOps []op        // of the current aperture, if it is a block
+
+	for _, line := range statements(data) {
+		switch {
+		case strings.HasPrefix(line, "%LPC"):
+			clear = true
+			continue
+		case strings.HasPrefix(line, "%LPD"):
+			clear = false
+			continue
+		case strings.HasPrefix(line, "%FSLAX") && len(line) >= 8:
+			if d, err := strconv.Atoi(line[7:8]); err == nil {
+				scale = math.Pow(10, float64(d))
+			}
+			continue
+		case strings.HasPrefix(line, "%FS"):
+			return nil, fmt.Errorf("unsupported coordinate format %v (only leading zero omission and absolute coordinates are)", line)
+		case strings.HasPrefix(line, "%MOIN"):
+			mm = 25.4
+			continue
+		case strings.HasPrefix(line, "%MOMM"):
+			mm = 1
+			continue
+		case strings.HasPrefix(line, "%TA.AperFunction,"):
+			function = strings.TrimSuffix(strings.TrimPrefix(line, "%TA.AperFunction,"), "*%")
+			continue
+		case strings.HasPrefix(line, "%TD"):
+			function = ""
+			continue
+		case strings.HasPrefix(line, "%AM"):
+			name, body, _ := strings.Cut(strings.TrimSuffix(strings.TrimPrefix(line, "%AM"), "%"), "*")
+			macros[name] = strings.Split(body, "*")
+			continue
+		case strings.HasPrefix(line, "%ADD"):
+			if n, a, o, ok := parseAD(line, mm, macros); ok && apertures[n] == nil && outlines[n] == nil {
+				if a != nil {
+					a.Function = function
+					apertures[n] = a
+				} else {
+					outlines[n] = o
+				}
+			}
+			continue
+		case strings.HasPrefix(line, "%SR"):
+			if repeat != nil {
+				ops = repeat.apply(ops)
+			}
+			if repeat = parseSR(line, mm); repeat != nil {
+				repeat.start = len(ops)
+			}
+			continue
+		case strings.HasPrefix(line, "%AB"):
+			if n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "%ABD"), "*%")); err == nil {
+				blockStack = append(blockStack, openBlock{code: n, start: len(ops)})
+			} else if len(blockStack) > 0 {
+				b := blockStack[len(blockStack)-1]
+				blockStack = blockStack[:len(blockStack)-1]
+				blocks[b.code] = append([]op(nil), ops[b.start:]...)
+				ops = ops[:b.start]
+			}
+			continue
+		case strings.HasPrefix(line, "%"):
+			continue
+		}
+		for _, block := range strings.Split(line, "*") {
+			if block == "" {
+				continue
+			}
+			if strings.HasPrefix(block, "G36") {
+				inRegion, region = true, nil
+				continue
+			}
+			if strings.HasPrefix(block, "G37") {
+				if len(region) > 0 {
+					ops = append(ops, op{code: regionOp, clear: clear, pts: region})
+				}
+				inRegion, region = false, nil
+				continue
+			}
+			block = strings.TrimPrefix(block, "G54")
+			switch {
+			case strings.HasPrefix(block, "G74"):
+				singleQuadrant = true
+				continue
+			case strings.HasPrefix(block, "G75"):
+				singleQuadrant = false
+				continue
+			case strings.HasPrefix(block, "G91"):
+				return nil, errors.New("unsupported incremental coordinates (G91)")
+			case strings.HasPrefix(block, "G01"):
+				arc, block = 0, block[3:]
+			case strings.HasPrefix(block, "G02"), strings.HasPrefix(block, "G03"):
+				arc, block = int(block[2]-'0'), block[3:]
+			}
+			if strings.HasPrefix(block, "D") {
+				if n, err := strconv.Atoi(block[1:]); err == nil && n >= 10 {
+					cur, shape, blockOps = apertures[n], outlines[n], blocks[n]
+					continue
+				}
+			}
+			nx, ny, ij, d, ok := parseXYD(block, x, y, scale/mm)
+			if d < 0 && lastD == 1 && strings.ContainsAny(block, "XY") {
+				d, ok = 1, true // deprecated modal D01
+			}
+			if !ok {
+				continue
+			}
+			lastD = d
+			switch d {
+			case 1:
+				pts := []Pt{{X: nx, Y: ny}}
+				if arc != 0 && singleQuadrant {
+					return nil, fmt.Errorf("unsupported arc in single quadrant mode (G74) at %v,%v", nx, ny)
+				}
+				if arc != 0 {
+					pts = arcPoints(Pt{X: x, Y: y}, Pt{X: nx, Y: ny}, Pt{X: x + ij.X, Y: y + ij.Y}, arc == 2)
+				}
+				if inRegion {
+					if len(region) == 0 {
+						region = append(region, Pt{X: x, Y: y})
+					}
+					region = append(region, pts...)
+					break
+				}
+				prev := Pt{X: x, Y: y}
+				for _, pt := range pts {
+					if shape != nil {
+						ops = append(ops, shape.draw(prev, pt, clear))
+					} else {
+						ops = append(ops, op{code: drawOp, aperture: cur, clear: clear, pts: []Pt{prev, pt}})
+					}
+					prev = pt
+				}
+			case 2:
+				if inRegion && len(region) > 0 {
+					ops = append(ops, op{code: regionOp, clear: clear, pts: region})
+					region = nil
+				}
+			case 3:
+				if blockOps != nil {
+					ops = append(ops, translate(blockOps, Pt{X: nx, Y: ny}, clear)...)
+					break
+				}
+				if shape != nil {
+					ops = append(ops, shape.flash(Pt{X: nx, Y: ny}, clear)...)
+					break
+				}
+				ops = append(ops, op{code: flashOp, aperture: cur, clear: clear, pts: []Pt{{X: nx, Y: ny}}})
+			}
+			x, y = nx, ny
+		}
+	}
+	if repeat != nil {
+		ops = repeat.apply(ops)
+	}
+	return ops, nil
+}
+
+// stepRepeat is an open step and repeat block of decoded operations.
+type stepRepeat struct {
+	x, y   int
+	dx, dy float64 // in millimeters
+	start  int     // index of its first operation
+}
+
+// parseSR parses a step and repeat statement such as "%SRX3Y2I5.0J4.0*%",
+// returning nil for the statement closing the block.
+func parseSR(line string, mm float64) *stepRepeat {
+	s := &stepRepeat{x: 1, y: 1}
+	body := strings.TrimSuffix(strings.TrimPrefix(line, "%SR"), "*%")
+	for body != "" {
+		i := strings.IndexAny(body[1:], "XYIJ") + 1
+		if i == 0 {
+			i = len(body)
+		}
+		v, _ := strconv.ParseFloat(body[1:i], 64)
+		switch body[0] {
+		case 'X':
+			s.x = int(v)
+		case 'Y':
+			s.y = int(v)
+		case 'I':
+			s.dx = v * mm
+		case 'J':
+			s.dy = v * mm
+		}
+		body = body[i:]
+	}
+	if s.x*s.y <= 1 {
+		return nil
+	}
+	return s
+}
+
+// apply closes the step and repeat block opened when ops had
+// s.start operations, appending the copies of its operations.
+func (s *stepRepeat) apply(ops []op) []op {
+	block := ops[s.start:]
+	var copies []op
+	for j := 0; j < s.y; j++ {
+		for i := 0; i < s.x; i++ {
+			if i > 0 || j > 0 {
+				copies = append(copies, translate(block, Pt{X: float64(i) * s.dx, Y: float64(j) * s.dy}, false)...)
+			}
+		}
+	}
+	return append(ops, copies...)
+}
+
+// openBlock is an aperture block being decoded.
+type openBlock struct {
+	code  int // D code of the block
+	start int // index of its first operation
+}
+
+// translate returns copies of the operations moved by offset, with
+// their polarity toggled if clear is true (as for aperture blocks
+// flashed with clear polarity).
+func translate(ops []op, offset Pt, clear bool) []op {
+	result := make([]op, len(ops))
+	for i, o := range ops {
+		o.clear = o.clear != clear
+		pts := make([]Pt, len(o.pts))
+		for j, pt := range o.pts {
+			pts[j] = Pt{X: pt.X + offset.X, Y: pt.Y + offset.Y}
+		}
+		o.pts = pts
+		result[i] = o
+	}
+	return result
+}
+
+// statements splits Gerber data into its extended commands (such as
+// aperture macros, which may span several lines) and its data blocks
+// (ending with '*'), whatever their layout on lines.
+func statements(data []byte) []string {
+	var result []string
+	var b strings.Builder
+	var extended bool
+	flush := func() {
+		if st := b.String(); st != "" {
+			result = append(result, st)
+		}
+		b.Reset()
+	}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		for _, c := range bytes.TrimSpace(line) {
+			switch {
+			case c == '%' && !extended:
+				flush()
+				b.WriteByte(c)
+				extended = true
+			case c == '%':
+				b.WriteByte(c)
+				flush()
+				extended = false
+			case c == '*' && !extended:
+				b.WriteByte(c)
+				flush()
+			default:
+				b.WriteByte(c)
+			}
+		}
+	}
+	flush()
+	return result
+}
+
+// parseXYD parses a coordinate data block such as "X100Y-200D01",
+// keeping the previous coordinates for omitted (modal) values.
+// It also returns the I/J center offset of circular interpolation.
+// Coordinates are divided by scale to convert them to millimeters.
+func parseXYD(block string, x, y, scale float64) (float64, float64, Pt, int, bool) {
+	var ij Pt
+	d := -1
+	for len(block) > 0 {
+		c := block[0]
+		i := 1
+		for i < len(block) && (block[i] == '-' || block[i] == '+' || (block[i] >= '0' && block[i] <= '9')) {
+			i++
+		}
+		v, err := strconv.ParseInt(block[1:i], 10, 64)
+		if err != nil {
+			return x, y, ij, d, false
+		}
+		switch c {
+		case 'X':
+			x = float64(v) / scale
+		case 'Y':
+			y = float64(v) / scale
+		case 'I':
+			ij.X = float64(v) / scale
+		case 'J':
+			ij.Y = float64(v) / scale
+		case 'D':
+			d = int(v)
+		}
+		block = block[i:]
+	}
+	return x, y, ij, d, d >= 1 && d <= 3
+}
+
+// arcPoints approximates the circular arc (in multi quadrant mode) from
+// start to end around center by segments no longer than 0.1mm and
+// returns the points after start. Coincident start and end points
+// describe a full circle.
+func arcPoints(start, end, center Pt, clockwise bool) []Pt {
+	r := math.Hypot(start.X-center.X, start.Y-center.Y)
+	a1 := math.Atan2(start.Y-center.Y, start.X-center.X)
+	a2 := math.Atan2(end.Y-center.Y, end.X-center.X)
+	sweep := a2 - a1
+	if clockwise {
+		sweep = -sweep
+	}
+	for sweep <= 1e-12 {
+		sweep += 2 * math.Pi
+	}
+	n := int(0.5+sweep*r*10.0) + 1
+	pts := make([]Pt, n)
+	for i := 1; i < n; i++ {
+		a := a1 + sweep*float64(i)/float64(n)
+		if clockwise {
+			a = a1 - sweep*float64(i)/float64(n)
+		}
+		pts[i-1] = Pt{X: center.X + r*math.Cos(a), Y: center.Y + r*math.Sin(a)}
+	}
+	pts[n-1] = end
+	return pts
+}
+
+// bounds returns the minimum bounding box of the decoded operations.
+func bounds(ops []op) (min, max Pt, ok bool) {
+	min = Pt{X: math.Inf(1), Y: math.Inf(1)}
+	max = Pt{X: math.Inf(-1), Y: math.Inf(-1)}
+	for _, o := range ops {
+		var r float64
+		if o.aperture != nil && o.code != regionOp {
+			r = 0.5 * o.aperture.Size
+		}
+		for _, pt := range o.pts {
+			min.X, min.Y = math.Min(min.X, pt.X-r), math.Min(min.Y, pt.Y-r)
+			max.X, max.Y = math.Max(max.X, pt.X+r), math.Max(max.Y, pt.Y+r)
+			ok = true
+		}
+	}
+	return min, max, ok
+}
+
+// contours returns the closed outlines (counterclockwise, in mm) of the
+// area covered by the operation: the region itself or the convex hull of
+// the aperture at the start and end of a draw.
+func contours(o op) [][]Pt {
+	if o.code == regionOp {
+		return [][]Pt{o.pts}
+	}
+	if o.aperture == nil || len(o.pts) == 0 {
+		return nil
+	}
+	var shape []Pt
+	r := 0.5 * o.aperture.Size
+	if o.aperture.Shape == RectShape {
+		shape = []Pt{{X: -r, Y: -r}, {X: r, Y: -r}, {X: r, Y: r}, {X: -r, Y: r}}
+	} else {
+		shape = circle(Pt{}, r)
+	}
+	var pts []Pt
+	for _, c := range o.pts {
+		for _, s := range shape {
+			pts = append(pts, Pt{X: c.X + s.X, Y: c.Y + s.Y})
+		}
+	}
+	hull := convexHull(pts)
+	return [][]Pt{append(hull, hull[0])}
+}
+
+// convexHull returns the counterclockwise convex hull of the points
+// using Andrew's monotone chain algorithm.
+func convexHull(pts []Pt) []Pt {
+	pts = append([]Pt(nil), pts...)
+	sort.Slice(pts, func(i, j int) bool {
+		if pts[i].X != pts[j].X {
+			return pts[i].X < pts[j].X
+		}
+		return pts[i].Y < pts[j].Y
+	})
+	if len(pts) < 3 {
+		return pts
+	}
+	cross := func(o, a, b Pt) float64 {
+		return (a.X-o.X)*(b.Y-o.Y) - (a.Y-o.Y)*(b.X-o.X)
+	}
+	hull := make([]Pt, 0, 2*len(pts))
+	for _, p := range pts {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	for i, lower := len(pts)-2, len(hull)+1; i >= 0; i-- {
+		p := pts[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	return hull[:len(hull)-1]
+}
+
+// circle returns the counterclockwise points of a circle (not closed)
+// approximated by segments no longer than 0.1mm.
+func circle(c Pt, r float64) []Pt {
+	// Resolution of segments is 0.1mm.
+	n := int(0.5+2*math.Pi*r*10.0) + 1
+	if n < 16 {
+		n = 16
+	}
+	pts := make([]Pt, n)
+	for i := range pts {
+		sin, cos := math.Sincos(2 * math.Pi * float64(i) / float64(n))
+		pts[i] = Pt{X: c.X + r*cos, Y: c.Y + r*sin}
+	}
+	return pts
+}
@@ -0,0 +1,102 @@
+package gerber
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PNGLayer maps a layer to its color in a composited PNG image.
+type PNGLayer struct {
+	Layer *Layer
+	// Color is the color of the dark areas of the layer; its alpha sets
+	// the transparency of the layer. If nil, the layer's SVGColors color
+	// is used, fully opaque.
+	Color color.Color
+}
+
+// WritePNG composites the layers (the first layer at the bottom) into a
+// PNG image at dpi dots per inch covering all of them. Areas that no
+// layer covers are transparent. Rendering does not need a display,
+// so previews can be generated headless (e.g. in CI).
+func WritePNG(w io.Writer, dpi float64, layers ...*PNGLayer) error {
+	img, err := compositeImage(dpi, layers)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// WritePNGFile composites the layers into a PNG image in the named file.
+func WritePNGFile(filename string, dpi float64, layers ...*PNGLayer) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := WritePNG(f, dpi, layers...); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// compositeImage rasterizes the layers onto a common pixel grid
+// and blends them over each other in order.
+func compositeImage(dpi float64, layers []*PNGLayer) (*image.RGBA, error) {
+	if dpi <= 0 {
+		return nil, fmt.Errorf("invalid resolution %v dpi", dpi)
+	}
+	var all []op
+	for _, pl := range layers {
+		all = append(all, pl.Layer.plot()...)
+	}
+	min, max, ok := bounds(all)
+	if !ok {
+		return nil, errors.New("empty layers")
+	}
+	pixel := 25.4 / dpi
+	width := int(math.Ceil((max.X - min.X) / pixel))
+	height := int(math.Ceil((max.Y - min.Y) / pixel))
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for _, pl := range layers {
+		c := pl.Color
+		if c == nil {
+			c = hexColor(SVGColors[pl.Layer.extension()])
+		}
+		mask := image.NewAlpha(img.Bounds())
+		r, err := pl.Layer.newRaster(dpi)
+		if err == nil {
+			r.width, r.height, r.origin = width, height, Pt{X: min.X, Y: max.Y}
+			for i, dark := range r.bitmap() {
+				if dark {
+					mask.Pix[i] = 0xff
+				}
+			}
+		}
+		if pl.Layer.Negative() {
+			for i := range mask.Pix {
+				mask.Pix[i] = ^mask.Pix[i]
+			}
+		}
+		draw.DrawMask(img, img.Bounds(), image.NewUniform(c), image.Point{}, mask, image.Point{}, draw.Over)
+	}
+	return img, nil
+}
+
+// hexColor parses a "#rrggbb" color, returning opaque black if invalid.
+func hexColor(s string) color.Color {
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "#"), 16, 32)
+	if len(s) != 7 || s[0] != '#' || err != nil {
+		return color.Black
+	}
+	return color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}
+}
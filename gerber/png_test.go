@@ -0,0 +1,61 @@
+package gerber
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestWritePNG(t *testing.T) {
+	g := New("board")
+	top, mask := g.TopCopper(), g.TopSolderMask()
+	top.Add(Polygon(0, 0, true, []Pt{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}, {X: 0, Y: 0}}, 0))
+	mask.Add(Polygon(0, 0, true, []Pt{{X: 5, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 5, Y: 10}, {X: 5, Y: 0}}, 0))
+	plane := g.NegativePlane(2)
+	plane.Add(Circle(2.5, 2.5, 2))
+
+	var buf bytes.Buffer
+	if err := WritePNG(&buf, 25.4,
+		&PNGLayer{Layer: top, Color: color.NRGBA{R: 0xff, A: 0xff}},
+		&PNGLayer{Layer: mask, Color: color.NRGBA{B: 0xff, A: 0x80}},
+		&PNGLayer{Layer: plane, Color: color.NRGBA{G: 0xff, A: 0xff}},
+	); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 10 || b.Dy() != 10 {
+		t.Fatalf("image is %vx%v pixels, want 10x10", b.Dx(), b.Dy())
+	}
+	tests := []struct {
+		x, y int
+		want color.NRGBA
+	}{
+		{2, 7, color.NRGBA{R: 0xff, A: 0xff}}, // copper in the plane's clearance
+		{2, 2, color.NRGBA{G: 0xff, A: 0xff}}, // plane covers everything else
+		{7, 7, color.NRGBA{G: 0xff, A: 0xff}}, // even the mask
+	}
+	for _, tt := range tests {
+		got := color.NRGBAModel.Convert(img.At(tt.x, tt.y)).(color.NRGBA)
+		if got != tt.want {
+			t.Errorf("pixel (%v,%v) = %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+
+	// Without the plane, the translucent mask tints the copper.
+	buf.Reset()
+	if err := WritePNG(&buf, 25.4, &PNGLayer{Layer: top, Color: color.NRGBA{R: 0xff, A: 0xff}}, &PNGLayer{Layer: mask, Color: color.NRGBA{B: 0xff, A: 0x80}}); err != nil {
+		t.Fatal(err)
+	}
+	img, _ = png.Decode(&buf)
+	if got := color.NRGBAModel.Convert(img.At(7, 5)).(color.NRGBA); got.R < 0x70 || got.R > 0x90 || got.B < 0x70 || got.B > 0x90 || got.A != 0xff {
+		t.Errorf("blended pixel = %v, want half red and half blue", got)
+	}
+
+	if err := WritePNG(&buf, 0, &PNGLayer{Layer: top}); err == nil {
+		t.Error("zero dpi: want error")
+	}
+}
@@ -0,0 +1,50 @@
+package gerber
+
+import "math"
+
+// Polar returns the point at radius from center in the direction of
+// angle (in degrees, counterclockwise from the positive X axis).
+// The radius is in millimeters (or a Length) and the angle in degrees
+// (or an Angle).
+func Polar[L Lengths, A Angles](center Pt, radius L, angle A) Pt {
+	sin, cos := math.Sincos(math.Pi * float64(angle) / 180.0)
+	return Pt{X: center.X + float64(radius)*cos, Y: center.Y + float64(radius)*sin}
+}
+
+// PlacePolar returns p (drawn around 0,0) moved to the polar position
+// (radius, angle) around center. If rotate is true, p is also rotated
+// by angle so that it faces away from the center like the numbers on
+// a dial. Apertures do not rotate, so rotated primitives should use
+// circular apertures or regions.
+// All dimensions are in millimeters and angles in degrees.
+func PlacePolar(p Primitive, center Pt, radius, angle float64, rotate bool) Primitive {
+	at := Polar(center, radius, angle)
+	sin, cos := 0.0, 1.0
+	if rotate {
+		sin, cos = math.Sincos(math.Pi * angle / 180.0)
+	}
+	return transformPrimitive(p, func(pt Pt) Pt {
+		return Pt{X: at.X + pt.X*cos - pt.Y*sin, Y: at.Y + pt.X*sin + pt.Y*cos}
+	})
+}
+
+// RingArray returns n copies of p (drawn around 0,0) placed at radius
+// around center with PlacePolar, evenly spaced from startAngle to
+// endAngle inclusive. If the angles span a full circle (or more), the
+// copies are spaced evenly around it without repeating the first one.
+func RingArray(p Primitive, center Pt, radius float64, n int, startAngle, endAngle float64, rotate bool) []Primitive {
+	if n < 1 {
+		return nil
+	}
+	step := 0.0
+	if sweep := endAngle - startAngle; math.Abs(sweep) >= 360 {
+		step = math.Copysign(360, sweep) / float64(n)
+	} else if n > 1 {
+		step = sweep / float64(n-1)
+	}
+	result := make([]Primitive, n)
+	for i := range result {
+		result[i] = PlacePolar(p, center, radius, startAngle+float64(i)*step, rotate)
+	}
+	return result
+}
@@ -0,0 +1,88 @@
+package gerber
+
+import "testing"
+
+func TestPolar(t *testing.T) {
+	tests := []struct {
+		radius, angle float64
+		want          Pt
+	}{
+		{radius: 2, angle: 0, want: Pt{X: 12, Y: 10}},
+		{radius: 2, angle: 90, want: Pt{X: 10, Y: 12}},
+		{radius: 3, angle: 180, want: Pt{X: 7, Y: 10}},
+		{radius: 1, angle: -90, want: Pt{X: 10, Y: 9}},
+	}
+	for _, tt := range tests {
+		got := Polar(Pt{X: 10, Y: 10}, tt.radius, tt.angle)
+		if toPoint(got) != toPoint(tt.want) {
+			t.Errorf("Polar(%v, %v) = %v, want %v", tt.radius, tt.angle, got, tt.want)
+		}
+	}
+}
+
+func TestPlacePolar(t *testing.T) {
+	tick := Line(0, 0, 1, 0, CircleShape, 0.2)
+	tests := []struct {
+		name   string
+		rotate bool
+		want   [2]Pt
+	}{
+		{name: "translated", want: [2]Pt{{X: 0, Y: 5}, {X: 1, Y: 5}}},
+		{name: "rotated", rotate: true, want: [2]Pt{{X: 0, Y: 5}, {X: 0, Y: 6}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := plot(PlacePolar(tick, Pt{}, 5, 90, tt.rotate))
+			if len(ops) != 1 || toPoint(ops[0].pts[0]) != toPoint(tt.want[0]) || toPoint(ops[0].pts[1]) != toPoint(tt.want[1]) {
+				t.Errorf("PlacePolar = %+v, want a line from %v to %v", ops, tt.want[0], tt.want[1])
+			}
+		})
+	}
+}
+
+func TestPlacePolar_composite(t *testing.T) {
+	// The copies of a step and repeat block keep the apertures of its
+	// primitives.
+	pads := StepRepeat(2, 1, 2, 0, Flash(0, 0, CircleShape, 1), Line(0, 0, 0, 1, RectShape, 0.5))
+	ops := plot(PlacePolar(pads, Pt{}, 5, 0, false))
+	if len(ops) != 4 {
+		t.Fatalf("got %v operations, want 4", len(ops))
+	}
+	for i, want := range []*Aperture{{Shape: CircleShape, Size: 1}, {Shape: RectShape, Size: 0.5}, {Shape: CircleShape, Size: 1}, {Shape: RectShape, Size: 0.5}} {
+		if a := ops[i].aperture; a == nil || a.Shape != want.Shape || a.Size != want.Size {
+			t.Errorf("operation #%v uses aperture %+v, want %+v", i, a, want)
+		}
+	}
+	if got := toPoint(ops[2].pts[0]); got != toPoint(Pt{X: 7}) {
+		t.Errorf("second copy at %v, want {7 0}", ops[2].pts[0])
+	}
+}
+
+func TestRingArray(t *testing.T) {
+	dot := Flash(0, 0, CircleShape, 0.5)
+	tests := []struct {
+		name       string
+		n          int
+		start, end float64
+		want       []Pt
+	}{
+		{name: "full circle", n: 4, start: 0, end: 360, want: []Pt{{X: 1, Y: 0}, {X: 0, Y: 1}, {X: -1, Y: 0}, {X: 0, Y: -1}}},
+		{name: "arc", n: 3, start: 0, end: 180, want: []Pt{{X: 1, Y: 0}, {X: 0, Y: 1}, {X: -1, Y: 0}}},
+		{name: "clockwise", n: 2, start: 90, end: 0, want: []Pt{{X: 0, Y: 1}, {X: 1, Y: 0}}},
+		{name: "single", n: 1, start: 45, end: 135, want: []Pt{Polar(Pt{}, 1, 45)}},
+		{name: "none", n: 0, start: 0, end: 360},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RingArray(dot, Pt{}, 1, tt.n, tt.start, tt.end, false)
+			if len(got) != len(tt.want) {
+				t.Fatalf("RingArray returned %v primitives, want %v", len(got), len(tt.want))
+			}
+			for i, p := range got {
+				if ops := plot(p); toPoint(ops[0].pts[0]) != toPoint(tt.want[i]) {
+					t.Errorf("RingArray[%v] at %v, want %v", i, ops[0].pts[0], tt.want[i])
+				}
+			}
+		})
+	}
+}
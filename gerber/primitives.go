@@ -1,9 +1,11 @@
 package gerber
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"math"
+	"strings"
 )
 
 const (
@@ -31,15 +33,38 @@ type Primitive interface {
 type Aperture struct {
 	Shape Shape
 	Size  float64
+	// Function is the Gerber X2 aperture function (e.g. "ViaPad"), if any.
+	Function string
+	// Custom, when set, defines the shape of the aperture by an aperture
+	// macro instead of Shape and Size. See RoundRectAperture.
+	Custom *CustomShape
 }
 
 // WriteGerber writes the aperture to the Gerber file.
 func (a *Aperture) WriteGerber(w io.Writer, apertureIndex int) error {
-	if a.Shape == CircleShape {
-		fmt.Fprintf(w, "%%ADD%vC,%0.5f*%%\n", apertureIndex, a.Size)
-		return nil
+	if a.Function != "" {
+		writeAttribute(w, "%%TA.AperFunction,%v*%%\n", a.Function)
+	}
+	s := size(w, a.Size)
+	if a.Custom != nil {
+		var params []string
+		for _, v := range a.Custom.Params {
+			params = append(params, size(w, v))
+		}
+		if len(params) > 0 {
+			s = "," + strings.Join(params, "X")
+		} else {
+			s = ""
+		}
+		fmt.Fprintf(w, "%%ADD%v%v%v*%%\n", apertureIndex, a.Custom.Macro.Name, s)
+	} else if a.Shape == CircleShape {
+		fmt.Fprintf(w, "%%ADD%vC,%v*%%\n", apertureIndex, s)
+	} else {
+		fmt.Fprintf(w, "%%ADD%vR,%vX%v*%%\n", apertureIndex, s, s)
+	}
+	if a.Function != "" {
+		writeAttribute(w, "%%TD.AperFunction*%%\n")
 	}
-	fmt.Fprintf(w, "%%ADD%vR,%0.5fX%0.5f*%%\n", apertureIndex, a.Size, a.Size)
 	return nil
 }
 
@@ -53,6 +78,19 @@ func (a *Aperture) ID() string {
 	if a == nil {
 		return "default"
 	}
+	if a.Custom != nil {
+		id := a.Custom.Macro.Name
+		for _, v := range a.Custom.Params {
+			id += fmt.Sprintf(",%0.5f", sf*v)
+		}
+		if a.Function != "" {
+			id += ";" + a.Function
+		}
+		return id
+	}
+	if a.Function != "" {
+		return fmt.Sprintf("%v%0.5f,%v", a.Shape, sf*a.Size, a.Function)
+	}
 	return fmt.Sprintf("%v%0.5f", a.Shape, sf*a.Size)
 }
 
@@ -62,9 +100,9 @@ type Pt struct {
 }
 
 // Point is a simple convenience function that keeps the code easy to read.
-// All dimensions are in millimeters.
-func Point(x, y float64) Pt {
-	return Pt{X: x, Y: y}
+// All dimensions are in millimeters (or Lengths).
+func Point[L Lengths](x, y L) Pt {
+	return Pt{X: float64(x), Y: float64(y)}
 }
 
 // ArcT represents an arc and satisfies the Primitive interface.
@@ -78,55 +116,103 @@ type ArcT struct {
 	startAngle float64
 	endAngle   float64
 	thickness  float64
+	circular   bool // drawn with circular interpolation
 }
 
 // Arc returns an arc primitive.
-// All dimensions are in millimeters. Angles are in degrees.
-func Arc(
-	x, y, radius float64,
+// All dimensions are in millimeters (or Lengths). Angles are in degrees
+// (or Angles).
+func Arc[L Lengths, A Angles](
+	x, y, radius L,
 	shape Shape,
-	xScale, yScale, startAngle, endAngle float64,
-	thickness float64) *ArcT {
+	xScale, yScale float64, startAngle, endAngle A,
+	thickness L) *ArcT {
 	if startAngle > endAngle {
 		startAngle, endAngle = endAngle, startAngle
 	}
 	return &ArcT{
-		x:          x,
-		y:          y,
-		radius:     radius,
+		x:          float64(x),
+		y:          float64(y),
+		radius:     float64(radius),
 		shape:      shape,
 		xScale:     math.Abs(xScale),
 		yScale:     math.Abs(yScale),
-		startAngle: math.Pi * startAngle / 180.0,
-		endAngle:   math.Pi * endAngle / 180.0,
-		thickness:  thickness,
+		startAngle: math.Pi * float64(startAngle) / 180.0,
+		endAngle:   math.Pi * float64(endAngle) / 180.0,
+		thickness:  float64(thickness),
+	}
+}
+
+// Circular draws the arc as a true circular arc (G02/G03) like
+// CircularArc instead of line segments of about 0.1mm. Only circular
+// arcs (of equal scales) drawn with CircleShape can be drawn this way.
+func (a *ArcT) Circular() *ArcT {
+	a.circular = true
+	return a
+}
+
+// circularArc returns the arc drawn counterclockwise as a CircularArcT.
+func (a *ArcT) circularArc() (*CircularArcT, error) {
+	if a.xScale != a.yScale || a.shape != CircleShape {
+		return nil, fmt.Errorf("arc at (%v,%v): circular interpolation needs equal scales and CircleShape", a.x, a.y)
 	}
+	return &CircularArcT{
+		center:     toPoint(Pt{X: a.x, Y: a.y}),
+		radius:     a.xScale * a.radius,
+		startAngle: a.startAngle,
+		endAngle:   a.endAngle,
+		direction:  CounterClockwise,
+		thickness:  a.thickness,
+	}, nil
 }
 
 // WriteGerber writes the primitive to the Gerber file.
 func (a *ArcT) WriteGerber(w io.Writer, apertureIndex int) error {
+	if a.circular {
+		c, err := a.circularArc()
+		if err != nil {
+			return err
+		}
+		return c.WriteGerber(w, apertureIndex)
+	}
 	delta := a.endAngle - a.startAngle
 	length := delta * a.radius
 	// Resolution of segments is 0.1mm
 	segments := int(0.5+length*10.0) + 1
 	delta /= float64(segments)
 
-	angle := float64(a.startAngle)
-	for i := 0; i < segments; i++ {
-		x1 := a.x + a.xScale*math.Cos(angle)*a.radius
-		y1 := a.y + a.yScale*math.Sin(angle)*a.radius
-
-		angle += delta
-
-		x2 := a.x + a.xScale*math.Cos(angle)*a.radius
-		y2 := a.y + a.yScale*math.Sin(angle)*a.radius
-
-		line := Line(x1, y1, x2, y2, a.shape, a.thickness)
+	// Angles are computed from the segment index (not accumulated) so
+	// that the final endpoint lands exactly on the end angle.
+	start := a.point(a.startAngle)
+	p1 := start
+	for i := 1; i <= segments; i++ {
+		p2 := a.point(a.startAngle + float64(i)*delta)
+		if i == segments {
+			p2 = a.point(a.endAngle)
+			if a.closed() {
+				p2 = start // Snap full circles closed.
+			}
+		}
+		line := Line(p1.X, p1.Y, p2.X, p2.Y, a.shape, a.thickness)
 		line.WriteGerber(w, apertureIndex)
+		p1 = p2
 	}
 	return nil
 }
 
+// point returns the point on the arc at the given angle (in radians).
+func (a *ArcT) point(angle float64) Pt {
+	return Pt{
+		X: a.x + a.xScale*math.Cos(angle)*a.radius,
+		Y: a.y + a.yScale*math.Sin(angle)*a.radius,
+	}
+}
+
+// closed reports whether the arc sweeps a full circle.
+func (a *ArcT) closed() bool {
+	return math.Abs(a.endAngle-a.startAngle-2*math.Pi) < 1e-9
+}
+
 // Aperture returns the primitive's desired aperture.
 func (a *ArcT) Aperture() *Aperture {
 	return &Aperture{
@@ -135,27 +221,118 @@ func (a *ArcT) Aperture() *Aperture {
 	}
 }
 
+// Direction is the direction of circular interpolation.
+type Direction string
+
+const (
+	// Clockwise draws arcs clockwise (G02).
+	Clockwise Direction = "G02"
+	// CounterClockwise draws arcs counterclockwise (G03).
+	CounterClockwise Direction = "G03"
+)
+
+// CircularArcT represents an arc drawn with native circular
+// interpolation and satisfies the Primitive interface.
+type CircularArcT struct {
+	center     point
+	radius     float64
+	startAngle float64
+	endAngle   float64
+	direction  Direction
+	thickness  float64
+}
+
+// CircularArc returns an arc primitive drawn with a round aperture as a
+// true circular arc (G02/G03) instead of line segments. The arc sweeps
+// from startAngle to endAngle around x,y in the given direction; equal
+// angles (or a sweep of 360 degrees or more) draw a full circle.
+// Counterclockwise arcs can also be drawn with Arc(...).Circular(),
+// which draws them the same way.
+// All dimensions are in millimeters (or Lengths). Angles are in degrees
+// (or Angles).
+func CircularArc[L Lengths, A Angles](x, y, radius L, startAngle, endAngle A, direction Direction, thickness L) *CircularArcT {
+	return &CircularArcT{
+		center:     toPoint(Pt{X: float64(x), Y: float64(y)}),
+		radius:     float64(radius),
+		startAngle: math.Pi * float64(startAngle) / 180.0,
+		endAngle:   math.Pi * float64(endAngle) / 180.0,
+		direction:  direction,
+		thickness:  float64(thickness),
+	}
+}
+
+// WriteGerber writes the primitive to the Gerber file.
+func (a *CircularArcT) WriteGerber(w io.Writer, apertureIndex int) error {
+	if err := a.check(); err != nil {
+		return err
+	}
+	start, end := a.ends()
+	fmt.Fprintf(w, "G54D%d*\n", apertureIndex)
+	writeArcMode(w)
+	writeXY(w, start.X, start.Y, 2)
+	writeArcXY(w, string(a.direction), end.X, end.Y, a.center.X-start.X, a.center.Y-start.Y)
+	io.WriteString(w, "G01*\n")
+	return nil
+}
+
+// check checks the direction and radius of the arc.
+func (a *CircularArcT) check() error {
+	if a.direction != Clockwise && a.direction != CounterClockwise {
+		return fmt.Errorf("unsupported arc direction %q", a.direction)
+	}
+	if a.radius <= 0 {
+		return fmt.Errorf("arc radius %v must be positive", a.radius)
+	}
+	return nil
+}
+
+// ends returns the start and end points of the arc, which are the same
+// for full circles.
+func (a *CircularArcT) ends() (point, point) {
+	start, end := a.point(a.startAngle), a.point(a.endAngle)
+	if math.Abs(a.endAngle-a.startAngle) >= 2*math.Pi-1e-9 {
+		end = start // Snap full circles closed.
+	}
+	return start, end
+}
+
+// point returns the point on the arc at the given angle (in radians).
+func (a *CircularArcT) point(angle float64) point {
+	return point{
+		X: a.center.X + toNM(a.radius*math.Cos(angle)),
+		Y: a.center.Y + toNM(a.radius*math.Sin(angle)),
+	}
+}
+
+// Aperture returns the primitive's desired aperture.
+func (a *CircularArcT) Aperture() *Aperture {
+	return &Aperture{
+		Shape: CircleShape,
+		Size:  a.thickness,
+	}
+}
+
 // CircleT represents a circle and satisfies the Primitive interface.
 type CircleT struct {
-	x, y      float64
+	x, y      nm
 	thickness float64
 }
 
 // Circle returns a circle primitive.
-// All dimensions are in millimeters.
-func Circle(x, y float64, thickness float64) *CircleT {
+// All dimensions are in millimeters (or Lengths).
+func Circle[L Lengths](x, y L, thickness L) *CircleT {
 	return &CircleT{
-		x:         x,
-		y:         y,
-		thickness: thickness,
+		x:         toNM(float64(x)),
+		y:         toNM(float64(y)),
+		thickness: float64(thickness),
 	}
 }
 
 // WriteGerber writes the primitive to the Gerber file.
 func (c *CircleT) WriteGerber(w io.Writer, apertureIndex int) error {
 	fmt.Fprintf(w, "G54D%d*\n", apertureIndex)
-	fmt.Fprintf(w, "X%06dY%06dD02*\n", int(0.5+sf*(c.x)), int(0.5+sf*(c.y)))
-	fmt.Fprintf(w, "X%06dY%06dD01*\n", int(0.5+sf*(c.x)), int(0.5+sf*(c.y)))
+	writeXY(w, c.x, c.y, 2)
+	writeXY(w, c.x, c.y, 1)
 	return nil
 }
 
@@ -167,32 +344,76 @@ func (c *CircleT) Aperture() *Aperture {
 	}
 }
 
+// FlashT represents a single flash of an aperture and satisfies the Primitive interface.
+type FlashT struct {
+	x, y      nm
+	shape     Shape
+	thickness float64
+	function  string
+	aperture  *Aperture // of FlashAperture
+}
+
+// Flash returns a flash primitive: the aperture of the given
+// shape and size replicated once at x,y.
+// All dimensions are in millimeters (or Lengths).
+func Flash[L Lengths](x, y L, shape Shape, thickness L) *FlashT {
+	return &FlashT{
+		x:         toNM(float64(x)),
+		y:         toNM(float64(y)),
+		shape:     shape,
+		thickness: float64(thickness),
+	}
+}
+
+// WriteGerber writes the primitive to the Gerber file.
+func (f *FlashT) WriteGerber(w io.Writer, apertureIndex int) error {
+	fmt.Fprintf(w, "G54D%d*\n", apertureIndex)
+	writeXY(w, f.x, f.y, 3)
+	return nil
+}
+
+// Aperture returns the primitive's desired aperture.
+func (f *FlashT) Aperture() *Aperture {
+	if f.aperture != nil {
+		a := *f.aperture
+		if f.function != "" {
+			a.Function = f.function
+		}
+		return &a
+	}
+	return &Aperture{
+		Shape:    f.shape,
+		Size:     f.thickness,
+		Function: f.function,
+	}
+}
+
 // LineT represents a line and satisfies the Primitive interface.
 type LineT struct {
-	x1, y1    float64
-	x2, y2    float64
+	x1, y1    nm
+	x2, y2    nm
 	shape     Shape
 	thickness float64
 }
 
 // Line returns a line primitive.
-// All dimensions are in millimeters.
-func Line(x1, y1, x2, y2 float64, shape Shape, thickness float64) *LineT {
+// All dimensions are in millimeters (or Lengths).
+func Line[L Lengths](x1, y1, x2, y2 L, shape Shape, thickness L) *LineT {
 	return &LineT{
-		x1:        x1,
-		y1:        y1,
-		x2:        x2,
-		y2:        y2,
+		x1:        toNM(float64(x1)),
+		y1:        toNM(float64(y1)),
+		x2:        toNM(float64(x2)),
+		y2:        toNM(float64(y2)),
 		shape:     shape,
-		thickness: thickness,
+		thickness: float64(thickness),
 	}
 }
 
 // WriteGerber writes the primitive to the Gerber file.
 func (l *LineT) WriteGerber(w io.Writer, apertureIndex int) error {
 	fmt.Fprintf(w, "G54D%d*\n", apertureIndex)
-	fmt.Fprintf(w, "X%06dY%06dD02*\n", int(0.5+sf*(l.x1)), int(0.5+sf*(l.y1)))
-	fmt.Fprintf(w, "X%06dY%06dD01*\n", int(0.5+sf*(l.x2)), int(0.5+sf*(l.y2)))
+	writeXY(w, l.x1, l.y1, 2)
+	writeXY(w, l.x2, l.y2, 1)
 	return nil
 }
 
@@ -206,32 +427,44 @@ func (l *LineT) Aperture() *Aperture {
 
 // PolygonT represents a polygon and satisfies the Primitive interface.
 type PolygonT struct {
-	x, y   float64
-	points []Pt
+	points []point
 }
 
 // Polygon returns a polygon primitive.
-// All dimensions are in millimeters.
-func Polygon(x, y float64, filled bool, points []Pt, thickness float64) *PolygonT {
+// All dimensions are in millimeters (or Lengths).
+func Polygon[L Lengths](x, y L, filled bool, points []Pt, thickness L) *PolygonT {
+	pts := make([]point, len(points))
+	for i, pt := range points {
+		pts[i] = toPoint(Pt{X: pt.X + float64(x), Y: pt.Y + float64(y)})
+	}
 	return &PolygonT{
-		x:      x,
-		y:      y,
-		points: points,
+		points: pts,
 	}
 }
 
 // WriteGerber writes the primitive to the Gerber file.
 func (p *PolygonT) WriteGerber(w io.Writer, apertureIndex int) error {
+	return writeRegion(w, p.points)
+}
+
+// writeRegion writes a contour fill (G36/G37) of the points
+// with the default aperture, closing the contour if needed.
+func writeRegion(w io.Writer, pts []point) error {
+	if len(pts) == 0 {
+		return errors.New("region contour has no points")
+	}
 	io.WriteString(w, "G54D11*\n")
 	io.WriteString(w, "G36*\n")
-	for i, pt := range p.points {
+	for i, pt := range pts {
 		if i == 0 {
-			fmt.Fprintf(w, "X%06dY%06dD02*\n", int(0.5+sf*(pt.X+p.x)), int(0.5+sf*(pt.Y+p.y)))
+			writeXY(w, pt.X, pt.Y, 2)
 			continue
 		}
-		fmt.Fprintf(w, "X%06dY%06dD01*\n", int(0.5+sf*(pt.X+p.x)), int(0.5+sf*(pt.Y+p.y)))
+		writeXY(w, pt.X, pt.Y, 1)
+	}
+	if pts[len(pts)-1] != pts[0] {
+		writeXY(w, pts[0].X, pts[0].Y, 1)
 	}
-	fmt.Fprintf(w, "X%06dY%06dD02*\n", int(0.5+sf*(p.points[0].X+p.x)), int(0.5+sf*(p.points[0].Y+p.y)))
 	io.WriteString(w, "G37*\n")
 	return nil
 }
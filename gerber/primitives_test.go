@@ -1,6 +1,10 @@
 package gerber
 
-import "testing"
+import (
+	"bytes"
+	"math"
+	"testing"
+)
 
 func TestAperture_Primitive(t *testing.T) {
 	var p Primitive = &Aperture{}
@@ -41,3 +45,193 @@ func TestPolygonT_Primitive(t *testing.T) {
 		t.Errorf("PolygonT does not implement the Primitive interface")
 	}
 }
+
+func TestObjectT_Primitive(t *testing.T) {
+	var p Primitive = &ObjectT{}
+	if p == nil {
+		// In actuality, this test won't compile if it isn't a Primitive.
+		t.Errorf("ObjectT does not implement the Primitive interface")
+	}
+}
+
+func TestKnockoutT_Primitive(t *testing.T) {
+	var p Primitive = &KnockoutT{}
+	if p == nil {
+		// In actuality, this test won't compile if it isn't a Primitive.
+		t.Errorf("KnockoutT does not implement the Primitive interface")
+	}
+}
+
+func TestTableT_Primitive(t *testing.T) {
+	var p Primitive = &TableT{}
+	if p == nil {
+		// In actuality, this test won't compile if it isn't a Primitive.
+		t.Errorf("TableT does not implement the Primitive interface")
+	}
+}
+
+func TestScaleBarT_Primitive(t *testing.T) {
+	var p Primitive = &ScaleBarT{}
+	if p == nil {
+		// In actuality, this test won't compile if it isn't a Primitive.
+		t.Errorf("ScaleBarT does not implement the Primitive interface")
+	}
+}
+
+func TestNorthArrowT_Primitive(t *testing.T) {
+	var p Primitive = &NorthArrowT{}
+	if p == nil {
+		// In actuality, this test won't compile if it isn't a Primitive.
+		t.Errorf("NorthArrowT does not implement the Primitive interface")
+	}
+}
+
+func TestGridT_Primitive(t *testing.T) {
+	var p Primitive = &GridT{}
+	if p == nil {
+		// In actuality, this test won't compile if it isn't a Primitive.
+		t.Errorf("GridT does not implement the Primitive interface")
+	}
+}
+
+func TestFlashT_Primitive(t *testing.T) {
+	var p Primitive = &FlashT{}
+	if p == nil {
+		// In actuality, this test won't compile if it isn't a Primitive.
+		t.Errorf("FlashT does not implement the Primitive interface")
+	}
+}
+
+func TestReplotT_Primitive(t *testing.T) {
+	var p Primitive = &replotT{}
+	if p == nil {
+		// In actuality, this test won't compile if it isn't a Primitive.
+		t.Errorf("replotT does not implement the Primitive interface")
+	}
+}
+
+func TestPlaneT_Primitive(t *testing.T) {
+	var p Primitive = &PlaneT{}
+	if p == nil {
+		// In actuality, this test won't compile if it isn't a Primitive.
+		t.Errorf("PlaneT does not implement the Primitive interface")
+	}
+}
+
+func TestStepRepeatT_Primitive(t *testing.T) {
+	var p Primitive = &StepRepeatT{}
+	if p == nil {
+		// In actuality, this test won't compile if it isn't a Primitive.
+		t.Errorf("StepRepeatT does not implement the Primitive interface")
+	}
+}
+
+func TestBlockT_Primitive(t *testing.T) {
+	var p Primitive = &BlockT{}
+	if p == nil {
+		// In actuality, this test won't compile if it isn't a Primitive.
+		t.Errorf("BlockT does not implement the Primitive interface")
+	}
+}
+
+func TestCircularArcT_Primitive(t *testing.T) {
+	var p Primitive = &CircularArcT{}
+	if p == nil {
+		// In actuality, this test won't compile if it isn't a Primitive.
+		t.Errorf("CircularArcT does not implement the Primitive interface")
+	}
+}
+
+func TestTaperedTraceT_Primitive(t *testing.T) {
+	var p Primitive = &TaperedTraceT{}
+	if p == nil {
+		// In actuality, this test won't compile if it isn't a Primitive.
+		t.Errorf("TaperedTraceT does not implement the Primitive interface")
+	}
+}
+
+func TestTraceT_Primitive(t *testing.T) {
+	var p Primitive = &TraceT{}
+	if p == nil {
+		// In actuality, this test won't compile if it isn't a Primitive.
+		t.Errorf("TraceT does not implement the Primitive interface")
+	}
+}
+
+func TestBusT_Primitive(t *testing.T) {
+	var p Primitive = &BusT{}
+	if p == nil {
+		// In actuality, this test won't compile if it isn't a Primitive.
+		t.Errorf("BusT does not implement the Primitive interface")
+	}
+}
+
+func TestCircularArc(t *testing.T) {
+	tests := []struct {
+		name      string
+		arc       *CircularArcT
+		want      string
+		wantSweep float64 // degrees, decoded from the output
+	}{
+		{
+			name:      "counterclockwise quarter",
+			arc:       CircularArc(1, 2, 3, 0, 90, CounterClockwise, 0.2),
+			want:      "G54D12*\nG75*\nX4000000Y2000000D02*\nG03X1000000Y5000000I-3000000J000000D01*\nG01*\n",
+			wantSweep: 90,
+		},
+		{
+			name:      "clockwise the long way",
+			arc:       CircularArc(0, 0, 1, 0, 90, Clockwise, 0.2),
+			want:      "G54D12*\nG75*\nX1000000Y000000D02*\nG02X000000Y1000000I-1000000J000000D01*\nG01*\n",
+			wantSweep: 270,
+		},
+		{
+			name:      "full circle",
+			arc:       CircularArc(0, 0, 1, 45, 405, Clockwise, 0.2),
+			want:      "G54D12*\nG75*\nX707107Y707107D02*\nG02X707107Y707107I-707107J-707107D01*\nG01*\n",
+			wantSweep: 360,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.arc.WriteGerber(&buf, 12); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("WriteGerber =\n%v\nwant\n%v", got, tt.want)
+			}
+			var length float64
+			for _, o := range plot(tt.arc) {
+				length += math.Hypot(o.pts[1].X-o.pts[0].X, o.pts[1].Y-o.pts[0].Y)
+			}
+			if want := tt.wantSweep * math.Pi / 180 * tt.arc.radius; math.Abs(length-want) > 0.01 {
+				t.Errorf("decoded arc length = %v, want %v", length, want)
+			}
+		})
+	}
+}
+
+func TestArcT_Circular(t *testing.T) {
+	var got, want bytes.Buffer
+	if err := Arc(1, 2, 3, CircleShape, 1, 1, 90, 0, 0.2).Circular().WriteGerber(&got, 12); err != nil {
+		t.Fatal(err)
+	}
+	if err := CircularArc(1, 2, 3, 0, 90, CounterClockwise, 0.2).WriteGerber(&want, 12); err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != want.String() {
+		t.Errorf("WriteGerber =\n%v\nwant\n%v", got.String(), want.String())
+	}
+	if err := Arc(0, 0, 3, CircleShape, 1, 0.5, 0, 90, 0.2).Circular().WriteGerber(&got, 12); err == nil {
+		t.Error("WriteGerber of a circular ellipse = nil, want error")
+	}
+}
+
+func TestRegionT_Primitive(t *testing.T) {
+	var p Primitive = &RegionT{}
+	if p == nil {
+		// In actuality, this test won't compile if it isn't a Primitive.
+		t.Errorf("RegionT does not implement the Primitive interface")
+	}
+}
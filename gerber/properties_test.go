@@ -0,0 +1,199 @@
+package gerber
+
+import (
+	"math"
+	"testing"
+	"testing/quick"
+)
+
+// tol is the maximum error (in mm) introduced by emitting a coordinate,
+// which is half an LSB of the default coordinate format.
+const tol = 0.5/sf + 1e-9
+
+// mm maps an arbitrary int32 onto a board coordinate within ±2147mm
+// with micrometer-and-below fractional parts.
+func mm(v int32) float64 {
+	return float64(v) / 1e6 * 1.0003
+}
+
+func TestProperty_CircleRoundTrip(t *testing.T) {
+	f := func(xi, yi int32) bool {
+		x, y := mm(xi), mm(yi)
+		ops := plot(Circle(x, y, 1))
+		if len(ops) != 1 {
+			return false
+		}
+		got := ops[0].pts[0]
+		return math.Abs(got.X-x) <= tol && math.Abs(got.Y-y) <= tol
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProperty_LineBoundsContainEndpoints(t *testing.T) {
+	f := func(x1i, y1i, x2i, y2i int32, wi uint16) bool {
+		x1, y1, x2, y2 := mm(x1i), mm(y1i), mm(x2i), mm(y2i)
+		width := float64(wi) / 1000
+		min, max, ok := bounds(plot(Line(x1, y1, x2, y2, CircleShape, width)))
+		if !ok {
+			return false
+		}
+		for _, pt := range []Pt{{X: x1, Y: y1}, {X: x2, Y: y2}} {
+			if pt.X-0.5*width < min.X-tol || pt.X+0.5*width > max.X+tol ||
+				pt.Y-0.5*width < min.Y-tol || pt.Y+0.5*width > max.Y+tol {
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProperty_ArcPointsLieOnCircle(t *testing.T) {
+	f := func(xi, yi int16, ri uint8, start, end int16) bool {
+		x, y := float64(xi)/100, float64(yi)/100
+		r := 0.1 + float64(ri)/10
+		ops := plot(Arc(x, y, r, CircleShape, 1, 1, float64(start%360), float64(end%360), 0.1))
+		if len(ops) == 0 {
+			return false
+		}
+		for _, o := range ops {
+			for _, pt := range o.pts {
+				if math.Abs(math.Hypot(pt.X-x, pt.Y-y)-r) > 2*tol {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProperty_DiffIdentity(t *testing.T) {
+	f := func(coords []int16) bool {
+		g := New("board")
+		top := g.TopCopper()
+		for i := 0; i+1 < len(coords); i += 2 {
+			x, y := float64(coords[i])/100, float64(coords[i+1])/100
+			top.Add(
+				Object(Circle(x, y, 0.5)).Component(string(rune('A'+i%26))),
+				Line(0, 0, x, y, RectShape, 0.2),
+			)
+		}
+		return len(Diff(g, g)) == 0
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProperty_NameUUIDStable(t *testing.T) {
+	f := func(name string) bool {
+		a := NameUUID(name)
+		return a == NameUUID(name) && len(a) == 36 && a[14] == '5'
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProperty_FormatQuantize(t *testing.T) {
+	f := func(v int32, d uint8) bool {
+		format := Format{Integer: 3, Decimal: 1 + int(d%6)}
+		lsb := math.Pow10(6 - format.Decimal)
+		got := float64(format.quantize(nm(v))) * lsb
+		return math.Abs(got-float64(v)) <= 0.5*lsb
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProperty_InchQuantize(t *testing.T) {
+	f := func(v int32, d uint8) bool {
+		format := Format{Integer: 3, Decimal: 1 + int(d%6)}
+		lsb := nmPerInch / math.Pow10(format.Decimal)
+		q, got := quantizeUnits(format, Inches, nm(v))
+		return math.Abs(got-float64(v)) <= 0.5*lsb && math.Abs(float64(q)*lsb-got) < 1e-6
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// darkArea returns the dark area of the regions of the operations: the
+// area of the dark contours less that of the clear ones.
+func darkArea(ops []op) float64 {
+	var area float64
+	for _, o := range ops {
+		if o.code != regionOp {
+			continue
+		}
+		a := math.Abs(signedArea(o.pts[:len(o.pts)-1]))
+		if o.clear {
+			a = -a
+		}
+		area += a
+	}
+	return area
+}
+
+// rect maps arbitrary bytes onto a rectangle of 0.1 to 25.6mm sides
+// within ±12.8mm of the origin.
+func rect(x, y int8, w, h uint8) []Pt {
+	x0, y0 := float64(x)/10, float64(y)/10
+	x1, y1 := x0+0.1+float64(w)/10, y0+0.1+float64(h)/10
+	return []Pt{{X: x0, Y: y0}, {X: x1, Y: y0}, {X: x1, Y: y1}, {X: x0, Y: y1}, {X: x0, Y: y0}}
+}
+
+func TestProperty_UnionAreaAtLeastMaxInput(t *testing.T) {
+	f := func(rects [3][4]uint8) bool {
+		var prims []Primitive
+		var inputs, max float64
+		for _, r := range rects {
+			pts := rect(int8(r[0]), int8(r[1]), r[2], r[3])
+			a := math.Abs(signedArea(pts[:4]))
+			inputs += a
+			max = math.Max(max, a)
+			prims = append(prims, Region(pts))
+		}
+		regions, err := Union(prims...)
+		if err != nil {
+			return false
+		}
+		var ops []op
+		for _, r := range regions {
+			ops = append(ops, plot(r)...)
+		}
+		area := darkArea(ops)
+		return area >= max-1e-6 && area <= inputs+1e-6
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 50}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProperty_OffsetInverse(t *testing.T) {
+	f := func(x, y int8, w, h, di uint8) bool {
+		d := 0.05 + float64(di%50)/100
+		region := plot(Region(rect(x, y, w, h)))[0]
+		var back []op
+		for _, o := range offsetRegion(region, d) {
+			back = append(back, offsetRegion(o, -d)...)
+		}
+		gotMin, gotMax, ok := bounds(back)
+		wantMin, wantMax, _ := bounds([]op{region})
+		const eps = 1e-3
+		return ok && math.Abs(darkArea(back)-darkArea([]op{region})) <= eps*(wantMax.X-wantMin.X+wantMax.Y-wantMin.Y) &&
+			math.Abs(gotMin.X-wantMin.X) <= eps && math.Abs(gotMin.Y-wantMin.Y) <= eps &&
+			math.Abs(gotMax.X-wantMax.X) <= eps && math.Abs(gotMax.Y-wantMax.Y) <= eps
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 20}); err != nil {
+		t.Error(err)
+	}
+}
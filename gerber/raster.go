@@ -0,0 +1,229 @@
+package gerber
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+const (
+	bandRows = 256 // rows rendered at a time
+)
+
+// shape is a decoded operation flattened to polygons for rasterizing.
+type shape struct {
+	clear    bool
+	polys    [][]Pt
+	min, max Pt
+}
+
+// raster renders a layer band by band at the given resolution,
+// calling emit for each row from top to bottom with 1 bits for dark pixels
+// (most significant bit first).
+type raster struct {
+	width, height int
+	pixel         float64 // pixel size in mm
+	origin        Pt      // top left corner in mm
+	shapes        []*shape
+}
+
+// newRaster prepares rasterizing the layer at dpi dots per inch.
+func (l *Layer) newRaster(dpi float64) (*raster, error) {
+	if dpi <= 0 {
+		return nil, fmt.Errorf("invalid resolution %v dpi", dpi)
+	}
+	r := &raster{pixel: 25.4 / dpi}
+	ops := l.plot()
+	min, max, ok := bounds(ops)
+	if !ok {
+		return nil, errors.New("empty layer")
+	}
+	r.origin = Pt{X: min.X, Y: max.Y}
+	r.width = int(math.Ceil((max.X - min.X) / r.pixel))
+	r.height = int(math.Ceil((max.Y - min.Y) / r.pixel))
+	for _, o := range ops {
+		polys := contours(o)
+		if smin, smax, ok := bounds([]op{{code: regionOp, pts: concat(polys)}}); ok {
+			r.shapes = append(r.shapes, &shape{clear: o.clear, polys: polys, min: smin, max: smax})
+		}
+	}
+	return r, nil
+}
+
+// concat returns all points of the polygons.
+func concat(polys [][]Pt) []Pt {
+	var result []Pt
+	for _, p := range polys {
+		result = append(result, p...)
+	}
+	return result
+}
+
+// render calls emit for every row of the image.
+func (r *raster) render(emit func(row []byte) error) error {
+	stride := (r.width + 7) / 8
+	band := make([]byte, bandRows*stride)
+	for top := 0; top < r.height; top += bandRows {
+		rows := bandRows
+		if top+rows > r.height {
+			rows = r.height - top
+		}
+		for i := range band {
+			band[i] = 0
+		}
+		// Y coordinates of the pixel centers of the first and last rows.
+		y0 := r.origin.Y - (float64(top)+0.5)*r.pixel
+		y1 := r.origin.Y - (float64(top+rows)-0.5)*r.pixel
+		for _, s := range r.shapes {
+			if s.min.Y > y0 || s.max.Y < y1 {
+				continue
+			}
+			for row := 0; row < rows; row++ {
+				y := y0 - float64(row)*r.pixel
+				if y < s.min.Y || y > s.max.Y {
+					continue
+				}
+				r.fillRow(band[row*stride:(row+1)*stride], s, y)
+			}
+		}
+		for row := 0; row < rows; row++ {
+			if err := emit(band[row*stride : (row+1)*stride]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fillRow paints the pixels of the row whose centers lie inside the shape.
+func (r *raster) fillRow(row []byte, s *shape, y float64) {
+	for _, poly := range s.polys {
+		var xs []float64
+		for i := 1; i < len(poly); i++ {
+			a, b := poly[i-1], poly[i]
+			if (a.Y <= y) != (b.Y <= y) {
+				xs = append(xs, a.X+(y-a.Y)*(b.X-a.X)/(b.Y-a.Y))
+			}
+		}
+		sort.Float64s(xs)
+		for i := 0; i+1 < len(xs); i += 2 {
+			// Pixels whose centers lie within the span.
+			from := int(math.Ceil((xs[i]-r.origin.X)/r.pixel - 0.5))
+			to := int(math.Floor((xs[i+1]-r.origin.X)/r.pixel - 0.5))
+			if from < 0 {
+				from = 0
+			}
+			if to >= r.width {
+				to = r.width - 1
+			}
+			for x := from; x <= to; x++ {
+				if s.clear {
+					row[x/8] &^= 0x80 >> uint(x%8)
+				} else {
+					row[x/8] |= 0x80 >> uint(x%8)
+				}
+			}
+		}
+	}
+}
+
+// WritePBM writes the layer as a 1-bit binary PBM image (dark is black)
+// at dpi dots per inch, rendering it in bands to limit memory use.
+func (l *Layer) WritePBM(w io.Writer, dpi float64) error {
+	r, err := l.newRaster(dpi)
+	if err != nil {
+		return fmt.Errorf("%v: %v", l.Filename, err)
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "P4\n%v %v\n", r.width, r.height)
+	if err := r.render(func(row []byte) error {
+		_, err := bw.Write(row)
+		return err
+	}); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// WriteTIFF writes the layer as an uncompressed 1-bit TIFF image (dark is
+// black) at dpi dots per inch, rendering it in bands to limit memory use.
+func (l *Layer) WriteTIFF(w io.Writer, dpi float64) error {
+	r, err := l.newRaster(dpi)
+	if err != nil {
+		return fmt.Errorf("%v: %v", l.Filename, err)
+	}
+	stride := (r.width + 7) / 8
+	size := int64(stride) * int64(r.height)
+	if size > math.MaxUint32 {
+		return fmt.Errorf("%v: image of %vx%v pixels is too large for TIFF", l.Filename, r.width, r.height)
+	}
+
+	// Header, a single IFD then the image data in a single strip.
+	type entry struct {
+		tag, typ uint16
+		value    uint32
+	}
+	const (
+		short    = 3
+		long     = 4
+		rational = 5
+	)
+	entries := []entry{
+		{256, long, uint32(r.width)},  // ImageWidth
+		{257, long, uint32(r.height)}, // ImageLength
+		{258, short, 1},               // BitsPerSample
+		{259, short, 1},               // Compression: none
+		{262, short, 0},               // PhotometricInterpretation: WhiteIsZero
+		{273, long, 0},                // StripOffsets (set below)
+		{277, short, 1},               // SamplesPerPixel
+		{278, long, uint32(r.height)}, // RowsPerStrip
+		{279, long, uint32(size)},     // StripByteCounts
+		{282, rational, 0},            // XResolution (set below)
+		{283, rational, 0},            // YResolution (set below)
+		{296, short, 2},               // ResolutionUnit: inch
+	}
+	ifdSize := 2 + 12*len(entries) + 4
+	resOffset := uint32(8 + ifdSize)
+	dataOffset := resOffset + 8
+	for i := range entries {
+		switch entries[i].tag {
+		case 273:
+			entries[i].value = dataOffset
+		case 282, 283:
+			entries[i].value = resOffset
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	le := binary.LittleEndian
+	bw.WriteString("II")
+	binary.Write(bw, le, uint16(42))
+	binary.Write(bw, le, uint32(8))
+	binary.Write(bw, le, uint16(len(entries)))
+	for _, e := range entries {
+		binary.Write(bw, le, e.tag)
+		binary.Write(bw, le, e.typ)
+		binary.Write(bw, le, uint32(1))
+		if e.typ == short {
+			binary.Write(bw, le, uint16(e.value))
+			binary.Write(bw, le, uint16(0))
+		} else {
+			binary.Write(bw, le, e.value)
+		}
+	}
+	binary.Write(bw, le, uint32(0)) // no next IFD
+	binary.Write(bw, le, uint32(math.Round(dpi)))
+	binary.Write(bw, le, uint32(1))
+
+	if err := r.render(func(row []byte) error {
+		_, err := bw.Write(row)
+		return err
+	}); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,73 @@
+package gerber
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+func TestLayer_WritePBM(t *testing.T) {
+	l := New("board").TopCopper()
+	// A 1x1mm square at 50.8 dpi (1 pixel = 0.5mm): 2x2 pixels.
+	l.Add(Polygon(0, 0, true, []Pt{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}, {X: 0, Y: 0}}, 0))
+	var buf bytes.Buffer
+	if err := l.WritePBM(&buf, 50.8); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "P4\n2 2\n\xc0\xc0"; got != want {
+		t.Errorf("WritePBM = %q, want %q", got, want)
+	}
+
+	// Clear the upper right pixel.
+	l.Add(&clearSquare{})
+	buf.Reset()
+	l.WritePBM(&buf, 50.8)
+	if got, want := buf.String(), "P4\n2 2\n\x80\xc0"; got != want {
+		t.Errorf("WritePBM with clear polarity = %q, want %q", got, want)
+	}
+
+	if err := New("board").TopCopper().WritePBM(&buf, 50.8); err == nil {
+		t.Error("WritePBM of an empty layer must fail")
+	}
+}
+
+func TestLayer_WriteTIFF(t *testing.T) {
+	l := New("board").TopCopper()
+	l.Add(Polygon(0, 0, true, []Pt{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}, {X: 0, Y: 0}}, 0))
+	var buf bytes.Buffer
+	if err := l.WriteTIFF(&buf, 254); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if string(data[:4]) != "II*\x00" {
+		t.Fatalf("bad TIFF header %q", data[:4])
+	}
+	// 10x10 pixels, 2 bytes per row, all dark.
+	pixels := data[len(data)-20:]
+	for i, b := range pixels {
+		if want := byte(0xff); i%2 == 1 {
+			want = 0xc0
+			if b != want {
+				t.Fatalf("row %v = %02x%02x, want ffc0", i/2, pixels[i-1], b)
+			}
+		} else if b != want {
+			t.Fatalf("row %v = %02x, want ff", i/2, b)
+		}
+	}
+	if n := binary.LittleEndian.Uint16(data[8:]); n != 12 {
+		t.Errorf("got %v IFD entries, want 12", n)
+	}
+}
+
+// clearSquare is a clear 0.5mm square in the upper right of the unit square.
+type clearSquare struct{}
+
+func (c *clearSquare) WriteGerber(w io.Writer, apertureIndex int) error {
+	io.WriteString(w, "%LPC*%\n")
+	Polygon(0.5, 0.5, true, []Pt{{X: 0, Y: 0}, {X: 0.5, Y: 0}, {X: 0.5, Y: 0.5}, {X: 0, Y: 0.5}, {X: 0, Y: 0}}, 0).WriteGerber(w, apertureIndex)
+	io.WriteString(w, "%LPD*%\n")
+	return nil
+}
+
+func (c *clearSquare) Aperture() *Aperture { return nil }
@@ -0,0 +1,81 @@
+package gerber
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// ReadLayer reads an RS274X Gerber layer file (such as one exported by
+// KiCad) into the package's primitives and adds it to the design, e.g. to
+// add artwork to an existing board and write it again.
+// Draws and flashes of circle and square apertures become lines and
+// flashes, regions become polygons, and arcs are approximated by lines.
+// Flashes and draws of other apertures (rectangles, obrounds, polygons and
+// aperture macros) become the regions they cover. Objects drawn in clear
+// polarity are kept as such. The kind of layer is derived from the
+// extension of the filename. Files with coordinate formats other than
+// leading zero omission and absolute coordinates, or with arcs in single
+// quadrant mode (G74), return an error.
+func (g *Gerber) ReadLayer(filename string, r io.Reader) (*Layer, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", filename, err)
+	}
+	apertures := map[int]*Aperture{}
+	ops, err := decode(data, apertures)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", filename, err)
+	}
+	for _, o := range ops {
+		if o.code != regionOp && o.aperture == nil {
+			return nil, fmt.Errorf("%v: operation at %v uses an undefined aperture", filename, o.pts[0])
+		}
+	}
+
+	l := &Layer{Filename: filepath.Base(filename), apertureMap: map[string]int{"default": -1}, g: g}
+	for _, spec := range layerSpecs {
+		if spec.Extension == l.extension() {
+			l.spec = spec
+		}
+	}
+	for i := 0; i < len(ops); {
+		j := i + 1
+		for j < len(ops) && ops[j].more {
+			j++
+		}
+		if j > i+1 {
+			// The contours of a flashed aperture outline make one primitive.
+			l.Add(&replotT{ops: ops[i:j]})
+		} else {
+			l.Add(primitiveOf(ops[i]))
+		}
+		i = j
+	}
+	g.Layers = append(g.Layers, l)
+	return l, nil
+}
+
+// primitiveOf returns the primitive that writes the decoded operation.
+func primitiveOf(o op) Primitive {
+	a := o.aperture
+	if o.code == regionOp {
+		a = nil
+	}
+	if o.clear {
+		return &replotT{ops: []op{o}, aperture: a}
+	}
+	switch o.code {
+	case drawOp:
+		line := Line(o.pts[0].X, o.pts[0].Y, o.pts[1].X, o.pts[1].Y, a.Shape, a.Size)
+		if a.Function != "" {
+			return Object(line).Function(a.Function)
+		}
+		return line
+	case flashOp:
+		f := Flash(o.pts[0].X, o.pts[0].Y, a.Shape, a.Size)
+		f.function = a.Function
+		return f
+	}
+	return Polygon(0, 0, true, o.pts, 0)
+}
@@ -0,0 +1,172 @@
+package gerber
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGerber_ReadLayer(t *testing.T) {
+	for _, units := range []Units{Millimeters, Inches} {
+		g := New("board")
+		g.Units = units
+		top := g.TopCopper()
+		top.Add(
+			Line(0, 0, 25.4, 12.7, CircleShape, 0.254),
+			Flash(5.08, 5.08, RectShape, 1.27),
+			Polygon(0, 0, true, []Pt{{X: 0, Y: 0}, {X: 2.54, Y: 0}, {X: 2.54, Y: 2.54}, {X: 0, Y: 0}}, 0),
+		)
+		var buf bytes.Buffer
+		if err := top.WriteGerber(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		r := New("check")
+		l, err := r.ReadLayer("out/board.gtl", &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l.Filename != "board.gtl" || len(r.Layers) != 1 || len(l.Primitives) != 3 {
+			t.Fatalf("%v: got %v with %v primitives", units, l.Filename, len(l.Primitives))
+		}
+		for i, p := range top.Primitives {
+			if got, want := plot(l.Primitives[i]), plot(p); !reflect.DeepEqual(got, want) {
+				t.Errorf("%v: primitive #%v = %+v, want %+v", units, i, got, want)
+			}
+		}
+	}
+
+	if _, err := New("x").ReadLayer("x.gtl", bytes.NewBufferString("G54D12*\nX0Y0D03*\n")); err == nil {
+		t.Error("expected an error for an undefined aperture")
+	}
+}
+
+func TestGerber_ReadLayer_oneLine(t *testing.T) {
+	// A long file without line breaks (e.g. written by some CAM tools).
+	var data strings.Builder
+	data.WriteString("%FSLAX46Y46*%%MOMM*%%ADD10C,0.2*%D10*")
+	for i := range 20000 {
+		fmt.Fprintf(&data, "X%vY0D03*", i*1000)
+	}
+	data.WriteString("M02*")
+	l, err := New("x").ReadLayer("x.gtl", strings.NewReader(data.String()))
+	if err != nil || len(l.Primitives) != 20000 {
+		t.Fatalf("ReadLayer = %v primitives, %v, want 20000", len(l.Primitives), err)
+	}
+	if min, max, _ := l.bounds(); math.Abs(min.X+0.1) > 1e-9 || math.Abs(max.X-20.099) > 1e-9 {
+		t.Errorf("bounds = %v-%v", min, max)
+	}
+}
+
+func TestGerber_ReadLayer_unsupported(t *testing.T) {
+	for _, data := range []string{
+		"%FSTAX24Y24*%%MOIN*%%ADD10C,0.01*%D10*X1Y1D03*M02*",
+		"%FSLIX46Y46*%%MOMM*%%ADD10C,0.1*%D10*X1000Y0D03*M02*",
+		"%FSLAX46Y46*%%MOMM*%%ADD10C,0.1*%D10*G91*X1000Y0D03*M02*",
+		"%FSLAX46Y46*%%MOMM*%%ADD10C,0.1*%D10*G74*X0Y0D02*G02X1000000Y1000000I1000000J0D01*M02*",
+	} {
+		if _, err := New("x").ReadLayer("x.gtl", strings.NewReader(data)); err == nil {
+			t.Errorf("ReadLayer(%q): expected an error", data)
+		}
+	}
+	// Single quadrant mode without arcs is fine.
+	if _, err := New("x").ReadLayer("x.gtl", strings.NewReader("%FSLAX46Y46*%%MOMM*%%ADD10C,0.1*%G74*D10*X0Y0D02*X1000000Y0D01*M02*")); err != nil {
+		t.Error(err)
+	}
+}
+
+// kicadGerber is an excerpt of a top copper layer exported by KiCad.
+const kicadGerber = `%TF.GenerationSoftware,KiCad,Pcbnew,7.0.9*%
+%TF.FileFunction,Copper,L1,Top*%
+%FSLAX46Y46*%
+G04 Gerber Fmt 4.6, Leading zero omitted, Abs format (unit mm)*
+%MOMM*%
+%LPD*%
+G01*
+G04 APERTURE LIST*
+%AMRoundRect*
+0 Rectangle with rounded corners*
+0 $1 Rounding radius*
+0 $2 $3 $4 $5 $6 $7 $8 $9 X,Y pos of 4 corners*
+4,1,4,$2,$3,$4,$5,$6,$7,$8,$9,$2,$3,0*
+1,1,$1+$1,$2,$3*
+1,1,$1+$1,$4,$5*
+1,1,$1+$1,$6,$7*
+1,1,$1+$1,$8,$9*
+20,1,$1+$1,$2,$3,$4,$5,0*
+20,1,$1+$1,$4,$5,$6,$7,0*
+20,1,$1+$1,$6,$7,$8,$9,0*
+20,1,$1+$1,$8,$9,$2,$3,0*%
+%TA.AperFunction,SMDPad,CuDef*%
+%ADD10RoundRect,0.250000X-0.450000X-0.350000X0.450000X-0.350000X0.450000X0.350000X-0.450000X0.350000X0*%
+%TD*%
+%TA.AperFunction,ComponentPad*%
+%ADD11O,1.700000X1.000000*%
+%TD*%
+%ADD12R,2.000000X1.000000*%
+%TA.AperFunction,Conductor*%
+%ADD13C,0.250000*%
+%TD*%
+G04 APERTURE END LIST*
+D10*
+X10000000Y5000000D03*
+D11*
+X20000000Y5000000D03*
+D12*
+X30000000Y5000000D03*
+D13*
+X10000000Y5000000D02*
+X20000000Y5000000D01*
+X20000000Y15000000*
+G36*
+X0Y0D02*
+G01*
+X4000000Y0D01*
+G03*
+X4000000Y4000000I0J2000000D01*
+G01*
+X0Y4000000D01*
+X0Y0D01*
+G37*
+M02*
+`
+
+func TestGerber_ReadLayer_KiCad(t *testing.T) {
+	l, err := New("board").ReadLayer("board-F_Cu.gtl", strings.NewReader(kicadGerber))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Primitives) != 6 {
+		t.Fatalf("got %v primitives, want 6", len(l.Primitives))
+	}
+	tests := []struct {
+		name     string
+		min, max Pt
+	}{
+		{"round rect pad", Pt{X: 9.3, Y: 4.4}, Pt{X: 10.7, Y: 5.6}},
+		{"obround pad", Pt{X: 19.15, Y: 4.5}, Pt{X: 20.85, Y: 5.5}},
+		{"rectangular pad", Pt{X: 29, Y: 4.5}, Pt{X: 31, Y: 5.5}},
+		{"trace", Pt{X: 9.875, Y: 4.875}, Pt{X: 20.125, Y: 5.125}},
+		{"modal trace", Pt{X: 19.875, Y: 4.875}, Pt{X: 20.125, Y: 15.125}},
+		{"region with an arc", Pt{X: 0, Y: 0}, Pt{X: 6, Y: 4}},
+	}
+	for i, tt := range tests {
+		min, max, ok := bounds(plot(l.Primitives[i]))
+		if !ok || math.Hypot(min.X-tt.min.X, min.Y-tt.min.Y) > 0.01 || math.Hypot(max.X-tt.max.X, max.Y-tt.max.Y) > 0.01 {
+			t.Errorf("%v: bounds = %v-%v, want %v-%v", tt.name, min, max, tt.min, tt.max)
+		}
+	}
+	if a := l.Primitives[3].Aperture(); a == nil || a.Function != "Conductor" || a.Size != 0.25 {
+		t.Errorf("trace aperture = %+v, want 0.25mm conductor", a)
+	}
+
+	// The design can be extended and written again.
+	l.Add(Text(0, 20, 1, "REV B", "latoregular", 12))
+	var buf bytes.Buffer
+	if err := l.WriteGerber(&buf); err != nil {
+		t.Fatal(err)
+	}
+}
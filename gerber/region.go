@@ -0,0 +1,79 @@
+package gerber
+
+import (
+	"errors"
+	"io"
+)
+
+// RegionT represents a filled region with cutouts (such as a copper pour
+// with keep-out areas) and satisfies the Primitive interface.
+type RegionT struct {
+	outline []point
+	cutouts [][]point
+	islands [][]point
+}
+
+// Region returns a filled region primitive of the outline with the
+// cutouts removed from it: the outline is filled in dark polarity, then
+// the cutouts are cleared in clear polarity before dark polarity is
+// restored. Contours are closed automatically.
+// All dimensions are in millimeters.
+func Region(outline []Pt, cutouts ...[]Pt) *RegionT {
+	r := &RegionT{outline: toPoints(outline)}
+	for _, c := range cutouts {
+		r.cutouts = append(r.cutouts, toPoints(c))
+	}
+	return r
+}
+
+// Island adds a filled island to the region, drawn after the cutouts
+// so that it can sit inside one (e.g. a pad isolated within a keep-out).
+func (r *RegionT) Island(pts []Pt) *RegionT {
+	r.islands = append(r.islands, toPoints(pts))
+	return r
+}
+
+// toPoints converts points in millimeters to a closed contour
+// in nanometers.
+func toPoints(pts []Pt) []point {
+	result := make([]point, len(pts), len(pts)+1)
+	for i, pt := range pts {
+		result[i] = toPoint(pt)
+	}
+	if len(result) > 0 && result[0] != result[len(result)-1] {
+		result = append(result, result[0])
+	}
+	return result
+}
+
+// WriteGerber writes the primitive to the Gerber file.
+func (r *RegionT) WriteGerber(w io.Writer, apertureIndex int) error {
+	for _, c := range append(append([][]point{r.outline}, r.cutouts...), r.islands...) {
+		if len(c) < 4 {
+			return errors.New("region contours need at least 3 points")
+		}
+	}
+	if err := writeRegion(w, r.outline); err != nil {
+		return err
+	}
+	if len(r.cutouts) > 0 {
+		io.WriteString(w, "%LPC*%\n")
+		for _, c := range r.cutouts {
+			if err := writeRegion(w, c); err != nil {
+				return err
+			}
+		}
+		io.WriteString(w, "%LPD*%\n")
+	}
+	for _, c := range r.islands {
+		if err := writeRegion(w, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Aperture returns nil for RegionT because it uses the default aperture.
+func (r *RegionT) Aperture() *Aperture {
+	return nil
+}
@@ -0,0 +1,43 @@
+package gerber
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRegion(t *testing.T) {
+	square := func(x0, y0, x1, y1 float64) []Pt {
+		return []Pt{{X: x0, Y: y0}, {X: x1, Y: y0}, {X: x1, Y: y1}, {X: x0, Y: y1}}
+	}
+	r := Region(square(0, 0, 10, 10), square(2, 2, 8, 8)).Island(square(4, 4, 6, 6))
+
+	var buf bytes.Buffer
+	if err := r.WriteGerber(&buf, 11); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(buf.String(), "%LPC*%"); got != 1 {
+		t.Errorf("got %v clear polarity switches, want 1", got)
+	}
+	if !strings.HasSuffix(buf.String(), "G37*\n") || !strings.Contains(buf.String(), "%LPD*%\nG54D11*\nG36*\nX4000000Y4000000D02*") {
+		t.Errorf("island is not drawn dark after the cutout:\n%v", buf.String())
+	}
+
+	ops := plot(r)
+	if len(ops) != 3 {
+		t.Fatalf("got %v ops, want 3", len(ops))
+	}
+	for i, want := range []bool{false, true, false} {
+		o := ops[i]
+		if o.code != regionOp || o.clear != want {
+			t.Errorf("op #%v: code=%v clear=%v, want region clear=%v", i, o.code, o.clear, want)
+		}
+		if o.pts[0] != o.pts[len(o.pts)-1] {
+			t.Errorf("op #%v: contour is not closed", i)
+		}
+	}
+
+	if err := Region([]Pt{{X: 0, Y: 0}, {X: 1, Y: 1}}).WriteGerber(&buf, 11); err == nil {
+		t.Error("degenerate region: want error")
+	}
+}
@@ -0,0 +1,166 @@
+package gerber
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// composite is implemented by the primitives made of other primitives
+// with their own apertures, which are added to the layer with them.
+type composite interface {
+	children() []Primitive
+}
+
+// apertures returns the apertures used by the primitive, including
+// those of the primitives it is made of.
+func apertures(p Primitive) []*Aperture {
+	var result []*Aperture
+	if a := p.Aperture(); a != nil {
+		result = append(result, a)
+	}
+	if c, ok := unwrap(p).(composite); ok {
+		for _, child := range c.children() {
+			result = append(result, apertures(child)...)
+		}
+	}
+	return result
+}
+
+// apertureCode returns the D code of the aperture in effect for w
+// (0 if it is unknown, e.g. when w is not a layer writer).
+func apertureCode(w io.Writer, a *Aperture) int {
+	if fw, ok := w.(*writer); ok {
+		return fw.codes[a.ID()]
+	}
+	return 0
+}
+
+// writeChildren writes the primitives with their apertures.
+func writeChildren(w io.Writer, primitives []Primitive) error {
+	for _, p := range primitives {
+		if err := p.WriteGerber(w, apertureCode(w, p.Aperture())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StepRepeatT represents a grid of copies of primitives written once in
+// a Gerber step and repeat (%SR) block and satisfies the Primitive interface.
+type StepRepeatT struct {
+	columns, rows int
+	dx, dy        float64
+	primitives    []Primitive
+}
+
+// StepRepeat returns a primitive repeating the primitives (such as the
+// pads of an LED matrix) in a grid of columns by rows copies stepped by
+// dx along X and dy along Y, which must not be negative. The first copy
+// is the primitives where they are. Step and repeat blocks can't be nested.
+// All dimensions are in millimeters.
+func StepRepeat(columns, rows int, dx, dy float64, primitives ...Primitive) *StepRepeatT {
+	return &StepRepeatT{columns: columns, rows: rows, dx: dx, dy: dy, primitives: primitives}
+}
+
+// WriteGerber writes the primitive to the Gerber file.
+func (s *StepRepeatT) WriteGerber(w io.Writer, apertureIndex int) error {
+	if s.columns < 1 || s.rows < 1 {
+		return fmt.Errorf("step and repeat of %vx%v copies", s.columns, s.rows)
+	}
+	if s.dx < 0 || s.dy < 0 {
+		return fmt.Errorf("step and repeat steps %v,%v must not be negative", s.dx, s.dy)
+	}
+	fw, ok := w.(*writer)
+	if ok && fw.repeating {
+		return errors.New("step and repeat blocks can't be nested")
+	}
+	if ok {
+		fw.repeating = true
+		defer func() { fw.repeating = false }()
+	}
+	fmt.Fprintf(w, "%%SRX%vY%vI%vJ%v*%%\n", s.columns, s.rows, size(w, s.dx), size(w, s.dy))
+	if err := writeChildren(w, s.primitives); err != nil {
+		return err
+	}
+	io.WriteString(w, "%SR*%\n")
+	return nil
+}
+
+// Aperture returns nil: the repeated primitives use their own apertures.
+func (s *StepRepeatT) Aperture() *Aperture {
+	return nil
+}
+
+func (s *StepRepeatT) children() []Primitive {
+	return s.primitives
+}
+
+// BlockT represents copies of primitives placed at points, written once
+// as a Gerber aperture block (%AB) flashed at every point, and satisfies
+// the Primitive interface.
+type BlockT struct {
+	at         []point
+	primitives []Primitive
+}
+
+// Block returns a primitive placing a copy of the primitives, given
+// relative to the origin, at each of the points (such as the repeated
+// sub-patterns of a fractal). Revisions without aperture blocks (see
+// RevisionX1) write every copy instead.
+// All dimensions are in millimeters.
+func Block(at []Pt, primitives ...Primitive) *BlockT {
+	b := &BlockT{primitives: primitives}
+	for _, pt := range at {
+		b.at = append(b.at, toPoint(pt))
+	}
+	return b
+}
+
+// WriteGerber writes the primitive to the Gerber file.
+func (b *BlockT) WriteGerber(w io.Writer, apertureIndex int) error {
+	fw, ok := w.(*writer)
+	if !ok {
+		fw = &writer{Writer: w, format: DefaultFormat, units: Millimeters}
+	}
+	if !fw.revision.atLeast(RevisionX2) {
+		offset := fw.offset
+		defer func() { fw.offset = offset }()
+		for _, pt := range b.at {
+			fw.offset = point{X: offset.X + pt.X, Y: offset.Y + pt.Y}
+			if err := writeChildren(fw, b.primitives); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	code := fw.block()
+	fmt.Fprintf(fw, "%%ABD%d*%%\n", code)
+	if err := writeChildren(fw, b.primitives); err != nil {
+		return err
+	}
+	io.WriteString(fw, "%AB*%\n")
+	fmt.Fprintf(fw, "G54D%d*\n", code)
+	for _, pt := range b.at {
+		writeXY(fw, pt.X, pt.Y, 3)
+	}
+	return nil
+}
+
+// Aperture returns nil: the placed primitives use their own apertures.
+func (b *BlockT) Aperture() *Aperture {
+	return nil
+}
+
+func (b *BlockT) children() []Primitive {
+	return b.primitives
+}
+
+// block returns an unused D code for an aperture block.
+func (w *writer) block() int {
+	if w.nextCode < 12 {
+		w.nextCode = 12
+	}
+	w.nextCode++
+	return w.nextCode - 1
+}
@@ -0,0 +1,72 @@
+package gerber
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStepRepeat(t *testing.T) {
+	g := New("board")
+	top := g.TopCopper()
+	top.Add(StepRepeat(3, 2, 2, 5,
+		Flash(0, 0, CircleShape, 1),
+		Line(0, 0, 1, 0, RectShape, 0.2),
+	))
+	if len(top.Apertures) != 2 {
+		t.Errorf("layer has %v apertures, want 2", len(top.Apertures))
+	}
+	var buf bytes.Buffer
+	if err := top.WriteGerber(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "%SRX3Y2I2.000000J5.000000*%\nG54D12*\nX000000Y000000D03*\nG54D13*\nX000000Y000000D02*\nX1000000Y000000D01*\n%SR*%\n"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("missing %q in:\n%v", want, buf.String())
+	}
+
+	ops := top.plot()
+	if len(ops) != 12 {
+		t.Fatalf("decoded %v operations, want 12", len(ops))
+	}
+	if min, max, _ := bounds(ops); min != (Pt{X: -0.5, Y: -0.5}) || max != (Pt{X: 5.1, Y: 5.5}) {
+		t.Errorf("bounds = %v..%v, want (-0.5,-0.5)..(5.1,5.5)", min, max)
+	}
+
+	top.Add(StepRepeat(2, 2, 1, 1, StepRepeat(2, 2, 1, 1, Flash(0, 0, CircleShape, 1))))
+	if err := top.WriteGerber(&bytes.Buffer{}); err == nil {
+		t.Error("WriteGerber = nil, want an error for nested step and repeat blocks")
+	}
+}
+
+func TestBlock(t *testing.T) {
+	at := []Pt{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 0, Y: 10}}
+	tests := []struct {
+		revision Revision
+		want     string
+	}{
+		{want: "%ABD13*%\nG54D12*\nX000000Y000000D03*\nG54D12*\nX1000000Y000000D03*\n%AB*%\nG54D13*\nX000000Y000000D03*\nX10000000Y000000D03*\nX000000Y10000000D03*\n"},
+		{revision: RevisionX1, want: "G54D12*\nX000000Y000000D03*\nG54D12*\nX1000000Y000000D03*\nG54D12*\nX10000000Y000000D03*\nG54D12*\nX11000000Y000000D03*\n"},
+	}
+	for _, tt := range tests {
+		g := New("board")
+		g.Revision = tt.revision
+		top := g.TopCopper()
+		top.Add(Block(at, Flash(0, 0, CircleShape, 0.5), Flash(1, 0, CircleShape, 0.5)))
+		var buf bytes.Buffer
+		if err := top.WriteGerber(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), tt.want) {
+			t.Errorf("%q: missing %q in:\n%v", tt.revision, tt.want, buf.String())
+		}
+	}
+
+	ops := plot(Block(at, Flash(0, 0, CircleShape, 0.5), Flash(1, 0, CircleShape, 0.5)))
+	if len(ops) != 6 {
+		t.Fatalf("decoded %v operations, want 6", len(ops))
+	}
+	if got := ops[5].pts[0]; got != (Pt{X: 1, Y: 10}) {
+		t.Errorf("last flash at %v, want (1,10)", got)
+	}
+}
@@ -0,0 +1,122 @@
+package gerber
+
+import "math/big"
+
+// Geometry computations snap their inputs to the nanometer grid used for
+// the output and then decide orientations exactly with integer arithmetic,
+// so that degenerate inputs (collinear, coincident or touching edges, as
+// often found in imported fonts and SVGs) are classified consistently
+// instead of by floating point noise.
+
+// pt returns the point in mm.
+func (p point) pt() Pt {
+	return Pt{X: p.X.mm(), Y: p.Y.mm()}
+}
+
+// exactLimit bounds the coordinate differences whose products can't
+// overflow int64 (about 2m).
+const exactLimit = 1 << 31
+
+// orient returns the exact orientation of the triangle abc:
+// 1 if counterclockwise, -1 if clockwise and 0 if collinear.
+func orient(a, b, c point) int {
+	dx1, dy1 := int64(b.X-a.X), int64(b.Y-a.Y)
+	dx2, dy2 := int64(c.X-a.X), int64(c.Y-a.Y)
+	if abs64(dx1) < exactLimit && abs64(dy1) < exactLimit && abs64(dx2) < exactLimit && abs64(dy2) < exactLimit {
+		d := dx1*dy2 - dy1*dx2
+		switch {
+		case d > 0:
+			return 1
+		case d < 0:
+			return -1
+		}
+		return 0
+	}
+	l := new(big.Int).Mul(big.NewInt(dx1), big.NewInt(dy2))
+	r := new(big.Int).Mul(big.NewInt(dy1), big.NewInt(dx2))
+	return l.Cmp(r)
+}
+
+func abs64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// onSegment reports whether r, collinear with pq, lies within its bounding box.
+func onSegment(p, q, r point) bool {
+	return min(p.X, q.X) <= r.X && r.X <= max(p.X, q.X) && min(p.Y, q.Y) <= r.Y && r.Y <= max(p.Y, q.Y)
+}
+
+// segmentsIntersect reports whether the segments ab and cd share any point.
+func segmentsIntersect(a, b, c, d point) bool {
+	d1, d2 := orient(a, b, c), orient(a, b, d)
+	d3, d4 := orient(c, d, a), orient(c, d, b)
+	if d1*d2 < 0 && d3*d4 < 0 {
+		return true
+	}
+	return d1 == 0 && onSegment(a, b, c) || d2 == 0 && onSegment(a, b, d) ||
+		d3 == 0 && onSegment(c, d, a) || d4 == 0 && onSegment(c, d, b)
+}
+
+// snapContour returns the contour snapped to the nanometer grid without
+// its closing vertex and repeated vertices.
+func snapContour(contour []Pt) []point {
+	var pts []point
+	for _, pt := range contour {
+		if p := toPoint(pt); len(pts) == 0 || p != pts[len(pts)-1] {
+			pts = append(pts, p)
+		}
+	}
+	for len(pts) > 1 && pts[0] == pts[len(pts)-1] {
+		pts = pts[:len(pts)-1]
+	}
+	return pts
+}
+
+// twiceArea returns twice the signed area of the contour exactly
+// (positive for counterclockwise contours).
+func twiceArea(pts []point) *big.Int {
+	area := new(big.Int)
+	for i, p := range pts {
+		q := pts[(i+1)%len(pts)]
+		area.Add(area, new(big.Int).Mul(big.NewInt(int64(p.X)), big.NewInt(int64(q.Y))))
+		area.Sub(area, new(big.Int).Mul(big.NewInt(int64(q.X)), big.NewInt(int64(p.Y))))
+	}
+	return area
+}
+
+// simple reports whether the edges of the contours never intersect
+// (except adjacent edges of a contour at their shared vertex).
+func simple(contours [][]point) bool {
+	type edge struct {
+		c, i int
+		a, b point
+	}
+	var edges []edge
+	for c, pts := range contours {
+		for i, p := range pts {
+			edges = append(edges, edge{c, i, p, pts[(i+1)%len(pts)]})
+		}
+	}
+	for i, e := range edges {
+		for _, f := range edges[i+1:] {
+			if e.c == f.c && (f.i == e.i+1 || e.i == 0 && f.i == len(contours[e.c])-1) {
+				// Adjacent edges as, sc only share s unless they fold back.
+				a, s, c := e.a, e.b, f.b
+				if f.i != e.i+1 {
+					a, s, c = f.a, f.b, e.b
+				}
+				if orient(a, s, c) == 0 && (onSegment(a, s, c) || onSegment(s, c, a)) {
+					return false
+				}
+				continue
+			}
+			if segmentsIntersect(e.a, e.b, f.a, f.b) {
+				return false
+			}
+		}
+	}
+	return true
+}
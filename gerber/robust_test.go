@@ -0,0 +1,79 @@
+package gerber
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOrient(t *testing.T) {
+	tests := []struct {
+		a, b, c point
+		want    int
+	}{
+		{point{0, 0}, point{10, 0}, point{0, 10}, 1},
+		{point{0, 0}, point{0, 10}, point{10, 0}, -1},
+		{point{0, 0}, point{5, 5}, point{10, 10}, 0},
+		// Nearly collinear points that floating point misclassifies.
+		{point{0, 0}, point{3_000_000_001, 1_000_000_000}, point{6_000_000_003, 2_000_000_001}, 1},
+		{point{-5e12, -5e12}, point{5e12, 5e12}, point{1, 1}, 0},
+	}
+	for i, tt := range tests {
+		if got := orient(tt.a, tt.b, tt.c); got != tt.want {
+			t.Errorf("test #%v: orient = %v, want %v", i, got, tt.want)
+		}
+	}
+}
+
+func TestTriangulatePolygon_Degenerate(t *testing.T) {
+	tests := []struct {
+		name  string
+		outer []Pt
+		holes [][]Pt
+	}{
+		{"bow tie", []Pt{{X: 0, Y: 0}, {X: 1, Y: 1}, {X: 1, Y: 0}, {X: 0, Y: 1}}, nil},
+		{"spike", []Pt{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}}, nil},
+		{"crossing hole", []Pt{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 4}, {X: 0, Y: 4}}, [][]Pt{{{X: 3, Y: 1}, {X: 5, Y: 1}, {X: 5, Y: 2}}}},
+		{"outside hole", []Pt{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 4}, {X: 0, Y: 4}}, [][]Pt{{{X: 6, Y: 1}, {X: 7, Y: 1}, {X: 7, Y: 2}}}},
+		{"all collinear", []Pt{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tris, err := TriangulatePolygon(tt.outer, tt.holes...); err == nil {
+				t.Errorf("got %v triangles, want an error", len(tris))
+			}
+		})
+	}
+}
+
+// FuzzTriangulatePolygon checks that arbitrary contours never crash the
+// triangulation and that successful results exactly cover the contour.
+func FuzzTriangulatePolygon(f *testing.F) {
+	f.Add([]byte{0, 0, 10, 0, 10, 10, 0, 10}, uint8(1))
+	f.Add([]byte{0, 0, 10, 0, 10, 10, 5, 2, 0, 10}, uint8(3))
+	f.Add([]byte{0, 0, 5, 0, 10, 0, 10, 10, 0, 10, 0, 5}, uint8(1))
+	f.Add([]byte{0, 0, 10, 10, 10, 0, 0, 10}, uint8(1))
+	f.Add([]byte{0, 0, 4, 0, 4, 4, 2, 4, 2, 2, 2, 4, 0, 4}, uint8(7))
+	f.Add([]byte{0, 0, 200, 1, 100, 0, 50, 0, 255, 255}, uint8(255))
+	f.Fuzz(func(t *testing.T, data []byte, scale uint8) {
+		var pts []Pt
+		for i := 0; i+1 < len(data) && len(pts) < 64; i += 2 {
+			s := 0.001 * math.Pow(10, float64(scale%8))
+			pts = append(pts, Pt{X: float64(int8(data[i])) * s, Y: float64(int8(data[i+1])) * s})
+		}
+		tris, err := TriangulatePolygon(pts)
+		if err != nil {
+			return
+		}
+		var area float64
+		for _, tri := range tris {
+			if tri.Area() <= 0 {
+				t.Fatalf("triangle %v is not counterclockwise", tri)
+			}
+			area += tri.Area()
+		}
+		want := math.Abs(signedArea(toPts(snapContour(pts))))
+		if math.Abs(area-want) > 1e-9*math.Max(1, want) {
+			t.Fatalf("triangles cover %v, want %v", area, want)
+		}
+	})
+}
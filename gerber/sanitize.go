@@ -0,0 +1,107 @@
+package gerber
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sanitize removes constructs that some photoplotters reject from all
+// layers of the design and returns a description of every change.
+// See Layer.Sanitize.
+func (g *Gerber) Sanitize() []string {
+	var changes []string
+	for _, l := range g.Layers {
+		changes = append(changes, l.Sanitize()...)
+	}
+	return changes
+}
+
+// Sanitize removes constructs that some photoplotters reject from the
+// layer and returns a description of every change, annotated with the
+// provenance of the primitive as in Error:
+//   - primitives drawn with zero-size apertures are removed,
+//   - zero-length draws (such as circles and dots) are replaced by
+//     flashes of the same aperture, which produce the same image,
+//   - duplicate consecutive polygon points are removed, as are polygons
+//     left with fewer than three distinct points.
+func (l *Layer) Sanitize() []string {
+	var changes []string
+	var prims []Primitive
+	var callers []string
+	for i, p := range l.Primitives {
+		s, change := sanitize(unwrap(p))
+		if change != "" {
+			changes = append(changes, l.wrapErr(i, errors.New(change)).Error())
+		}
+		if s == nil {
+			continue
+		}
+		if o, ok := p.(*ObjectT); ok {
+			// Keep the attributes of the object.
+			for o.p != unwrap(p) {
+				o = o.p.(*ObjectT)
+			}
+			o.p = s
+		} else {
+			p = s
+		}
+		prims = append(prims, p)
+		if i < len(l.callers) {
+			callers = append(callers, l.callers[i])
+		}
+	}
+	l.Primitives = prims
+	if l.callers != nil {
+		l.callers = callers
+	}
+	return changes
+}
+
+// sanitize returns the sanitized primitive (nil if it must be removed)
+// and a description of the change, if any.
+func sanitize(p Primitive) (Primitive, string) {
+	switch v := p.(type) {
+	case *CircleT:
+		if v.thickness <= 0 {
+			return nil, "removed circle with zero-size aperture"
+		}
+		return &FlashT{x: v.x, y: v.y, shape: CircleShape, thickness: v.thickness}, "replaced zero-length draw with flash"
+	case *LineT:
+		if v.thickness <= 0 {
+			return nil, "removed line with zero-size aperture"
+		}
+		if v.x1 == v.x2 && v.y1 == v.y2 {
+			return &FlashT{x: v.x1, y: v.y1, shape: v.shape, thickness: v.thickness}, "replaced zero-length draw with flash"
+		}
+	case *ArcT:
+		if v.thickness <= 0 {
+			return nil, "removed arc with zero-size aperture"
+		}
+		if v.radius == 0 || (v.xScale == 0 && v.yScale == 0) {
+			return Flash(v.x, v.y, v.shape, v.thickness), "replaced zero-length arc with flash"
+		}
+	case *FlashT:
+		if v.thickness <= 0 {
+			return nil, "removed flash with zero-size aperture"
+		}
+	case *PolygonT:
+		pts := []point{}
+		for _, pt := range v.points {
+			if len(pts) == 0 || pt != pts[len(pts)-1] {
+				pts = append(pts, pt)
+			}
+		}
+		// The closing point duplicates the first one.
+		distinct := len(pts)
+		if distinct > 1 && pts[0] == pts[distinct-1] {
+			distinct--
+		}
+		if distinct < 3 {
+			return nil, "removed degenerate polygon"
+		}
+		if removed := len(v.points) - len(pts); removed > 0 {
+			return &PolygonT{points: pts}, fmt.Sprintf("removed %v duplicate point(s)", removed)
+		}
+	}
+	return p, ""
+}
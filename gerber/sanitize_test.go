@@ -0,0 +1,43 @@
+package gerber
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	g := New("board")
+	top := g.TopCopper()
+	top.Add(
+		Line(0, 0, 1, 0, CircleShape, 0.2),
+		Line(1, 1, 1, 1, RectShape, 1.7),
+		Object(Circle(2, 2, 0.6)).Name("pad1"),
+		Line(0, 0, 1, 1, CircleShape, 0),
+		Polygon(0, 0, true, []Pt{{0, 0}, {1, 0}, {1, 0}, {1, 1}, {0, 0}}, 0),
+		Polygon(0, 0, true, []Pt{{0, 0}, {1, 0}, {1, 0}, {0, 0}}, 0),
+	)
+
+	changes := g.Sanitize()
+	want := []string{
+		"board.gtl: primitive #1: replaced zero-length draw with flash",
+		"board.gtl: primitive #2: pad1: replaced zero-length draw with flash",
+		"board.gtl: primitive #3: removed line with zero-size aperture",
+		"board.gtl: primitive #4: removed 1 duplicate point(s)",
+		"board.gtl: primitive #5: removed degenerate polygon",
+	}
+	if got := strings.Join(changes, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("Sanitize =\n%v\nwant\n%v", got, strings.Join(want, "\n"))
+	}
+
+	if got := len(top.Primitives); got != 4 {
+		t.Fatalf("got %v primitives after Sanitize, want 4", got)
+	}
+	if o, ok := top.Primitives[2].(*ObjectT); !ok || o.name != "pad1" {
+		t.Errorf("Sanitize must keep object attributes, got %#v", top.Primitives[2])
+	} else if ops := plot(o); len(ops) != 1 || ops[0].code != flashOp {
+		t.Errorf("circle must be replaced by a single flash, got %+v", ops)
+	}
+	if changes := g.Sanitize(); len(changes) != 0 {
+		t.Errorf("second Sanitize = %v, want no changes", changes)
+	}
+}
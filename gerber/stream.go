@@ -0,0 +1,101 @@
+package gerber
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// LayerStream writes primitives to the Gerber file of a layer as they
+// are added, through a buffered writer, without keeping them in memory:
+// for generative designs with millions of primitives. Apertures are
+// defined in the file before their first use.
+//
+// Streamed primitives are not part of the layer, so the checks over the
+// whole design (such as DRC, Validate or the off-grid pads of Strict)
+// do not cover them.
+type LayerStream struct {
+	l      *Layer
+	bw     *bufio.Writer
+	fw     *writer
+	macros map[string]bool
+	n      int            // index of the next primitive, for errors
+	uuids  map[string]int // the names of the UUIDs so far
+	err    error
+}
+
+// Stream starts writing the layer to w and returns the stream to add
+// the primitives to, after the primitives of the layer (if any).
+// Close must be called to end the file.
+func (l *Layer) Stream(w io.Writer) (*LayerStream, error) {
+	bw := bufio.NewWriterSize(w, 1<<16)
+	fw, err := l.newWriter(bw)
+	if err != nil {
+		return nil, err
+	}
+	s := &LayerStream{l: l, bw: bw, fw: fw, macros: map[string]bool{}, uuids: map[string]int{}}
+	l.writeHeader(fw)
+	defaultAperture.WriteGerber(fw, 11)
+	fw.codes = map[string]int{"default": 11}
+	fw.nextCode = 12
+	if err := s.Add(l.Primitives...); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Add writes the primitives to the layer file, defining their new
+// apertures first.
+func (s *LayerStream) Add(primitives ...Primitive) error {
+	if s.err != nil {
+		return s.err
+	}
+	g, fw := s.l.g, s.fw
+	for _, p := range primitives {
+		i := s.n
+		s.n++
+		if g != nil {
+			if p = forVariant(p, g.Variant); p == nil {
+				continue
+			}
+		}
+		for _, a := range apertures(p) {
+			id := a.ID()
+			if _, ok := fw.codes[id]; ok {
+				continue
+			}
+			if a.Custom != nil && !s.macros[a.Custom.Macro.Name] {
+				s.macros[a.Custom.Macro.Name] = true
+				a.Custom.Macro.WriteGerber(fw)
+			}
+			fw.codes[id] = fw.nextCode
+			a.WriteGerber(fw, fw.nextCode)
+			fw.nextCode++
+		}
+		if g != nil && g.UniqueIDs {
+			p = withUUID(p, s.l.Filename, s.uuids)
+		}
+		if err := p.WriteGerber(fw, fw.codes[p.Aperture().ID()]); err != nil {
+			s.err = &Error{Layer: s.l.Filename, Index: i, Path: objectPath(p), Err: err}
+			return s.err
+		}
+		if fw.err != nil {
+			s.err = &Error{Layer: s.l.Filename, Index: i, Path: objectPath(p), Err: fw.err}
+			return s.err
+		}
+	}
+	return nil
+}
+
+// Close ends the layer file and flushes it. It returns the first error
+// of the stream, if any.
+func (s *LayerStream) Close() error {
+	if s.err != nil {
+		return s.err
+	}
+	s.err = errors.New("layer stream is closed")
+	if err := s.l.writeTrailer(s.fw); err != nil {
+		return err
+	}
+	return s.bw.Flush()
+}
@@ -0,0 +1,206 @@
+package gerber
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// artwork returns n primitives of assorted apertures.
+func artwork(n int) []Primitive {
+	var prims []Primitive
+	for i := 0; i < n; i++ {
+		x, y := float64(i%100), float64(i/100)
+		switch i % 4 {
+		case 0:
+			prims = append(prims, Flash(x, y, CircleShape, 0.5+0.1*float64(i%3)))
+		case 1:
+			prims = append(prims, Line(x, y, x+0.8, y+0.4, CircleShape, 0.2))
+		case 2:
+			prims = append(prims, Object(FlashAperture(x, y, RoundRectAperture(0.6, 0.4, 0.1))).Net("N"))
+		default:
+			prims = append(prims, Polygon(x, y, true, []Pt{{X: 0, Y: 0}, {X: 0.5, Y: 0}, {X: 0, Y: 0.5}}, 0))
+		}
+	}
+	return prims
+}
+
+func TestLayer_Stream(t *testing.T) {
+	g := New("board")
+	l := g.TopCopper()
+	l.Add(artwork(8)...)
+	var want bytes.Buffer
+	if err := l.WriteGerber(&want); err != nil {
+		t.Fatal(err)
+	}
+
+	var got bytes.Buffer
+	s, err := New("board").TopCopper().Stream(&got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range artwork(8) {
+		if err := s.Add(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(Circle(0, 0, 1)); err == nil {
+		t.Error("Add after Close = nil, want error")
+	}
+
+	// Both files draw the same artwork.
+	wantOps, _ := decode(want.Bytes(), map[int]*Aperture{})
+	gotOps, _ := decode(got.Bytes(), map[int]*Aperture{})
+	if len(gotOps) != len(wantOps) {
+		t.Fatalf("streamed %v operations, want %v", len(gotOps), len(wantOps))
+	}
+	wmin, wmax, _ := bounds(wantOps)
+	gmin, gmax, _ := bounds(gotOps)
+	if math.Abs(wmin.X-gmin.X)+math.Abs(wmin.Y-gmin.Y)+math.Abs(wmax.X-gmax.X)+math.Abs(wmax.Y-gmax.Y) > 1e-9 {
+		t.Errorf("streamed bounds %v..%v, want %v..%v", gmin, gmax, wmin, wmax)
+	}
+	if n := bytes.Count(got.Bytes(), []byte("%AMRoundRect*")); n != 1 {
+		t.Errorf("RoundRect macro defined %v times, want once", n)
+	}
+}
+
+func TestGerber_WriteGerber(t *testing.T) {
+	dir := t.TempDir()
+	b := Board4Layer(filepath.Join(dir, "b"))
+	b.TopCopper().Add(artwork(100)...)
+	if err := b.WriteGerber(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.OpenReader(filepath.Join(dir, "b.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	layers := b.withDerived()
+	want := len(layers)
+	for _, l := range layers {
+		if l.drill() {
+			want++ // its Excellon file
+		}
+	}
+	if len(zr.File) != want {
+		t.Errorf("ZIP has %v files, want %v", len(zr.File), want)
+	}
+	for i, l := range layers {
+		var want bytes.Buffer
+		if err := l.WriteGerber(&want); err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(l.Filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want.Bytes()) {
+			t.Errorf("%v differs from WriteGerber", l.Filename)
+		}
+		if i == 0 {
+			rc, err := zr.File[0].Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			zipped, _ := io.ReadAll(rc)
+			rc.Close()
+			if zr.File[0].Name != l.Filename || !bytes.Equal(zipped, got) {
+				t.Errorf("first ZIP file is %v, want %v", zr.File[0].Name, l.Filename)
+			}
+		}
+	}
+}
+
+func TestGerber_WriteGerber_error(t *testing.T) {
+	dir := t.TempDir()
+	b := Board4Layer(filepath.Join(dir, "b"))
+	b.TopCopper().Add(artwork(100)...)
+	b.BottomCopper().Add(Polygon(0, 0, true, nil, 0))
+	if err := b.WriteGerber(); err == nil {
+		t.Fatal("WriteGerber = nil, want error")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b.zip")); !os.IsNotExist(err) {
+		t.Errorf("partial ZIP file left behind: %v", err)
+	}
+}
+
+// benchmarkDesign returns a design of 8 layers of n primitives each.
+func benchmarkDesign(n int) *Gerber {
+	g := New("bench")
+	for i := 0; i < 8; i++ {
+		g.makeLayer(fmt.Sprintf("g%vl", i+1)).Add(artwork(n)...)
+	}
+	return g
+}
+
+func BenchmarkGerber_render(b *testing.B) {
+	g := benchmarkDesign(20000)
+	files, err := g.files(g.Layers, false)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("sequential", func(b *testing.B) {
+		for k := 0; k < b.N; k++ {
+			for _, f := range files {
+				if err := f.write(io.Discard); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("concurrent", func(b *testing.B) {
+		for k := 0; k < b.N; k++ {
+			rendered, stop := render(files)
+			for _, r := range rendered {
+				<-r.done
+				if r.err != nil {
+					b.Fatal(r.err)
+				}
+				r.release()
+			}
+			stop()
+		}
+	})
+}
+
+func BenchmarkLayer_Stream(b *testing.B) {
+	const n = 100000
+	b.Run("WriteGerber", func(b *testing.B) {
+		b.ReportAllocs()
+		for k := 0; k < b.N; k++ {
+			l := New("bench").TopCopper()
+			for i := 0; i < n; i += 1000 {
+				l.Add(artwork(1000)...)
+			}
+			if err := l.WriteGerber(io.Discard); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Stream", func(b *testing.B) {
+		b.ReportAllocs()
+		for k := 0; k < b.N; k++ {
+			s, err := New("bench").TopCopper().Stream(io.Discard)
+			if err != nil {
+				b.Fatal(err)
+			}
+			for i := 0; i < n; i += 1000 {
+				if err := s.Add(artwork(1000)...); err != nil {
+					b.Fatal(err)
+				}
+			}
+			if err := s.Close(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
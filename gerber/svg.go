@@ -0,0 +1,186 @@
+package gerber
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// SVGColors are the colors of the layers in SVG previews, by filename
+// extension. Layers of other kinds are drawn in black.
+var SVGColors = map[string]string{
+	"gtl":  "#c87533",
+	"gbl":  "#4d7fc4",
+	"g2l":  "#b5a642",
+	"g3l":  "#7fa04d",
+	"gts":  "#1a7a3c",
+	"gbs":  "#1a5c7a",
+	"gto":  "#f0f0f0",
+	"gbo":  "#d0d0f0",
+	"gtp":  "#a0a0a0",
+	"gbp":  "#808090",
+	"xln":  "#000000",
+	"nxln": "#303030",
+	"gko":  "#e0c000",
+	"gvs":  "#c000c0",
+}
+
+// SVGNativeText, when true, makes WriteSVG write text primitives as
+// native SVG text elements in their font (by name) and size instead of
+// their outlines, so that the text of the image remains editable (e.g.
+// in Inkscape). Renderers substitute their own fonts, so the text only
+// approximately matches the Gerber artwork. Text laid out on a path,
+// with per-character transforms or as outlines (see TextT.Outline) is
+// always written as outlines.
+var SVGNativeText bool
+
+// WriteSVG writes the layer as an SVG image for previewing it in a
+// browser or embedding it in documentation.
+func (l *Layer) WriteSVG(w io.Writer) error {
+	return WriteSVG(w, l)
+}
+
+// WriteSVGFile writes the layer as an SVG image to the named file.
+func (l *Layer) WriteSVGFile(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := l.WriteSVG(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteSVG writes the layers as a single SVG image, stacked in order
+// (the first layer at the bottom) and drawn in their SVGColors.
+// Clear polarity removes what the layer drew before it (and nothing
+// from the layers below it) and negative layers are drawn inverted
+// within the bounds of the image, as a fab would image them. Text is
+// drawn by its outlines unless SVGNativeText is set.
+// Coordinates are in millimeters with the Y axis pointing up, as in Gerber.
+func WriteSVG(w io.Writer, layers ...*Layer) error {
+	// The elements of each layer in order: the paths of the contours of
+	// every operation or the native text elements.
+	items := make([][]svgItem, len(layers))
+	var all []op
+	for i, l := range layers {
+		for _, p := range l.Primitives {
+			if p = forVariant(p, l.variant()); p == nil {
+				continue
+			}
+			ops := plot(p)
+			all = append(all, ops...)
+			if t, ok := unwrap(p).(*TextT); ok && SVGNativeText {
+				if text, ok := t.svgText(); ok {
+					items[i] = append(items[i], svgItem{element: text})
+					continue
+				}
+			}
+			for _, o := range ops {
+				var paths strings.Builder
+				for _, c := range contours(o) {
+					fmt.Fprintf(&paths, "<path d=\"%v\"/>\n", svgPath(c))
+				}
+				items[i] = append(items[i], svgItem{clear: o.clear, element: paths.String()})
+			}
+		}
+	}
+	min, max, ok := bounds(all)
+	if !ok {
+		min, max = Pt{}, Pt{}
+	}
+	// rect covers the whole image.
+	rect := fmt.Sprintf("<rect x=\"%.4f\" y=\"%.4f\" width=\"%.4f\" height=\"%.4f\"", min.X, 0-max.Y, max.X-min.X, max.Y-min.Y)
+
+	io.WriteString(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%.3fmm\" height=\"%.3fmm\" viewBox=\"%.4f %.4f %.4f %.4f\">\n",
+		max.X-min.X, max.Y-min.Y, min.X, -max.Y, max.X-min.X, max.Y-min.Y)
+	var masks int
+	for i, l := range layers {
+		color, ok := SVGColors[l.extension()]
+		if !ok {
+			color = "#000000"
+		}
+		// The content of the layer so far, wrapped in a mask every time
+		// clear polarity removes some of it.
+		var body strings.Builder
+		if l.Negative() {
+			body.WriteString(rect + "/>\n")
+		}
+		for j := 0; j < len(items[i]); {
+			clear := items[i][j].clear != l.Negative()
+			var paths strings.Builder
+			for ; j < len(items[i]) && (items[i][j].clear != l.Negative()) == clear; j++ {
+				paths.WriteString(items[i][j].element)
+			}
+			if !clear {
+				body.WriteString(paths.String())
+				continue
+			}
+			masks++
+			id := fmt.Sprintf("clear%v", masks)
+			content := body.String()
+			body.Reset()
+			fmt.Fprintf(&body, "<mask id=\"%v\">\n%v fill=\"#ffffff\"/>\n<g fill=\"#000000\">\n%v</g>\n</mask>\n", id, rect, paths.String())
+			fmt.Fprintf(&body, "<g mask=\"url(#%v)\">\n%v</g>\n", id, content)
+		}
+		fmt.Fprintf(w, "<g id=\"%v\" fill=\"%v\" stroke=\"none\">\n%v</g>\n", l.extension(), color, body.String())
+	}
+	io.WriteString(w, "</svg>\n")
+	return nil
+}
+
+// svgItem is an element of a layer in an SVG image.
+type svgItem struct {
+	clear   bool
+	element string
+}
+
+// svgText returns the text as a native SVG text element (with a tspan
+// per line), or false if it can't be written as one.
+func (t *TextT) svgText() (string, bool) {
+	if t.font == nil || t.path != nil || t.transform != nil || t.stroke > 0 {
+		return "", false
+	}
+	// scale is the size of a font unit in millimeters.
+	scale := t.pts * mmPerPt / t.font.HorizAdvX
+	em := t.pts * mmPerPt
+	if t.font.UnitsPerEm > 0 {
+		em = t.font.UnitsPerEm * scale
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "<text font-family=\"%v\" font-size=\"%.4f\"", escapeXML(t.fontName), em)
+	if anchor := map[Alignment]string{AlignCenter: "middle", AlignRight: "end"}[t.align]; anchor != "" {
+		fmt.Fprintf(&b, " text-anchor=\"%v\"", anchor)
+	}
+	if t.tracking != 0 {
+		fmt.Fprintf(&b, " letter-spacing=\"%.4f\"", t.tracking)
+	}
+	if t.wordSpacing != 0 {
+		fmt.Fprintf(&b, " word-spacing=\"%.4f\"", t.wordSpacing)
+	}
+	x := t.x
+	if t.xScale < 0 {
+		// Mirrored text is laid out from the mirrored origin.
+		b.WriteString(" transform=\"scale(-1,1)\"")
+		x = -x
+	}
+	b.WriteString(">")
+	for i, line := range strings.Split(t.s, "\n") {
+		y := t.y + scale*(t.baseline()-float64(i)*t.lineHeight())
+		fmt.Fprintf(&b, "<tspan x=\"%.4f\" y=\"%.4f\">%v</tspan>", 0+x, 0-y, escapeXML(line))
+	}
+	b.WriteString("</text>\n")
+	return b.String(), true
+}
+
+// escapeXML returns s escaped for XML text and attribute values.
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
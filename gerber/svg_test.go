@@ -0,0 +1,109 @@
+package gerber
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriteSVG(t *testing.T) {
+	g := New("board")
+	top := g.TopCopper()
+	square := []Pt{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}}
+	hole := []Pt{{X: 2, Y: 2}, {X: 8, Y: 2}, {X: 8, Y: 8}, {X: 2, Y: 8}}
+	top.Add(Region(square, hole).Island([]Pt{{X: 4, Y: 4}, {X: 6, Y: 4}, {X: 6, Y: 6}}))
+	plane := g.NegativePlane(2)
+	plane.Add(Circle(5, 5, 1))
+
+	tests := []struct {
+		name   string
+		layers []*Layer
+		want   []string
+	}{
+		{
+			name:   "clear polarity masks",
+			layers: []*Layer{top},
+			want: []string{
+				`<g id="gtl" fill="#c87533" stroke="none">`,
+				`<mask id="clear1">`,
+				`<g mask="url(#clear1)">`,
+				// The island is drawn after (outside of) the mask.
+				"</g>\n<path d=\"M4.0000 -4.0000L6.0000 -4.0000L6.0000 -6.0000L4.0000 -4.0000Z\"/>\n</g>\n</svg>\n",
+			},
+		},
+		{
+			name:   "negative layer",
+			layers: []*Layer{top, plane},
+			want: []string{
+				`viewBox="0.0000 -10.0000 10.0000 10.0000"`,
+				`<g id="gtl"`,
+				`<g id="g2l" fill="#b5a642" stroke="none">` + "\n<mask id=\"clear2\">",
+				`<g mask="url(#clear2)">` + "\n" + `<rect x="0.0000" y="-10.0000" width="10.0000" height="10.0000"/>`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteSVG(&buf, tt.layers...); err != nil {
+				t.Fatal(err)
+			}
+			got := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("WriteSVG missing %q:\n%v", want, got)
+				}
+			}
+			d := xml.NewDecoder(&buf)
+			for {
+				if _, err := d.Token(); err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatalf("invalid XML: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteSVG_nativeText(t *testing.T) {
+	SVGNativeText = true
+	defer func() { SVGNativeText = false }()
+	g := New("board")
+	top, bottom := g.TopSilkscreen(), g.BottomSilkscreen()
+	top.Add(Text(1, 2, 1, "A<B\nC", "latoregular", 12).Align(AlignCenter, AnchorBaseline))
+	bottom.Add(Text(1, 2, -1, "D", "latoregular", 12))
+	top.Add(Text(0, 0, 1, "E", "latoregular", 12).Outline(0.1))
+
+	var buf bytes.Buffer
+	if err := WriteSVG(&buf, top, bottom); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		`<text font-family="latoregular" font-size="`,
+		`" text-anchor="middle"><tspan x="1.0000" y="-2.0000">A&lt;B</tspan><tspan x="1.0000" y="`,
+		`transform="scale(-1,1)"><tspan x="-1.0000" y="-2.0000">D</tspan></text>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("WriteSVG missing %q:\n%v", want, got)
+		}
+	}
+	// Only the outlined text is drawn as paths.
+	if n := strings.Count(got, "<text"); n != 2 {
+		t.Errorf("got %v text elements, want 2:\n%v", n, got)
+	}
+	if !strings.Contains(got, "<path") {
+		t.Errorf("outlined text is not drawn:\n%v", got)
+	}
+	d := xml.NewDecoder(&buf)
+	for {
+		if _, err := d.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("invalid XML: %v", err)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package gerber
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Params are the parameter values of a variant of a sweep, by name.
+type Params map[string]float64
+
+// Sweep generates a variant of a board for every combination of the
+// values of its parameters (e.g. coil turns by trace width), to compare
+// them side by side. Each variant is written to its own directory of
+// the output tree with its parameters:
+//
+//	<Dir>/<name>/<name>.gtl, ... <name>.zip, parameters.json
+//
+// where the name of the variant lists its parameters (e.g.
+// "turns-10_width-0.2").
+type Sweep struct {
+	// Dir is the root directory of the output tree.
+	Dir string
+	// Grid are the values of every parameter, by name.
+	Grid map[string][]float64
+	// Generate returns the design of the variant with the given
+	// parameters, writing to filenamePrefix.
+	Generate func(filenamePrefix string, p Params) (*Gerber, error)
+	// PreviewDPI, when positive, is the resolution of the PNG previews of
+	// the variants, written to their directories and composited into a
+	// contact sheet (<Dir>/contact-sheet.png) in the order of Variants,
+	// row by row.
+	PreviewDPI float64
+}
+
+// SweepVariant is a variant generated by a sweep.
+type SweepVariant struct {
+	Name   string
+	Params Params
+	Design *Gerber
+}
+
+// NewSweep returns a new sweep of generate writing to dir.
+func NewSweep(dir string, generate func(filenamePrefix string, p Params) (*Gerber, error)) *Sweep {
+	return &Sweep{Dir: dir, Grid: map[string][]float64{}, Generate: generate}
+}
+
+// Param sets the values of the named parameter and returns the sweep.
+func (s *Sweep) Param(name string, values ...float64) *Sweep {
+	s.Grid[name] = values
+	return s
+}
+
+// Variants returns the parameters of every variant, varying the
+// parameters in the order of their names, the last one fastest.
+func (s *Sweep) Variants() []Params {
+	var names []string
+	for name := range s.Grid {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	variants := []Params{{}}
+	for _, name := range names {
+		var next []Params
+		for _, p := range variants {
+			for _, v := range s.Grid[name] {
+				q := Params{name: v}
+				for k, v := range p {
+					q[k] = v
+				}
+				next = append(next, q)
+			}
+		}
+		variants = next
+	}
+	return variants
+}
+
+// Name returns the name of the variant with the parameters, e.g.
+// "turns-10_width-0.2".
+func (p Params) Name() string {
+	var names []string
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		names[i] = fmt.Sprintf("%v-%v", name, p[name])
+	}
+	return strings.Join(names, "_")
+}
+
+// Run generates and writes every variant of the sweep (and the contact
+// sheet of their previews if PreviewDPI is set) and returns them.
+func (s *Sweep) Run() ([]*SweepVariant, error) {
+	if len(s.Grid) == 0 {
+		return nil, errors.New("sweep has no parameters")
+	}
+	variants := s.Variants()
+	if len(variants) == 0 {
+		return nil, errors.New("sweep has a parameter without values")
+	}
+	var result []*SweepVariant
+	var previews []image.Image
+	for _, p := range variants {
+		v := &SweepVariant{Name: p.Name(), Params: p}
+		dir := filepath.Join(s.Dir, v.Name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+		g, err := s.Generate(filepath.Join(dir, v.Name), p)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", v.Name, err)
+		}
+		v.Design = g
+		if err := g.WriteGerber(); err != nil {
+			return nil, fmt.Errorf("%v: %v", v.Name, err)
+		}
+		buf, err := json.MarshalIndent(p, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, "parameters.json"), append(buf, '\n'), 0666); err != nil {
+			return nil, err
+		}
+		if s.PreviewDPI > 0 {
+			img, err := g.preview(s.PreviewDPI)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v", v.Name, err)
+			}
+			if err := writePNGImage(filepath.Join(dir, v.Name+".png"), img); err != nil {
+				return nil, err
+			}
+			previews = append(previews, img)
+		}
+		result = append(result, v)
+	}
+	if len(previews) > 0 {
+		if err := writePNGImage(filepath.Join(s.Dir, "contact-sheet.png"), contactSheet(previews)); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// previewExtensions are the layers of a preview, from the bottom up.
+var previewExtensions = []string{"gko", "gbl", "gtl", "gto", "xln", "nxln"}
+
+// preview composites the outline, outer copper, top silkscreen and drill
+// layers of the design (including its pad stacks) into an image.
+func (g *Gerber) preview(dpi float64) (*image.RGBA, error) {
+	var layers []*PNGLayer
+	all := g.withDerived()
+	for _, ext := range previewExtensions {
+		for _, l := range all {
+			if l.extension() == ext {
+				layers = append(layers, &PNGLayer{Layer: l})
+			}
+		}
+	}
+	return compositeImage(dpi, layers)
+}
+
+// contactSheet arranges the images in a grid of equal cells.
+func contactSheet(images []image.Image) image.Image {
+	const gap = 8 // pixels between the cells
+	var w, h int
+	for _, img := range images {
+		w, h = max(w, img.Bounds().Dx()), max(h, img.Bounds().Dy())
+	}
+	columns := int(math.Ceil(math.Sqrt(float64(len(images)))))
+	rows := (len(images) + columns - 1) / columns
+	sheet := image.NewRGBA(image.Rect(0, 0, columns*(w+gap)-gap, rows*(h+gap)-gap))
+	for i, img := range images {
+		at := image.Pt((i%columns)*(w+gap), (i/columns)*(h+gap))
+		draw.Draw(sheet, img.Bounds().Sub(img.Bounds().Min).Add(at), img, img.Bounds().Min, draw.Src)
+	}
+	return sheet
+}
+
+// writePNGImage writes the image to the named PNG file.
+func writePNGImage(filename string, img image.Image) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
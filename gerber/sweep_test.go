@@ -0,0 +1,73 @@
+package gerber
+
+import (
+	"encoding/json"
+	"errors"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSweep_Run(t *testing.T) {
+	s := NewSweep(t.TempDir(), func(prefix string, p Params) (*Gerber, error) {
+		g := New(prefix)
+		g.Generator = &Generator{}
+		size := p["size"]
+		g.Outline().Add(rectangle(Pt{}, Pt{X: size, Y: size}, 0.1)...)
+		g.TopCopper().Add(Line(1, 1, size-1, 1, CircleShape, p["width"]))
+		return g, nil
+	}).Param("width", 0.2, 0.5).Param("size", 5, 10)
+	s.PreviewDPI = 100
+
+	want := []Params{{"size": 5, "width": 0.2}, {"size": 5, "width": 0.5}, {"size": 10, "width": 0.2}, {"size": 10, "width": 0.5}}
+	if got := s.Variants(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Variants = %v, want %v", got, want)
+	}
+	variants, err := s.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(variants) != 4 || variants[1].Name != "size-5_width-0.5" {
+		t.Fatalf("Run = %v variants, want 4 starting with size-5_width-0.2", len(variants))
+	}
+	for _, v := range variants {
+		dir := filepath.Join(s.Dir, v.Name)
+		for _, f := range []string{v.Name + ".gtl", v.Name + ".zip", v.Name + ".png"} {
+			if _, err := os.Stat(filepath.Join(dir, f)); err != nil {
+				t.Errorf("missing %v: %v", f, err)
+			}
+		}
+		buf, err := os.ReadFile(filepath.Join(dir, "parameters.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var p Params
+		if err := json.Unmarshal(buf, &p); err != nil || !reflect.DeepEqual(p, v.Params) {
+			t.Errorf("%v: parameters.json = %s, %v", v.Name, buf, err)
+		}
+	}
+
+	f, err := os.Open(filepath.Join(s.Dir, "contact-sheet.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2x2 cells of the largest (10.1mm) variant at 100 dpi.
+	if b := img.Bounds(); b.Dx() != 2*40+8 || b.Dy() != 2*40+8 {
+		t.Errorf("contact sheet is %vx%v, want 88x88", b.Dx(), b.Dy())
+	}
+
+	s.Generate = func(string, Params) (*Gerber, error) { return nil, errors.New("boom") }
+	if _, err := s.Run(); err == nil || err.Error() != "size-5_width-0.2: boom" {
+		t.Errorf("Run = %v, want the error of the first variant", err)
+	}
+	if _, err := NewSweep(s.Dir, s.Generate).Param("x").Run(); err == nil {
+		t.Error("Run of a parameter without values = nil, want error")
+	}
+}
@@ -0,0 +1,112 @@
+package gerber
+
+import (
+	"io"
+	"math"
+)
+
+// MeasureText returns the width and height of the rendered text in
+// millimeters, or zeros if the text has no visible glyphs.
+func MeasureText(s, fontName string, pts float64) (width, height float64) {
+	return Text(0, 0, 1, s, fontName, pts).Measure()
+}
+
+// Measure returns the width and height of the rendered text in
+// millimeters, or zeros if the text has no visible glyphs.
+func (t *TextT) Measure() (width, height float64) {
+	min, max, ok := bounds(plot(t))
+	if !ok {
+		return 0, 0
+	}
+	return max.X - min.X, max.Y - min.Y
+}
+
+// TableT represents rows and columns of text with borders
+// and satisfies the Primitive interface.
+type TableT struct {
+	cells     []*TextT
+	borders   []*LineT
+	thickness float64
+}
+
+// Table returns a table primitive that lays out rows of text cells
+// with borders, sizing each column to fit its widest cell.
+// x and y are the top left corner of the table (top right for xScale -1.0),
+// padding is the space between the text and the borders and thickness is
+// the width of the borders.
+// All dimensions are in millimeters.
+// xScale is 1.0 for top silkscreen and -1.0 for bottom silkscreen.
+func Table(x, y, xScale float64, rows [][]string, fontName string, pts, padding, thickness float64) *TableT {
+	var widths []float64
+	for _, row := range rows {
+		for i, s := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			w, _ := MeasureText(s, fontName, pts)
+			widths[i] = math.Max(widths[i], w+2*padding)
+		}
+	}
+
+	var ascent, height float64 // in millimeters
+	if font, ok := Fonts[fontName]; ok {
+		mmPerUnit := pts * mmPerPt / font.HorizAdvX
+		ascent, height = font.Ascent*mmPerUnit, (font.Ascent-font.Descent)*mmPerUnit
+	}
+
+	t := &TableT{thickness: thickness}
+	var tableWidth float64
+	for _, w := range widths {
+		tableWidth += w
+	}
+	top := y
+	for _, row := range rows {
+		left := x
+		for i, s := range row {
+			// Text starts at the minimum of its bounding box, not its origin.
+			text := Text(0, 0, xScale, s, fontName, pts)
+			min, max, ok := bounds(plot(text))
+			dx := 0.0
+			if ok {
+				dx = min.X
+				if xScale < 0 {
+					dx = max.X
+				}
+			}
+			t.cells = append(t.cells, Text(left+xScale*padding-dx, top-padding-ascent, xScale, s, fontName, pts))
+			left += xScale * widths[i]
+		}
+		t.borders = append(t.borders, Line(x, top, x+xScale*tableWidth, top, CircleShape, thickness))
+		top -= height + 2*padding
+	}
+	t.borders = append(t.borders, Line(x, top, x+xScale*tableWidth, top, CircleShape, thickness))
+	left := x
+	for _, w := range append([]float64{0}, widths...) {
+		left += xScale * w
+		t.borders = append(t.borders, Line(left, y, left, top, CircleShape, thickness))
+	}
+	return t
+}
+
+// WriteGerber writes the primitive to the Gerber file.
+func (t *TableT) WriteGerber(w io.Writer, apertureIndex int) error {
+	for _, text := range t.cells {
+		if err := text.WriteGerber(w, apertureIndex); err != nil {
+			return err
+		}
+	}
+	for _, border := range t.borders {
+		if err := border.WriteGerber(w, apertureIndex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Aperture returns the primitive's desired aperture.
+func (t *TableT) Aperture() *Aperture {
+	return &Aperture{
+		Shape: CircleShape,
+		Size:  t.thickness,
+	}
+}
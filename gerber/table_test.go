@@ -0,0 +1,44 @@
+package gerber
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTable(t *testing.T) {
+	rows := [][]string{
+		{"1", "GND"},
+		{"2", "VCC"},
+		{"10", "SDA"},
+	}
+	table := Table(0, 0, 1, rows, "latoregular", 8, 0.2, 0.1)
+
+	w0, _ := MeasureText("10", "latoregular", 8)
+	w1, _ := MeasureText("VCC", "latoregular", 8)
+	if w0 <= 0 || w1 <= 0 {
+		t.Fatalf("MeasureText = %v, %v, want > 0", w0, w1)
+	}
+	for _, s := range []string{"GND", "SDA"} {
+		w, _ := MeasureText(s, "latoregular", 8)
+		w1 = math.Max(w1, w)
+	}
+
+	min, max, ok := bounds(plot(table))
+	if !ok {
+		t.Fatal("Table must draw")
+	}
+	if got, want := max.X-min.X, w0+w1+4*0.2+0.1; math.Abs(got-want) > 2*tol {
+		t.Errorf("table width = %v, want %v", got, want)
+	}
+	if max.Y > 0.05+tol || min.Y >= 0 {
+		t.Errorf("table must hang below its top left corner, got %v-%v", min, max)
+	}
+
+	// All text must lie within the borders.
+	for i, cell := range table.cells {
+		cmin, cmax, _ := bounds(plot(cell))
+		if cmin.X < min.X || cmax.X > max.X || cmin.Y < min.Y || cmax.Y > max.Y {
+			t.Errorf("cell %v at %v-%v outside table %v-%v", i, cmin, cmax, min, max)
+		}
+	}
+}
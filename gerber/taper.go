@@ -0,0 +1,93 @@
+package gerber
+
+import (
+	"io"
+	"math"
+)
+
+// curveSegments is the number of segments approximating curved
+// tapered traces and text paths.
+const curveSegments = 64
+
+// TaperedTraceT represents a trace whose width changes linearly along
+// its length, rendered as a filled region, and satisfies the Primitive
+// interface.
+type TaperedTraceT struct {
+	points []point
+}
+
+// TaperedLine returns a straight trace from x1,y1 (width1 wide) to
+// x2,y2 (width2 wide) with square ends.
+// All dimensions are in millimeters (or Lengths).
+func TaperedLine[L Lengths](x1, y1, x2, y2, width1, width2 L) *TaperedTraceT {
+	return taperedTrace([]Pt{Point(x1, y1), Point(x2, y2)}, float64(width1), float64(width2))
+}
+
+// TaperedArc returns a trace along the arc of radius around x,y from
+// startAngle to endAngle (counterclockwise if endAngle is larger),
+// tapering from width1 to width2.
+// All dimensions are in millimeters (or Lengths). Angles are in degrees
+// (or Angles).
+func TaperedArc[L Lengths, A Angles](x, y, radius L, startAngle, endAngle A, width1, width2 L) *TaperedTraceT {
+	start, end := float64(startAngle), float64(endAngle)
+	pts := make([]Pt, curveSegments+1)
+	for i := range pts {
+		angle := start + (end-start)*float64(i)/curveSegments
+		pts[i] = Polar(Point(x, y), radius, angle)
+	}
+	return taperedTrace(pts, float64(width1), float64(width2))
+}
+
+// TaperedBezier returns a trace along the cubic Bézier curve from p0 to
+// p3 with control points p1 and p2, tapering from width1 to width2.
+// All dimensions are in millimeters (or Lengths).
+func TaperedBezier[L Lengths](p0, p1, p2, p3 Pt, width1, width2 L) *TaperedTraceT {
+	return taperedTrace(BezierPath(p0, p1, p2, p3), float64(width1), float64(width2))
+}
+
+// taperedTrace offsets the centerline to both sides by half the width,
+// interpolated by the distance along the centerline. Curves tighter
+// than half the width fold over themselves.
+func taperedTrace(centerline []Pt, width1, width2 float64) *TaperedTraceT {
+	var pts []Pt
+	for i, pt := range centerline {
+		if i == 0 || pt != pts[len(pts)-1] {
+			pts = append(pts, pt)
+		}
+	}
+	if len(pts) < 2 {
+		return &TaperedTraceT{}
+	}
+	dist := make([]float64, len(pts))
+	for i := 1; i < len(pts); i++ {
+		dist[i] = dist[i-1] + math.Hypot(pts[i].X-pts[i-1].X, pts[i].Y-pts[i-1].Y)
+	}
+	total := dist[len(dist)-1]
+
+	left := make([]point, len(pts))
+	right := make([]point, len(pts))
+	for i, pt := range pts {
+		// The normal at a vertex is that of the chord of its neighbors.
+		a, b := pts[max(i-1, 0)], pts[min(i+1, len(pts)-1)]
+		l := math.Hypot(b.X-a.X, b.Y-a.Y)
+		half := 0.5 * (width1 + (width2-width1)*dist[i]/total)
+		n := Pt{X: -(b.Y - a.Y) / l * half, Y: (b.X - a.X) / l * half}
+		right[i] = toPoint(Pt{X: pt.X - n.X, Y: pt.Y - n.Y})
+		left[len(pts)-1-i] = toPoint(Pt{X: pt.X + n.X, Y: pt.Y + n.Y})
+	}
+	// Counterclockwise: out along the right side and back along the left.
+	return &TaperedTraceT{points: append(right, left...)}
+}
+
+// WriteGerber writes the primitive to the Gerber file.
+func (t *TaperedTraceT) WriteGerber(w io.Writer, apertureIndex int) error {
+	if len(t.points) == 0 {
+		return nil
+	}
+	return writeRegion(w, t.points)
+}
+
+// Aperture returns nil for TaperedTraceT because it uses the default aperture.
+func (t *TaperedTraceT) Aperture() *Aperture {
+	return nil
+}
@@ -0,0 +1,50 @@
+package gerber
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTaperedTrace(t *testing.T) {
+	tests := []struct {
+		name     string
+		trace    *TaperedTraceT
+		wantArea float64
+	}{
+		{name: "line", trace: TaperedLine(0, 0, 10, 0, 1, 3), wantArea: 20},
+		{name: "straight bezier", trace: TaperedBezier(Pt{}, Pt{X: 2}, Pt{X: 6}, Pt{X: 10}, 1, 3), wantArea: 20},
+		// Half an annulus between the radii 4.5 and 5.5.
+		{name: "arc", trace: TaperedArc(0, 0, 5, 0, 180, 1, 1), wantArea: 0.5 * math.Pi * (5.5*5.5 - 4.5*4.5)},
+		{name: "clockwise arc", trace: TaperedArc(0, 0, 5, 180, 0, 1, 1), wantArea: 0.5 * math.Pi * (5.5*5.5 - 4.5*4.5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := plot(tt.trace)
+			if len(ops) != 1 || ops[0].code != regionOp {
+				t.Fatalf("got %+v, want a single region", ops)
+			}
+			if got := signedArea(ops[0].pts); math.Abs(got-tt.wantArea) > 0.01*tt.wantArea {
+				t.Errorf("area = %v, want %v", got, tt.wantArea)
+			}
+		})
+	}
+}
+
+func TestTaperedLine_Widths(t *testing.T) {
+	ops := plot(TaperedLine(0, 0, 0, 10, 0.5, 2))
+	want := []Pt{{X: 0.25, Y: 0}, {X: 1, Y: 10}, {X: -1, Y: 10}, {X: -0.25, Y: 0}, {X: 0.25, Y: 0}}
+	if len(ops) != 1 || len(ops[0].pts) != len(want) {
+		t.Fatalf("got %+v, want a region of %v points", ops, len(want))
+	}
+	for i, pt := range want {
+		if toPoint(ops[0].pts[i]) != toPoint(pt) {
+			t.Errorf("point #%v = %v, want %v", i, ops[0].pts[i], pt)
+		}
+	}
+}
+
+func TestTaperedTrace_Degenerate(t *testing.T) {
+	if ops := plot(TaperedLine(1, 1, 1, 1, 1, 2)); len(ops) != 0 {
+		t.Errorf("zero-length tapered line = %+v, want nothing", ops)
+	}
+}
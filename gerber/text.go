@@ -1,9 +1,10 @@
 package gerber
 
 import (
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"math"
 	"strings"
 
 	"github.com/gmlewis/go3d/float64/bezier2"
@@ -18,74 +19,361 @@ const (
 	maxSteps   = 100
 )
 
+// MissingGlyphMode determines what happens when text uses a character
+// that is missing from its font.
+type MissingGlyphMode int
+
+const (
+	// MissingSkip logs a warning and leaves a blank space (the default).
+	MissingSkip MissingGlyphMode = iota
+	// MissingError makes WriteGerber return an error.
+	MissingError
+	// MissingBox draws a .notdef box in place of the character.
+	MissingBox
+	// MissingFallback substitutes the glyph from the first fallback font
+	// that has it, drawing a .notdef box if none do.
+	MissingFallback
+)
+
 // TextT represents text and satisfies the Primitive interface.
 type TextT struct {
 	x, y, xScale float64
 	s            string
+	fontName     string
 	font         *Font
 	pts          float64
+	missing      MissingGlyphMode
+	fallbacks    []string
+	minHeight    float64
+	dilation     float64
+	knockout     bool
+	stroke       float64
+	transform    func(index int, c rune) GlyphTransform
+	glyphXF      *GlyphTransform // transform of the glyph being written
+	tracking     float64
+	wordSpacing  float64
+	tabular      bool
+	snapHeight   float64
+	snap         float64 // snapping grid pitch
+	align        Alignment
+	anchor       Anchor
+	lineSpacing  float64
+	path         []Pt
+}
+
+// GlyphTransform is the extra transform applied to a single character
+// of text during layout.
+type GlyphTransform struct {
+	// DX and DY offset the character (in millimeters).
+	DX, DY float64
+	// Rotate rotates the character about its origin (in degrees).
+	Rotate float64
+	// Scale scales the character and its advance (1 if zero).
+	Scale float64
 }
 
 // Text returns a text primitive.
-// All dimensions are in millimeters.
+// All dimensions are in millimeters (or Lengths). x and y are the start
+// of the baseline of the first line (unless aligned otherwise with
+// Align), so text in different fonts placed at the same y shares the
+// same baseline.
+// Lines are separated by newlines.
 // xScale is 1.0 for top silkscreen and -1.0 for bottom silkscreen.
-func Text(x, y, xScale float64, s, fontName string, pts float64) *TextT {
-	if len(Fonts) == 0 {
-		log.Fatal("No fonts available")
-	}
-
+func Text[L Lengths](x, y L, xScale float64, s, fontName string, pts float64) *TextT {
 	font, ok := Fonts[fontName]
-	if !ok {
+	if !ok && len(Fonts) > 0 {
 		var name string
 		for name, font = range Fonts {
 			break
 		}
-		log.Printf("Could not find font %q: using %q instead", fontName, name)
+		logger.Warn("could not find font: using another font instead", "font", fontName, "using", name)
 	}
 
 	return &TextT{
-		x:      x,
-		y:      y,
-		xScale: xScale,
-		s:      s,
-		font:   font,
-		pts:    pts,
+		x:        float64(x),
+		y:        float64(y),
+		xScale:   xScale,
+		s:        s,
+		fontName: fontName,
+		font:     font,
+		pts:      pts,
 	}
 }
 
+// MissingGlyph sets what happens when a character is missing from the font.
+func (t *TextT) MissingGlyph(mode MissingGlyphMode) *TextT {
+	t.missing = mode
+	return t
+}
+
+// Fallback sets the fonts searched (in order) for characters missing
+// from the text's font and selects the MissingFallback mode.
+func (t *TextT) Fallback(fontNames ...string) *TextT {
+	t.fallbacks = fontNames
+	t.missing = MissingFallback
+	return t
+}
+
 // WriteGerber writes the primitive to the Gerber file.
 func (t *TextT) WriteGerber(w io.Writer, apertureIndex int) error {
-	x, y := t.x, t.y
+	if t.font == nil {
+		return errors.New("no fonts available")
+	}
+	if _, ok := Fonts[t.fontName]; !ok {
+		strictError(w, "could not find font", "font", t.fontName) // Logged by Text.
+	}
+	widths, err := t.lineWidths()
+	if err != nil {
+		return err
+	}
+	// x and y are in font units relative to the text origin.
+	var line int
+	x, y := t.lineStart(widths, 0), t.baseline()
+	index := -1
 	for _, c := range t.s {
+		index++
 		if c == rune('\n') {
-			x, y = t.x, y-(t.font.Ascent-t.font.Descent)
+			line++
+			x, y = t.lineStart(widths, line), y-t.lineHeight()
 			continue
 		}
 		if c == rune('\t') {
 			x += 2.0 * t.xScale * t.font.HorizAdvX
 			continue
 		}
+		gt, scale := t, 1.0
+		if t.transform != nil || t.path != nil {
+			var xf GlyphTransform
+			if t.transform != nil {
+				xf = t.transform(index, c)
+			}
+			if xf.Scale == 0 {
+				xf.Scale = 1
+			}
+			if t.path != nil {
+				adv, _ := t.charAdvance(c)
+				t.followPath(&xf, x, y, adv*xf.Scale)
+			}
+			ct := *t
+			ct.glyphXF = &xf
+			gt, scale = &ct, xf.Scale
+		}
+		spacing := t.charSpacing(c)
 		g, ok := t.font.Glyphs[string(c)]
 		if !ok {
-			log.Printf("Warning: missing glyph %+q: skipping", c)
-			x += t.xScale * t.font.HorizAdvX
+			dx, err := gt.writeMissing(w, apertureIndex, c, x, y)
+			if err != nil {
+				return err
+			}
+			x += t.xScale * (dx*scale + spacing)
+			continue
+		}
+		if t.tabular && c >= '0' && c <= '9' {
+			// Center the figure within a cell the width of the widest figure.
+			cell := t.font.figureWidth()
+			offset := 0.5 * (cell - g.advance(t.font))
+			g.WriteGerber(w, apertureIndex, gt, x+t.xScale*scale*offset, y)
+			x += t.xScale * (cell*scale + spacing)
 			continue
 		}
-		dx := g.WriteGerber(w, apertureIndex, t, x, y)
+		dx := g.WriteGerber(w, apertureIndex, gt, x, y)
 		if dx == 0 {
 			dx = t.font.HorizAdvX
 		}
-		x += dx * t.xScale
+		x += t.xScale * (dx*scale + spacing)
 	}
 	return nil
 }
 
-// Aperture returns nil for TextT because it uses the default aperture.
+// Spacing sets the tracking (extra space after every character) and
+// the word spacing (extra space after every space character).
+// All dimensions are in millimeters.
+func (t *TextT) Spacing(tracking, wordSpacing float64) *TextT {
+	t.tracking = tracking
+	t.wordSpacing = wordSpacing
+	return t
+}
+
+// Tabular makes all figures (0-9) advance by the same width so that
+// numbers line up in columns, such as in connector pin tables.
+func (t *TextT) Tabular() *TextT {
+	t.tabular = true
+	return t
+}
+
+// fontUnits converts millimeters to font units at the size of the text.
+func (t *TextT) fontUnits(mm float64) float64 {
+	return mm * t.font.HorizAdvX / (t.pts * mmPerPt)
+}
+
+// advance returns the horizontal advance of the glyph in font units.
+func (g *Glyph) advance(f *Font) float64 {
+	if g.HorizAdvX == 0 {
+		return f.HorizAdvX
+	}
+	return g.HorizAdvX
+}
+
+// figureWidth returns the advance of the widest figure (0-9) in font units.
+func (f *Font) figureWidth() float64 {
+	var width float64
+	for c := '0'; c <= '9'; c++ {
+		if g, ok := f.Glyphs[string(c)]; ok {
+			width = math.Max(width, g.advance(f))
+		}
+	}
+	return width
+}
+
+// Boost enables the small-text readability mode: when the cap height
+// of the text is below minHeight, glyph outlines are dilated by offset
+// to compensate for silkscreen process spread.
+// All dimensions are in millimeters.
+func (t *TextT) Boost(minHeight, offset float64) *TextT {
+	t.minHeight = minHeight
+	t.dilation = offset
+	return t
+}
+
+// Transform sets a callback that returns an extra transform for each
+// character (by rune index within the text) during layout, enabling
+// wavy text, per-character rotation and size ramps.
+func (t *TextT) Transform(f func(index int, c rune) GlyphTransform) *TextT {
+	t.transform = f
+	return t
+}
+
+// Outline renders only the outlines of the glyphs, stroked with
+// a circular aperture of the given width, instead of filling them.
+// All dimensions are in millimeters.
+func (t *TextT) Outline(width float64) *TextT {
+	t.stroke = width
+	return t
+}
+
+// Snap enables grid snapping of glyph outlines for tiny text: when the
+// cap height of the text is below maxHeight, every outline vertex is
+// rounded to a multiple of grid so that stems of the same design width
+// come out the same width on fine silkscreen. A zero grid snaps to the
+// coordinate grid of the output (the last digit of its format).
+// All dimensions are in millimeters.
+func (t *TextT) Snap(maxHeight, grid float64) *TextT {
+	t.snapHeight = maxHeight
+	t.snap = grid
+	return t
+}
+
+// snapGrid returns the pitch of the snapping grid (in nanometers) used
+// when writing the text to w, or zero if the outlines are not snapped.
+func (t *TextT) snapGrid(w io.Writer) nm {
+	if t.snapHeight <= 0 || t.capHeight() >= t.snapHeight {
+		return 0
+	}
+	if t.snap > 0 {
+		return toNM(t.snap)
+	}
+	lsb := math.Pow10(-formatOf(w).Decimal)
+	if unitsOf(w) == Inches {
+		lsb *= 25.4
+	}
+	return toNM(lsb)
+}
+
+// boosted reports whether glyph outlines are dilated.
+func (t *TextT) boosted() bool {
+	return t.dilation > 0 && t.font != nil && t.capHeight() < t.minHeight
+}
+
+// capHeight returns the height of a capital letter in millimeters.
+func (t *TextT) capHeight() float64 {
+	capHeight := t.font.CapHeight
+	if capHeight == 0 {
+		capHeight = 0.7 * t.font.Ascent
+	}
+	return capHeight * t.pts * mmPerPt / t.font.HorizAdvX
+}
+
+// writeMissing handles a character missing from the font according to
+// the missing glyph mode and returns the advance in font units.
+func (t *TextT) writeMissing(w io.Writer, apertureIndex int, c rune, x, y float64) (float64, error) {
+	switch t.missing {
+	case MissingError:
+		return 0, fmt.Errorf("missing glyph %+q in font %q", c, t.fontName)
+	case MissingFallback:
+		for _, name := range t.fallbacks {
+			font, ok := Fonts[name]
+			if !ok {
+				continue
+			}
+			g, ok := font.Glyphs[string(c)]
+			if !ok {
+				continue
+			}
+			// Convert between the font units of both fonts.
+			ft := *t
+			ft.font = font
+			ratio := font.HorizAdvX / t.font.HorizAdvX
+			dx := g.WriteGerber(w, apertureIndex, &ft, x*ratio, y*ratio)
+			if dx == 0 {
+				dx = font.HorizAdvX
+			}
+			return dx / ratio, nil
+		}
+		fallthrough
+	case MissingBox:
+		g := t.font.notdef()
+		return g.WriteGerber(w, apertureIndex, t, x, y), nil
+	}
+	if !strictError(w, "missing glyph", "glyph", fmt.Sprintf("%+q", c)) {
+		logger.Warn("missing glyph: skipping", "glyph", fmt.Sprintf("%+q", c))
+	}
+	return t.font.HorizAdvX, nil
+}
+
+// notdef returns a .notdef glyph: a hollow box the width of a missing glyph
+// and the height of a capital letter.
+func (f *Font) notdef() *Glyph {
+	adv := f.MissingHorizAdvX
+	if adv == 0 {
+		adv = f.HorizAdvX
+	}
+	top := f.CapHeight
+	if top == 0 {
+		top = 0.7 * f.Ascent
+	}
+	x0, x1 := 0.1*adv, 0.9*adv
+	sw := 0.05 * f.UnitsPerEm // stroke width
+	bar := func(x, y, w, h float64) []*PathStep {
+		return []*PathStep{
+			{C: 'M', P: []float64{x, y}},
+			{C: 'h', P: []float64{w}},
+			{C: 'v', P: []float64{h}},
+			{C: 'h', P: []float64{-w}},
+			{C: 'z'},
+		}
+	}
+	var steps []*PathStep
+	steps = append(steps, bar(x0, 0, x1-x0, sw)...)
+	steps = append(steps, bar(x0, top-sw, x1-x0, sw)...)
+	steps = append(steps, bar(x0, sw, sw, top-2*sw)...)
+	steps = append(steps, bar(x1-sw, sw, sw, top-2*sw)...)
+	return &Glyph{HorizAdvX: adv, Unicode: ".notdef", GerberLP: "dddd", PathSteps: steps}
+}
+
+// Aperture returns nil for TextT because it uses the default aperture,
+// unless glyph outlines are stroked or dilated by stroking them.
 func (t *TextT) Aperture() *Aperture {
+	if t.stroke > 0 {
+		return &Aperture{Shape: CircleShape, Size: t.stroke}
+	}
+	if t.boosted() {
+		return &Aperture{Shape: CircleShape, Size: 2 * t.dilation}
+	}
 	return nil
 }
 
 // WriteGerber writes the primitive to the Gerber file.
+// x and y are the glyph origin in font units relative to the text origin.
 func (g *Glyph) WriteGerber(w io.Writer, apertureIndex int, t *TextT, x, y float64) float64 {
 	xScale := t.xScale
 	oX, oY := x, y         // origin for this glyph
@@ -93,32 +381,113 @@ func (g *Glyph) WriteGerber(w io.Writer, apertureIndex int, t *TextT, x, y float
 	currentPolarity := "d" // d=dark, c=clear
 	var curveNum int
 
-	fsf := sf * t.pts * mmPerPt / t.font.HorizAdvX
+	fsf := nmPerMM * t.pts * mmPerPt / t.font.HorizAdvX
+	tx, ty := toNM(t.x), toNM(t.y)
 
-	dumpPoly := func() {
-		if g.GerberLP != "" && curveNum < len(g.GerberLP) {
-			polarity := g.GerberLP[curveNum : curveNum+1]
-			if polarity != currentPolarity {
-				fmt.Fprintf(w, "%%LP%v*%%\n", strings.ToUpper(polarity))
-				currentPolarity = polarity
+	// snap rounds a coordinate to the snapping grid of tiny text.
+	grid := t.snapGrid(w)
+	snap := func(v nm) nm {
+		if grid <= 1 {
+			return v
+		}
+		return nm(math.Round(float64(v)/float64(grid))) * grid
+	}
+
+	// xy converts a point in font units to nanometers.
+	xy := func(pt Pt) (nm, nm) {
+		x, y := tx, ty
+		if xf := t.glyphXF; xf != nil {
+			sin, cos := math.Sincos(xf.Rotate * math.Pi / 180.0)
+			dx, dy := xf.Scale*(pt.X-oX), xf.Scale*(pt.Y-oY)
+			pt = Pt{X: oX + dx*cos - dy*sin, Y: oY + dx*sin + dy*cos}
+			x, y = x+toNM(xf.DX), y+toNM(xf.DY)
+		}
+		return snap(x + nm(math.Round(fsf*pt.X))), snap(y + nm(math.Round(fsf*pt.Y)))
+	}
+
+	// ink is the polarity of the glyph's dark contours, which is clear
+	// for knocked out text.
+	ink := "d"
+	if t.knockout {
+		ink = "c"
+	}
+	setPolarity := func(polarity string) {
+		if polarity != currentPolarity {
+			fmt.Fprintf(w, "%%LP%v*%%\n", strings.ToUpper(polarity))
+			currentPolarity = polarity
+		}
+	}
+
+	// outline converts the current polygon to nanometers, dropping the
+	// vertices repeated by grid snapping.
+	outline := func() (out []point) {
+		for _, pt := range pts {
+			x, y := xy(pt)
+			if p := (point{X: x, Y: y}); len(out) == 0 || p != out[len(out)-1] {
+				out = append(out, p)
+			}
+		}
+		return out
+	}
+
+	strokePoly := func(out []point) {
+		fmt.Fprintf(w, "G54D%d*\n", apertureIndex)
+		for i, pt := range append(out, out[0]) {
+			d := 1
+			if i == 0 {
+				d = 2
 			}
-			// } else if g.GerberLP == "" && curveNum > 0 && currentPolarity == "d" {
-			// 	io.WriteString(w, "%LPC%*\n")
-			// 	currentPolarity = "c"
+			writeXY(w, pt.X, pt.Y, d)
 		}
+	}
+
+	// contours are the closed polygons of the glyph (in font units)
+	// with the index of the curve (of GerberLP) each belongs to.
+	var contours [][]Pt
+	var curves []int
+	dumpPoly := func() {
+		contours = append(contours, pts)
+		curves = append(curves, curveNum)
+		pts = []Pt{}
+	}
+
+	emitPoly := func(polarity string) {
+		out := outline()
+		if n := len(out); n < 3 || n == 3 && out[0] == out[2] {
+			return // Collapsed by grid snapping.
+		}
+		if t.stroke > 0 {
+			// Outlines of dark and clear contours alike are drawn in ink.
+			setPolarity(ink)
+			strokePoly(out)
+			return
+		}
+
+		if t.knockout {
+			polarity = map[string]string{"d": "c", "c": "d"}[polarity]
+		}
+		setPolarity(polarity)
 
 		io.WriteString(w, "G54D11*\n")
 		io.WriteString(w, "G36*\n")
-		for i, pt := range pts {
+		for i, pt := range out {
+			d := 1
 			if i == 0 {
-				fmt.Fprintf(w, "X%06dY%06dD02*\n", int(0.5+fsf*pt.X), int(0.5+fsf*pt.Y))
-				continue
+				d = 2
 			}
-			fmt.Fprintf(w, "X%06dY%06dD01*\n", int(0.5+fsf*pt.X), int(0.5+fsf*pt.Y))
+			writeXY(w, pt.X, pt.Y, d)
+		}
+		if out[len(out)-1] != out[0] {
+			writeXY(w, out[0].X, out[0].Y, 1)
 		}
-		fmt.Fprintf(w, "X%06dY%06dD02*\n", int(0.5+fsf*pts[0].X), int(0.5+fsf*pts[0].Y))
 		io.WriteString(w, "G37*\n")
-		pts = []Pt{}
+
+		if t.boosted() {
+			// Stroke the contour in ink polarity: this grows dark
+			// contours and shrinks clear ones (counters) alike.
+			setPolarity(ink)
+			strokePoly(out)
+		}
 	}
 
 	var lastQ *qbezier2.T
@@ -282,7 +651,9 @@ func (g *Glyph) WriteGerber(w io.Writer, apertureIndex int, t *TextT, x, y float
 			}
 			curveNum++
 		default:
-			log.Fatalf("Unsupported path command %q", ps.C)
+			if !strictError(w, "unsupported path command", "command", string(ps.C), "glyph", g.Unicode) {
+				logger.Error("unsupported path command: skipping", "command", string(ps.C), "glyph", g.Unicode)
+			}
 		}
 		lastCommand = ps.C
 	}
@@ -290,10 +661,29 @@ func (g *Glyph) WriteGerber(w io.Writer, apertureIndex int, t *TextT, x, y float
 		dumpPoly()
 	}
 
-	// Restore dark polarity for the rest of the Gerber layer.
-	if currentPolarity != "d" {
-		io.WriteString(w, "%LPD%*\n")
+	if len(g.GerberLP) == curveNum && curveNum > 0 {
+		// Hand-tuned polarities of the curves, in path order.
+		for i, c := range contours {
+			polarity := "d"
+			if curves[i] < len(g.GerberLP) {
+				polarity = g.GerberLP[curves[i] : curves[i]+1]
+			}
+			pts = c
+			emitPoly(polarity)
+		}
+	} else {
+		for _, c := range fillContours(contours, t.font.FillRule) {
+			polarity := "d"
+			if !c.dark {
+				polarity = "c"
+			}
+			pts = c.pts
+			emitPoly(polarity)
+		}
 	}
 
+	// Restore dark polarity for the rest of the Gerber layer.
+	setPolarity("d")
+
 	return g.HorizAdvX
 }
@@ -0,0 +1,281 @@
+package gerber
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestText_BaselineAlignment(t *testing.T) {
+	for _, name := range []string{"latoregular", "ubuntumonoregular", "overlockregular"} {
+		min, _, ok := bounds(plot(Text(10, 5, 1, "H", name, 12)))
+		if !ok {
+			t.Fatalf("%v: no geometry", name)
+		}
+		if math.Abs(min.Y-5) > 0.05 || min.X < 10 || min.X > 11 {
+			t.Errorf("%v: H starts at %v, want on the baseline at (10,5)", name, min)
+		}
+	}
+}
+
+func TestFont_Coverage(t *testing.T) {
+	font := Fonts["latoregular"]
+	if got := string(font.Missing("Hello, Ω\nHello→")); got != "Ω→" {
+		t.Errorf("Missing = %q, want %q", got, "Ω→")
+	}
+	cov := font.Coverage()
+	if len(cov) == 0 || cov[0].Block.Name != "Basic Latin" || cov[0].Covered != cov[0].Total {
+		t.Errorf("Coverage()[0] = %v, want full Basic Latin coverage", cov[0])
+	}
+}
+
+func TestText_MissingGlyph(t *testing.T) {
+	const s = "AΩB"
+	regions := func(p Primitive) int {
+		var n int
+		for _, o := range plot(p) {
+			if o.code == regionOp {
+				n++
+			}
+		}
+		return n
+	}
+
+	skip := regions(Text(0, 0, 1, s, "latoregular", 12))
+	if got := regions(Text(0, 0, 1, s, "latoregular", 12).MissingGlyph(MissingBox)); got != skip+4 {
+		t.Errorf("MissingBox: got %v regions, want %v", got, skip+4)
+	}
+	if got := regions(Text(0, 0, 1, s, "latoregular", 12).Fallback("snickles")); got <= skip {
+		t.Errorf("Fallback: got %v regions, want more than %v", got, skip)
+	}
+	if err := Text(0, 0, 1, s, "latoregular", 12).MissingGlyph(MissingError).WriteGerber(io.Discard, 11); err == nil {
+		t.Errorf("MissingError: WriteGerber = nil, want error")
+	}
+}
+
+func TestText_Boost(t *testing.T) {
+	small := Text(0, 0, 1, "o", "latoregular", 2).Boost(1, 0.02)
+	if a := small.Aperture(); a == nil || a.Size != 0.04 {
+		t.Fatalf("small text Aperture = %v, want 0.04mm circle", a)
+	}
+	min1, max1, _ := bounds(plot(Text(0, 0, 1, "o", "latoregular", 2)))
+	min2, max2, _ := bounds(plot(small))
+	if got, want := (max2.X-min2.X)-(max1.X-min1.X), 0.04; math.Abs(got-want) > 0.001 {
+		t.Errorf("boosted text grew by %vmm, want %vmm", got, want)
+	}
+
+	if large := Text(0, 0, 1, "o", "latoregular", 72).Boost(1, 0.02); large.Aperture() != nil {
+		t.Errorf("large text Aperture = %v, want nil", large.Aperture())
+	}
+}
+
+func TestTextKnockout(t *testing.T) {
+	k := TextKnockout(Text(0, 0, 1, "Go", "latoregular", 12), 0.5, 0.3)
+	ops := plot(k)
+	if len(ops) < 2 || ops[0].clear || !ops[1].clear {
+		t.Fatalf("TextKnockout must draw a dark box followed by clear text")
+	}
+	min, max, _ := bounds(ops[:1])
+	tmin, tmax, _ := bounds(plot(Text(0, 0, 1, "Go", "latoregular", 12)))
+	if math.Abs(tmin.X-min.X-0.5) > 1e-6 || math.Abs(max.Y-tmax.Y-0.5) > 1e-6 {
+		t.Errorf("box = %v-%v, want text bounds %v-%v plus 0.5mm margin", min, max, tmin, tmax)
+	}
+	var buf bytes.Buffer
+	k.WriteGerber(&buf, 11)
+	if s := buf.String(); s[strings.LastIndex(s, "%LP"):][:5] != "%LPD*" {
+		t.Errorf("TextKnockout must restore dark polarity")
+	}
+}
+
+func TestText_Outline(t *testing.T) {
+	text := Text(0, 0, 1, "Go", "latoregular", 36).Outline(0.2)
+	if a := text.Aperture(); a == nil || a.Size != 0.2 {
+		t.Fatalf("Aperture = %v, want 0.2mm circle", a)
+	}
+	ops := plot(text)
+	if len(ops) == 0 {
+		t.Fatal("Outline text must draw")
+	}
+	for _, o := range ops {
+		if o.code != drawOp || o.clear {
+			t.Fatalf("Outline text must only draw dark strokes, got %+v", o)
+		}
+	}
+}
+
+func TestText_Transform(t *testing.T) {
+	plain := Text(0, 0, 1, "II", "latoregular", 12)
+	wavy := Text(0, 0, 1, "II", "latoregular", 12).Transform(func(index int, c rune) GlyphTransform {
+		return GlyphTransform{DY: float64(index)}
+	})
+	p, q := plot(plain), plot(wavy)
+	if len(p) != 2 || len(q) != 2 {
+		t.Fatalf("got %v and %v ops, want 2 each", len(p), len(q))
+	}
+	for i := range p {
+		min1, _, _ := bounds(p[i : i+1])
+		min2, _, _ := bounds(q[i : i+1])
+		if got, want := min2.Y-min1.Y, float64(i); math.Abs(got-want) > tol {
+			t.Errorf("glyph %v offset by %vmm, want %vmm", i, got, want)
+		}
+	}
+
+	ramp := Text(0, 0, 1, "II", "latoregular", 12).Transform(func(index int, c rune) GlyphTransform {
+		return GlyphTransform{Scale: 2}
+	})
+	min1, max1, _ := bounds(p)
+	min2, max2, _ := bounds(plot(ramp))
+	if got, want := max2.X-min2.X, 2*(max1.X-min1.X); math.Abs(got-want) > 2*tol {
+		t.Errorf("scaled text width = %v, want %v", got, want)
+	}
+}
+
+func TestText_Spacing(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		text *TextT
+		want float64 // offset of the last glyph in mm
+	}{
+		{"tracking", "II", Text(0, 0, 1, "II", "latoregular", 12).Spacing(1, 0), 1},
+		{"word spacing", "I I", Text(0, 0, 1, "I I", "latoregular", 12).Spacing(0, 2), 2},
+		{"both", "I I", Text(0, 0, 1, "I I", "latoregular", 12).Spacing(0.5, 2), 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := plot(Text(0, 0, 1, tt.s, "latoregular", 12))
+			q := plot(tt.text)
+			min1, _, _ := bounds(p[len(p)-1:])
+			min2, _, _ := bounds(q[len(q)-1:])
+			if got := min2.X - min1.X; math.Abs(got-tt.want) > 2*tol {
+				t.Errorf("last glyph offset by %vmm, want %vmm", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestText_Tabular(t *testing.T) {
+	// The last figure must start at the same position regardless of
+	// the width of the figures before it.
+	narrow := plot(Text(0, 0, 1, "110", "latoregular", 12).Tabular())
+	wide := plot(Text(0, 0, 1, "880", "latoregular", 12).Tabular())
+	min1, _, _ := bounds(narrow[len(narrow)-2:])
+	min2, _, _ := bounds(wide[len(wide)-2:])
+	if math.Abs(min1.X-min2.X) > 2*tol {
+		t.Errorf("tabular figures misaligned: %v != %v", min1.X, min2.X)
+	}
+}
+
+func TestText_Snap(t *testing.T) {
+	const grid = 0.01
+	onGrid := func(v float64) bool {
+		return math.Abs(v/grid-math.Round(v/grid)) < 1e-6
+	}
+	// The second stem starts off-grid: snapped, both stems are equally wide.
+	ops := plot(Text(0.0037, 0, 1, "ll", "latoregular", 2).Spacing(0.0041, 0).Snap(1, grid))
+	if len(ops) != 2 {
+		t.Fatalf("got %v contours, want 2", len(ops))
+	}
+	var widths []float64
+	for _, o := range ops {
+		for _, pt := range o.pts {
+			if !onGrid(pt.X) || !onGrid(pt.Y) {
+				t.Fatalf("vertex %v is not on the %vmm grid", pt, grid)
+			}
+		}
+		min, max, _ := bounds([]op{o})
+		widths = append(widths, max.X-min.X)
+	}
+	if math.Abs(widths[0]-widths[1]) > 2*tol {
+		t.Errorf("snapped stem widths %v differ", widths)
+	}
+
+	// Text above the maximum height is left alone.
+	for _, o := range plot(Text(0.0037, 0, 1, "l", "latoregular", 72).Snap(1, grid)) {
+		for _, pt := range o.pts {
+			if !onGrid(pt.X) {
+				return
+			}
+		}
+	}
+	t.Error("large text was snapped to the grid")
+}
+
+func TestText_Align(t *testing.T) {
+	const s = "HHHH\nH"
+	font := Fonts["latoregular"]
+	k := 12 * mmPerPt / font.HorizAdvX // mm per font unit
+	lineHeight := (font.Ascent - font.Descent) * k
+	plain := Text(10, 5, 1, s, "latoregular", 12)
+	lmin, lmax, _ := bounds(plot(plain))
+	center := 0.5 * (lmin.X + lmax.X)
+	capTop := lmax.Y // cap height of the first line
+	plain.align = AlignCenter
+	widths, _ := plain.lineWidths()
+	advance := widths[0] * k // of the first line
+
+	tests := []struct {
+		name          string
+		text          *TextT
+		wantX, wantDY float64 // center of the block, offset of its top
+	}{
+		{name: "center baseline", text: Text(10, 5, 1, s, "latoregular", 12).Align(AlignCenter, AnchorBaseline), wantX: center - advance/2},
+		{name: "right top", text: Text(10, 5, 1, s, "latoregular", 12).Align(AlignRight, AnchorTop), wantX: center - advance, wantDY: 5 - capTop},
+		{name: "left bottom", text: Text(10, 5, 1, s, "latoregular", 12).Align(AlignLeft, AnchorBottom), wantX: center, wantDY: lineHeight},
+		{name: "spaced", text: Text(10, 5, 1, s, "latoregular", 12).Align(AlignLeft, AnchorBottom).LineSpacing(2), wantX: center, wantDY: 2 * lineHeight},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			min, max, ok := bounds(plot(tt.text))
+			if !ok {
+				t.Fatal("no geometry")
+			}
+			if got := 0.5 * (min.X + max.X); math.Abs(got-tt.wantX) > 0.01 {
+				t.Errorf("block centered at x=%v, want %v", got, tt.wantX)
+			}
+			if got := max.Y - capTop; math.Abs(got-tt.wantDY) > 0.05 {
+				t.Errorf("top offset by %v, want %v", got, tt.wantDY)
+			}
+		})
+	}
+
+	// The short last line is centered below the first one.
+	ops := plot(Text(0, 0, 1, s, "latoregular", 12).Align(AlignCenter, AnchorBaseline))
+	last, _, _ := bounds(ops[len(ops)-1:])
+	_, lastMax, _ := bounds(ops[len(ops)-1:])
+	if got := 0.5 * (last.X + lastMax.X); math.Abs(got) > 0.3 {
+		t.Errorf("last line centered at x=%v, want 0", got)
+	}
+}
+
+func TestText_OnPath(t *testing.T) {
+	// Text along a vertical line runs upwards, rotated by 90 degrees.
+	column := Text(0, 0, 1, "HH", "latoregular", 12).OnPath([]Pt{{X: 5, Y: 0}, {X: 5, Y: 100}})
+	min, max, ok := bounds(plot(column))
+	if !ok {
+		t.Fatal("no geometry")
+	}
+	if max.X > 5.05 || min.X < 5-column.capHeight()-0.05 || min.Y < 0 || max.Y-min.Y < max.X-min.X {
+		t.Errorf("text on a vertical path spans %v-%v, want a column left of x=5", min, max)
+	}
+
+	// Ring text stays within the annulus between the baseline circle
+	// and the cap height above it.
+	const r = 20
+	ring := Text(0, 0, 1, "HELLO", "latoregular", 6).Align(AlignCenter, AnchorBaseline).OnPath(CirclePath(0, 0, r, 270, Clockwise))
+	capHeight := ring.capHeight()
+	for _, o := range plot(ring) {
+		for _, pt := range o.pts {
+			if d := math.Hypot(pt.X, pt.Y); d < r-0.05 || d > r+capHeight+0.1 {
+				t.Fatalf("ring text point %v at radius %v, want within [%v,%v]", pt, d, r, r+capHeight)
+			}
+		}
+	}
+	// Centered on the top of the circle.
+	min, max, _ = bounds(plot(ring))
+	if got := 0.5 * (min.X + max.X); math.Abs(got) > 0.3 || min.Y < 0 {
+		t.Errorf("ring text spans %v-%v, want centered over the top", min, max)
+	}
+}
@@ -0,0 +1,275 @@
+package gerber
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Alignment is the horizontal alignment of the lines of text.
+type Alignment int
+
+const (
+	// AlignLeft starts every line at the text origin (the default).
+	AlignLeft Alignment = iota
+	// AlignCenter centers every line on the text origin.
+	AlignCenter
+	// AlignRight ends every line at the text origin.
+	AlignRight
+)
+
+// Anchor is the vertical position of the text origin within the text.
+type Anchor int
+
+const (
+	// AnchorBaseline puts the origin on the baseline of the first line
+	// (the default).
+	AnchorBaseline Anchor = iota
+	// AnchorTop puts the origin at the cap height of the first line.
+	AnchorTop
+	// AnchorMiddle puts the origin halfway between the cap height of the
+	// first line and the baseline of the last line.
+	AnchorMiddle
+	// AnchorBottom puts the origin on the baseline of the last line.
+	AnchorBottom
+)
+
+// Align sets the horizontal alignment of the lines of the text and the
+// vertical anchoring of the text at its origin.
+func (t *TextT) Align(align Alignment, anchor Anchor) *TextT {
+	t.align = align
+	t.anchor = anchor
+	return t
+}
+
+// LineSpacing sets the distance between the baselines of consecutive
+// lines as a multiple of the font's line height (1 if unset).
+func (t *TextT) LineSpacing(factor float64) *TextT {
+	t.lineSpacing = factor
+	return t
+}
+
+// OnPath lays the baseline of the text along the path, a polyline in
+// millimeters, instead of a straight line from the text origin (which
+// is then ignored): each character is rotated to follow the path at
+// its center. The alignment positions the lines along the path (from its
+// start, centered on it or up to its end) and further lines follow
+// parallel paths below the first one. Characters running off the ends
+// of the path continue along the tangent there.
+// Use CirclePath for ring text and BezierPath for curved labels.
+// Paths are laid out for unmirrored text (an xScale of 1).
+func (t *TextT) OnPath(path []Pt) *TextT {
+	t.path = path
+	return t
+}
+
+// CirclePath returns a closed path around the circle of radius centered
+// at x,y starting at startAngle (in degrees). Text laid Clockwise along
+// it reads upright around the outside of the circle; text laid
+// CounterClockwise reads upright along the inside of the circle.
+// Centered text is centered opposite startAngle: a startAngle of 270
+// centers a label over the top of a badge.
+// All dimensions are in millimeters.
+func CirclePath(x, y, radius, startAngle float64, direction Direction) []Pt {
+	n := max(int(2*math.Pi*radius/resolution), curveSegments)
+	sweep := 360.0
+	if direction == Clockwise {
+		sweep = -sweep
+	}
+	pts := make([]Pt, n+1)
+	for i := range pts {
+		pts[i] = Polar(Pt{X: x, Y: y}, radius, startAngle+sweep*float64(i)/float64(n))
+	}
+	return pts
+}
+
+// BezierPath returns the path approximating the cubic Bézier curve from
+// p0 to p3 with control points p1 and p2.
+// All dimensions are in millimeters.
+func BezierPath(p0, p1, p2, p3 Pt) []Pt {
+	pts := make([]Pt, curveSegments+1)
+	for i := range pts {
+		t := float64(i) / curveSegments
+		a, b, c, d := (1-t)*(1-t)*(1-t), 3*(1-t)*(1-t)*t, 3*(1-t)*t*t, t*t*t
+		pts[i] = Pt{
+			X: a*p0.X + b*p1.X + c*p2.X + d*p3.X,
+			Y: a*p0.Y + b*p1.Y + c*p2.Y + d*p3.Y,
+		}
+	}
+	return pts
+}
+
+// alignment returns the fraction of the width of each line that lies
+// before the text origin.
+func (t *TextT) alignment() float64 {
+	switch t.align {
+	case AlignCenter:
+		return 0.5
+	case AlignRight:
+		return 1
+	}
+	return 0
+}
+
+// lineHeight returns the distance between baselines in font units.
+func (t *TextT) lineHeight() float64 {
+	h := t.font.Ascent - t.font.Descent
+	if t.lineSpacing > 0 {
+		h *= t.lineSpacing
+	}
+	return h
+}
+
+// lineStart returns the start of the given line in font units relative
+// to the text origin.
+func (t *TextT) lineStart(widths []float64, line int) float64 {
+	if line >= len(widths) {
+		return 0
+	}
+	return -t.xScale * t.alignment() * widths[line]
+}
+
+// baseline returns the baseline of the first line in font units
+// relative to the text origin.
+func (t *TextT) baseline() float64 {
+	capHeight := t.font.CapHeight
+	if capHeight == 0 {
+		capHeight = 0.7 * t.font.Ascent
+	}
+	below := float64(strings.Count(t.s, "\n")) * t.lineHeight()
+	switch t.anchor {
+	case AnchorTop:
+		return -capHeight
+	case AnchorMiddle:
+		return -0.5 * (capHeight - below)
+	case AnchorBottom:
+		return below
+	}
+	return 0
+}
+
+// lineWidths returns the advance of each line of the text in font units,
+// laid out as by WriteGerber, or nil for left-aligned straight text.
+func (t *TextT) lineWidths() ([]float64, error) {
+	if t.align == AlignLeft && t.path == nil {
+		return nil, nil
+	}
+	widths := []float64{0}
+	index := -1
+	for _, c := range t.s {
+		index++
+		switch c {
+		case '\n':
+			widths = append(widths, 0)
+			continue
+		case '\t':
+			widths[len(widths)-1] += 2.0 * t.font.HorizAdvX
+			continue
+		}
+		adv, err := t.charAdvance(c)
+		if err != nil {
+			return nil, err
+		}
+		widths[len(widths)-1] += adv*t.charScale(index, c) + t.charSpacing(c)
+	}
+	return widths, nil
+}
+
+// charScale returns the scale of the character at index set by Transform.
+func (t *TextT) charScale(index int, c rune) float64 {
+	if t.transform != nil {
+		if scale := t.transform(index, c).Scale; scale != 0 {
+			return scale
+		}
+	}
+	return 1
+}
+
+// charSpacing returns the spacing after the character in font units.
+func (t *TextT) charSpacing(c rune) float64 {
+	spacing := t.fontUnits(t.tracking)
+	if c == ' ' {
+		spacing += t.fontUnits(t.wordSpacing)
+	}
+	return spacing
+}
+
+// charAdvance returns the advance of the character (before scaling and
+// spacing) in font units, as written by WriteGerber.
+func (t *TextT) charAdvance(c rune) (float64, error) {
+	g, ok := t.font.Glyphs[string(c)]
+	if ok && t.tabular && c >= '0' && c <= '9' {
+		return t.font.figureWidth(), nil
+	}
+	if ok {
+		return g.advance(t.font), nil
+	}
+	switch t.missing {
+	case MissingError:
+		return 0, fmt.Errorf("missing glyph %+q in font %q", c, t.fontName)
+	case MissingFallback:
+		for _, name := range t.fallbacks {
+			if font, ok := Fonts[name]; ok {
+				if g, ok := font.Glyphs[string(c)]; ok {
+					return g.advance(font) * t.font.HorizAdvX / font.HorizAdvX, nil
+				}
+			}
+		}
+		fallthrough
+	case MissingBox:
+		return t.font.notdef().HorizAdvX, nil
+	}
+	return t.font.HorizAdvX, nil
+}
+
+// followPath adds the rotation and offset moving the glyph of advance
+// adv (in font units) with its origin at x,y (in font units relative to
+// the text origin) onto the path.
+func (t *TextT) followPath(xf *GlyphTransform, x, y, adv float64) {
+	k := t.pts * mmPerPt / t.font.HorizAdvX // mm per font unit
+	half := 0.5 * adv * k
+	center, angle := t.pathAt(t.alignment()*pathLength(t.path) + t.xScale*x*k + half)
+	sin, cos := math.Sincos(angle)
+	origin := Pt{
+		X: center.X - half*cos - y*k*sin,
+		Y: center.Y - half*sin + y*k*cos,
+	}
+	xf.Rotate += angle * 180 / math.Pi
+	xf.DX += origin.X - t.x - x*k
+	xf.DY += origin.Y - t.y - y*k
+}
+
+// pathAt returns the point at distance s along the path and the angle
+// (in radians) of its tangent there.
+func (t *TextT) pathAt(s float64) (Pt, float64) {
+	path := t.path
+	if len(path) < 2 {
+		if len(path) == 1 {
+			return Pt{X: path[0].X + s, Y: path[0].Y}, 0
+		}
+		return Pt{X: t.x + s, Y: t.y}, 0
+	}
+	for i := 1; i < len(path); i++ {
+		a, b := path[i-1], path[i]
+		l := math.Hypot(b.X-a.X, b.Y-a.Y)
+		if l == 0 {
+			continue
+		}
+		if s <= l || i == len(path)-1 {
+			// Beyond the ends, f extends the first or last segment.
+			f := s / l
+			return Pt{X: a.X + f*(b.X-a.X), Y: a.Y + f*(b.Y-a.Y)}, math.Atan2(b.Y-a.Y, b.X-a.X)
+		}
+		s -= l
+	}
+	return path[len(path)-1], 0
+}
+
+// pathLength returns the length of the path.
+func pathLength(path []Pt) float64 {
+	var length float64
+	for i := 1; i < len(path); i++ {
+		length += math.Hypot(path[i].X-path[i-1].X, path[i].Y-path[i-1].Y)
+	}
+	return length
+}
@@ -0,0 +1,80 @@
+package gerber
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ThermalPad designates the thermal pad of a hot component and the
+// copper spreading it needs.
+type ThermalPad struct {
+	// Name identifies the pad in the report (e.g. "U1 EP").
+	Name string
+	// Layer is the copper layer of the pad.
+	Layer *Layer
+	// At is a point on the pad.
+	At Pt
+	// MinArea is the minimum contiguous copper area (in mm²) connected
+	// to the pad on its layer.
+	MinArea float64
+	// MinVias is the minimum number of holes within the connected copper.
+	MinVias int
+}
+
+// ThermalResult is the measured copper spreading of a thermal pad.
+type ThermalResult struct {
+	Pad *ThermalPad
+	// Area is the contiguous copper area (in mm²) connected to the pad.
+	Area float64
+	// Vias is the number of holes of the design within the connected copper.
+	Vias int
+}
+
+// ThermalReport measures the contiguous copper area and the number of
+// vias connected to each thermal pad, rasterizing the copper at dpi dots
+// per inch. It returns the measurements and an error listing the pads
+// falling short of their thresholds.
+func (g *Gerber) ThermalReport(pads []*ThermalPad, dpi float64) ([]*ThermalResult, error) {
+	rasters := map[*Layer]*raster{}
+	bitmaps := map[*Layer][]bool{}
+	holes := g.holes()
+	var results []*ThermalResult
+	var errs []error
+	for _, pad := range pads {
+		r, ok := rasters[pad.Layer]
+		if !ok {
+			var err error
+			if r, err = pad.Layer.newRaster(dpi); err != nil {
+				return nil, fmt.Errorf("%v: %v", pad.Layer.Filename, err)
+			}
+			rasters[pad.Layer], bitmaps[pad.Layer] = r, r.bitmap()
+		}
+		copper := bitmaps[pad.Layer]
+		i, ok := r.index(pad.At)
+		if !ok || !copper[i] {
+			return nil, fmt.Errorf("%v: thermal pad %v at %v is not on copper", pad.Layer.Filename, pad.Name, pad.At)
+		}
+		connected := flood(copper, r.width, i)
+
+		result := &ThermalResult{Pad: pad}
+		for _, c := range connected {
+			if c {
+				result.Area += r.pixel * r.pixel
+			}
+		}
+		for _, h := range holes {
+			if j, ok := r.index(h.center); ok && connected[j] {
+				result.Vias++
+			}
+		}
+		results = append(results, result)
+
+		if result.Area < pad.MinArea {
+			errs = append(errs, fmt.Errorf("thermal pad %v: copper area %.1fmm² is below %vmm²", pad.Name, result.Area, pad.MinArea))
+		}
+		if result.Vias < pad.MinVias {
+			errs = append(errs, fmt.Errorf("thermal pad %v: %v vias, want at least %v", pad.Name, result.Vias, pad.MinVias))
+		}
+	}
+	return results, errors.Join(errs...)
+}
@@ -0,0 +1,42 @@
+package gerber
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestGerber_ThermalReport(t *testing.T) {
+	g := New("board")
+	top, drill := g.TopCopper(), g.Drill()
+	// A 10x10mm pour under U1 with 4 vias, and an isolated 2x2mm pad.
+	top.Add(
+		Polygon(0, 0, true, []Pt{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}, {X: 0, Y: 0}}, 0),
+		Polygon(20, 0, true, []Pt{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 2}, {X: 0, Y: 2}, {X: 0, Y: 0}}, 0),
+	)
+	for _, x := range []float64{2, 4, 6, 8} {
+		drill.Add(Circle(x, 5, 0.3))
+	}
+
+	pads := []*ThermalPad{
+		{Name: "U1 EP", Layer: top, At: Point(5, 5), MinArea: 80, MinVias: 4},
+		{Name: "U2 EP", Layer: top, At: Point(21, 1), MinArea: 50, MinVias: 1},
+	}
+	results, err := g.ThermalReport(pads, 254)
+	if len(results) != 2 {
+		t.Fatalf("got %v results, want 2", len(results))
+	}
+	if r := results[0]; math.Abs(r.Area-100) > 1 || r.Vias != 4 {
+		t.Errorf("U1: area %v, vias %v, want 100 and 4", r.Area, r.Vias)
+	}
+	if r := results[1]; math.Abs(r.Area-4) > 0.5 || r.Vias != 0 {
+		t.Errorf("U2: area %v, vias %v, want 4 and 0", r.Area, r.Vias)
+	}
+	if err == nil || strings.Contains(err.Error(), "U1") || strings.Count(err.Error(), "U2") != 2 {
+		t.Errorf("err = %v, want area and via violations of U2 only", err)
+	}
+
+	if _, err := g.ThermalReport([]*ThermalPad{{Name: "X", Layer: top, At: Point(15, 5)}}, 254); err == nil {
+		t.Error("expected an error for a pad off copper")
+	}
+}
@@ -0,0 +1,238 @@
+package gerber
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// TraceT represents a chain of connected straight traces drawn with a
+// round aperture, optionally with filleted corners, and satisfies the
+// Primitive interface.
+type TraceT struct {
+	points    []Pt
+	thickness float64
+	radius    float64
+}
+
+// Trace returns a trace through the points.
+// All dimensions are in millimeters (or Lengths).
+func Trace[L Lengths](points []Pt, thickness L) *TraceT {
+	return &TraceT{points: points, thickness: float64(thickness)}
+}
+
+// Fillet replaces the sharp corners of the trace by tangent arcs of the
+// given radius, drawn with circular interpolation (G02/G03), for RF
+// friendly routing or style. Fillets never extend beyond the segments
+// adjacent to their corner: at corners between short segments the radius
+// is reduced so that each fillet uses at most half of a segment shared
+// with another corner (or all of the first and last segments).
+// Filleting only moves the trace towards the inside of its corners; use
+// Gerber.FitFillets to keep it clear of the copper there.
+func (t *TraceT) Fillet(radius float64) *TraceT {
+	t.radius = radius
+	return t
+}
+
+// FitFillets reduces the radius of the fillets of the traces (see
+// TraceT.Fillet) on the layers checked by the "clearance" rules until
+// no fillet brings its trace closer than the clearance to another
+// primitive of the layer (of another net) that the sharp trace kept
+// clear, including the pads derived from flagged objects and pad stacks.
+// Radii are halved down to a hundredth of the requested one, below which
+// the corners are left sharp. It returns a description of every reduced
+// radius.
+func (g *Gerber) FitFillets(rules []*Rule) []string {
+	var changes []string
+	for _, rule := range rules {
+		clearance, ok := rule.Params["clearance"]
+		if rule.Kind != "clearance" || !ok {
+			continue
+		}
+		for _, l := range g.withDerived() {
+			if !rule.applies(l) || !l.copper() {
+				continue
+			}
+			for i, p := range l.Primitives {
+				t, ok := unwrap(p).(*TraceT)
+				if !ok || t.radius <= 0 {
+					continue
+				}
+				if radius := t.fitFillet(l, i, clearance); radius != t.radius {
+					changes = append(changes, l.wrapErr(i, fmt.Errorf("reduced fillet radius from %vmm to %vmm for clearance %vmm (rule %v)", t.radius, radius, clearance, rule.Name)).Error())
+					t.radius = radius
+				}
+			}
+		}
+	}
+	return changes
+}
+
+// fitFillet returns the largest radius up to that of the trace (primitive
+// i of the layer) whose fillets keep the clearance to the other
+// primitives of the layer wherever the sharp trace does.
+func (t *TraceT) fitFillet(l *Layer, i int, clearance float64) float64 {
+	netOf := func(p Primitive) string {
+		if o, ok := p.(*ObjectT); ok {
+			return o.net
+		}
+		return ""
+	}
+	net := netOf(l.Primitives[i])
+	ops := plot(&TraceT{points: t.points, thickness: t.thickness})
+	sharp := features(ops)
+	min, max, ok := bounds(ops)
+	if !ok {
+		return t.radius
+	}
+	// The nearby primitives of other nets and the gap the sharp trace
+	// leaves to each of them (if below the clearance).
+	var others [][]feature
+	var gaps []float64
+	for j, p := range l.Primitives {
+		if j == i || net != "" && netOf(p) == net {
+			continue
+		}
+		ops := plot(p)
+		pmin, pmax, ok := bounds(ops)
+		if !ok || pmin.X > max.X+clearance || min.X > pmax.X+clearance || pmin.Y > max.Y+clearance || min.Y > pmax.Y+clearance {
+			continue
+		}
+		f := features(ops)
+		others = append(others, f)
+		gaps = append(gaps, math.Min(clearance, featuresDistance(sharp, f)))
+	}
+	for r := t.radius; r >= 0.01*t.radius; r /= 2 {
+		filleted := *t
+		filleted.radius = r
+		f := features(plot(&filleted))
+		fits := true
+		for k, o := range others {
+			if featuresDistance(f, o) < gaps[k]-1e-9 {
+				fits = false
+				break
+			}
+		}
+		if fits {
+			return r
+		}
+	}
+	return 0
+}
+
+// featuresDistance returns the smallest gap between the features of a
+// and those of b, comparing the edges of polygons.
+func featuresDistance(a, b []feature) float64 {
+	edges := func(fs []feature) []feature {
+		var result []feature
+		for _, f := range fs {
+			if f.polygon == nil {
+				result = append(result, f)
+				continue
+			}
+			for j, pt := range f.polygon {
+				result = append(result, feature{a: pt, b: f.polygon[(j+1)%len(f.polygon)]})
+			}
+		}
+		return result
+	}
+	d := math.Inf(1)
+	for _, f := range edges(a) {
+		for _, g := range edges(b) {
+			d = math.Min(d, f.distance(g))
+		}
+	}
+	return d
+}
+
+// fillet is a corner of the trace replaced by an arc from start to end
+// around center.
+type fillet struct {
+	start, end, center Pt
+	direction          Direction
+}
+
+// fillets returns the fillet of each corner of the trace (by the index
+// of the corner's point), if any.
+func (t *TraceT) fillets() map[int]*fillet {
+	if t.radius <= 0 || len(t.points) < 3 {
+		return nil
+	}
+	fillets := map[int]*fillet{}
+	n := len(t.points)
+	length := func(i int) float64 { // of the segment from point i to i+1
+		return math.Hypot(t.points[i+1].X-t.points[i].X, t.points[i+1].Y-t.points[i].Y)
+	}
+	for i := 1; i < n-1; i++ {
+		a, p, b := t.points[i-1], t.points[i], t.points[i+1]
+		lin, lout := length(i-1), length(i)
+		if lin == 0 || lout == 0 {
+			continue
+		}
+		u1 := Pt{X: (p.X - a.X) / lin, Y: (p.Y - a.Y) / lin}
+		u2 := Pt{X: (b.X - p.X) / lout, Y: (b.Y - p.Y) / lout}
+		cross, dot := u1.X*u2.Y-u1.Y*u2.X, u1.X*u2.X+u1.Y*u2.Y
+		turn := math.Atan2(math.Abs(cross), dot)
+		if turn < 1e-9 || turn > math.Pi-1e-9 {
+			continue // straight or reversing
+		}
+		// The tangent points are d away from the corner on both segments.
+		if i > 1 {
+			lin /= 2
+		}
+		if i < n-2 {
+			lout /= 2
+		}
+		tan := math.Tan(turn / 2)
+		d := math.Min(t.radius*tan, math.Min(lin, lout))
+		r := d / tan
+		f := &fillet{
+			start:     Pt{X: p.X - u1.X*d, Y: p.Y - u1.Y*d},
+			end:       Pt{X: p.X + u2.X*d, Y: p.Y + u2.Y*d},
+			direction: CounterClockwise,
+		}
+		normal := Pt{X: -u1.Y, Y: u1.X} // to the left
+		if cross < 0 {
+			normal, f.direction = Pt{X: u1.Y, Y: -u1.X}, Clockwise
+		}
+		f.center = Pt{X: f.start.X + normal.X*r, Y: f.start.Y + normal.Y*r}
+		fillets[i] = f
+	}
+	return fillets
+}
+
+// WriteGerber writes the primitive to the Gerber file.
+func (t *TraceT) WriteGerber(w io.Writer, apertureIndex int) error {
+	if len(t.points) < 2 {
+		return fmt.Errorf("trace needs at least 2 points, got %v", len(t.points))
+	}
+	fillets := t.fillets()
+	fmt.Fprintf(w, "G54D%d*\n", apertureIndex)
+	cur := toPoint(t.points[0])
+	writeXY(w, cur.X, cur.Y, 2)
+	for i, pt := range t.points[1:] {
+		f, ok := fillets[i+1]
+		if !ok {
+			cur = toPoint(pt)
+			writeXY(w, cur.X, cur.Y, 1)
+			continue
+		}
+		s, e, c := toPoint(f.start), toPoint(f.end), toPoint(f.center)
+		if s != cur { // The previous fillet may end where this one starts.
+			writeXY(w, s.X, s.Y, 1)
+		}
+		writeArcMode(w)
+		writeArcXY(w, string(f.direction), e.X, e.Y, c.X-s.X, c.Y-s.Y)
+		io.WriteString(w, "G01*\n")
+		cur = e
+	}
+	return nil
+}
+
+// Aperture returns the primitive's desired aperture.
+func (t *TraceT) Aperture() *Aperture {
+	return &Aperture{
+		Shape: CircleShape,
+		Size:  t.thickness,
+	}
+}
@@ -0,0 +1,136 @@
+package gerber
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTrace_Fillet(t *testing.T) {
+	tests := []struct {
+		name  string
+		trace *TraceT
+		want  string
+	}{
+		{
+			name:  "sharp",
+			trace: Trace([]Pt{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}}, 0.2),
+			want: `G54D12*
+X000000Y000000D02*
+X10000000Y000000D01*
+X10000000Y10000000D01*
+`,
+		},
+		{
+			name:  "left turn",
+			trace: Trace([]Pt{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}}, 0.2).Fillet(2),
+			want: `G54D12*
+X000000Y000000D02*
+X8000000Y000000D01*
+G75*
+G03X10000000Y2000000I000000J2000000D01*
+G01*
+X10000000Y10000000D01*
+`,
+		},
+		{
+			name:  "right turn",
+			trace: Trace([]Pt{{X: 0, Y: 0}, {X: 0, Y: 10}, {X: 10, Y: 10}}, 0.2).Fillet(2),
+			want: `G54D12*
+X000000Y000000D02*
+X000000Y8000000D01*
+G75*
+G02X2000000Y10000000I2000000J000000D01*
+G01*
+X10000000Y10000000D01*
+`,
+		},
+		{
+			name:  "short segments",
+			trace: Trace([]Pt{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 1}}, 0.2).Fillet(5),
+			want: `G54D12*
+X000000Y000000D02*
+X500000Y000000D01*
+G75*
+G03X1000000Y500000I000000J500000D01*
+G01*
+G75*
+G02X1500000Y1000000I500000J000000D01*
+G01*
+X2000000Y1000000D01*
+`,
+		},
+		{
+			name:  "straight",
+			trace: Trace([]Pt{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0}}, 0.2).Fillet(1),
+			want: `G54D12*
+X000000Y000000D02*
+X1000000Y000000D01*
+X2000000Y000000D01*
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.trace.WriteGerber(&buf, 12); err != nil {
+				t.Fatalf("WriteGerber: %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("WriteGerber =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrace_FilletPlot(t *testing.T) {
+	ops := plot(Trace([]Pt{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}}, 0.2).Fillet(2))
+	min, max, ok := bounds(ops)
+	if !ok || toPoint(min) != toPoint(Pt{X: -0.1, Y: -0.1}) || toPoint(max) != toPoint(Pt{X: 10.1, Y: 10.1}) {
+		t.Errorf("bounds = %v-%v, want (-0.1,-0.1)-(10.1,10.1)", min, max)
+	}
+	for _, o := range ops {
+		for _, pt := range o.pts {
+			if pt.X > 8 && pt.Y < 2 { // on the fillet
+				if d := (pt.X-8)*(pt.X-8) + (pt.Y-2)*(pt.Y-2); d < 3.99 || d > 4.01 {
+					t.Errorf("fillet point %v is not on the circle of radius 2 around (8,2)", pt)
+				}
+			}
+		}
+	}
+}
+
+func TestTrace_Errors(t *testing.T) {
+	if err := Trace([]Pt{{X: 1, Y: 1}}, 0.2).WriteGerber(&bytes.Buffer{}, 12); err == nil {
+		t.Error("expected an error for a trace with a single point")
+	}
+}
+
+func TestGerber_FitFillets(t *testing.T) {
+	g := New("board")
+	top := g.TopCopper()
+	tight := Trace([]Pt{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}}, 0.2).Fillet(5)
+	free := Trace([]Pt{{X: 0, Y: 20}, {X: 10, Y: 20}, {X: 10, Y: 30}}, 0.2).Fillet(5)
+	same := Trace([]Pt{{X: 20, Y: 0}, {X: 30, Y: 0}, {X: 30, Y: 10}}, 0.2).Fillet(5)
+	top.Add(
+		tight,
+		Flash(8.5, 1.5, CircleShape, 0.5), // in the way of the fillet
+		free,
+		Object(same).Net("GND"),
+		Object(Flash(28.5, 1.5, CircleShape, 0.5)).Net("GND"),
+	)
+	rules := []*Rule{{Name: "copper", Kind: "clearance", Params: map[string]float64{"clearance": 0.2}}}
+	changes := g.FitFillets(rules)
+	if len(changes) != 1 {
+		t.Errorf("FitFillets = %q, want one change", changes)
+	}
+	if tight.radius != 2.5 || free.radius != 5 || same.radius != 5 {
+		t.Errorf("radii = %v, %v, %v, want 2.5, 5, 5", tight.radius, free.radius, same.radius)
+	}
+	report, err := g.DRC(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Violations) != 0 {
+		t.Errorf("DRC violations after FitFillets: %+v", report.Violations[0])
+	}
+}
@@ -0,0 +1,223 @@
+package gerber
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Triangle is a triangle (counterclockwise, in mm).
+type Triangle [3]Pt
+
+// Area returns the area of the triangle in mm².
+func (t Triangle) Area() float64 {
+	return 0.5 * cross(t[0], t[1], t[2])
+}
+
+// Triangulate returns triangles exactly covering the area of a filled
+// primitive as it will be written (see Outlines), e.g. for meshing it
+// for 3D export or simulation. Every clear contour is cut as a hole out
+// of the last dark contour drawn before it that contains it; clear
+// contours only partially overlapping dark ones are not supported.
+// Overlapping dark contours are triangulated independently.
+func Triangulate(p Primitive) ([]Triangle, error) {
+	type polygon struct {
+		outer []Pt
+		holes [][]Pt
+	}
+	var polys []*polygon
+	for _, o := range plot(p) {
+		for _, c := range contours(o) {
+			if !o.clear {
+				polys = append(polys, &polygon{outer: c})
+				continue
+			}
+			for i := len(polys) - 1; i >= 0; i-- {
+				if insidePolygon(polys[i].outer, c[0]) {
+					polys[i].holes = append(polys[i].holes, c)
+					break
+				}
+			}
+		}
+	}
+	var result []Triangle
+	for i, poly := range polys {
+		tris, err := TriangulatePolygon(poly.outer, poly.holes...)
+		if err != nil {
+			return nil, fmt.Errorf("contour #%v: %v", i, err)
+		}
+		result = append(result, tris...)
+	}
+	return result, nil
+}
+
+// TriangulatePolygon returns triangles covering the polygon with the
+// outer contour minus the holes, by ear clipping. Contours may be open
+// or closed and of either orientation. Coordinates are snapped to the
+// nanometer output grid and all orientation tests are exact, so
+// degenerate (e.g. collinear) vertices are handled consistently;
+// self-intersecting contours, holes crossing the outer contour or each
+// other and holes outside of it are reported as errors.
+func TriangulatePolygon(outer []Pt, holes ...[]Pt) ([]Triangle, error) {
+	pts := orientContour(snapContour(outer), 1)
+	if len(pts) < 3 || twiceArea(pts).Sign() == 0 {
+		return nil, errors.New("degenerate contour")
+	}
+	contours := [][]point{pts}
+	var hs [][]point
+	for _, h := range holes {
+		if h := orientContour(snapContour(h), -1); len(h) >= 3 && twiceArea(h).Sign() != 0 {
+			hs = append(hs, h)
+			contours = append(contours, h)
+		}
+	}
+	if !simple(contours) {
+		return nil, errors.New("self-intersecting contours")
+	}
+	for _, h := range hs {
+		if !insidePolygon(toPts(pts), h[0].pt()) {
+			return nil, fmt.Errorf("hole at %v is not inside the contour", h[0].pt())
+		}
+	}
+	// Bridge the holes, rightmost first, so that earlier bridges
+	// never cross later holes.
+	sort.Slice(hs, func(a, b int) bool { return hs[a][rightmostIndex(hs[a])].X > hs[b][rightmostIndex(hs[b])].X })
+	for i, h := range hs {
+		var err error
+		if pts, err = bridge(pts, h, hs[i+1:]); err != nil {
+			return nil, err
+		}
+	}
+	return earClip(pts)
+}
+
+// orientContour returns the contour with the given orientation
+// (1 for counterclockwise, -1 for clockwise).
+func orientContour(pts []point, orientation int) []point {
+	if twiceArea(pts).Sign() == -orientation {
+		for i, j := 0, len(pts)-1; i < j; i, j = i+1, j-1 {
+			pts[i], pts[j] = pts[j], pts[i]
+		}
+	}
+	return pts
+}
+
+// toPts returns the points in mm.
+func toPts(pts []point) []Pt {
+	result := make([]Pt, len(pts))
+	for i, p := range pts {
+		result[i] = p.pt()
+	}
+	return result
+}
+
+// rightmostIndex returns the index of the vertex with the largest X.
+func rightmostIndex(pts []point) int {
+	best := 0
+	for i, pt := range pts {
+		if pt.X > pts[best].X {
+			best = i
+		}
+	}
+	return best
+}
+
+// bridge merges the hole into the polygon by a pair of coincident edges
+// from the hole's rightmost vertex to the closest polygon vertex it can
+// see (without crossing the polygon or the remaining holes).
+func bridge(poly, hole []point, others [][]point) ([]point, error) {
+	mi := rightmostIndex(hole)
+	m := hole[mi]
+	contours := append([][]point{poly, hole}, others...)
+	best, bestDist := -1, math.Inf(1)
+	for i, v := range poly {
+		d := math.Hypot(float64(v.X-m.X), float64(v.Y-m.Y))
+		if d >= bestDist || !visible(m, v, contours) {
+			continue
+		}
+		best, bestDist = i, d
+	}
+	if best < 0 {
+		return nil, fmt.Errorf("hole at %v can't be bridged to the contour", m.pt())
+	}
+	result := append([]point(nil), poly[:best+1]...)
+	for i := 0; i <= len(hole); i++ {
+		result = append(result, hole[(mi+i)%len(hole)])
+	}
+	result = append(result, poly[best:]...)
+	return result, nil
+}
+
+// visible reports whether the segment ab crosses none of the edges of
+// the contours (touching at a or b is allowed).
+func visible(a, b point, contours [][]point) bool {
+	for _, pts := range contours {
+		for i, p := range pts {
+			q := pts[(i+1)%len(pts)]
+			if p == a || p == b || q == a || q == b {
+				continue
+			}
+			if segmentsIntersect(a, b, p, q) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// cross returns twice the signed area of the triangle abc
+// (positive when counterclockwise).
+func cross(a, b, c Pt) float64 {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
+
+// earClip triangulates a simple counterclockwise contour (possibly with
+// coincident bridge vertices) by repeatedly clipping ears.
+func earClip(pts []point) ([]Triangle, error) {
+	idx := make([]int, len(pts))
+	for i := range idx {
+		idx[i] = i
+	}
+	var result []Triangle
+	for len(idx) > 3 {
+		n := len(idx)
+		clipped := false
+		for i := 0; i < n; i++ {
+			a, b, c := pts[idx[(i+n-1)%n]], pts[idx[i]], pts[idx[(i+1)%n]]
+			o := orient(a, b, c)
+			if o < 0 || o > 0 && !isEar(a, b, c, pts, idx) {
+				continue
+			}
+			if o > 0 {
+				result = append(result, Triangle{a.pt(), b.pt(), c.pt()})
+			}
+			// Degenerate (collinear) vertices are just dropped.
+			idx = append(idx[:i], idx[i+1:]...)
+			clipped = true
+			break
+		}
+		if !clipped {
+			return nil, fmt.Errorf("can't triangulate contour near %v", pts[idx[0]].pt())
+		}
+	}
+	if a, b, c := pts[idx[0]], pts[idx[1]], pts[idx[2]]; orient(a, b, c) > 0 {
+		result = append(result, Triangle{a.pt(), b.pt(), c.pt()})
+	}
+	return result, nil
+}
+
+// isEar reports whether no other remaining vertex lies inside
+// (or on the boundary of) the convex triangle abc.
+func isEar(a, b, c point, pts []point, idx []int) bool {
+	for _, j := range idx {
+		p := pts[j]
+		if p == a || p == b || p == c {
+			continue
+		}
+		if orient(a, b, p) >= 0 && orient(b, c, p) >= 0 && orient(c, a, p) >= 0 {
+			return false
+		}
+	}
+	return true
+}
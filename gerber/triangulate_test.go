@@ -0,0 +1,76 @@
+package gerber
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTriangulatePolygon(t *testing.T) {
+	square := []Pt{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}, {X: 0, Y: 0}}
+	tests := []struct {
+		name  string
+		outer []Pt
+		holes [][]Pt
+		area  float64
+	}{
+		{"square", square, nil, 100},
+		{"clockwise", []Pt{{X: 0, Y: 0}, {X: 0, Y: 10}, {X: 10, Y: 10}, {X: 10, Y: 0}}, nil, 100},
+		{"concave", []Pt{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 5, Y: 2}, {X: 0, Y: 10}}, nil, 60},
+		{"collinear", []Pt{{X: 0, Y: 0}, {X: 5, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}}, nil, 100},
+		{"holes", square, [][]Pt{
+			{{X: 1, Y: 1}, {X: 3, Y: 1}, {X: 3, Y: 3}, {X: 1, Y: 3}},
+			{{X: 6, Y: 6}, {X: 9, Y: 6}, {X: 9, Y: 9}, {X: 6, Y: 9}},
+		}, 100 - 4 - 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tris, err := TriangulatePolygon(tt.outer, tt.holes...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var area float64
+			for _, tri := range tris {
+				if tri.Area() <= 0 {
+					t.Errorf("triangle %v is not counterclockwise", tri)
+				}
+				area += tri.Area()
+			}
+			if math.Abs(area-tt.area) > 1e-9 {
+				t.Errorf("area = %v, want %v", area, tt.area)
+			}
+		})
+	}
+
+	if _, err := TriangulatePolygon([]Pt{{X: 0, Y: 0}, {X: 1, Y: 1}}); err == nil {
+		t.Error("expected an error for a degenerate contour")
+	}
+}
+
+func TestTriangulate(t *testing.T) {
+	poly := []Pt{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}, {X: 0, Y: 0}}
+	hole := []Pt{{X: 4, Y: 4}, {X: 6, Y: 4}, {X: 6, Y: 6}, {X: 4, Y: 6}}
+	tris, err := Triangulate(FromPolygon([][][2]float64{ToPoints[[2]float64](poly), ToPoints[[2]float64](hole)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var area float64
+	for _, tri := range tris {
+		area += tri.Area()
+	}
+	if math.Abs(area-96) > 1e-9 {
+		t.Errorf("area = %v, want 96", area)
+	}
+
+	// A round trace: the hull of the aperture along the line.
+	tris, err = Triangulate(Line(0, 0, 10, 0, CircleShape, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	area = 0
+	for _, tri := range tris {
+		area += tri.Area()
+	}
+	if want := 10 + math.Pi*0.25; math.Abs(area-want) > 0.01 {
+		t.Errorf("trace area = %v, want about %v", area, want)
+	}
+}
@@ -0,0 +1,53 @@
+package gerber
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Trombone returns a trace from start to end that is extra millimeters
+// longer than the straight line between them, for fixing the length of
+// a route after the fact. The detour is a trombone (a single U-shaped
+// loop) or, when the loop would not fit within the corridor, an accordion
+// of identical loops pitch apart. The loops rise at most |corridor| from
+// the straight line, to its left for a positive corridor and to its
+// right for a negative one, and are centered between start and end.
+// Filleting the corners of the returned trace shortens it by
+// (2 - π/2) times the fillet radius per corner.
+// All dimensions are in millimeters.
+func Trombone(start, end Pt, extra, corridor, pitch, thickness float64) (*TraceT, error) {
+	d := math.Hypot(end.X-start.X, end.Y-start.Y)
+	switch {
+	case d == 0:
+		return nil, errors.New("trombone needs distinct end points")
+	case extra < 0:
+		return nil, fmt.Errorf("invalid extra length %v", extra)
+	case extra == 0:
+		return Trace([]Pt{start, end}, thickness), nil
+	case corridor == 0 || pitch <= 0:
+		return nil, errors.New("trombone needs a corridor and a positive pitch")
+	}
+
+	// Each loop adds twice its height.
+	loops := int(math.Ceil(extra / (2 * math.Abs(corridor))))
+	height := math.Copysign(extra/float64(2*loops), corridor)
+	span := float64(2*loops-1) * pitch
+	if span > d {
+		return nil, fmt.Errorf("%v loops %vmm apart need %vmm but the points are %vmm apart", loops, pitch, span, d)
+	}
+
+	u := Pt{X: (end.X - start.X) / d, Y: (end.Y - start.Y) / d}
+	n := Pt{X: -u.Y, Y: u.X} // to the left
+	at := func(along, across float64) Pt {
+		return Pt{X: start.X + along*u.X + across*n.X, Y: start.Y + along*u.Y + across*n.Y}
+	}
+	pts := []Pt{start}
+	s := 0.5 * (d - span)
+	for i := 0; i < loops; i++ {
+		pts = append(pts, at(s, 0), at(s, height), at(s+pitch, height), at(s+pitch, 0))
+		s += 2 * pitch
+	}
+	pts = append(pts, end)
+	return Trace(pts, thickness), nil
+}
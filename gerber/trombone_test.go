@@ -0,0 +1,68 @@
+package gerber
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTrombone(t *testing.T) {
+	tests := []struct {
+		name                         string
+		start, end                   Pt
+		extra, corridor, pitch       float64
+		wantPoints                   int
+		wantMinAcross, wantMaxAcross float64
+	}{
+		{name: "trombone", start: Pt{X: 0, Y: 0}, end: Pt{X: 10, Y: 0}, extra: 4, corridor: 3, pitch: 1, wantPoints: 6, wantMaxAcross: 2},
+		{name: "accordion", start: Pt{X: 0, Y: 0}, end: Pt{X: 10, Y: 0}, extra: 10, corridor: 2, pitch: 0.5, wantPoints: 14, wantMaxAcross: 5.0 / 3},
+		{name: "right side", start: Pt{X: 0, Y: 0}, end: Pt{X: 10, Y: 0}, extra: 2, corridor: -3, pitch: 1, wantPoints: 6, wantMinAcross: -1},
+		{name: "diagonal", start: Pt{X: 1, Y: 1}, end: Pt{X: 7, Y: 9}, extra: 3.3, corridor: 1, pitch: 0.6, wantPoints: 10},
+		{name: "no extra", start: Pt{X: 0, Y: 0}, end: Pt{X: 10, Y: 0}, extra: 0, corridor: 1, pitch: 1, wantPoints: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trace, err := Trombone(tt.start, tt.end, tt.extra, tt.corridor, tt.pitch, 0.2)
+			if err != nil {
+				t.Fatalf("Trombone: %v", err)
+			}
+			if got := len(trace.points); got != tt.wantPoints {
+				t.Errorf("got %v points, want %v", got, tt.wantPoints)
+			}
+			d := math.Hypot(tt.end.X-tt.start.X, tt.end.Y-tt.start.Y)
+			if got := pathLength(trace.points); math.Abs(got-d-tt.extra) > 1e-9 {
+				t.Errorf("length = %v, want %v", got, d+tt.extra)
+			}
+			if trace.points[0] != tt.start || trace.points[len(trace.points)-1] != tt.end {
+				t.Errorf("trace runs from %v to %v, want %v to %v", trace.points[0], trace.points[len(trace.points)-1], tt.start, tt.end)
+			}
+			if tt.start.Y != tt.end.Y {
+				return
+			}
+			min, max := math.Inf(1), math.Inf(-1)
+			for _, pt := range trace.points {
+				min, max = math.Min(min, pt.Y), math.Max(max, pt.Y)
+			}
+			if math.Abs(min-tt.wantMinAcross) > 1e-9 || math.Abs(max-tt.wantMaxAcross) > 1e-9 {
+				t.Errorf("loops span y=%v..%v, want %v..%v", min, max, tt.wantMinAcross, tt.wantMaxAcross)
+			}
+		})
+	}
+}
+
+func TestTrombone_Errors(t *testing.T) {
+	tests := []struct {
+		name                   string
+		end                    Pt
+		extra, corridor, pitch float64
+	}{
+		{name: "coincident", end: Pt{}, extra: 1, corridor: 1, pitch: 1},
+		{name: "negative extra", end: Pt{X: 10}, extra: -1, corridor: 1, pitch: 1},
+		{name: "no corridor", end: Pt{X: 10}, extra: 1, corridor: 0, pitch: 1},
+		{name: "does not fit", end: Pt{X: 10}, extra: 20, corridor: 1, pitch: 1},
+	}
+	for _, tt := range tests {
+		if _, err := Trombone(Pt{}, tt.end, tt.extra, tt.corridor, tt.pitch, 0.2); err == nil {
+			t.Errorf("%v: expected an error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package gerber
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// LoadFont reads a TrueType (.ttf) or OpenType (.otf) font file and
+// registers it in Fonts under name (or the font's PostScript name if
+// name is empty) so that Text can render it without generating code
+// with font2go first. See ParseFont.
+func LoadFont(name, filename string) (*Font, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	f, err := ParseFont(data)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", filename, err)
+	}
+	if name != "" {
+		f.ID = name
+	}
+	Fonts[f.ID] = f
+	return f, nil
+}
+
+// ParseFont converts a TrueType or OpenType font into a Font with the
+// glyph outlines of all the characters of the Basic Multilingual Plane
+// that it maps, in font units with the Y axis pointing up as in the
+// generated fonts. Quadratic curves are converted to cubic ones and the
+// outlines are filled with the NonZero rule, as in the font.
+// As TrueType fonts have no default advance, the font's HorizAdvX (which
+// sets the size of text) is the average advance of its ASCII characters.
+func ParseFont(data []byte) (*Font, error) {
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	var buf sfnt.Buffer
+	// At one pixel per font unit, 26.6 fixed-point values are 1/64 units.
+	ppem := fixed.Int26_6(f.UnitsPerEm())
+	units := func(v fixed.Int26_6) float64 { return float64(v) / 64 }
+
+	m, err := f.Metrics(&buf, ppem, font.HintingNone)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := f.Name(&buf, sfnt.NameIDPostScript)
+	result := &Font{
+		ID:         id,
+		UnitsPerEm: float64(f.UnitsPerEm()),
+		Ascent:     units(m.Ascent),
+		Descent:    -units(m.Descent),
+		CapHeight:  units(m.CapHeight),
+		XHeight:    units(m.XHeight),
+		FillRule:   NonZero,
+		Glyphs:     map[string]*Glyph{},
+	}
+	if adv, err := f.GlyphAdvance(&buf, 0, ppem, font.HintingNone); err == nil {
+		result.MissingHorizAdvX = units(adv)
+	}
+
+	glyphs := map[sfnt.GlyphIndex]*Glyph{}
+	var ascii, n float64
+	for r := rune(0x20); r <= 0xffff; r++ {
+		if r >= 0xd800 && r <= 0xdfff {
+			continue // surrogates
+		}
+		x, err := f.GlyphIndex(&buf, r)
+		if err != nil || x == 0 {
+			continue
+		}
+		g, ok := glyphs[x]
+		if !ok {
+			if g, err = loadGlyph(f, &buf, x, ppem); err != nil {
+				continue // Unsupported glyphs are reported as missing.
+			}
+			glyphs[x] = g
+		}
+		c := *g
+		c.Unicode = string(r)
+		result.Glyphs[string(r)] = &c
+		if r < 0x7f {
+			ascii, n = ascii+c.HorizAdvX, n+1
+		}
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("font %q maps no ASCII characters", id)
+	}
+	result.HorizAdvX = ascii / n
+	return result, nil
+}
+
+// loadGlyph converts the outline of the glyph into path steps.
+func loadGlyph(f *sfnt.Font, buf *sfnt.Buffer, x sfnt.GlyphIndex, ppem fixed.Int26_6) (*Glyph, error) {
+	adv, err := f.GlyphAdvance(buf, x, ppem, font.HintingNone)
+	if err != nil {
+		return nil, err
+	}
+	segments, err := f.LoadGlyph(buf, x, ppem, nil)
+	if err != nil {
+		return nil, err
+	}
+	// sfnt's Y axis points down.
+	pt := func(p fixed.Point26_6) (float64, float64) {
+		return float64(p.X) / 64, -float64(p.Y) / 64
+	}
+	g := &Glyph{HorizAdvX: float64(adv) / 64}
+	var cx, cy float64 // current point
+	for _, s := range segments {
+		switch s.Op {
+		case sfnt.SegmentOpMoveTo:
+			if len(g.PathSteps) > 0 {
+				g.PathSteps = append(g.PathSteps, &PathStep{C: 'Z'})
+			}
+			cx, cy = pt(s.Args[0])
+			g.PathSteps = append(g.PathSteps, &PathStep{C: 'M', P: []float64{cx, cy}})
+		case sfnt.SegmentOpLineTo:
+			cx, cy = pt(s.Args[0])
+			g.PathSteps = append(g.PathSteps, &PathStep{C: 'L', P: []float64{cx, cy}})
+		case sfnt.SegmentOpQuadTo:
+			// The cubic control points lie 2/3 of the way to the quadratic one.
+			qx, qy := pt(s.Args[0])
+			ex, ey := pt(s.Args[1])
+			g.PathSteps = append(g.PathSteps, &PathStep{C: 'C', P: []float64{
+				cx + 2.0/3*(qx-cx), cy + 2.0/3*(qy-cy),
+				ex + 2.0/3*(qx-ex), ey + 2.0/3*(qy-ey),
+				ex, ey,
+			}})
+			cx, cy = ex, ey
+		case sfnt.SegmentOpCubeTo:
+			x1, y1 := pt(s.Args[0])
+			x2, y2 := pt(s.Args[1])
+			cx, cy = pt(s.Args[2])
+			g.PathSteps = append(g.PathSteps, &PathStep{C: 'C', P: []float64{x1, y1, x2, y2, cx, cy}})
+		}
+	}
+	if len(g.PathSteps) > 0 {
+		g.PathSteps = append(g.PathSteps, &PathStep{C: 'Z'})
+	}
+	return g, nil
+}
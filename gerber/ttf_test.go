@@ -0,0 +1,65 @@
+package gerber
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestParseFont(t *testing.T) {
+	f, err := ParseFont(goregular.TTF)
+	if err != nil {
+		t.Fatalf("ParseFont: %v", err)
+	}
+	if f.UnitsPerEm != 2048 || f.Ascent <= 0 || f.Descent >= 0 || f.CapHeight <= 0 || f.HorizAdvX <= 0 {
+		t.Errorf("metrics = %v/%v/%v/%v/%v, want positive ascent, cap height and advance, negative descent", f.UnitsPerEm, f.Ascent, f.Descent, f.CapHeight, f.HorizAdvX)
+	}
+	for _, c := range []string{"A", "g", "0", " ", "é"} {
+		if _, ok := f.Glyphs[c]; !ok {
+			t.Errorf("missing glyph %q", c)
+		}
+	}
+	if g := f.Glyphs["O"]; g == nil || len(g.PathSteps) == 0 || g.PathSteps[0].C != 'M' || g.PathSteps[len(g.PathSteps)-1].C != 'Z' {
+		t.Errorf("glyph O = %+v, want closed contours", g)
+	}
+
+	if _, err := ParseFont([]byte("not a font")); err == nil {
+		t.Error("expected an error for invalid font data")
+	}
+}
+
+func TestLoadFont(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "goregular.ttf")
+	if err := os.WriteFile(filename, goregular.TTF, 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := LoadFont("goregular-test", filename)
+	if err != nil {
+		t.Fatalf("LoadFont: %v", err)
+	}
+	defer delete(Fonts, "goregular-test")
+	if Fonts["goregular-test"] != f {
+		t.Fatal("LoadFont must register the font")
+	}
+
+	// H sits on the baseline and is as tall as the cap height.
+	text := Text(10, 5, 1, "H", "goregular-test", 12)
+	min, max, ok := bounds(plot(text))
+	if !ok {
+		t.Fatal("no geometry")
+	}
+	if math.Abs(min.Y-5) > 0.01 || min.X < 10 || math.Abs(max.Y-min.Y-text.capHeight()) > 0.01 {
+		t.Errorf("H spans %v-%v, want on the baseline at (10,5) and %vmm tall", min, max, text.capHeight())
+	}
+	// The counter of O is a hole.
+	if _, clear := Outlines(Text(0, 0, 1, "O", "goregular-test", 12)); len(clear) != 1 {
+		t.Errorf("O has %v holes, want 1", len(clear))
+	}
+
+	if _, err := LoadFont("", filepath.Join(t.TempDir(), "missing.ttf")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
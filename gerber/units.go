@@ -0,0 +1,104 @@
+package gerber
+
+import (
+	"fmt"
+	"math"
+)
+
+// Length is a length in millimeters that keeps track of its units: it
+// can't be mixed with a float64 (or an Angle) without a conversion, so
+// that a generated design can't pass a dimension in mils or an angle
+// where millimeters are expected. MM, Mil and Inch return Lengths and
+// the MM, Mil and Inch methods convert them back to plain numbers.
+//
+// The constructors of the package primitives and apertures (Point,
+// Line, Flash, Circle, Arc, CircularArc, Polygon, Trace, Text, Via, Bus,
+// RoundedRect, RoundedPolygon, CircleOutline, Stadium, the Tapered
+// traces, the custom apertures and FlashAperture) and Polar accept
+// Lengths as well as millimeters. All other functions and methods (such
+// as the coupons, tables and design rules) take plain millimeters: pass
+// them l.MM().
+type Length float64
+
+// MM returns the length of v millimeters.
+func MM(v float64) Length {
+	return Length(v)
+}
+
+// Mil returns the length of v mils (thousandths of an inch),
+// e.g. Line(0, 0, Mil(500), 0, CircleShape, Mil(8)) for an 8 mil trace.
+func Mil(v float64) Length {
+	return Length(v * 0.0254)
+}
+
+// Inch returns the length of v inches.
+func Inch(v float64) Length {
+	return Length(v * 25.4)
+}
+
+// Length returns the length of v in the units.
+func (u Units) Length(v float64) Length {
+	return Length(u.MM(v))
+}
+
+// MM returns the length in millimeters.
+func (l Length) MM() float64 {
+	return float64(l)
+}
+
+// Mil returns the length in mils.
+func (l Length) Mil() float64 {
+	return float64(l) / 0.0254
+}
+
+// Inch returns the length in inches.
+func (l Length) Inch() float64 {
+	return float64(l) / 25.4
+}
+
+// String returns the length in millimeters, e.g. "0.254mm".
+func (l Length) String() string {
+	return fmt.Sprintf("%vmm", float64(l))
+}
+
+// Angle is an angle in degrees that keeps track of its units like Length.
+// CircularArc and Polar accept Angles as well as degrees.
+type Angle float64
+
+// Deg returns the angle of v degrees.
+func Deg(v float64) Angle {
+	return Angle(v)
+}
+
+// Rad returns the angle of v radians.
+func Rad(v float64) Angle {
+	return Angle(v * 180 / math.Pi)
+}
+
+// Deg returns the angle in degrees.
+func (a Angle) Deg() float64 {
+	return float64(a)
+}
+
+// Rad returns the angle in radians.
+func (a Angle) Rad() float64 {
+	return float64(a) * math.Pi / 180
+}
+
+// String returns the angle in degrees, e.g. "90°".
+func (a Angle) String() string {
+	return fmt.Sprintf("%v°", float64(a))
+}
+
+// Lengths are the types of the lengths accepted by the package: Length
+// values or plain millimeters (including untyped integer constants,
+// as in Point(1, 2)).
+type Lengths interface {
+	int | float64 | Length
+}
+
+// Angles are the types of the angles accepted by the package: Angle
+// values or plain degrees.
+type Angles interface {
+	int | float64 | Angle
+}
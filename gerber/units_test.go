@@ -0,0 +1,50 @@
+package gerber
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestLength(t *testing.T) {
+	w := Mil(8)
+	if got, want := Line(MM(1), 0, Mil(100), 0, CircleShape, w), Line(1, 0, 2.54, 0, CircleShape, 0.2032); !reflect.DeepEqual(got, want) {
+		t.Errorf("Line of Lengths = %+v, want %+v", got, want)
+	}
+	if got, want := Flash(Inches.Length(1), 0, RectShape, 1), Flash(25.4, 0, RectShape, 1); !reflect.DeepEqual(got, want) {
+		t.Errorf("Flash of Lengths = %+v, want %+v", got, want)
+	}
+	if got := Point(1, 2); got != (Pt{X: 1, Y: 2}) {
+		t.Errorf("Point(1, 2) = %v", got)
+	}
+	if got := math.Abs(w.Mil() - 8); got > 1e-12 {
+		t.Errorf("Mil = %v, want 8", w.Mil())
+	}
+	if got := math.Abs(Inch(0.5).Inch() - 0.5); got > 1e-12 {
+		t.Errorf("Inch = %v, want 0.5", Inch(0.5).Inch())
+	}
+	if got, want := Via(Inch(1), 0, Mil(12), Mil(24)), Via(25.4, 0, Mil(12).MM(), Mil(24).MM()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Via of Lengths = %+v, want %+v", got, want)
+	}
+	if got, want := Arc(0, 0, MM(5), CircleShape, 1, 1, Deg(0), Deg(90), Mil(8)), Arc(0, 0, 5, CircleShape, 1, 1, 0, 90, 0.2032); !reflect.DeepEqual(got, want) {
+		t.Errorf("Arc of Lengths = %+v, want %+v", got, want)
+	}
+	if got := Mil(10).String(); got != "0.254mm" {
+		t.Errorf("String = %q, want 0.254mm", got)
+	}
+}
+
+func TestAngle(t *testing.T) {
+	if got := Rad(math.Pi / 2); math.Abs(got.Deg()-90) > 1e-12 || math.Abs(Deg(180).Rad()-math.Pi) > 1e-12 {
+		t.Errorf("Rad(pi/2) = %v, want 90°", got)
+	}
+	if got := Polar(Pt{X: 1}, MM(2), Deg(90)); math.Abs(got.X-1) > 1e-12 || math.Abs(got.Y-2) > 1e-12 {
+		t.Errorf("Polar = %v, want {1 2}", got)
+	}
+	if got, want := CircularArc(0, 0, MM(5), Deg(0), Deg(90), CounterClockwise, MM(0.2)), CircularArc(0, 0, 5, 0, 90, CounterClockwise, 0.2); !reflect.DeepEqual(got, want) {
+		t.Errorf("CircularArc of Lengths = %+v, want %+v", got, want)
+	}
+	if got := Deg(45).String(); got != "45°" {
+		t.Errorf("String = %q, want 45°", got)
+	}
+}
@@ -0,0 +1,356 @@
+package gerber
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Validate runs all validation passes over every layer of the design
+// and returns all problems found. Each problem is an *Error
+// identifying the offending primitive.
+func (g *Gerber) Validate() error {
+	var errs []error
+	for _, l := range g.Layers {
+		errs = append(errs, l.Validate())
+	}
+	return errors.Join(errs...)
+}
+
+// Validate runs all validation passes over the layer, including
+// ValidateOutline for the outline layer (with Gerber.OutlineContours).
+func (l *Layer) Validate() error {
+	errs := []error{l.ValidateArcs(), l.ValidateRegions()}
+	if l.extension() == "gko" {
+		contours := 0
+		if l.g != nil {
+			contours = l.g.OutlineContours
+		}
+		errs = append(errs, l.ValidateOutline(contours))
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateArcs checks that every arc in the layer has a valid radius
+// and sweep, and that its emitted segments are continuous within the
+// Gerber coordinate resolution (and closed for full circles). Circular
+// arcs (see CircularArc) are checked for a valid direction, radius and
+// thickness and for end points equidistant from their center.
+func (l *Layer) ValidateArcs() error {
+	var errs []error
+	for i, p := range l.Primitives {
+		var err error
+		switch a := unwrap(p).(type) {
+		case *ArcT:
+			err = a.validate()
+		case *CircularArcT:
+			err = a.validate()
+		}
+		if err != nil {
+			errs = append(errs, l.wrapErr(i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validate checks the circular arc's direction, radius and thickness,
+// and that its emitted end points are the same distance from its center
+// within the Gerber coordinate resolution.
+func (a *CircularArcT) validate() error {
+	at := a.center.pt()
+	if err := a.check(); err != nil {
+		return fmt.Errorf("arc at (%v,%v): %v", at.X, at.Y, err)
+	}
+	if a.thickness <= 0 {
+		return fmt.Errorf("arc at (%v,%v): thickness must be positive", at.X, at.Y)
+	}
+	start, end := a.ends()
+	r1, r2 := math.Hypot(start.pt().X-at.X, start.pt().Y-at.Y), math.Hypot(end.pt().X-at.X, end.pt().Y-at.Y)
+	if math.Abs(r1-r2) > 2/sf {
+		return fmt.Errorf("arc at (%v,%v): end points are %vmm and %vmm from the center", at.X, at.Y, r1, r2)
+	}
+	return nil
+}
+
+// validate checks the arc's radius, sweep and segment continuity.
+func (a *ArcT) validate() error {
+	sweep := a.endAngle - a.startAngle
+	switch {
+	case a.radius <= 0 || a.xScale == 0 || a.yScale == 0:
+		return fmt.Errorf("arc at (%v,%v): radius must be positive", a.x, a.y)
+	case a.thickness <= 0:
+		return fmt.Errorf("arc at (%v,%v): thickness must be positive", a.x, a.y)
+	case sweep == 0:
+		return fmt.Errorf("arc at (%v,%v): zero sweep", a.x, a.y)
+	case sweep > 2*math.Pi+1e-9:
+		return fmt.Errorf("arc at (%v,%v): sweep of %v degrees exceeds 360", a.x, a.y, 180*sweep/math.Pi)
+	}
+	if a.circular {
+		if _, err := a.circularArc(); err != nil {
+			return err
+		}
+	}
+
+	ops := plot(a)
+	for i := 1; i < len(ops); i++ {
+		if ops[i].pts[0] != ops[i-1].pts[1] {
+			return fmt.Errorf("arc at (%v,%v): segment %v starts at %v, not at previous end %v", a.x, a.y, i, ops[i].pts[0], ops[i-1].pts[1])
+		}
+	}
+	if a.closed() && len(ops) > 0 && ops[0].pts[0] != ops[len(ops)-1].pts[1] {
+		return fmt.Errorf("arc at (%v,%v): full circle is not closed", a.x, a.y)
+	}
+	return nil
+}
+
+// ValidateRegions checks that every region (G36/G37) contour emitted
+// by the layer's primitives is closed, has at least three distinct
+// vertices and a non-zero area, and is consistently oriented
+// (i.e. it is not twisted or looping over itself).
+func (l *Layer) ValidateRegions() error {
+	var errs []error
+	for i, p := range l.Primitives {
+		for _, err := range regionErrors(p) {
+			errs = append(errs, l.wrapErr(i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// regionErrors checks the region contours emitted by the primitive.
+func regionErrors(p Primitive) []error {
+	var errs []error
+	var n int
+	for _, o := range plot(p) {
+		if o.code != regionOp {
+			continue
+		}
+		if err := validateContour(o.pts); err != nil {
+			errs = append(errs, fmt.Errorf("%T: contour #%v at %v: %w", unwrap(p), n, o.pts[0], err))
+		}
+		n++
+	}
+	return errs
+}
+
+// validateContour checks a single region contour.
+func validateContour(pts []Pt) error {
+	if len(pts) < 2 || pts[0] != pts[len(pts)-1] {
+		return errors.New("contour is not closed")
+	}
+	var distinct []Pt
+	for _, pt := range pts[1:] {
+		if len(distinct) == 0 || pt != distinct[len(distinct)-1] {
+			distinct = append(distinct, pt)
+		}
+	}
+	if len(distinct) == 1 {
+		return errors.New("single-point contour")
+	}
+	if len(distinct) < 3 || math.Abs(signedArea(distinct)) < 1e-12 {
+		return errors.New("degenerate contour with zero area")
+	}
+	if t := turns(distinct); t != 1 && t != -1 {
+		return fmt.Errorf("inconsistently oriented contour (turning number %v)", t)
+	}
+	return nil
+}
+
+// signedArea returns the signed area of a closed contour (without the
+// repeated closing vertex). It is positive for counterclockwise contours.
+func signedArea(pts []Pt) float64 {
+	var area float64
+	for i, p1 := range pts {
+		p2 := pts[(i+1)%len(pts)]
+		area += p1.X*p2.Y - p2.X*p1.Y
+	}
+	return 0.5 * area
+}
+
+// turns returns the turning number of a closed contour (without the
+// repeated closing vertex): +1 for a simple counterclockwise contour
+// and -1 for a simple clockwise contour.
+func turns(pts []Pt) int {
+	var sum float64
+	n := len(pts)
+	for i := range pts {
+		p0, p1, p2 := pts[(i+n-1)%n], pts[i], pts[(i+1)%n]
+		a1 := math.Atan2(p1.Y-p0.Y, p1.X-p0.X)
+		a2 := math.Atan2(p2.Y-p1.Y, p2.X-p1.X)
+		d := a2 - a1
+		for d > math.Pi {
+			d -= 2 * math.Pi
+		}
+		for d < -math.Pi {
+			d += 2 * math.Pi
+		}
+		sum += d
+	}
+	return int(math.Round(sum / (2 * math.Pi)))
+}
+
+// outlineEdge is a draw of the outline layer.
+type outlineEdge struct {
+	index     int // of the primitive
+	a, b      Pt
+	thickness float64
+}
+
+// outlineNode identifies an end point of outline edges (in nm).
+type outlineNode struct{ x, y int64 }
+
+func nodeOf(pt Pt) outlineNode {
+	return outlineNode{int64(math.Round(pt.X * nmPerMM)), int64(math.Round(pt.Y * nmPerMM))}
+}
+
+// outlineEdges returns the non-empty draws of the layer and the edges
+// ending at each of their end points.
+func (l *Layer) outlineEdges() ([]outlineEdge, map[outlineNode][]int) {
+	var edges []outlineEdge
+	ends := map[outlineNode][]int{}
+	for i, p := range l.Primitives {
+		for _, o := range plot(p) {
+			if o.code != drawOp || o.clear || nodeOf(o.pts[0]) == nodeOf(o.pts[1]) {
+				continue
+			}
+			e := outlineEdge{index: i, a: o.pts[0], b: o.pts[1]}
+			if o.aperture != nil {
+				e.thickness = o.aperture.Size
+			}
+			ends[nodeOf(e.a)] = append(ends[nodeOf(e.a)], len(edges))
+			ends[nodeOf(e.b)] = append(ends[nodeOf(e.b)], len(edges))
+			edges = append(edges, e)
+		}
+	}
+	return edges, ends
+}
+
+// ValidateOutline checks that the draws of the outline (profile) layer
+// form exactly contours closed contours (one if contours is 0, e.g. 2 for
+// a board with an internal cutout): every end point must join exactly two
+// draws (within the 1nm resolution) and no two draws may overlap.
+// Flashes and zero-length draws are ignored. See CloseOutline to close
+// tiny gaps.
+func (l *Layer) ValidateOutline(contours int) error {
+	if contours <= 0 {
+		contours = 1
+	}
+	edges, ends := l.outlineEdges()
+	var errs []error
+	var nodes []outlineNode
+	for n := range ends {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].x < nodes[j].x || nodes[i].x == nodes[j].x && nodes[i].y < nodes[j].y
+	})
+	for _, n := range nodes {
+		at := Pt{X: float64(n.x) / nmPerMM, Y: float64(n.y) / nmPerMM}
+		switch e := ends[n]; {
+		case len(e) == 1:
+			gap := math.Inf(1)
+			for _, m := range nodes {
+				if m != n && len(ends[m]) == 1 {
+					gap = math.Min(gap, math.Hypot(float64(m.x-n.x), float64(m.y-n.y))/nmPerMM)
+				}
+			}
+			msg := fmt.Sprintf("outline is open at %v", at)
+			if !math.IsInf(gap, 1) {
+				msg += fmt.Sprintf(" (gap of %.4fmm)", gap)
+			}
+			errs = append(errs, l.wrapErr(edges[e[0]].index, errors.New(msg)))
+		case len(e) > 2:
+			errs = append(errs, l.wrapErr(edges[e[0]].index, fmt.Errorf("outline joins %v draws at %v", len(e), at)))
+		}
+	}
+	for i, e := range edges {
+		for _, f := range edges[i+1:] {
+			if overlap(e.a, e.b, f.a, f.b) {
+				errs = append(errs, l.wrapErr(f.index, fmt.Errorf("outline draws overlap from %v to %v", f.a, f.b)))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	// Every node joins two draws: count the closed contours.
+	seen := make([]bool, len(edges))
+	var n int
+	for i := range edges {
+		if seen[i] {
+			continue
+		}
+		n++
+		for stack := []int{i}; len(stack) > 0; {
+			j := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if seen[j] {
+				continue
+			}
+			seen[j] = true
+			stack = append(stack, ends[nodeOf(edges[j].a)]...)
+			stack = append(stack, ends[nodeOf(edges[j].b)]...)
+		}
+	}
+	if n != contours {
+		return fmt.Errorf("%v: outline has %v closed contours, want %v", l.Filename, n, contours)
+	}
+	return nil
+}
+
+// overlap reports whether the segments ab and cd are collinear and share
+// more than their end points.
+func overlap(a, b, c, d Pt) bool {
+	const eps = 1e-6
+	dx, dy := b.X-a.X, b.Y-a.Y
+	length := math.Hypot(dx, dy)
+	off := func(p Pt) float64 { return math.Abs(dx*(p.Y-a.Y)-dy*(p.X-a.X)) / length }
+	if off(c) > eps || off(d) > eps {
+		return false
+	}
+	t := func(p Pt) float64 { return (dx*(p.X-a.X) + dy*(p.Y-a.Y)) / length }
+	lo, hi := math.Min(t(c), t(d)), math.Max(t(c), t(d))
+	return math.Min(hi, length)-math.Max(lo, 0) > eps
+}
+
+// CloseOutline closes the gaps of up to tolerance (in mm) between the
+// open ends of the outline (profile) layer by adding draws between the
+// closest ones, of the width of the draws they join, and returns a
+// description of every change. See ValidateOutline.
+func (l *Layer) CloseOutline(tolerance float64) []string {
+	edges, ends := l.outlineEdges()
+	var open []outlineNode
+	for n, e := range ends {
+		if len(e) == 1 {
+			open = append(open, n)
+		}
+	}
+	sort.Slice(open, func(i, j int) bool {
+		return open[i].x < open[j].x || open[i].x == open[j].x && open[i].y < open[j].y
+	})
+	pt := func(n outlineNode) Pt { return Pt{X: float64(n.x) / nmPerMM, Y: float64(n.y) / nmPerMM} }
+	var changes []string
+	closed := map[outlineNode]bool{}
+	for i, n := range open {
+		if closed[n] {
+			continue
+		}
+		best, gap := -1, tolerance
+		for j, m := range open[i+1:] {
+			if d := math.Hypot(float64(m.x-n.x), float64(m.y-n.y)) / nmPerMM; !closed[m] && d <= gap {
+				best, gap = i+1+j, d
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		m := open[best]
+		closed[n], closed[m] = true, true
+		a, b := pt(n), pt(m)
+		l.Add(Line(a.X, a.Y, b.X, b.Y, CircleShape, edges[ends[n][0]].thickness))
+		changes = append(changes, l.wrapErr(edges[ends[n][0]].index, fmt.Errorf("closed outline gap of %.4fmm from %v to %v", gap, a, b)).Error())
+	}
+	return changes
+}
@@ -0,0 +1,175 @@
+package gerber
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLayer_ValidateArcs(t *testing.T) {
+	tests := []struct {
+		name    string
+		arc     Primitive
+		wantErr bool
+	}{
+		{"full circle", Arc(0, 0, 5.123, CircleShape, 1, 1, 0, 360, 0.1), false},
+		{"quarter", Arc(1, 1, 2, RectShape, 1, 1, 90, 180, 0.1), false},
+		{"ellipse", Arc(0, 0, 3, CircleShape, 1, 0.5, 17, 377, 0.1), false},
+		{"zero radius", Arc(0, 0, 0, CircleShape, 1, 1, 0, 90, 0.1), true},
+		{"zero sweep", Arc(0, 0, 1, CircleShape, 1, 1, 45, 45, 0.1), true},
+		{"too long", Arc(0, 0, 1, CircleShape, 1, 1, 0, 400, 0.1), true},
+		{"no thickness", Arc(0, 0, 1, CircleShape, 1, 1, 0, 90, 0), true},
+		{"circular", Arc(1, 1, 2, CircleShape, 1, 1, 90, 180, 0.1).Circular(), false},
+		{"circular full circle", Arc(0, 0, 5.123, CircleShape, 1, 1, 0, 360, 0.1).Circular(), false},
+		{"circular ellipse", Arc(0, 0, 3, CircleShape, 1, 0.5, 0, 90, 0.1).Circular(), true},
+		{"circular rect", Arc(0, 0, 3, RectShape, 1, 1, 0, 90, 0.1).Circular(), true},
+		{"circular arc", CircularArc(1, 1, 2, 0, 90, Clockwise, 0.1), false},
+		{"circular arc full circle", CircularArc(1, 1, 2, 45, 45, CounterClockwise, 0.1), false},
+		{"circular arc zero radius", CircularArc(1, 1, 0, 0, 90, Clockwise, 0.1), true},
+		{"circular arc no thickness", CircularArc(1, 1, 2, 0, 90, Clockwise, 0), true},
+		{"circular arc direction", CircularArc(1, 1, 2, 0, 90, Direction("G04"), 0.1), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("test")
+			l := g.Outline()
+			l.Add(tt.arc)
+			if err := l.ValidateArcs(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateArcs = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLayer_ValidateRegions(t *testing.T) {
+	square := []Pt{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}
+	tests := []struct {
+		name    string
+		pts     []Pt
+		wantErr bool
+	}{
+		{"ccw square", square, false},
+		{"cw square", []Pt{{0, 0}, {0, 1}, {1, 1}, {1, 0}, {0, 0}}, false},
+		{"closed when written", square[:4], false},
+		{"single point", []Pt{{1, 1}, {1, 1}}, true},
+		{"collinear", []Pt{{0, 0}, {1, 0}, {2, 0}, {0, 0}}, true},
+		{"figure eight", []Pt{{0, 0}, {1, 1}, {1, 0}, {0, 1}, {0, 0}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("test")
+			l := g.TopCopper()
+			l.Add(Polygon(0, 0, true, tt.pts, 0))
+			if err := l.ValidateRegions(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRegions = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLayer_WriteGerber_invalidRegions(t *testing.T) {
+	tests := []struct {
+		name string
+		p    Primitive
+		want string
+	}{
+		{"collinear", Polygon(0, 0, true, []Pt{{0, 0}, {1, 0}, {2, 0}}, 0), "zero area"},
+		{"no points", Polygon(0, 0, true, nil, 0), "no points"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New("test")
+			g.Strict = true
+			l := g.TopCopper()
+			l.Add(Circle(5, 5, 1), tt.p)
+			err := l.WriteGerber(io.Discard)
+			var e *Error
+			if !errors.As(err, &e) || e.Index != 1 || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("WriteGerber = %v, want an error of primitive #1 containing %q", err, tt.want)
+			}
+		})
+	}
+
+	// Open contours are closed with a draw back to their first point.
+	var buf bytes.Buffer
+	l := New("test").TopCopper()
+	l.Add(Polygon(0, 0, true, []Pt{{0, 0}, {1, 0}, {1, 1}}, 0))
+	if err := l.WriteGerber(&buf); err != nil || !strings.Contains(buf.String(), "X1000000Y1000000D01*\nX000000Y000000D01*\nG37*") {
+		t.Errorf("WriteGerber = %v, %v", err, buf.String())
+	}
+}
+
+func TestError_Provenance(t *testing.T) {
+	g := New("test")
+	g.Provenance = true
+	l := g.Outline()
+	l.Add(Circle(0, 0, 1))
+	l.Add(Object(Object(Arc(0, 0, 0, CircleShape, 1, 1, 0, 90, 0.1)).Name("pad3")).Name("U1"))
+
+	err := l.Validate()
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("Validate = %v, want *Error", err)
+	}
+	if e.Layer != "test.gko" || e.Index != 1 || e.Path != "U1/pad3" {
+		t.Errorf("Error = %+v, want layer test.gko, index 1, path U1/pad3", e)
+	}
+	if !strings.Contains(e.Caller, "validate_test.go:") {
+		t.Errorf("Caller = %q, want validate_test.go call site", e.Caller)
+	}
+}
+
+func TestLayer_ValidateOutline(t *testing.T) {
+	square := func(x, y float64) []Primitive { return rectangle(Pt{X: x, Y: y}, Pt{X: x + 10, Y: y + 10}, 0.1) }
+	tests := []struct {
+		name     string
+		prims    []Primitive
+		contours int
+		want     string
+	}{
+		{name: "closed", prims: square(0, 0)},
+		{name: "round", prims: []Primitive{CircleOutline(5, 5, 10, 0.1)}},
+		{name: "open", prims: square(0, 0)[:3], want: "outline is open at {0 10} (gap of 10.0000mm)"},
+		{name: "gap", prims: append(square(0, 0)[:3], Line(0, 10, 0, 0.005, CircleShape, 0.1)), want: "(gap of 0.0050mm)"},
+		{name: "overlap", prims: append(square(0, 0), Line(2, 0, 8, 0, CircleShape, 0.1)), want: "outline draws overlap from {2 0} to {8 0}"},
+		{name: "branch", prims: append(square(0, 0), Line(0, 0, 5, 5, CircleShape, 0.1)), want: "outline joins 3 draws at {0 0}"},
+		{name: "cutout", prims: append(square(0, 0), square(20, 0)...), want: "outline has 2 closed contours, want 1"},
+		{name: "declared cutout", prims: append(square(0, 0), square(20, 0)...), contours: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := New("board").Outline()
+			l.Add(tt.prims...)
+			err := l.ValidateOutline(tt.contours)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("ValidateOutline = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ValidateOutline = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLayer_CloseOutline(t *testing.T) {
+	l := New("board").Outline()
+	l.Add(rectangle(Pt{}, Pt{X: 10, Y: 10}, 0.1)[:3]...)
+	l.Add(Line(0, 10, 0, 0.005, CircleShape, 0.1))
+	if changes := l.CloseOutline(0.001); len(changes) != 0 {
+		t.Errorf("CloseOutline(0.001) = %q, want no changes", changes)
+	}
+	changes := l.CloseOutline(0.01)
+	if len(changes) != 1 || !strings.Contains(changes[0], "closed outline gap of 0.0050mm") {
+		t.Errorf("CloseOutline(0.01) = %q, want one closed gap", changes)
+	}
+	if err := l.ValidateOutline(0); err != nil {
+		t.Errorf("ValidateOutline after CloseOutline = %v", err)
+	}
+}
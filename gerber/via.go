@@ -0,0 +1,139 @@
+package gerber
+
+// ViaTreatment is the treatment of a via per IPC-4761.
+type ViaTreatment int
+
+const (
+	// ViaUntented leaves the via pads exposed by solder mask openings (the default).
+	ViaUntented ViaTreatment = iota
+	// ViaTented covers the via with solder mask on both sides (IPC-4761 type I).
+	ViaTented
+	// ViaPlugged plugs the hole and covers it with solder mask on both sides
+	// (IPC-4761 type III).
+	ViaPlugged
+	// ViaFilledCapped fills the hole and plates it over (IPC-4761 type VII)
+	// so that it can be placed in pads, leaving its pads exposed.
+	ViaFilledCapped
+)
+
+// ipc4761 returns the IPC-4761 type of the treatment for the X2 ViaDrill function.
+func (t ViaTreatment) ipc4761() string {
+	switch t {
+	case ViaTented:
+		return "Ib"
+	case ViaPlugged:
+		return "IIIb"
+	case ViaFilledCapped:
+		return "VII"
+	}
+	return "None"
+}
+
+// exposed reports whether the via pads have solder mask openings.
+func (t ViaTreatment) exposed() bool {
+	return t == ViaUntented || t == ViaFilledCapped
+}
+
+// ViaT represents a plated through via spanning several layers.
+type ViaT struct {
+	x, y, drill, pad float64
+	treatment        ViaTreatment
+	net              string
+	layers           []*Layer // of the pads for AddTo, if selected
+}
+
+// Via returns a via with the given drill and pad diameters at x,y.
+// All dimensions are in millimeters (or Lengths).
+func Via[L Lengths](x, y, drill, pad L) *ViaT {
+	return &ViaT{x: float64(x), y: float64(y), drill: float64(drill), pad: float64(pad)}
+}
+
+// Treatment sets the treatment of the via, which determines its solder
+// mask openings and the X2 attributes of its drill.
+func (v *ViaT) Treatment(t ViaTreatment) *ViaT {
+	v.treatment = t
+	return v
+}
+
+// Net sets the name of the net the via belongs to.
+func (v *ViaT) Net(name string) *ViaT {
+	v.net = name
+	return v
+}
+
+// Pads selects the copper layers the via has pads on when added with
+// AddTo, e.g. to leave out the non-functional pads of inner layers.
+func (v *ViaT) Pads(layers ...*Layer) *ViaT {
+	v.layers = layers
+	return v
+}
+
+// AddTo adds the via to the design: its pads to the copper layers
+// selected with Pads (by default all the copper layers of the design),
+// its hole to the drill layer and (depending on its treatment) its solder
+// mask openings (grown by Gerber.Derive) to the solder mask layers of
+// both sides. Missing outer copper, drill and solder mask layers are
+// added as needed.
+func (v *ViaT) AddTo(g *Gerber) *ViaT {
+	copper := v.layers
+	if copper == nil {
+		g.layerFor("gtl")
+		g.layerFor("gbl")
+		for _, l := range g.Layers {
+			if l.copper() {
+				copper = append(copper, l)
+			}
+		}
+	}
+	var topMask, bottomMask *Layer
+	if v.treatment.exposed() {
+		topMask, bottomMask = g.layerFor("gts"), g.layerFor("gbs")
+	}
+	v.add(copper, g.layerFor("xln"), topMask, bottomMask)
+	return v
+}
+
+// Add adds the via's pads, hole and (depending on its treatment) solder
+// mask openings to the given layers. Nil layers are skipped. The openings
+// are grown by the solder mask rules of the design of the mask layers
+// (see Gerber.Derive).
+func (v *ViaT) Add(top, bottom, drill, topMask, bottomMask *Layer) {
+	v.add([]*Layer{top, bottom}, drill, topMask, bottomMask)
+}
+
+// add adds the via's pads to the copper layers, its hole to the drill
+// layer and its solder mask openings to the mask layers if exposed.
+func (v *ViaT) add(copper []*Layer, drill, topMask, bottomMask *Layer) {
+	add := func(l *Layer, p *FlashT) {
+		if l == nil {
+			return
+		}
+		if v.net != "" {
+			l.Add(Object(p).Net(v.net))
+			return
+		}
+		l.Add(p)
+	}
+	pad := func(function string) *FlashT {
+		p := Flash(v.x, v.y, CircleShape, v.pad)
+		p.function = function
+		return p
+	}
+	for _, l := range copper {
+		add(l, pad("ViaPad"))
+	}
+	hole := Flash(v.x, v.y, CircleShape, v.drill)
+	hole.function = "ViaDrill," + v.treatment.ipc4761()
+	add(drill, hole)
+	if v.treatment.exposed() {
+		var r DeriveRules
+		for _, l := range []*Layer{bottomMask, topMask} {
+			if l != nil && l.g != nil {
+				r = l.g.Derive
+			}
+		}
+		opening := Flash(v.x, v.y, CircleShape, v.pad*(1+r.MaskPercent/100)+2*r.MaskExpansion)
+		add(topMask, opening)
+		add(bottomMask, opening)
+	}
+}
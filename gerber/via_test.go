@@ -0,0 +1,84 @@
+package gerber
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestVia_Treatment(t *testing.T) {
+	tests := []struct {
+		treatment ViaTreatment
+		masks     int
+		drill     string
+	}{
+		{ViaUntented, 1, "%TA.AperFunction,ViaDrill,None*%"},
+		{ViaTented, 0, "%TA.AperFunction,ViaDrill,Ib*%"},
+		{ViaPlugged, 0, "%TA.AperFunction,ViaDrill,IIIb*%"},
+		{ViaFilledCapped, 1, "%TA.AperFunction,ViaDrill,VII*%"},
+	}
+	for _, tt := range tests {
+		g := New("board")
+		top, bottom, drill := g.TopCopper(), g.BottomCopper(), g.Drill()
+		topMask, bottomMask := g.TopSolderMask(), g.BottomSolderMask()
+		Via(1, 2, 0.3, 0.6).Treatment(tt.treatment).Net("GND").Add(top, bottom, drill, topMask, bottomMask)
+
+		if got := len(topMask.Primitives) + len(bottomMask.Primitives); got != 2*tt.masks {
+			t.Errorf("treatment %v: got %v mask openings, want %v", tt.treatment, got, 2*tt.masks)
+		}
+		var buf bytes.Buffer
+		if err := drill.WriteGerber(&buf); err != nil {
+			t.Fatal(err)
+		}
+		want := tt.drill + "\n%ADD12C,0.300000*%\n%TD.AperFunction*%\n"
+		if got := buf.String(); !strings.Contains(got, want) || !strings.Contains(got, "%TO.N,GND*%\nG54D12*\nX1000000Y2000000D03*\n") {
+			t.Errorf("treatment %v: drill layer =\n%v\nwant %q", tt.treatment, got, want)
+		}
+	}
+}
+
+func TestVia_AddTo(t *testing.T) {
+	g := New("board")
+	top, inner2, inner3, bottom := g.TopCopper(), g.Layer2(), g.Layer3(), g.BottomCopper()
+	Via(1, 2, 0.3, 0.6).Net("GND").AddTo(g)
+	Via(3, 4, 0.3, 0.6).Treatment(ViaTented).Pads(top, bottom).AddTo(g)
+
+	count := func(ext string) int {
+		var n int
+		for _, l := range g.Layers {
+			if l.extension() == ext {
+				n += len(l.Primitives)
+			}
+		}
+		return n
+	}
+	for _, tt := range []struct {
+		ext  string
+		want int
+	}{{"gtl", 2}, {"g2l", 1}, {"g3l", 1}, {"gbl", 2}, {"xln", 2}, {"gts", 1}, {"gbs", 1}} {
+		if got := count(tt.ext); got != tt.want {
+			t.Errorf("%v has %v primitives, want %v", tt.ext, got, tt.want)
+		}
+	}
+	if len(inner2.Primitives) != 1 || len(inner3.Primitives) != 1 {
+		t.Error("the tented via has pads on the inner layers")
+	}
+	if o, ok := top.Primitives[0].(*ObjectT); !ok || o.net != "GND" {
+		t.Errorf("top pad = %#v, want net GND", top.Primitives[0])
+	}
+}
+
+func TestVia_maskRules(t *testing.T) {
+	g := New("board")
+	g.Derive = DeriveRules{MaskExpansion: 0.1, MaskPercent: 10}
+	Via(1, 2, 0.3, 0.6).Treatment(ViaUntented).AddTo(g)
+	for _, l := range []*Layer{g.layerFor("gts"), g.layerFor("gbs")} {
+		if len(l.Primitives) != 1 {
+			t.Fatalf("%v has %v primitives, want 1", l.Filename, len(l.Primitives))
+		}
+		if f, ok := unwrap(l.Primitives[0]).(*FlashT); !ok || math.Abs(f.thickness-0.86) > 1e-9 {
+			t.Errorf("%v opening = %#v, want a 0.86mm circle", l.Filename, l.Primitives[0])
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package gerber
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Workspace is a family of related boards generated together by one
+// program (e.g. a main board and its daughter boards), sharing a
+// library of pad stacks, a silkscreen font and a fab profile, and
+// written to one output tree:
+//
+//	<Dir>/<name>/<name>.gtl, ... <name>.zip   for every board
+//	<Dir>/panels/<name>-panel.gtl, ...        for every panelized board
+type Workspace struct {
+	// Dir is the root directory of the output tree.
+	Dir string
+	// PadStacks is the library of pad stacks shared by the boards, by
+	// name (see AddPadStack).
+	PadStacks map[string]*PadStack
+	// Font is the name of the font of the labels (see Label and Fonts).
+	Font string
+	// Rules are the design rules of the fab profile of the boards (see
+	// LoadRules), checked by DRC.
+	Rules []*Rule
+
+	names  []string // of the boards, in the order they were added
+	boards map[string]*Board
+}
+
+// NewWorkspace returns a new workspace writing to dir.
+func NewWorkspace(dir string) *Workspace {
+	return &Workspace{Dir: dir, PadStacks: map[string]*PadStack{}, boards: map[string]*Board{}}
+}
+
+// Board returns the named board of the workspace, first creating it
+// with preset (e.g. Board2Layer) if the workspace has none.
+func (w *Workspace) Board(name string, preset func(filenamePrefix string) *Board) *Board {
+	if b, ok := w.boards[name]; ok {
+		return b
+	}
+	b := preset(filepath.Join(w.Dir, name, name))
+	w.names = append(w.names, name)
+	w.boards[name] = b
+	return b
+}
+
+// Names returns the names of the boards of the workspace, in the order
+// they were added.
+func (w *Workspace) Names() []string {
+	return append([]string(nil), w.names...)
+}
+
+// AddPadStack adds the pad stack to the library under its name and
+// returns it, replacing any previous stack of the same name.
+func (w *Workspace) AddPadStack(s *PadStack) *PadStack {
+	w.PadStacks[s.Name] = s
+	return s
+}
+
+// Label returns the text of the given size (in points) at x,y in the
+// font of the workspace.
+func (w *Workspace) Label(x, y float64, s string, pts float64) *TextT {
+	return Text(x, y, 1, s, w.Font, pts)
+}
+
+// DRC runs the rules of the fab profile over every board and returns
+// the reports by board name.
+func (w *Workspace) DRC() (map[string]*DRCReport, error) {
+	reports := map[string]*DRCReport{}
+	for _, name := range w.names {
+		r, err := w.boards[name].DRC(w.Rules)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", name, err)
+		}
+		reports[name] = r
+	}
+	return reports, nil
+}
+
+// WriteGerber writes the files (and ZIP file) of every board to its
+// directory of the output tree.
+func (w *Workspace) WriteGerber() error {
+	for _, name := range w.names {
+		if err := os.MkdirAll(filepath.Join(w.Dir, name), 0755); err != nil {
+			return err
+		}
+		if err := w.boards[name].WriteGerber(); err != nil {
+			return fmt.Errorf("%v: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// Panelize arrays each of the named boards into a panel of columns by
+// rows boards (see NewPanel) and writes it to the panels directory of
+// the output tree.
+func (w *Workspace) Panelize(columns, rows int, names ...string) error {
+	dir := filepath.Join(w.Dir, "panels")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, name := range names {
+		b, ok := w.boards[name]
+		if !ok {
+			return fmt.Errorf("workspace has no board %q", name)
+		}
+		g, err := NewPanel(b.Gerber, columns, rows).Gerber(filepath.Join(dir, name+"-panel"))
+		if err != nil {
+			return fmt.Errorf("%v: %v", name, err)
+		}
+		if err := g.WriteGerber(); err != nil {
+			return fmt.Errorf("%v: %v", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package gerber
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWorkspace(t *testing.T) {
+	w := NewWorkspace(t.TempDir())
+	w.Font = "aaarghnormal"
+	w.Rules = []*Rule{{Name: "min-trace", Kind: "min-width", Params: map[string]float64{"width": 0.1}}}
+	pad := w.AddPadStack(&PadStack{Name: "SMD_1x1", Top: &PadShape{Shape: RectShape, Width: 1}, Paste: true})
+
+	for _, name := range []string{"main", "sensor"} {
+		b := w.Board(name, Board2Layer)
+		b.Outline().Add(rectangle(Pt{}, Pt{X: 20, Y: 10}, 0.1)...)
+		b.AddPad(w.PadStacks["SMD_1x1"].At(5, 5))
+		b.TopSilkscreen().Add(w.Label(2, 2, name, 8))
+	}
+	if w.Board("main", Board4Layer) != w.Board("main", Board2Layer) {
+		t.Error("Board returned a new board for an existing name")
+	}
+	if got := w.Names(); !reflect.DeepEqual(got, []string{"main", "sensor"}) {
+		t.Errorf("Names = %v", got)
+	}
+	if w.Board("sensor", Board2Layer).Pads[0].Stack() != pad {
+		t.Error("boards do not share the pad stack")
+	}
+
+	reports, err := w.DRC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 2 || len(reports["main"].Violations) != 0 {
+		t.Errorf("DRC = %+v, want 2 clean reports", reports)
+	}
+
+	if err := w.WriteGerber(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Panelize(2, 1, "sensor"); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{"main/main.gtl", "main/main.gtp", "main/main.zip", "sensor/sensor.gko", "panels/sensor-panel.gko", "panels/sensor-panel.zip"} {
+		if _, err := os.Stat(filepath.Join(w.Dir, f)); err != nil {
+			t.Errorf("missing %v: %v", f, err)
+		}
+	}
+	if err := w.Panelize(2, 1, "missing"); err == nil {
+		t.Error("Panelize of a missing board = nil, want error")
+	}
+}
@@ -1,3 +1,12 @@
 module github.com/gmlewis/go-gerber
 
-require github.com/gmlewis/go3d v0.0.0-20190127042539-d4534de02598
+// The code itself needs Go 1.21 (for min, max and log/slog); the
+// golang.org/x/image and golang.org/x/text dependencies need Go 1.26.
+go 1.26.0
+
+require (
+	github.com/gmlewis/go3d v0.0.0-20190127042539-d4534de02598
+	golang.org/x/image v0.46.0
+)
+
+require golang.org/x/text v0.42.0 // indirect
@@ -0,0 +1,261 @@
+// Package cli provides the flags, environment variables and config file
+// handling shared by all go-gerber commands.
+//
+// Every flag (shared or command-specific) can also be provided by an
+// environment variable named GERBER_<FLAG> (upper case, with dashes
+// replaced by underscores) or by a "name = value" line in a config file
+// given by -config (or GERBER_CONFIG). Command-line flags take precedence
+// over environment variables, which take precedence over the config file.
+// Names of the config file that aren't flags of the command are errors.
+package cli
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gmlewis/go-gerber/gerber"
+)
+
+// Options represents the options shared by all commands.
+type Options struct {
+	// Units is the unit of the output ("mm" or "in").
+	Units string
+	// Format is the coordinate format of the output (e.g. "3.6").
+	Format string
+	// Compat is the revision of the Gerber specification the output is
+	// compatible with (e.g. "2021.02" or "X1" for conservative CAM tools).
+	Compat string
+	// OutDir is the directory where output files are written.
+	OutDir string
+	// Quiet only logs errors.
+	Quiet bool
+	// JSONLog writes machine-readable JSON logs (e.g. for CI).
+	JSONLog bool
+	// Config is the optional config file.
+	Config string
+	// Strict makes the command fail if it logged any warning
+	// (see Options.Err), e.g. so that CI builds fail loudly.
+	Strict bool
+
+	warnings int // logged in strict mode
+}
+
+// Register registers the shared flags on fs and returns their options.
+func Register(fs *flag.FlagSet) *Options {
+	o := &Options{}
+	fs.StringVar(&o.Units, "units", "mm", "Units of the output (mm or in)")
+	fs.StringVar(&o.Format, "format", "3.6", "Coordinate format of the output (integer.decimal digits)")
+	fs.StringVar(&o.Compat, "compat", string(gerber.Revision2021), "Gerber revision the output is compatible with (X1, 2019.06 or 2021.02)")
+	fs.StringVar(&o.OutDir, "outdir", ".", "Directory where output files are written")
+	fs.BoolVar(&o.Quiet, "quiet", false, "Only log errors")
+	fs.BoolVar(&o.JSONLog, "json-log", false, "Write machine-readable JSON logs (e.g. for CI)")
+	fs.StringVar(&o.Config, "config", "", "Optional config file of 'flag = value' lines")
+	fs.BoolVar(&o.Strict, "strict", false, "Fail on warnings instead of only logging them")
+	return o
+}
+
+// Parse parses the command-line arguments, then fills in all flags not
+// set on the command line from the environment and then the config file.
+func Parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	var config map[string]string
+	if f := fs.Lookup("config"); f != nil {
+		name := f.Value.String()
+		if v, ok := os.LookupEnv(envName("config")); ok && !set["config"] {
+			name = v
+		}
+		if name != "" {
+			var err error
+			if config, err = readConfig(name, fs); err != nil {
+				return err
+			}
+		}
+	}
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if set[f.Name] || err != nil {
+			return
+		}
+		v, ok := os.LookupEnv(envName(f.Name))
+		if !ok {
+			v, ok = config[f.Name]
+		}
+		if ok {
+			if e := f.Value.Set(v); e != nil {
+				err = fmt.Errorf("invalid value %q for flag -%v: %v", v, f.Name, e)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if f := fs.Lookup("units"); f != nil {
+		if u := f.Value.String(); u != "mm" && u != "in" {
+			return fmt.Errorf("unsupported units %q", u)
+		}
+	}
+	if f := fs.Lookup("format"); f != nil {
+		if _, err := (&Options{Format: f.Value.String()}).GerberFormat(); err != nil {
+			return err
+		}
+	}
+	if f := fs.Lookup("compat"); f != nil {
+		if _, err := (&Options{Compat: f.Value.String()}).GerberRevision(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// envName returns the environment variable name for a flag.
+func envName(flag string) string {
+	return "GERBER_" + strings.ToUpper(strings.Replace(flag, "-", "_", -1))
+}
+
+// readConfig reads a config file of "name = value" lines, the names being
+// those of the flags of fs.
+// Blank lines and lines starting with '#' are ignored.
+func readConfig(name string, fs *flag.FlagSet) (map[string]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	config := map[string]string{}
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%v:%v: expected 'flag = value'", name, n)
+		}
+		key := strings.TrimSpace(parts[0])
+		if fs.Lookup(key) == nil {
+			return nil, fmt.Errorf("%v:%v: unknown flag %q", name, n, key)
+		}
+		config[key] = strings.Trim(strings.TrimSpace(parts[1]), `"`)
+	}
+	return config, s.Err()
+}
+
+// GerberFormat returns the coordinate format as a gerber.Format, of 1 to 6
+// integer and decimal digits.
+func (o *Options) GerberFormat() (gerber.Format, error) {
+	parts := strings.Split(o.Format, ".")
+	if len(parts) != 2 {
+		return gerber.Format{}, fmt.Errorf("invalid format %q: want integer.decimal digits (e.g. 3.6)", o.Format)
+	}
+	i, err1 := strconv.Atoi(parts[0])
+	d, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil {
+		return gerber.Format{}, fmt.Errorf("invalid format %q: want integer.decimal digits (e.g. 3.6)", o.Format)
+	}
+	if i < 1 || i > 6 || d < 1 || d > 6 {
+		return gerber.Format{}, fmt.Errorf("unsupported format %q: want 1 to 6 integer and decimal digits", o.Format)
+	}
+	return gerber.Format{Integer: i, Decimal: d}, nil
+}
+
+// GerberUnits returns the units as gerber.Units.
+func (o *Options) GerberUnits() gerber.Units {
+	if o.Units == "in" {
+		return gerber.Inches
+	}
+	return gerber.Millimeters
+}
+
+// GerberRevision returns the output compatibility as a gerber.Revision.
+func (o *Options) GerberRevision() (gerber.Revision, error) {
+	for _, r := range gerber.Revisions {
+		if strings.EqualFold(o.Compat, string(r)) {
+			return r, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported compat %q: want one of %v", o.Compat, gerber.Revisions)
+}
+
+// Path returns the path of the named output file within OutDir.
+func (o *Options) Path(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(o.OutDir, name)
+}
+
+// Logger returns a logger writing to stderr honoring Quiet and JSONLog.
+func (o *Options) Logger() *slog.Logger {
+	return o.newLogger(os.Stderr)
+}
+
+func (o *Options) newLogger(w io.Writer) *slog.Logger {
+	opts := &slog.HandlerOptions{}
+	if o.Quiet {
+		opts.Level = slog.LevelError
+	}
+	var h slog.Handler = slog.NewTextHandler(w, opts)
+	if o.JSONLog {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	if o.Strict {
+		h = &strictHandler{Handler: h, o: o}
+	}
+	return slog.New(h)
+}
+
+// Err returns an error in strict mode if any warnings were logged by
+// the logger of Options.Logger. Commands check it before writing their
+// output.
+func (o *Options) Err() error {
+	if o.Strict && o.warnings > 0 {
+		return fmt.Errorf("%v warning(s) in strict mode", o.warnings)
+	}
+	return nil
+}
+
+// strictHandler counts the warnings of quiet loggers too.
+type strictHandler struct {
+	slog.Handler
+	o *Options
+}
+
+func (h *strictHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelWarn || h.Handler.Enabled(ctx, level)
+}
+
+func (h *strictHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level == slog.LevelWarn {
+		h.o.warnings++
+	}
+	if !h.Handler.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *strictHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &strictHandler{Handler: h.Handler.WithAttrs(attrs), o: h.o}
+}
+
+func (h *strictHandler) WithGroup(name string) slog.Handler {
+	return &strictHandler{Handler: h.Handler.WithGroup(name), o: h.o}
+}
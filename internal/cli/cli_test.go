@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gmlewis/go-gerber/gerber"
+)
+
+func TestParse_Precedence(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "gerber.conf")
+	if err := os.WriteFile(config, []byte("# comment\nformat = 2.4\noutdir = from-config\nout = \"config.go\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("GERBER_OUTDIR", "from-env")
+	defer os.Unsetenv("GERBER_OUTDIR")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	o := Register(fs)
+	out := fs.String("out", "fonts.go", "")
+	if err := Parse(fs, []string{"-config", config, "-units", "in", "-compat", "x1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if o.Units != "in" || o.GerberUnits() != gerber.Inches {
+		t.Errorf("Units = %q (%v), want flag value in", o.Units, o.GerberUnits())
+	}
+	if o.OutDir != "from-env" {
+		t.Errorf("OutDir = %q, want env value from-env", o.OutDir)
+	}
+	if o.Format != "2.4" || *out != "config.go" {
+		t.Errorf("Format = %q, out = %q, want config values 2.4 and config.go", o.Format, *out)
+	}
+	if r, err := o.GerberRevision(); err != nil || r != gerber.RevisionX1 {
+		t.Errorf("GerberRevision = %v, %v, want X1", r, err)
+	}
+	if f, err := o.GerberFormat(); err != nil || f.Integer != 2 || f.Decimal != 4 {
+		t.Errorf("GerberFormat = %v, %v, want {2 4}", f, err)
+	}
+}
+
+func TestParse_invalid(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		config string
+		args   []string
+		want   string
+	}{
+		{"outdri = out\n", nil, `gerber.conf:1: unknown flag "outdri"`},
+		{"# comment\nformat = 3.6\nouts = x\n", nil, `gerber.conf:3: unknown flag "outs"`},
+		{"", []string{"-format", "9.9"}, `unsupported format "9.9"`},
+		{"format = 0.0\n", nil, `unsupported format "0.0"`},
+		{"", []string{"-format", "3"}, `invalid format "3"`},
+	} {
+		config := filepath.Join(dir, "gerber.conf")
+		if err := os.WriteFile(config, []byte(tc.config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		Register(fs)
+		fs.String("out", "fonts.go", "")
+		err := Parse(fs, append([]string{"-config", config}, tc.args...))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Parse(%q, %q) = %v, want %v", tc.config, tc.args, err, tc.want)
+		}
+	}
+}
+
+func TestOptions_Err(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		o := &Options{Quiet: true, Strict: strict}
+		log := o.newLogger(io.Discard)
+		log.Info("info")
+		if err := o.Err(); err != nil {
+			t.Errorf("strict=%v: Err after info = %v, want nil", strict, err)
+		}
+		log.With("file", "x").Warn("warning")
+		if err := o.Err(); (err != nil) != strict {
+			t.Errorf("strict=%v: Err after a quiet warning = %v", strict, err)
+		}
+	}
+}